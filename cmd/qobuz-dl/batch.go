@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/config"
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/engine"
+)
+
+// newBatchCmd creates the batch command that downloads every URL or ID listed in a file.
+func newBatchCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "batch [file]",
+		Short: "Download tracks, albums and artists listed in a file (one URL or ID per line)",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			jobs, err := readBatchFile(args[0])
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			if len(jobs) == 0 {
+				fmt.Println("No entries found in batch file")
+				return
+			}
+
+			client, err := setupClient(false)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(exitCodeFor(err))
+			}
+
+			eng := engine.New(client)
+			if cfg, err := config.LoadConfig(); err == nil {
+				eng.MaxPathLength = cfg.MaxPathLength
+				eng.LongPaths = cfg.LongPaths
+				eng.Tagger.WriteSource = !cfg.DisableSourceTags
+				if cfg.Quality != 0 && !cmd.Flags().Changed("quality") {
+					flagQuality = cfg.Quality // Precedence: .qobuz-quality > --quality > config
+				}
+				if cfg.FlacPadding != 0 {
+					eng.Tagger.PaddingBytes = max(cfg.FlacPadding, 0)
+				}
+				eng.GroupByInitial = cfg.GroupByInitial
+				if err := applyFileNaming(eng, cfg); err != nil {
+					fmt.Printf("Invalid config: %v\n", err)
+					os.Exit(1)
+				}
+			}
+			applyThreads(eng)
+			eng.GlobalConcurrency = flagGlobal
+			eng.APIConcurrency = flagAPIConc
+			eng.FailFast = flagFailFast
+			eng.KeepGoing = flagKeepGoing
+			eng.AlbumDelay = flagDelay
+
+			queue := eng.NewQueue(flagQuality, flagOutputDir)
+			queue.Add(jobs...)
+
+			fmt.Printf("Queued %d jobs from %s\n", queue.Len(), args[0])
+			result := queue.Run(context.Background())
+
+			failed := result.Failed()
+			if result.StoppedBy != nil {
+				fmt.Printf("\nStopped by --fail-fast after %s failed: %v\n", result.StoppedBy.Job, result.StoppedBy.Err)
+			}
+			fmt.Printf("\nBatch complete: %d succeeded, %d failed\n", result.Succeeded(), len(failed))
+			for _, res := range failed {
+				fmt.Printf("  - %s: %v\n", res.Job, res.Err)
+			}
+			if len(failed) > 0 {
+				if result.Succeeded() > 0 {
+					os.Exit(exitPartial)
+				}
+				os.Exit(exitCodeFor(failed[0].Err))
+			}
+		},
+	}
+
+	addQualityFlag(cmd)
+	cmd.Flags().StringVarP(&flagOutputDir, "output", "o", ".", "Output directory")
+	addThreadsFlag(cmd)
+	cmd.Flags().IntVar(&flagGlobal, "global-threads", 0, "Maximum simultaneous downloads across all albums (0 = unlimited)")
+	cmd.Flags().IntVar(&flagAPIConc, "api-threads", 0, "Maximum simultaneous API requests (track URLs, album metadata), independent of download threads (0 = unlimited)")
+	cmd.Flags().BoolVar(&flagKeepGoing, "keep-going", false, "Keep every downloaded file when tagging fails and list the failures in .tag-errors.log in the album folder")
+	cmd.Flags().DurationVar(&flagDelay, "delay-between-albums", 0, "Pause this long between entries and between the albums of artist entries (e.g. 30s), to stay under rate limits on long runs")
+	cmd.Flags().BoolVar(&flagFailFast, "fail-fast", false, "Stop at the first failed entry instead of continuing and reporting failures at the end")
+
+	return cmd
+}
+
+// readBatchFile parses a batch file into jobs, skipping blank lines and # comments.
+func readBatchFile(path string) ([]engine.Job, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open batch file: %w", err)
+	}
+	defer f.Close()
+
+	var jobs []engine.Job
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		jobs = append(jobs, engine.ParseJob(line))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read batch file: %w", err)
+	}
+
+	return jobs, nil
+}
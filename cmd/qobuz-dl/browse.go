@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/engine"
+)
+
+// newBrowseCmd creates the browse command that lists featured albums (new releases,
+// press awards, ...) and optionally downloads them.
+func newBrowseCmd() *cobra.Command {
+	var (
+		genreID  int
+		limit    int
+		download bool
+	)
+
+	cmd := &cobra.Command{
+		Use:       "browse [type]",
+		Short:     "List featured albums such as new releases, optionally downloading them",
+		Long:      "List featured albums. Types: " + strings.Join(api.FeaturedTypes, ", "),
+		Args:      cobra.MatchAll(cobra.MaximumNArgs(1), cobra.OnlyValidArgs),
+		ValidArgs: api.FeaturedTypes,
+		Run: func(cmd *cobra.Command, args []string) {
+			featuredType := "new-releases"
+			if len(args) > 0 {
+				featuredType = args[0]
+			}
+
+			client, err := setupClient(false)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(exitCodeFor(err))
+			}
+
+			albums, err := client.GetFeatured(featuredType, genreID, limit)
+			if err != nil {
+				fmt.Printf("Browse failed: %v\n", err)
+				os.Exit(exitCodeFor(err))
+			}
+			if len(albums) == 0 {
+				fmt.Println("No albums")
+				return
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "#\tID\tARTIST\tTITLE\tRELEASED")
+			for i, album := range albums {
+				fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", i+1, album.ID, album.Artist.Name, album.Title, album.ReleaseDateOrg)
+			}
+			w.Flush()
+
+			if !download {
+				return
+			}
+
+			eng := engine.New(client)
+			applyThreads(eng)
+			queue := eng.NewQueue(flagQuality, flagOutputDir)
+			for _, album := range albums {
+				queue.Add(engine.Job{Type: api.TypeAlbum, ID: album.ID})
+			}
+
+			result := queue.Run(context.Background())
+			failed := result.Failed()
+			fmt.Printf("\nBrowse download complete: %d succeeded, %d failed\n", result.Succeeded(), len(failed))
+			for _, res := range failed {
+				fmt.Printf("  - %s: %v\n", res.Job, res.Err)
+			}
+			if len(failed) > 0 {
+				if result.Succeeded() > 0 {
+					os.Exit(exitPartial)
+				}
+				os.Exit(exitCodeFor(failed[0].Err))
+			}
+		},
+	}
+
+	cmd.Flags().IntVar(&genreID, "genre", 0, "Only list albums of this Qobuz genre ID (0 = all genres)")
+	cmd.Flags().IntVarP(&limit, "limit", "l", 20, "Maximum number of albums")
+	cmd.Flags().BoolVar(&download, "download", false, "Download the listed albums")
+	addQualityFlag(cmd)
+	cmd.Flags().StringVarP(&flagOutputDir, "output", "o", ".", "Output directory")
+	addThreadsFlag(cmd)
+
+	return cmd
+}
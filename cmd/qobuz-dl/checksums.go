@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/engine"
+)
+
+// newVerifyChecksumsCmd creates the verify-checksums command that checks album
+// folders against the checksums.sha256 manifest written by dl --checksums.
+func newVerifyChecksumsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "verify-checksums [dir]",
+		Short: "Verify downloaded files against the " + engine.ChecksumFile + " manifest",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			results, err := engine.VerifyChecksums(args[0])
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			bad := 0
+			for _, r := range results {
+				fmt.Printf("%s: %s\n", r.Path, r.Status)
+				if r.Status != engine.ChecksumOK {
+					bad++
+				}
+			}
+
+			if bad > 0 {
+				fmt.Printf("\n%d of %d files failed verification\n", bad, len(results))
+				os.Exit(1)
+			}
+			fmt.Printf("\nAll %d files OK\n", len(results))
+		},
+	}
+}
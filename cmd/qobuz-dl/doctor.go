@@ -0,0 +1,251 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/imroc/req/v3"
+	"github.com/spf13/cobra"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/config"
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/engine"
+)
+
+// doctorCheck is the outcome of a single diagnostic check.
+type doctorCheck struct {
+	Name     string
+	OK       bool
+	Detail   string
+	Hint     string // Actionable suggestion shown on failure
+	Optional bool   // Failure is reported as a warning and does not fail the run
+}
+
+// newDoctorCmd creates the doctor command that diagnoses common setup problems.
+func newDoctorCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "doctor",
+		Short: "Check configuration, credentials and network connectivity",
+		Run: func(cmd *cobra.Command, args []string) {
+			checks := runDoctorChecks()
+
+			failed := 0
+			for _, c := range checks {
+				mark := "PASS"
+				switch {
+				case !c.OK && c.Optional:
+					mark = "WARN"
+				case !c.OK:
+					mark = "FAIL"
+					failed++
+				}
+				fmt.Printf("[%s] %s", mark, c.Name)
+				if c.Detail != "" {
+					fmt.Printf(" - %s", c.Detail)
+				}
+				fmt.Println()
+				if !c.OK && c.Hint != "" {
+					fmt.Printf("       Hint: %s\n", c.Hint)
+				}
+			}
+
+			fmt.Printf("\n%d checks, %d failed\n", len(checks), failed)
+			if failed > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+}
+
+// runDoctorChecks runs all diagnostics in order and returns their results.
+func runDoctorChecks() []doctorCheck {
+	var checks []doctorCheck
+
+	// 1. Config directory
+	checks = append(checks, checkConfigWritable())
+
+	// 2. Account
+	acc, err := config.LoadAccount()
+	if err != nil {
+		checks = append(checks, doctorCheck{
+			Name:   "Account file",
+			Detail: err.Error(),
+			Hint:   fmt.Sprintf("Fix or delete %s and log in again", config.GetAccountPath()),
+		})
+		acc = &config.Account{}
+	} else {
+		hasAuth := acc.UserToken != "" || (acc.Email != "" && acc.Password != "")
+		checks = append(checks, doctorCheck{
+			Name:   "Account credentials",
+			OK:     hasAuth || flagToken != "",
+			Detail: config.GetAccountPath(),
+			Hint:   "Run a download once to log in, or pass --token / --email / --password",
+		})
+	}
+
+	appID := flagAppID
+	if appID == "" {
+		appID = acc.AppID
+	}
+	appSecret := flagAppSecret
+	if appSecret == "" {
+		appSecret = acc.AppSecret
+	}
+	appIDCheck := doctorCheck{
+		Name:   "App ID",
+		OK:     appID != "",
+		Detail: appID,
+		Hint:   "App ID is fetched automatically on the next download; check network access to the web player",
+	}
+	if appID == "" {
+		appIDCheck.Detail = "missing"
+	}
+	checks = append(checks, appIDCheck)
+
+	// 3. Proxy
+	probe := req.C().SetTimeout(10 * time.Second).SetUserAgent(api.UserAgent)
+	if flagInsecure {
+		probe.EnableInsecureSkipVerify()
+	}
+	if flagCACert != "" {
+		caCheck := doctorCheck{Name: "CA certificate", Detail: flagCACert}
+		if pool, err := api.LoadCertPool(flagCACert); err != nil {
+			caCheck.Detail = err.Error()
+			caCheck.Hint = "Pass a PEM file holding the proxy's CA certificate"
+		} else {
+			probe.GetTLSClientConfig().RootCAs = pool
+			caCheck.OK = true
+		}
+		checks = append(checks, caCheck)
+	}
+	if flagProxy != "" {
+		proxyCheck := doctorCheck{Name: "Proxy", Detail: flagProxy}
+		client := api.NewClient("", "")
+		if err := client.SetProxy(flagProxy); err != nil {
+			proxyCheck.Detail = err.Error()
+			proxyCheck.Hint = "Use a http://, https:// or socks5:// proxy URL"
+		} else {
+			probe.SetProxyURL(flagProxy)
+			proxyCheck.OK = true
+		}
+		checks = append(checks, proxyCheck)
+	}
+
+	// 4. Network reachability
+	targets := []struct{ name, url string }{
+		{"Qobuz API", api.BaseURLDirect},
+		{"Qobuz web player", api.PlayURLDirect},
+	}
+	if !flagNoCDN {
+		targets = append(targets,
+			struct{ name, url string }{"CDN API proxy", api.BaseURLProxy},
+			struct{ name, url string }{"CDN web player proxy", api.PlayURLProxy},
+		)
+	}
+	for _, t := range targets {
+		checks = append(checks, checkReachable(probe, t.name, t.url))
+	}
+
+	// 5. Secret validity (needs app id, secret and user token)
+	secretCheck := doctorCheck{
+		Name: "App secret",
+		Hint: "Run a download to refresh the secret automatically",
+	}
+	token := flagToken
+	if token == "" {
+		token = acc.UserToken
+	}
+	switch {
+	case appID == "" || appSecret == "":
+		secretCheck.Detail = "missing"
+	case token == "":
+		secretCheck.Detail = "cannot validate without a user token"
+		secretCheck.Hint = "Log in first so the secret can be validated"
+	default:
+		// Built like the download client, so proxy and TLS flags apply
+		client, err := newAPIClient(appID, appSecret)
+		if err != nil {
+			secretCheck.Detail = err.Error()
+			break
+		}
+		client.SetUserToken(token)
+		secretCheck = checkSecret(client)
+	}
+	checks = append(checks, secretCheck)
+
+	// 6. ffmpeg (only needed for server transcoding)
+	ffmpegCheck := doctorCheck{
+		Name:     "ffmpeg (transcoding)",
+		Hint:     "Install ffmpeg to enable /stream/:trackID?format=mp3 in serve mode",
+		Optional: true,
+	}
+	if path, err := engine.FFmpegPath(); err != nil {
+		ffmpegCheck.Detail = err.Error()
+	} else {
+		ffmpegCheck.OK = true
+		ffmpegCheck.Detail = path
+	}
+	checks = append(checks, ffmpegCheck)
+
+	return checks
+}
+
+// checkConfigWritable verifies that account.json can be written next to the executable.
+func checkConfigWritable() doctorCheck {
+	dir := config.GetConfigDir()
+	check := doctorCheck{
+		Name:   "Config directory writable",
+		Detail: dir,
+		Hint:   "Move the executable to a writable directory or use --nosave",
+	}
+
+	probePath := filepath.Join(dir, ".qobuz-dl-doctor")
+	if err := os.WriteFile(probePath, []byte("ok"), 0600); err != nil {
+		check.Detail = err.Error()
+		return check
+	}
+	os.Remove(probePath)
+	check.OK = true
+	return check
+}
+
+// checkSecret validates the app secret of client, telling a secret rejected by
+// Qobuz apart from a request that never got an answer.
+func checkSecret(client *api.Client) doctorCheck {
+	check := doctorCheck{Name: "App secret"}
+	err := client.CheckSecret()
+	if err == nil {
+		check.OK = true
+		check.Detail = "valid"
+		return check
+	}
+	if _, ok := api.AsAPIError(err); ok {
+		check.Detail = "rejected by Qobuz (or token expired)"
+		check.Hint = "Run a download to refresh the secret automatically"
+		return check
+	}
+	check.Detail = err.Error()
+	check.Hint = "Check your connection; behind a proxy, pass the same --proxy, --insecure, --ca-cert or --http1 flags as for downloads"
+	return check
+}
+
+// checkReachable verifies that an HTTP response can be obtained from url.
+// Any HTTP status counts as reachable; only transport errors fail.
+func checkReachable(client *req.Client, name, url string) doctorCheck {
+	check := doctorCheck{
+		Name: name,
+		Hint: "Check your internet connection or set --proxy; use --nocdn if the CDN is blocked",
+	}
+
+	start := time.Now()
+	resp, err := client.R().Get(url)
+	if err != nil {
+		check.Detail = err.Error()
+		return check
+	}
+	check.OK = true
+	check.Detail = fmt.Sprintf("HTTP %d in %dms", resp.StatusCode, time.Since(start).Milliseconds())
+	return check
+}
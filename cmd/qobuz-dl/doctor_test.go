@@ -0,0 +1,119 @@
+package main
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/imroc/req/v3"
+)
+
+func TestCheckSecret(t *testing.T) {
+	tests := []struct {
+		name       string
+		secret     string
+		insecure   bool   // --insecure
+		caCert     string // --ca-cert: "server" for the server's certificate, "missing" for a missing file
+		http1      bool   // --http1
+		wantErr    bool   // The client cannot be built
+		wantOK     bool
+		wantDetail string
+		wantProto  int // HTTP major version seen by the server
+	}{
+		{name: "valid", secret: "good", insecure: true, wantOK: true, wantDetail: "valid", wantProto: 2},
+		{name: "rejected", secret: "bad", insecure: true, wantDetail: "rejected by Qobuz"},
+		{name: "untrusted certificate", secret: "good", wantDetail: "certificate"},
+		{name: "private CA", secret: "good", caCert: "server", wantOK: true, wantDetail: "valid"},
+		{name: "missing CA file", secret: "good", caCert: "missing", wantErr: true},
+		{name: "HTTP/1.1 only", secret: "good", insecure: true, http1: true, wantOK: true, wantDetail: "valid", wantProto: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proto := 0
+			srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				proto = r.ProtoMajor
+				q := r.URL.Query()
+				sum := md5.Sum([]byte(fmt.Sprintf("trackgetFileUrlformat_id%sintent%strack_id%s%sgood",
+					q.Get("format_id"), q.Get("intent"), q.Get("track_id"), q.Get("request_ts"))))
+				w.Header().Set("Content-Type", "application/json")
+				if q.Get("request_sig") != hex.EncodeToString(sum[:]) {
+					w.WriteHeader(http.StatusBadRequest)
+					w.Write([]byte(`{"status":"error","code":400,"message":"Invalid Request Signature parameter (request_sig)"}`))
+					return
+				}
+				w.Write([]byte(`{"url":"https://example.com/track.mp3","format_id":5}`))
+			}))
+			srv.EnableHTTP2 = true
+			srv.StartTLS()
+			defer srv.Close()
+
+			caPath := ""
+			switch tt.caCert {
+			case "server":
+				caPath = filepath.Join(t.TempDir(), "ca.pem")
+				data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+				if err := os.WriteFile(caPath, data, 0600); err != nil {
+					t.Fatal(err)
+				}
+			case "missing":
+				caPath = filepath.Join(t.TempDir(), "missing.pem")
+			}
+			flagInsecure, flagCACert, flagHTTP1 = tt.insecure, caPath, tt.http1
+			t.Cleanup(func() { flagInsecure, flagCACert, flagHTTP1 = false, "", false })
+
+			client, err := newAPIClient("app", tt.secret)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("newAPIClient succeeded")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newAPIClient: %v", err)
+			}
+			client.HTTP.SetBaseURL(srv.URL)
+			client.SetUserToken("token")
+
+			check := checkSecret(client)
+			if check.OK != tt.wantOK || !strings.Contains(check.Detail, tt.wantDetail) {
+				t.Errorf("checkSecret() = %+v, want OK=%v with detail %q", check, tt.wantOK, tt.wantDetail)
+			}
+			if !check.OK && check.Hint == "" {
+				t.Error("failed check has no hint")
+			}
+			if tt.wantProto != 0 && proto != tt.wantProto {
+				t.Errorf("request used HTTP/%d, want HTTP/%d", proto, tt.wantProto)
+			}
+		})
+	}
+}
+
+func TestCheckReachable(t *testing.T) {
+	up := httptest.NewServer(http.NotFoundHandler())
+	defer up.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	tests := []struct {
+		name       string
+		url        string
+		wantOK     bool
+		wantDetail string
+	}{
+		{name: "any status is reachable", url: up.URL, wantOK: true, wantDetail: "HTTP 404"},
+		{name: "connection refused", url: down.URL, wantDetail: "refused"},
+	}
+	for _, tt := range tests {
+		check := checkReachable(req.C(), tt.name, tt.url)
+		if check.OK != tt.wantOK || !strings.Contains(check.Detail, tt.wantDetail) {
+			t.Errorf("%s: checkReachable() = %+v, want OK=%v with detail %q", tt.name, check, tt.wantOK, tt.wantDetail)
+		}
+	}
+}
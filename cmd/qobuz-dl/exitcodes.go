@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"net/url"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/engine"
+)
+
+// Process exit codes, stable for use in scripts:
+//
+//	0  success
+//	1  general error (bad arguments, file system errors, ...)
+//	2  authentication failed or missing credentials
+//	3  network error or Qobuz server error (5xx)
+//	4  album, track, artist or playlist not found
+//	5  partial failure: some items failed, the rest were downloaded
+const (
+	exitOK       = 0
+	exitError    = 1
+	exitAuth     = 2
+	exitNetwork  = 3
+	exitNotFound = 4
+	exitPartial  = 5
+)
+
+// errAuthRequired is returned by setupClient when no credentials are available.
+var errAuthRequired = errors.New("authentication required. Provide --token or --email/--password")
+
+// exitCodeFor maps an error from the api or engine packages to an exit code.
+func exitCodeFor(err error) int {
+	if err == nil {
+		return exitOK
+	}
+
+	if errors.Is(err, errAuthRequired) {
+		return exitAuth
+	}
+
+	if apiErr, ok := api.AsAPIError(err); ok {
+		switch {
+		case apiErr.IsAuth():
+			return exitAuth
+		case apiErr.IsNotFound():
+			return exitNotFound
+		case apiErr.StatusCode >= 500:
+			return exitNetwork
+		}
+		return exitError
+	}
+
+	var partial *engine.PartialError
+	if errors.As(err, &partial) && partial.Failed < partial.Total {
+		return exitPartial
+	}
+
+	var netErr net.Error
+	var urlErr *url.Error
+	if errors.As(err, &netErr) || errors.As(err, &urlErr) {
+		return exitNetwork
+	}
+
+	return exitError
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"testing"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/engine"
+)
+
+func TestExitCodeFor(t *testing.T) {
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "success", want: exitOK},
+		{name: "missing credentials", err: errAuthRequired, want: exitAuth},
+		{name: "unauthorized", err: &api.APIError{StatusCode: 401}, want: exitAuth},
+		{name: "forbidden, wrapped", err: fmt.Errorf("failed to get album metadata: %w", &api.APIError{StatusCode: 403}), want: exitAuth},
+		{name: "not found", err: &api.APIError{StatusCode: 404, Message: "No result matching given argument"}, want: exitNotFound},
+		{name: "server error", err: &api.APIError{StatusCode: 503}, want: exitNetwork},
+		{name: "bad request", err: &api.APIError{StatusCode: 400}, want: exitError},
+		{name: "connection refused", err: &url.Error{Op: "Get", URL: "https://www.qobuz.com/api.json", Err: dialErr}, want: exitNetwork},
+		{name: "net error", err: fmt.Errorf("download: %w", dialErr), want: exitNetwork},
+		{name: "partial failure", err: &engine.PartialError{Failed: 2, Total: 10, Unit: "tracks"}, want: exitPartial},
+		{name: "everything failed", err: &engine.PartialError{Failed: 3, Total: 3, Unit: "albums"}, want: exitError},
+		{name: "other", err: errors.New("disk full"), want: exitError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCodeFor(tt.err); got != tt.want {
+				t.Errorf("exitCodeFor(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
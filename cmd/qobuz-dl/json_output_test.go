@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/engine"
+)
+
+// runJSONCommand runs a command printing JSON the way main does: messages are
+// sent to stderr, setupClient prints its status lines, run writes the document
+// to the returned stdout and the version banner follows. It returns what
+// reached the real stdout and stderr.
+func runJSONCommand(t *testing.T, run func(stdout io.Writer)) (stdout, stderr []byte) {
+	t.Helper()
+	dir := t.TempDir()
+	files := make(map[string]*os.File)
+	for _, name := range []string{"stdout", "stderr", "stdin"} {
+		f, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		files[name] = f
+	}
+	realStdout, realStderr, realStdin := os.Stdout, os.Stderr, os.Stdin
+	os.Stdout, os.Stderr, os.Stdin = files["stdout"], files["stderr"], files["stdin"]
+	defer func() { os.Stdout, os.Stderr, os.Stdin = realStdout, realStderr, realStdin }()
+
+	out := messagesToStderr()
+
+	// Without a token, setupClient prints its notices and prompts, then gives up
+	// on the empty stdin before any request
+	flagAppID, flagAppSecret, flagNoCDN, flagInsecure = "app", "secret", true, true
+	defer func() { flagAppID, flagAppSecret, flagNoCDN, flagInsecure = "", "", false, false }()
+	if _, err := setupClient(false); !errors.Is(err, errAuthRequired) {
+		t.Fatalf("setupClient() error = %v, want %v", err, errAuthRequired)
+	}
+
+	run(out)
+	showVersionInfo()
+
+	stdout, err := os.ReadFile(files["stdout"].Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr, err = os.ReadFile(files["stderr"].Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(stderr), "CDN proxy disabled") || !strings.Contains(string(stderr), "Qobuz DL Go") {
+		t.Errorf("status lines and banner missing from stderr: %q", stderr)
+	}
+	return stdout, stderr
+}
+
+// decodeJSONDocument decodes stdout into v, failing unless it holds exactly one
+// JSON document.
+func decodeJSONDocument(t *testing.T, stdout []byte, v any) {
+	t.Helper()
+	dec := json.NewDecoder(bytes.NewReader(stdout))
+	if err := dec.Decode(v); err != nil {
+		t.Fatalf("stdout is not JSON: %v\n%s", err, stdout)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		t.Fatalf("stdout holds more than the JSON document:\n%s", stdout)
+	}
+}
+
+func TestAlbumQualitiesJSON(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/album/get":
+			w.Write([]byte(`{"id":"alb1","title":"Album","artist":{"name":"Band"},"tracks":{"total":2,"items":[
+				{"id":1,"title":"One","track_number":1,"media_number":1,"maximum_bit_depth":24,"maximum_sampling_rate":96},
+				{"id":2,"title":"Two","track_number":2,"media_number":1,"maximum_bit_depth":16}]}}`))
+		case "/track/getFileUrl":
+			if r.URL.Query().Get("track_id") == "2" {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"status":"error","code":404,"message":"No result matching given argument"}`))
+				return
+			}
+			fmt.Fprintf(w, `{"url":"https://cdn/1.flac","format_id":%s,"bit_depth":24,"sampling_rate":96}`, r.URL.Query().Get("format_id"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	client := api.NewClient("app", "secret")
+	client.HTTP.SetBaseURL(srv.URL)
+
+	stdout, _ := runJSONCommand(t, func(stdout io.Writer) {
+		if err := printAlbumQualities(stdout, client, "alb1", true); err != nil {
+			t.Errorf("printAlbumQualities: %v", err)
+		}
+	})
+
+	var got engine.AlbumQualities
+	decodeJSONDocument(t, stdout, &got)
+	if got.Title != "Album" || len(got.Tracks) != 2 {
+		t.Fatalf("decoded %+v, want both tracks of Album", got)
+	}
+	if got.Tracks[0].FormatID != 7 || got.Tracks[0].BitDepth != 24 || got.Tracks[1].Error == "" {
+		t.Errorf("tracks = %+v, want track 1 in format 7 and track 2 unavailable", got.Tracks)
+	}
+}
+
+func TestSearchJSON(t *testing.T) {
+	srv, _ := newSearchServer(t, []string{"alb1", "alb2"}, "")
+	client := api.NewClient("app", "secret")
+	client.HTTP.SetBaseURL(srv.URL)
+
+	stdout, _ := runJSONCommand(t, func(stdout io.Writer) {
+		results, err := client.Search("Album", 10)
+		if err != nil {
+			t.Fatalf("Search: %v", err)
+		}
+		printSearchResults(stdout, filterSearchResults(results, api.TypeAlbum), true)
+	})
+
+	var got []api.SearchResult
+	decodeJSONDocument(t, stdout, &got)
+	if len(got) != 2 || got[0].Type != api.TypeAlbum || got[1].Type != api.TypeAlbum {
+		t.Errorf("decoded %+v, want the two albums", got)
+	}
+}
+
+func TestPreviewTemplateJSON(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/album/get" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"id":"alb1","title":"Album","artist":{"name":"Band"},"tracks_count":2,"media_count":1,"tracks":{"total":2,"items":[
+			{"id":1,"title":"One","track_number":1,"media_number":1},
+			{"id":2,"title":"Two","track_number":2,"media_number":1}]}}`))
+	}))
+	defer srv.Close()
+	client := api.NewClient("app", "secret")
+	client.HTTP.SetBaseURL(srv.URL)
+
+	stdout, _ := runJSONCommand(t, func(stdout io.Writer) {
+		preview, err := engine.New(client).PreviewAlbumTemplate("alb1", "out")
+		if err != nil {
+			t.Fatalf("PreviewAlbumTemplate: %v", err)
+		}
+		printTemplatePreview(stdout, preview, true)
+	})
+
+	var got engine.TemplatePreview
+	decodeJSONDocument(t, stdout, &got)
+	if got.Template == "" || len(got.Tracks) != 2 {
+		t.Fatalf("decoded %+v, want the template and both tracks", got)
+	}
+	if want := filepath.Join("out", "Band - Album", "01. One"); got.Tracks[0].Path != want {
+		t.Errorf("first path = %q, want %q", got.Tracks[0].Path, want)
+	}
+}
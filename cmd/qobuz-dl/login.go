@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/config"
+)
+
+// skipSavedToken makes setupClient ignore the saved user token and log in again.
+var skipSavedToken bool
+
+// newLoginCmd creates the login command that refreshes and saves credentials
+// without downloading anything.
+func newLoginCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "login",
+		Short: "Authenticate and save a fresh user token without downloading",
+		Run: func(cmd *cobra.Command, args []string) {
+			// Re-authenticate with email/password instead of reusing the cached token
+			skipSavedToken = flagToken == ""
+
+			client, err := setupClient(false)
+			if err != nil {
+				fmt.Printf("Login failed: %v\n", err)
+				os.Exit(exitCodeFor(err))
+			}
+
+			if !client.ValidateSecret() {
+				fmt.Println("Login failed: the token or app secret was rejected by Qobuz")
+				os.Exit(1)
+			}
+
+			if err := saveLoginToken(); err != nil {
+				fmt.Printf("Warning: Failed to save account: %v\n", err)
+			}
+
+			fmt.Println("Login successful!")
+			fmt.Printf("  App ID: %s\n", client.AppID)
+			fmt.Printf("  Token:  %s\n", redactToken(client.UserToken))
+			if flagNoSave {
+				fmt.Println("  Credentials were not saved (--nosave)")
+			} else {
+				fmt.Printf("  Saved to %s\n", config.GetAccountPath())
+			}
+		},
+	}
+}
+
+// saveLoginToken saves the token passed with --token, unless --nosave is set.
+// setupClient only persists tokens obtained by logging in.
+func saveLoginToken() error {
+	if flagToken == "" || flagNoSave {
+		return nil
+	}
+	acc, err := config.LoadAccount()
+	if err != nil {
+		return err
+	}
+	acc.UserToken = flagToken
+	return config.SaveAccount(acc)
+}
+
+// redactToken hides all but the first and last few characters of a token.
+func redactToken(token string) string {
+	const visible = 4
+	if len(token) <= visible*2 {
+		return "****"
+	}
+	return token[:visible] + "..." + token[len(token)-visible:]
+}
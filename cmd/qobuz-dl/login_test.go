@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/config"
+)
+
+func TestRedactToken(t *testing.T) {
+	tests := []struct{ token, want string }{
+		{token: "", want: "****"},
+		{token: "short", want: "****"},
+		{token: "12345678", want: "****"},
+		{token: "123456789", want: "1234...6789"},
+		{token: "abcdEFGH-secret-part-wxyz", want: "abcd...wxyz"},
+	}
+	for _, tt := range tests {
+		if got := redactToken(tt.token); got != tt.want {
+			t.Errorf("redactToken(%q) = %q, want %q", tt.token, got, tt.want)
+		}
+	}
+}
+
+func TestSaveLoginToken(t *testing.T) {
+	saved := &config.Account{Email: "user@example.com", UserToken: "old-token", AppID: "app", AppSecret: "app-secret"}
+
+	tests := []struct {
+		name     string
+		token    string // --token
+		noSave   bool   // --nosave
+		existing *config.Account
+		corrupt  bool // account.json holds invalid JSON
+		want     *config.Account
+		wantErr  bool
+	}{
+		{name: "no account yet", token: "new-token", want: &config.Account{UserToken: "new-token"}},
+		{
+			name: "replaces the saved token", token: "new-token", existing: saved,
+			want: &config.Account{Email: "user@example.com", UserToken: "new-token", AppID: "app", AppSecret: "app-secret"},
+		},
+		{name: "logged in with a password", existing: saved, want: saved},
+		{name: "nosave", token: "new-token", noSave: true, existing: saved, want: saved},
+		{name: "corrupt account file", token: "new-token", corrupt: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := config.GetAccountPath()
+			if _, err := os.Stat(path); err == nil {
+				t.Skipf("%s exists; not overwriting it", path)
+			}
+			t.Cleanup(func() { os.Remove(path) })
+			switch {
+			case tt.corrupt:
+				if err := os.WriteFile(path, []byte("{not json"), 0600); err != nil {
+					t.Fatal(err)
+				}
+			case tt.existing != nil:
+				if err := config.SaveAccount(tt.existing); err != nil {
+					t.Fatal(err)
+				}
+			}
+			flagToken, flagNoSave = tt.token, tt.noSave
+			t.Cleanup(func() { flagToken, flagNoSave = "", false })
+
+			err := saveLoginToken()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("saveLoginToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if data, _ := os.ReadFile(path); string(data) != "{not json" {
+					t.Errorf("account file rewritten after a failed load: %q", data)
+				}
+				return
+			}
+			got, err := config.LoadAccount()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Email != tt.want.Email || got.UserToken != tt.want.UserToken || got.AppID != tt.want.AppID || got.AppSecret != tt.want.AppSecret {
+				t.Errorf("saved account = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
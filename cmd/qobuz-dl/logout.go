@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/config"
+)
+
+// newLogoutCmd creates the logout command that clears stored credentials.
+func newLogoutCmd() *cobra.Command {
+	var profile string
+	var yes bool
+	var keepApp bool
+
+	cmd := &cobra.Command{
+		Use:   "logout",
+		Short: "Clear stored credentials from account.json",
+		Run: func(cmd *cobra.Command, args []string) {
+			if !yes {
+				fmt.Printf("Clear stored credentials in %s? [y/N]: ", config.GetAccountPath())
+				answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+				answer = strings.ToLower(strings.TrimSpace(answer))
+				if answer != "y" && answer != "yes" {
+					fmt.Println("Aborted.")
+					return
+				}
+			}
+
+			if err := config.ClearAccount(profile, keepApp); err != nil {
+				fmt.Printf("Logout failed: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println("Logged out. Stored credentials cleared.")
+		},
+	}
+
+	cmd.Flags().StringVar(&profile, "profile", "", "Account profile to clear (default profile if empty)")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Do not ask for confirmation")
+	cmd.Flags().BoolVar(&keepApp, "keep-app", false, "Keep the App ID and secret so the next login skips fetching them")
+
+	return cmd
+}
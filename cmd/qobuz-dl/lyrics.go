@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/engine"
+)
+
+// newLyricsCmd creates the lyrics command that embeds user-supplied LRC lyrics
+// into downloaded files. Qobuz does not provide lyrics.
+func newLyricsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lyrics [file|dir] [lrc]",
+		Short: "Embed synchronized lyrics from an .lrc file into a downloaded FLAC or MP3",
+		Long: `Embed synchronized lyrics into downloaded files. Qobuz does not provide lyrics,
+so they come from .lrc files you supply.
+
+With a file and an .lrc, the lyrics are embedded and the .lrc is copied next to
+the file so later re-tags keep them. With only a file, its existing .lrc sidecar
+is used. With a directory, every FLAC or MP3 that has an .lrc sidecar is updated.`,
+		Args: cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			info, err := os.Stat(args[0])
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			tagger := engine.NewTagger()
+			if !info.IsDir() {
+				lrcPath := engine.LyricsSidecarPath(args[0])
+				if len(args) == 2 {
+					lrcPath = args[1]
+				}
+				if err := tagger.EmbedLyricsFile(args[0], lrcPath); err != nil {
+					fmt.Printf("Error: %v\n", err)
+					os.Exit(1)
+				}
+				fmt.Printf("[Lyrics] %s\n", args[0])
+				return
+			}
+
+			if len(args) == 2 {
+				fmt.Println("Error: an .lrc file can only be given for a single audio file")
+				os.Exit(1)
+			}
+			files, err := engine.FindLyricsSidecars(args[0])
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			if len(files) == 0 {
+				fmt.Println("No files with .lrc sidecars found.")
+				return
+			}
+
+			failed := 0
+			for _, f := range files {
+				if err := tagger.EmbedLyricsFile(f, engine.LyricsSidecarPath(f)); err != nil {
+					fmt.Printf("[Error] %s: %v\n", f, err)
+					failed++
+					continue
+				}
+				fmt.Printf("[Lyrics] %s\n", f)
+			}
+			if failed > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+	return cmd
+}
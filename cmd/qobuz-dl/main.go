@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -31,7 +32,49 @@ var (
 	flagNoSave    bool
 	flagPort      string
 	flagThreads   int
-	flagNoCDN     bool // Disable CDN proxy site
+	flagNoCDN     bool          // Disable CDN proxy site
+	flagSecretsTO time.Duration // Per-request timeout while scraping the web player for secrets
+	flagSince     string        // Only download artist albums released on or after this date
+	flagExport    string        // Write the download plan to this file instead of downloading
+	flagExportFmt string        // Export plan format (sh/json)
+	flagNoSplit   bool          // Keep combined performer names in a single artist tag
+	flagSplitList bool          // Also split performers on "&" and ", "
+	flagNoWork    bool          // Don't split "Work: I. Movement" titles into work tags
+	flagDateFrom  string        // Release date used for the DATE tag (original/stream)
+	flagExt       string        // Force the output file extension
+	flagVerify    bool          // Check FLAC downloads for truncation
+	flagIfExists  string        // Strategy for existing output files
+	flagExtraArt  bool          // Embed back cover and booklet images
+	flagDiscArt   bool          // Save and embed per-disc artwork for box sets
+	flagSquare    bool          // Center-crop non-square covers before embedding
+	flagIdle      time.Duration // Abort downloads receiving no data for this long
+	flagUPC       string        // Look up the album to download by barcode
+	flagGlobal    int           // Maximum simultaneous downloads across albums
+	flagAPIConc   int           // Maximum simultaneous API requests
+	flagChecksums bool          // Write checksums.sha256 in album folders
+	flagHeaders   []string      // Extra HTTP headers (key=value) for API and download requests
+	flagMatchTags bool          // Recognize existing files by embedded tags
+	flagCovers    int           // Workers prefetching covers of upcoming artist albums
+	flagMetaConc  int           // Workers prefetching album metadata for artist downloads
+	flagStdout    bool          // Write the track audio to stdout instead of a file
+	flagStdoutTag bool          // Tag the audio written to stdout
+	flagStdoutFmt string        // Transcode the audio written to stdout (mp3, aac, opus)
+	flagFailFast  bool          // Stop artist/playlist/batch runs at the first failure
+	flagKeepGoing bool          // Log tagging failures to .tag-errors.log instead of only printing them
+	flagConnsFile int           // Parallel Range connections per file
+	flagMtime     bool          // Set file modification times to the release date
+	flagMinDepth  int           // Skip albums and tracks below this bit depth
+	flagNoReuse   bool          // Always create album folders from the current metadata
+	flagHTTP1     bool          // Force HTTP/1.1 for proxies that mishandle HTTP/2
+	flagLocale    string        // Language of catalog metadata (e.g. ja, en)
+	flagForce     bool          // Ignore the artist sync state and re-check every album
+	flagPreview   bool          // Download 30-second preview clips instead of full tracks
+	flagVerifyTag bool          // Read tags back after tagging to confirm they were written
+	flagProgFD    int           // File descriptor receiving JSON progress events (-1 = none)
+	flagInsecure  bool          // Skip TLS certificate verification (TLS-intercepting proxies)
+	flagCACert    string        // PEM bundle of extra trusted CAs (private proxy CAs)
+	flagDelay     time.Duration // Pause between albums of artist and batch runs
+	flagSidecars  bool          // Write a JSON metadata file next to each track
 )
 
 func main() {
@@ -55,36 +98,60 @@ func main() {
 			client, err := setupClient(true) // strict=true? Maybe false for server?
 			if err != nil {
 				fmt.Printf("Startup Error: %v\n", err)
-				os.Exit(1)
+				os.Exit(exitCodeFor(err))
 			}
 
 			eng := engine.New(client)
 			fmt.Printf("Starting Server on port %s...\n", flagPort)
-			server.Start(eng, flagPort)
+			server.Start(eng, flagPort, flagOutputDir)
 		},
 	}
 	serveCmd.Flags().StringVarP(&flagPort, "port", "P", "8080", "Server port")
+	serveCmd.Flags().StringVarP(&flagOutputDir, "output", "o", ".", "Output directory for downloads started with POST /jobs")
 
 	var dlCmd = &cobra.Command{
 		Use:   "dl [track_id/url]",
-		Short: "Download a track or album by ID or URL",
-		Args:  cobra.ExactArgs(1),
+		Short: "Download a track, album or artist by ID or URL",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if flagUPC != "" {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
 		Run: func(cmd *cobra.Command, args []string) {
-			input := args[0]
+			// Keep stdout for audio only; every message goes to stderr
+			stdout := os.Stdout
+			if flagStdout {
+				os.Stdout = os.Stderr
+			}
 
 			// Setup Client
 			client, err := setupClient(false)
 			if err != nil {
 				fmt.Printf("Error: %v\n", err)
-				os.Exit(1)
+				os.Exit(exitCodeFor(err))
 			}
 
-			// Parse Resource
-			resType, id, err := api.ParseURL(input)
-			if err != nil {
-				// Fallback to track ID if pure digits or simple string
-				resType = api.TypeTrack
-				id = input
+			var resType api.ResourceType
+			var id string
+			if flagUPC != "" {
+				// Resolve the barcode to an album
+				album, err := client.GetAlbumByUPC(flagUPC)
+				if err != nil {
+					fmt.Printf("UPC lookup failed: %v\n", err)
+					os.Exit(exitCodeFor(err))
+				}
+				fmt.Printf("UPC %s: %s - %s\n", flagUPC, album.Artist.Name, album.Title)
+				resType, id = api.TypeAlbum, album.ID
+			} else {
+				// Parse Resource
+				input := args[0]
+				resType, id, err = api.ParseURL(input)
+				if err != nil {
+					// Fallback to track ID if pure digits or simple string
+					resType = api.TypeTrack
+					id = input
+				}
 			}
 
 			fmt.Printf("Processing %s ID: %s\n", resType, id)
@@ -92,9 +159,79 @@ func main() {
 			// Initialize Engine
 			eng := engine.New(client)
 
+			// Apply path settings from config
+			if cfg, err := config.LoadConfig(); err == nil {
+				eng.MaxPathLength = cfg.MaxPathLength
+				eng.LongPaths = cfg.LongPaths
+				eng.Tagger.WriteSource = !cfg.DisableSourceTags
+				if cfg.Quality != 0 && !cmd.Flags().Changed("quality") {
+					flagQuality = cfg.Quality // Precedence: .qobuz-quality > --quality > config
+				}
+				if cfg.FlacPadding != 0 {
+					eng.Tagger.PaddingBytes = max(cfg.FlacPadding, 0)
+				}
+				eng.GroupByInitial = cfg.GroupByInitial
+				if err := applyFileNaming(eng, cfg); err != nil {
+					fmt.Printf("Invalid config: %v\n", err)
+					os.Exit(1)
+				}
+			}
+
 			// Set concurrency if specified
-			if flagThreads > 0 {
-				eng.SetConcurrency(flagThreads)
+			applyThreads(eng)
+
+			eng.Tagger.SplitArtists = !flagNoSplit
+			eng.Tagger.SplitArtistLists = flagSplitList
+			eng.Tagger.SplitWorkTitles = !flagNoWork
+			if flagDateFrom != engine.DateSourceOriginal && flagDateFrom != engine.DateSourceStream {
+				fmt.Printf("Invalid --date-from %q (use original or stream)\n", flagDateFrom)
+				os.Exit(1)
+			}
+			eng.Tagger.DateSource = flagDateFrom
+			eng.ForceExt = flagExt
+			eng.QuickVerify = flagVerify
+			if !engine.ValidIfExists(flagIfExists) {
+				fmt.Printf("Invalid --if-exists %q (use skip, overwrite, upgrade or resume)\n", flagIfExists)
+				os.Exit(1)
+			}
+			eng.IfExists = flagIfExists
+			eng.EmbedExtraArt = flagExtraArt
+			eng.DiscArt = flagDiscArt
+			eng.SquareCover = flagSquare
+			eng.IdleTimeout = flagIdle
+			eng.GlobalConcurrency = flagGlobal
+			eng.APIConcurrency = flagAPIConc
+			eng.WriteChecksums = flagChecksums
+			eng.MatchByTags = flagMatchTags
+			eng.ParallelCovers = flagCovers
+			eng.MetadataWorkers = flagMetaConc
+			eng.FailFast = flagFailFast
+			eng.KeepGoing = flagKeepGoing
+			eng.ConnectionsPerFile = flagConnsFile
+			eng.SetReleaseDateMtime = flagMtime
+			eng.MinBitDepth = flagMinDepth
+			eng.ReuseAlbumDirs = !flagNoReuse
+			eng.IgnoreSyncState = flagForce
+			eng.Preview = flagPreview
+			eng.VerifyTags = flagVerifyTag
+			eng.AlbumDelay = flagDelay
+			eng.TrackSidecars = flagSidecars
+			if flagProgFD >= 0 {
+				progressFile, err := openProgressFD(flagProgFD)
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					os.Exit(1)
+				}
+				eng.OnProgressEvent = newProgressWriter(progressFile)
+			}
+
+			if flagSince != "" {
+				since, err := time.Parse("2006-01-02", flagSince)
+				if err != nil {
+					fmt.Printf("Invalid --since date %q (expected YYYY-MM-DD)\n", flagSince)
+					os.Exit(1)
+				}
+				eng.Since = since
 			}
 
 			// Default Output Dir from Config if not flagged
@@ -102,12 +239,43 @@ func main() {
 				// We could load config default here, but let's stick to current dir
 			}
 
-			if resType == api.TypeAlbum {
+			if flagStdout {
+				streamToStdout(eng, resType, id, stdout)
+				return
+			}
+
+			if flagExport != "" {
+				exportPlan(eng, resType, id)
+				return
+			}
+
+			if resType == api.TypeArtist {
+				// Artist Discography Download
+				err := eng.DownloadArtist(context.Background(), id, flagQuality, flagOutputDir)
+				if err != nil {
+					fmt.Printf("Artist download failed: %v\n", err)
+					os.Exit(exitCodeFor(err))
+				}
+			} else if resType == api.TypePlaylist {
+				// Playlist Download (each track tagged with its own album cover)
+				err := eng.DownloadPlaylist(context.Background(), id, flagQuality, flagOutputDir)
+				if err != nil {
+					fmt.Printf("Playlist download failed: %v\n", err)
+					os.Exit(exitCodeFor(err))
+				}
+			} else if resType == api.TypeAlbum {
 				// Album Download
-				err := eng.DownloadAlbum(context.Background(), id, flagQuality, flagOutputDir)
+				result, err := eng.DownloadAlbum(context.Background(), id, flagQuality, flagOutputDir)
 				if err != nil {
 					fmt.Printf("Album download failed: %v\n", err)
-					os.Exit(1)
+					os.Exit(exitCodeFor(err))
+				}
+				printAlbumResult(result)
+				if len(result.Failed) > 0 {
+					if result.Partial() {
+						os.Exit(exitPartial)
+					}
+					os.Exit(exitError)
 				}
 			} else {
 				// Track Download with simple progress
@@ -116,14 +284,20 @@ func main() {
 					if total > 0 {
 						percent := int(float64(current) / float64(total) * 100)
 						fmt.Printf("\r  Progress: %d%%", percent)
+					} else {
+						fmt.Printf("\r  Downloading... %.1f MB", float64(current)/(1<<20))
 					}
 				})
 
 				if err != nil {
-					fmt.Printf("\nDownload failed: %v\n", err)
-					os.Exit(1)
+					fmt.Printf("\nDownload failed (%s): %v\n", engine.FailureDescription(engine.FailureReason(err)), err)
+					os.Exit(exitCodeFor(err))
+				}
+				if flagPreview {
+					fmt.Println("\n  Done (preview clip only)")
+				} else {
+					fmt.Println("\n  Done!")
 				}
-				fmt.Println("\n  Done!")
 			}
 
 			fmt.Println("Work complete!")
@@ -131,9 +305,45 @@ func main() {
 	}
 
 	// dlCmd Flags
-	dlCmd.Flags().IntVarP(&flagQuality, "quality", "q", 6, "Quality ID (5=MP3, 6=FLAC 16bit, 7=FLAC 24bit, 27=FLAC 24bit>96)")
+	addQualityFlag(dlCmd)
 	dlCmd.Flags().StringVarP(&flagOutputDir, "output", "o", ".", "Output directory")
-	dlCmd.Flags().IntVarP(&flagThreads, "threads", "n", 3, "Number of concurrent download threads (1-10)")
+	addThreadsFlag(dlCmd)
+	dlCmd.Flags().StringVar(&flagExport, "export-plan", "", "Write signed download commands to this file instead of downloading (album only)")
+	dlCmd.Flags().StringVar(&flagExportFmt, "export-format", "sh", "Export plan format (sh, json)")
+	dlCmd.Flags().BoolVar(&flagNoSplit, "no-split-artists", false, "Do not split \"A feat. B\" performers into separate artist tags")
+	dlCmd.Flags().BoolVar(&flagSplitList, "split-artist-lists", false, "Also split performers on \"&\" and \", \" (breaks names like \"Simon & Garfunkel\")")
+	dlCmd.Flags().BoolVar(&flagNoWork, "no-split-work", false, "Do not derive WORK/MOVEMENT tags from \"Work: I. Movement\" track titles")
+	dlCmd.Flags().StringVar(&flagDateFrom, "date-from", engine.DateSourceOriginal, "Release date for the DATE tag: original or stream (ORIGINALDATE is always written)")
+	dlCmd.Flags().StringVar(&flagExt, "ext", "", "Force the output file extension (e.g. mp3); the audio is not converted")
+	dlCmd.Flags().BoolVar(&flagVerify, "quick-verify", false, "Check downloaded FLAC files for truncation and retry on mismatch")
+	dlCmd.Flags().StringVar(&flagIfExists, "if-exists", engine.IfExistsSkip, "What to do with existing files: skip, overwrite, upgrade (higher bit depth) or resume")
+	dlCmd.Flags().BoolVar(&flagExtraArt, "extra-art", false, "Also embed the back cover and image booklet pages when available")
+	dlCmd.Flags().BoolVar(&flagSquare, "square-cover", false, "Center-crop non-square covers before embedding (cover.jpg keeps the original)")
+	dlCmd.Flags().BoolVar(&flagDiscArt, "disc-art", false, "Save distinct disc artwork of box sets as disc1.jpg, disc2.jpg, ... and embed it in each disc's tracks")
+	dlCmd.Flags().DurationVar(&flagIdle, "idle-timeout", 30*time.Second, "Abort and retry a download that receives no data for this long (0 disables)")
+	dlCmd.Flags().StringVar(&flagUPC, "upc", "", "Download the album with this UPC/EAN barcode instead of an ID or URL")
+	dlCmd.Flags().IntVar(&flagGlobal, "global-threads", 0, "Maximum simultaneous downloads across all albums (0 = unlimited)")
+	dlCmd.Flags().IntVar(&flagAPIConc, "api-threads", 0, "Maximum simultaneous API requests (track URLs, album metadata), independent of download threads (0 = unlimited)")
+	dlCmd.Flags().BoolVar(&flagChecksums, "checksums", false, "Write a checksums.sha256 manifest in each album folder")
+	dlCmd.Flags().BoolVar(&flagMatchTags, "match-tags", false, "Skip tracks already present under another file name, matched by embedded ISRC or title tags")
+	dlCmd.Flags().IntVar(&flagCovers, "parallel-covers", 0, "Prefetch the covers of upcoming artist albums with this many workers (0 = disabled)")
+	dlCmd.Flags().IntVar(&flagMetaConc, "metadata-concurrency", 0, "Fetch artist album metadata ahead of the downloads with this many concurrent requests (0 = disabled)")
+	dlCmd.Flags().BoolVar(&flagStdout, "stdout", false, "Write the track's audio to stdout instead of a file (tracks only); messages go to stderr")
+	dlCmd.Flags().BoolVar(&flagStdoutTag, "stdout-tags", false, "With --stdout, embed tags and cover art (the track is downloaded to a temp file first)")
+	dlCmd.Flags().StringVar(&flagStdoutFmt, "stdout-format", "", "With --stdout, transcode the audio through ffmpeg (mp3, aac, opus)")
+	dlCmd.Flags().BoolVar(&flagNoReuse, "no-reuse-folders", false, "Do not reuse an existing album folder found by its .album-id marker; name folders from the current metadata")
+	dlCmd.Flags().BoolVar(&flagMtime, "mtime-release-date", false, "Set the modification time of downloaded files and covers to the album's original release date")
+	dlCmd.Flags().IntVar(&flagConnsFile, "connections-per-file", 1, "Download each large file over this many parallel connections when the server supports ranges")
+	dlCmd.Flags().BoolVar(&flagKeepGoing, "keep-going", false, "Keep every downloaded file when tagging fails and list the failures in .tag-errors.log in the album folder")
+	dlCmd.Flags().BoolVar(&flagFailFast, "fail-fast", false, "Stop an artist or playlist download at the first failed album or track")
+	dlCmd.Flags().IntVar(&flagMinDepth, "min-bitdepth", 0, "Skip artist albums and album tracks whose highest available bit depth is below this (e.g. 24)")
+	dlCmd.Flags().BoolVar(&flagForce, "force", false, "Re-check every artist album, including those "+engine.SyncStateFile+" marks as completed")
+	dlCmd.Flags().BoolVar(&flagPreview, "preview", false, "Download the 30-second preview clips (saved with a \""+strings.TrimSpace(engine.PreviewSuffix)+"\" suffix) instead of full tracks")
+	dlCmd.Flags().BoolVar(&flagVerifyTag, "verify-after-download", false, "Read tags back after tagging and report tracks whose title, artist, album or cover did not stick")
+	dlCmd.Flags().IntVar(&flagProgFD, "progress-fd", -1, "Write newline-delimited JSON progress events to this file descriptor (for GUI frontends)")
+	dlCmd.Flags().DurationVar(&flagDelay, "delay-between-albums", 0, "Pause this long between the albums of an artist download (e.g. 30s), to stay under rate limits on long runs")
+	dlCmd.Flags().BoolVar(&flagSidecars, "sidecar-per-track", false, "Write a JSON file named like each track (e.g. \"01. Title.json\") with its full metadata and delivered format")
+	dlCmd.Flags().StringVar(&flagSince, "since", "", "Only download artist albums released on or after this date (YYYY-MM-DD)")
 
 	// Update Command
 	var updateCmd = &cobra.Command{
@@ -146,6 +356,18 @@ func main() {
 					fmt.Printf("Warning: Failed to set proxy for update: %v\n", err)
 				}
 			}
+			if flagInsecure {
+				fmt.Println(insecureWarning)
+				updater.SetInsecureSkipVerify(true)
+			}
+			if flagCACert != "" {
+				pool, err := api.LoadCertPool(flagCACert)
+				if err != nil {
+					fmt.Printf("Invalid --ca-cert: %v\n", err)
+					os.Exit(1)
+				}
+				updater.SetRootCAs(pool)
+			}
 
 			fmt.Println("Checking for updates...")
 
@@ -174,7 +396,7 @@ func main() {
 			fmt.Printf("Downloading %s (%.2f MB)...\n", asset.Name, float64(asset.Size)/1024/1024)
 
 			// Download and apply update atomically
-			err = updater.DownloadAndApply(asset, result.ReleaseInfo.TagName, func(current, total int64) {
+			err = updater.DownloadAndApply(result.ReleaseInfo, asset, func(current, total int64) {
 				percent := int(float64(current) / float64(total) * 100)
 				fmt.Printf("\r  Progress: %d%%", percent)
 			})
@@ -209,7 +431,7 @@ Generated files:
 			switch args[0] {
 			case "bash":
 				filename = "qobuz-dl-go.bash"
-				rootCmd.GenBashCompletion(&content)
+				rootCmd.GenBashCompletionV2(&content, true)
 			case "zsh":
 				filename = "_qobuz-dl-go"
 				rootCmd.GenZshCompletion(&content)
@@ -239,6 +461,20 @@ Generated files:
 	rootCmd.AddCommand(serveCmd)
 	rootCmd.AddCommand(updateCmd)
 	rootCmd.AddCommand(completionCmd)
+	rootCmd.AddCommand(newDoctorCmd())
+	rootCmd.AddCommand(newBatchCmd())
+	rootCmd.AddCommand(newLoginCmd())
+	rootCmd.AddCommand(newLogoutCmd())
+	rootCmd.AddCommand(newAlbumQualitiesCmd())
+	rootCmd.AddCommand(newReorganizeCmd())
+	rootCmd.AddCommand(newSearchCmd())
+	rootCmd.AddCommand(newRefreshCmd())
+	rootCmd.AddCommand(newVerifyChecksumsCmd())
+	rootCmd.AddCommand(newBrowseCmd())
+	rootCmd.AddCommand(newUpgradeCoversCmd())
+	rootCmd.AddCommand(newPreviewTemplateCmd())
+	rootCmd.AddCommand(newPurchasesCmd())
+	rootCmd.AddCommand(newLyricsCmd())
 
 	// Global Flags
 	rootCmd.PersistentFlags().StringVar(&flagAppID, "app-id", "", "Qobuz App ID")
@@ -247,15 +483,25 @@ Generated files:
 	rootCmd.PersistentFlags().StringVarP(&flagPassword, "password", "p", "", "User Password")
 	rootCmd.PersistentFlags().StringVarP(&flagToken, "token", "t", "", "User Auth Token")
 	rootCmd.PersistentFlags().StringVar(&flagProxy, "proxy", "", "Proxy URL (http/https/socks5), overrides HTTP_PROXY/HTTPS_PROXY env")
+	rootCmd.PersistentFlags().StringVar(&flagLocale, "locale", "", "Language to request titles and names in (e.g. ja, en); default is the server's choice")
+	rootCmd.PersistentFlags().BoolVar(&flagHTTP1, "http1", false, "Use HTTP/1.1 only, for proxies that stall or fail on HTTP/2")
+	rootCmd.PersistentFlags().BoolVar(&flagInsecure, "insecure", false, "Skip TLS certificate verification, for TLS-intercepting proxies (INSECURE: exposes your credentials)")
+	rootCmd.PersistentFlags().StringVar(&flagCACert, "ca-cert", "", "Also trust the CA certificates in this PEM file, for proxies with a private CA")
 	rootCmd.PersistentFlags().BoolVar(&flagNoSave, "nosave", false, "Do not save credentials to account.json")
+	rootCmd.PersistentFlags().StringArrayVar(&flagHeaders, "header", nil, "Extra HTTP header for API and download requests (key=value, repeatable)")
 	rootCmd.PersistentFlags().BoolVar(&flagNoCDN, "nocdn", false, "Disable CDN proxy, connect to Qobuz directly")
+	rootCmd.PersistentFlags().DurationVar(&flagSecretsTO, "secrets-timeout", api.DefaultSecretsTimeout, "Per-request timeout when fetching the App ID and secrets (0 disables)")
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
 
-	// Show version info after command execution
+	// Show version info after command execution, except after completion
+	// requests whose output the shell parses
+	if len(os.Args) > 1 && (os.Args[1] == cobra.ShellCompRequestCmd || os.Args[1] == cobra.ShellCompNoDescRequestCmd) {
+		return
+	}
 	showVersionInfo()
 }
 
@@ -284,13 +530,14 @@ func setupClient(isServer bool) (*api.Client, error) {
 	needSecretValidation := false
 	if appID == "" {
 		fmt.Println("App ID missing. Fetching from Qobuz...")
-		fetchedID, secrets, err := api.FetchSecrets(flagProxy, !flagNoCDN)
+		fetchedID, secrets, err := fetchSecrets()
 		if err != nil {
 			return nil, fmt.Errorf("failed to fetch secrets: %w", err)
 		}
 		appID = fetchedID
 		// Store secrets for later validation after login
 		acc.PendingSecrets = secrets
+		acc.SecretsCache = config.NewSecretsCache(fetchedID, secrets)
 		needSecretValidation = true
 	} else if appSecret == "" {
 		// Have appID but no secret
@@ -298,23 +545,20 @@ func setupClient(isServer bool) (*api.Client, error) {
 	}
 
 	// 4. Create Client with current appID/appSecret
-	client := api.NewClient(appID, appSecret)
-
-	// Set CDN proxy preference
+	client, err := newAPIClient(appID, appSecret)
+	if err != nil {
+		return nil, err
+	}
 	if flagNoCDN {
-		client.SetUseProxy(false)
 		fmt.Println("CDN proxy disabled, using direct connection")
 	}
-
-	if flagProxy != "" {
-		if err := client.SetProxy(flagProxy); err != nil {
-			fmt.Printf("Warning: Failed to set proxy: %v\n", err)
-		}
+	if flagInsecure {
+		fmt.Println(insecureWarning)
 	}
 
 	// 5. Resolve User Auth FIRST (needed for secret validation)
 	userToken := flagToken
-	if userToken == "" && acc.UserToken != "" {
+	if userToken == "" && acc.UserToken != "" && !skipSavedToken {
 		userToken = acc.UserToken
 	}
 
@@ -368,7 +612,7 @@ func setupClient(isServer bool) (*api.Client, error) {
 				acc.UserID = resp.User.ID
 			}
 		} else if !isServer {
-			return nil, fmt.Errorf("authentication required. Provide --token or --email/--password")
+			return nil, errAuthRequired
 		} else {
 			fmt.Println("Warning: Starting server without user authentication. Some features may fail.")
 		}
@@ -380,29 +624,48 @@ func setupClient(isServer bool) (*api.Client, error) {
 			fmt.Println("Saved secret is invalid. Refreshing...")
 		}
 
-		// Get fresh secrets if we don't have pending ones
+		// Before scraping, try the saved secret with the fallback App IDs
+		var validID, validSecret string
+		if len(acc.PendingSecrets) == 0 && appSecret != "" {
+			validID, validSecret, _ = client.FindValidAppID([]string{appSecret})
+		}
+
+		// Then the candidates cached from an earlier scrape, unless they expired
 		secrets := acc.PendingSecrets
-		if len(secrets) == 0 {
-			fmt.Println("Fetching secrets from Qobuz...")
-			fetchedID, fetchedSecrets, err := api.FetchSecrets(flagProxy, !flagNoCDN)
-			if err != nil {
-				return nil, fmt.Errorf("failed to fetch secrets: %w", err)
-			}
-			appID = fetchedID
-			secrets = fetchedSecrets
-			client = api.NewClient(appID, "")
-			if flagProxy != "" {
-				client.SetProxy(flagProxy)
+		if validSecret == "" && len(secrets) == 0 {
+			validID, validSecret = cachedSecret(client, acc.SecretsCache)
+		}
+
+		if validSecret == "" {
+			// Get fresh secrets if we don't have pending ones
+			if len(secrets) == 0 {
+				fmt.Println("Fetching secrets from Qobuz...")
+				fetchedID, fetchedSecrets, err := fetchSecrets()
+				if err != nil {
+					return nil, fmt.Errorf("failed to fetch secrets: %w", err)
+				}
+				appID = fetchedID
+				secrets = fetchedSecrets
+				acc.SecretsCache = config.NewSecretsCache(fetchedID, fetchedSecrets)
+				client, err = newAPIClient(appID, "")
+				if err != nil {
+					return nil, err
+				}
+				if userToken != "" {
+					client.SetUserToken(userToken)
+				}
 			}
-			if userToken != "" {
-				client.SetUserToken(userToken)
+
+			fmt.Printf("Testing %d secrets for AppID: %s...\n", len(secrets), appID)
+			var err error
+			validID, validSecret, err = client.FindValidAppID(secrets)
+			if err != nil {
+				return nil, fmt.Errorf("no valid secret found: %w", err)
 			}
 		}
-
-		fmt.Printf("Testing %d secrets for AppID: %s...\n", len(secrets), appID)
-		validSecret, err := client.FindValidSecret(secrets)
-		if err != nil {
-			return nil, fmt.Errorf("no valid secret found: %w", err)
+		if validID != appID {
+			fmt.Printf("App ID %s rejected, using fallback App ID %s\n", appID, validID)
+			appID = validID
 		}
 
 		fmt.Println("Valid secret found!")
@@ -427,6 +690,176 @@ func setupClient(isServer bool) (*api.Client, error) {
 	return client, nil
 }
 
+// cachedSecret tests the candidates of an earlier scrape on client and returns
+// the first valid App ID and secret. It returns empty strings, without any
+// request, when the cache is missing or expired, so the caller scrapes again.
+func cachedSecret(client *api.Client, cache *config.SecretsCache) (string, string) {
+	if !cache.Fresh() {
+		return "", ""
+	}
+	fmt.Printf("Testing %d cached secrets...\n", len(cache.Secrets))
+	client.SetAppID(cache.AppID)
+	appID, secret, _ := client.FindValidAppID(cache.Secrets)
+	return appID, secret
+}
+
+// insecureWarning is printed whenever --insecure disables certificate verification.
+const insecureWarning = "WARNING: --insecure disables TLS certificate verification. Anyone between you and Qobuz can read your credentials and tamper with downloads and updates. Only use it behind a trusted TLS-intercepting proxy."
+
+// fetchSecrets scrapes the App ID and secrets from the web player,
+// honoring --proxy, --nocdn, --http1, --insecure, --ca-cert and --secrets-timeout.
+func fetchSecrets() (string, []string, error) {
+	fetcher := api.NewSecretsFetcher(flagProxy, !flagNoCDN)
+	fetcher.Timeout = flagSecretsTO
+	if flagHTTP1 {
+		fetcher.Client.EnableForceHTTP1()
+	}
+	if flagInsecure {
+		fetcher.Client.EnableInsecureSkipVerify()
+	}
+	if flagCACert != "" {
+		pool, err := api.LoadCertPool(flagCACert)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid --ca-cert: %w", err)
+		}
+		fetcher.Client.GetTLSClientConfig().RootCAs = pool
+	}
+	return fetcher.Fetch()
+}
+
+// newAPIClient creates a client for appID and appSecret with the connection
+// flags applied: --header, --nocdn, --proxy, --http1, --insecure, --ca-cert and
+// --locale. An unusable --proxy is reported as a warning and ignored.
+func newAPIClient(appID, appSecret string) (*api.Client, error) {
+	client := api.NewClient(appID, appSecret)
+	client.SetAppIDCandidates(api.DefaultAppIDCandidates)
+	if err := applyExtraHeaders(client); err != nil {
+		return nil, err
+	}
+	if flagNoCDN {
+		client.SetUseProxy(false)
+	}
+	if flagProxy != "" {
+		if err := client.SetProxy(flagProxy); err != nil {
+			fmt.Printf("Warning: Failed to set proxy: %v\n", err)
+		}
+	}
+	if flagHTTP1 {
+		client.ForceHTTP1()
+	}
+	client.SetInsecureSkipVerify(flagInsecure)
+	if flagCACert != "" {
+		if err := client.SetRootCAs(flagCACert); err != nil {
+			return nil, fmt.Errorf("invalid --ca-cert: %w", err)
+		}
+	}
+	if err := client.SetLocale(flagLocale); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// applyExtraHeaders sets the --header values on client.
+func applyExtraHeaders(client *api.Client) error {
+	for _, h := range flagHeaders {
+		key, value, ok := strings.Cut(h, "=")
+		if !ok {
+			return fmt.Errorf("invalid --header %q (expected key=value)", h)
+		}
+		if err := client.SetExtraHeader(key, value); err != nil {
+			return fmt.Errorf("invalid --header %q: %w", h, err)
+		}
+	}
+	return nil
+}
+
+// streamToStdout writes a single track to stdout, raw or tagged, optionally transcoded.
+// Albums, artists and playlists are rejected since their tracks cannot be told apart in one stream.
+func streamToStdout(eng *engine.Engine, resType api.ResourceType, id string, stdout *os.File) {
+	if resType != api.TypeTrack {
+		fmt.Printf("--stdout only supports single tracks (got %s)\n", resType)
+		os.Exit(exitError)
+	}
+
+	if flagStdoutFmt != "" {
+		if _, err := engine.TranscodeMimeType(flagStdoutFmt); err != nil {
+			fmt.Printf("Invalid --stdout-format: %v\n", err)
+			os.Exit(exitError)
+		}
+	}
+
+	if _, err := eng.StreamTrackAs(context.Background(), id, flagQuality, flagStdoutFmt, flagStdoutTag, stdout); err != nil {
+		fmt.Printf("Stream failed: %v\n", err)
+		os.Exit(exitCodeFor(err))
+	}
+}
+
+// exportPlan writes the resolved album download plan to the --export-plan file.
+func exportPlan(eng *engine.Engine, resType api.ResourceType, id string) {
+	if resType != api.TypeAlbum {
+		fmt.Printf("Plan export only supports albums (got %s)\n", resType)
+		os.Exit(1)
+	}
+
+	plan, err := eng.ExportPlanFile(id, flagQuality, flagOutputDir, flagExportFmt, flagExport)
+	if err != nil {
+		fmt.Printf("Plan export failed: %v\n", err)
+		os.Exit(exitCodeFor(err))
+	}
+
+	fmt.Printf("Plan written to %s (%d tracks", flagExport, len(plan.Tracks))
+	if len(plan.Failed) > 0 {
+		fmt.Printf(", %d unavailable", len(plan.Failed))
+	}
+	fmt.Println(")")
+	fmt.Println("Warning: signed URLs expire shortly. Run the plan soon after exporting.")
+}
+
+// printAlbumResult lists failed tracks and tagging errors from an album download.
+func printAlbumResult(result *engine.AlbumResult) {
+	for _, t := range result.Failed {
+		fmt.Printf("  [Failed] %s (%s): %v\n", t.Title, engine.FailureDescription(t.Reason), t.Err)
+	}
+	for _, t := range result.Success {
+		if t.TagErr != nil {
+			fmt.Printf("  [Tag] %s: %v\n", t.Title, t.TagErr)
+		}
+	}
+}
+
+// applyFileNaming validates the configured file name templates and cover file
+// names and sets them on the engine.
+func applyFileNaming(eng *engine.Engine, cfg *config.Config) error {
+	if cfg.SingleTrackTemplate != "" {
+		if err := engine.CheckNamingTemplate(cfg.SingleTrackTemplate, true); err != nil {
+			return fmt.Errorf("single_track_template: %w", err)
+		}
+		eng.SingleTrackTemplate = cfg.SingleTrackTemplate
+	}
+	if cfg.AlbumTrackTemplate != "" {
+		if err := engine.CheckNamingTemplate(cfg.AlbumTrackTemplate, false); err != nil {
+			return fmt.Errorf("album_track_template: %w", err)
+		}
+		eng.AlbumTrackTemplate = cfg.AlbumTrackTemplate
+	}
+	if len(cfg.CoverFilenames) > 0 {
+		if err := engine.CheckCoverFilenames(cfg.CoverFilenames); err != nil {
+			return fmt.Errorf("cover_filenames: %w", err)
+		}
+		eng.CoverFilenames = cfg.CoverFilenames
+	}
+	return nil
+}
+
+// messagesToStderr points os.Stdout at stderr for a command printing a JSON
+// document, so that status lines and the version banner shown after the
+// command cannot corrupt it. It returns the real stdout for the document.
+func messagesToStderr() *os.File {
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	return stdout
+}
+
 // showVersionInfo displays version information and checks for updates
 func showVersionInfo() {
 	// Always show current version
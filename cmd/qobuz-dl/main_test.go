@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/engine"
+)
+
+func TestStreamToStdout(t *testing.T) {
+	tests := []struct {
+		name    string
+		quality int
+		audio   []byte
+	}{
+		{name: "small track", quality: 6, audio: []byte("fLaC\x00\x00\x00\x22 short stream")},
+		{name: "binary track", quality: 27, audio: bytes.Repeat([]byte{0xFF, 0xF8, 0x00, '\n', '\r', 0x1A}, 50000)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/track/getFileUrl":
+					w.Header().Set("Content-Type", "application/json")
+					fmt.Fprintf(w, `{"url":"http://%s/file/1.flac","mime_type":"audio/flac","format_id":%s}`, r.Host, r.URL.Query().Get("format_id"))
+				case "/file/1.flac":
+					w.Write(tt.audio)
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer srv.Close()
+
+			client := api.NewClient("app", "secret")
+			client.HTTP.SetBaseURL(srv.URL)
+			eng := engine.New(client)
+
+			dir := t.TempDir()
+			stdout, err := os.Create(filepath.Join(dir, "stdout"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer stdout.Close()
+			// Messages go to os.Stdout, which dl points at stderr with --stdout
+			messages, err := os.Create(filepath.Join(dir, "messages"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer messages.Close()
+			realStdout := os.Stdout
+			os.Stdout = messages
+			defer func() { os.Stdout = realStdout }()
+
+			flagQuality, flagStdoutFmt, flagStdoutTag = tt.quality, "", false
+			streamToStdout(eng, api.TypeTrack, "1", stdout)
+
+			got, err := os.ReadFile(stdout.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.audio) {
+				t.Errorf("stdout has %d bytes, want the %d byte stream unchanged", len(got), len(tt.audio))
+			}
+		})
+	}
+}
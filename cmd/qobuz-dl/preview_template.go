@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/config"
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/engine"
+)
+
+// newPreviewTemplateCmd creates the preview-template command that prints the
+// paths the naming templates produce for an album or track, without downloading.
+func newPreviewTemplateCmd() *cobra.Command {
+	var (
+		outputDir string
+		template  string
+		asJSON    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "preview-template [url/id]",
+		Short: "Show the file names the naming templates produce for an album or track",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			resType, id, err := api.ParseURL(args[0])
+			if err != nil {
+				resType, id = api.TypeTrack, args[0] // Same fallback as dl
+			}
+			if resType != api.TypeAlbum && resType != api.TypeTrack {
+				fmt.Printf("Expected an album or track, got %s\n", resType)
+				os.Exit(1)
+			}
+
+			stdout := os.Stdout
+			if asJSON {
+				stdout = messagesToStderr()
+			}
+
+			client, err := setupClient(false)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(exitCodeFor(err))
+			}
+
+			eng := engine.New(client)
+			if cfg, err := config.LoadConfig(); err == nil {
+				eng.MaxPathLength = cfg.MaxPathLength
+				eng.LongPaths = cfg.LongPaths
+				eng.GroupByInitial = cfg.GroupByInitial
+				if err := applyFileNaming(eng, cfg); err != nil {
+					fmt.Printf("Invalid config: %v\n", err)
+					os.Exit(1)
+				}
+			}
+			if template != "" {
+				if err := engine.CheckNamingTemplate(template, resType == api.TypeTrack); err != nil {
+					fmt.Printf("Invalid --template: %v\n", err)
+					os.Exit(1)
+				}
+				if resType == api.TypeTrack {
+					eng.SingleTrackTemplate = template
+				} else {
+					eng.AlbumTrackTemplate = template
+				}
+			}
+
+			var preview *engine.TemplatePreview
+			if resType == api.TypeAlbum {
+				preview, err = eng.PreviewAlbumTemplate(id, outputDir)
+			} else {
+				preview, err = eng.PreviewTrackTemplate(id, outputDir)
+			}
+			if err != nil {
+				fmt.Printf("Preview failed: %v\n", err)
+				os.Exit(exitCodeFor(err))
+			}
+
+			printTemplatePreview(stdout, preview, asJSON)
+			if len(preview.Collisions) > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputDir, "output", "o", ".", "Output directory")
+	cmd.Flags().StringVar(&template, "template", "", "Try this template instead of the configured one (album or single track, by resource)")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print the paths as JSON")
+	return cmd
+}
+
+// printTemplatePreview prints the paths of preview and their collisions to w,
+// as text or as JSON.
+func printTemplatePreview(w io.Writer, preview *engine.TemplatePreview, asJSON bool) {
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(preview)
+		return
+	}
+
+	fmt.Fprintf(w, "Template: %s\n\n", preview.Template)
+	for _, t := range preview.Tracks {
+		fmt.Fprintln(w, t.Path)
+	}
+	for _, c := range preview.Collisions {
+		fmt.Fprintf(w, "\n[Collision] %d tracks render to %s:\n", len(c.Titles), c.Path)
+		for _, title := range c.Titles {
+			fmt.Fprintf(w, "    %s\n", title)
+		}
+	}
+	fmt.Fprintln(w, "\nExtensions (.flac/.mp3) are added once the delivered format is known.")
+}
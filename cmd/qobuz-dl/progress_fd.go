@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/engine"
+)
+
+// progressByteStep is how many bytes a download of unknown size must advance
+// before another event is written.
+const progressByteStep = 1 << 20
+
+// openProgressFD opens the file descriptor passed with --progress-fd.
+func openProgressFD(fd int) (*os.File, error) {
+	f := os.NewFile(uintptr(fd), "progress-fd")
+	if f == nil {
+		return nil, fmt.Errorf("invalid --progress-fd %d", fd)
+	}
+	if _, err := f.Stat(); err != nil {
+		return nil, fmt.Errorf("invalid --progress-fd %d: %w", fd, err)
+	}
+	return f, nil
+}
+
+// newProgressWriter returns a callback writing each progress event to w as a
+// JSON line. Download events are only written when the percentage changes, so
+// frontends aren't flooded with one event per chunk.
+func newProgressWriter(w io.Writer) func(engine.ProgressEvent) {
+	var mu sync.Mutex
+	enc := json.NewEncoder(w)
+	last := make(map[int]engine.ProgressEvent) // Last written event per track ID
+	return func(event engine.ProgressEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if prev, ok := last[event.TrackID]; ok && event.Phase == engine.PhaseDownloading && prev.Phase == engine.PhaseDownloading {
+			if event.Total > 0 && event.Percent == prev.Percent {
+				return
+			}
+			if event.Total <= 0 && event.Bytes-prev.Bytes < progressByteStep {
+				return
+			}
+		}
+		last[event.TrackID] = event
+		_ = enc.Encode(event) // A closed reader must not abort the download
+	}
+}
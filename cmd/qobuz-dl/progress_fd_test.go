@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/engine"
+)
+
+func TestOpenProgressFD(t *testing.T) {
+	for _, fd := range []int{1 << 20, 987654} {
+		if f, err := openProgressFD(fd); err == nil || !strings.Contains(err.Error(), "invalid --progress-fd") {
+			t.Errorf("openProgressFD(%d) = %v, %v; want an invalid descriptor error", fd, f, err)
+		}
+	}
+}
+
+func TestProgressWriterThrottle(t *testing.T) {
+	downloading := func(trackID int, bytes, total int64) engine.ProgressEvent {
+		e := engine.ProgressEvent{TrackID: trackID, Phase: engine.PhaseDownloading, Bytes: bytes, Total: total}
+		if total > 0 {
+			e.Percent = int(bytes * 100 / total)
+		}
+		return e
+	}
+	tests := []struct {
+		name   string
+		events []engine.ProgressEvent
+		want   []int // Indexes of the events written
+	}{
+		{
+			name:   "same percentage dropped",
+			events: []engine.ProgressEvent{downloading(1, 10, 1000), downloading(1, 15, 1000), downloading(1, 20, 1000)},
+			want:   []int{0, 2},
+		},
+		{
+			name:   "unknown size by megabyte",
+			events: []engine.ProgressEvent{downloading(1, 1, -1), downloading(1, 512<<10, -1), downloading(1, 1<<20+1, -1), downloading(1, 1<<20+2, -1)},
+			want:   []int{0, 2},
+		},
+		{
+			name: "phase changes always written",
+			events: []engine.ProgressEvent{
+				downloading(1, 10, 1000), {TrackID: 1, Phase: engine.PhaseTagging},
+				{TrackID: 1, Phase: engine.PhaseDone, Percent: 100},
+			},
+			want: []int{0, 1, 2},
+		},
+		{
+			name:   "tracks throttled separately",
+			events: []engine.ProgressEvent{downloading(1, 10, 1000), downloading(2, 10, 1000), downloading(1, 11, 1000)},
+			want:   []int{0, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			write := newProgressWriter(&buf)
+			for _, event := range tt.events {
+				write(event)
+			}
+			var want bytes.Buffer
+			for _, i := range tt.want {
+				json.NewEncoder(&want).Encode(tt.events[i])
+			}
+			if buf.String() != want.String() {
+				t.Errorf("wrote\n%s\nwant\n%s", buf.String(), want.String())
+			}
+		})
+	}
+}
+
+func TestProgressFDAlbumDownload(t *testing.T) {
+	audio := bytes.Repeat([]byte("fLaC audio data "), 64<<10) // 1 MiB
+	trackIDs := []int{11, 12}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/album/get":
+			album := api.AlbumMetadata{ID: "alb1", Title: "Album", TracksCount: len(trackIDs), MediaCount: 1}
+			album.Artist.Name = "Band"
+			for i, id := range trackIDs {
+				track := api.TrackMetadata{ID: id, Title: fmt.Sprintf("Track %d", i+1), TrackNumber: i + 1, MediaNumber: 1}
+				track.Performer.Name = "Band"
+				album.Tracks.Items = append(album.Tracks.Items, track)
+			}
+			album.Tracks.Total = len(trackIDs)
+			json.NewEncoder(w).Encode(album)
+		case r.URL.Path == "/track/getFileUrl":
+			fmt.Fprintf(w, `{"url":"http://%s/file/%s.flac","mime_type":"audio/flac","bit_depth":16}`, r.Host, r.URL.Query().Get("track_id"))
+		case strings.HasPrefix(r.URL.Path, "/file/"):
+			w.Header().Set("Content-Length", strconv.Itoa(len(audio)))
+			// Slow enough for several progress callbacks, which come every 200ms
+			for off := 0; off < len(audio); off += 128 << 10 {
+				w.Write(audio[off : off+128<<10])
+				w.(http.Flusher).Flush()
+				time.Sleep(80 * time.Millisecond)
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	events := make(chan []engine.ProgressEvent)
+	go func() {
+		var read []engine.ProgressEvent
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			var event engine.ProgressEvent
+			if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+				t.Errorf("invalid event line %q: %v", scanner.Text(), err)
+				continue
+			}
+			read = append(read, event)
+		}
+		events <- read
+	}()
+
+	client := api.NewClient("app", "secret")
+	client.HTTP.SetBaseURL(srv.URL)
+	eng := engine.New(client)
+	eng.OnProgressEvent = newProgressWriter(w)
+	result, err := eng.DownloadAlbum(context.Background(), "alb1", 6, t.TempDir())
+	w.Close()
+	read := <-events
+	if err != nil || len(result.Success) != len(trackIDs) {
+		t.Fatalf("DownloadAlbum() = %v, %v", result, err)
+	}
+
+	for _, id := range trackIDs {
+		var phases []string
+		var percents []int
+		for _, event := range read {
+			if event.TrackID != id {
+				continue
+			}
+			if event.AlbumID != "alb1" || event.Title == "" {
+				t.Errorf("event %+v lacks the album or title", event)
+			}
+			if event.Phase == engine.PhaseDownloading {
+				percents = append(percents, event.Percent)
+			}
+			if len(phases) == 0 || phases[len(phases)-1] != event.Phase {
+				phases = append(phases, event.Phase)
+			}
+		}
+		if want := []string{engine.PhaseDownloading, engine.PhaseTagging, engine.PhaseDone}; !slices.Equal(phases, want) {
+			t.Errorf("track %d went through %v, want %v", id, phases, want)
+		}
+		if len(percents) < 2 || !slices.IsSorted(percents) || len(slices.Compact(slices.Clone(percents))) != len(percents) {
+			t.Errorf("track %d download percentages %v, want increasing without repeats", id, percents)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/config"
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/engine"
+)
+
+// newPurchasesCmd creates the purchases command that downloads every album and
+// track bought with the account.
+func newPurchasesCmd() *cobra.Command {
+	var listOnly bool
+
+	cmd := &cobra.Command{
+		Use:   "purchases",
+		Short: "Download every album and track bought with the account",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := setupClient(false)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(exitCodeFor(err))
+			}
+
+			purchases, err := client.GetUserPurchases()
+			if err != nil {
+				fmt.Printf("Failed to list purchases: %v\n", err)
+				os.Exit(exitCodeFor(err))
+			}
+			if len(purchases.Albums) == 0 && len(purchases.Tracks) == 0 {
+				fmt.Println("No purchases found for this account")
+				return
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "TYPE\tID\tARTIST\tTITLE")
+			for _, album := range purchases.Albums {
+				fmt.Fprintf(w, "album\t%s\t%s\t%s\n", album.ID, album.Artist.Name, album.Title)
+			}
+			for _, track := range purchases.Tracks {
+				fmt.Fprintf(w, "track\t%d\t%s\t%s\n", track.ID, track.Performer.Name, track.Title)
+			}
+			w.Flush()
+
+			if listOnly {
+				return
+			}
+
+			// Purchased files are requested the way the store delivers them
+			client.SetDownloadIntent(true)
+
+			eng := engine.New(client)
+			if cfg, err := config.LoadConfig(); err == nil {
+				eng.MaxPathLength = cfg.MaxPathLength
+				eng.LongPaths = cfg.LongPaths
+				eng.Tagger.WriteSource = !cfg.DisableSourceTags
+				if cfg.Quality != 0 && !cmd.Flags().Changed("quality") {
+					flagQuality = cfg.Quality
+				}
+				eng.GroupByInitial = cfg.GroupByInitial
+				if err := applyFileNaming(eng, cfg); err != nil {
+					fmt.Printf("Invalid config: %v\n", err)
+					os.Exit(1)
+				}
+			}
+			applyThreads(eng)
+			eng.AlbumDelay = flagDelay
+
+			queue := eng.NewQueue(flagQuality, flagOutputDir)
+			for _, album := range purchases.Albums {
+				queue.Add(engine.Job{Type: api.TypeAlbum, ID: album.ID})
+			}
+			for _, track := range purchases.Tracks {
+				queue.Add(engine.Job{Type: api.TypeTrack, ID: strconv.Itoa(track.ID)})
+			}
+
+			fmt.Printf("\nQueued %d purchases\n", queue.Len())
+			result := queue.Run(context.Background())
+
+			failed := result.Failed()
+			fmt.Printf("\nPurchases download complete: %d succeeded, %d failed\n", result.Succeeded(), len(failed))
+			for _, res := range failed {
+				fmt.Printf("  - %s: %v\n", res.Job, res.Err)
+			}
+			if len(failed) > 0 {
+				if result.Succeeded() > 0 {
+					os.Exit(exitPartial)
+				}
+				os.Exit(exitCodeFor(failed[0].Err))
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&listOnly, "list", false, "Only list the purchases without downloading them")
+	addQualityFlag(cmd)
+	cmd.Flags().StringVarP(&flagOutputDir, "output", "o", ".", "Output directory")
+	addThreadsFlag(cmd)
+	cmd.Flags().DurationVar(&flagDelay, "delay-between-albums", 0, "Pause this long between purchases (e.g. 30s), to stay under rate limits on long runs")
+
+	return cmd
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/engine"
+)
+
+// newAlbumQualitiesCmd creates the album-qualities command that lists the best
+// available format of every track of an album.
+func newAlbumQualitiesCmd() *cobra.Command {
+	var asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "album-qualities [album_id/url]",
+		Short: "Show the highest available quality of each track of an album",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			id := args[0]
+			if resType, parsed, err := api.ParseURL(id); err == nil {
+				if resType != api.TypeAlbum {
+					fmt.Printf("Expected an album, got %s\n", resType)
+					os.Exit(1)
+				}
+				id = parsed
+			}
+
+			stdout := os.Stdout
+			if asJSON {
+				stdout = messagesToStderr()
+			}
+
+			client, err := setupClient(false)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(exitCodeFor(err))
+			}
+
+			if err := printAlbumQualities(stdout, client, id, asJSON); err != nil {
+				fmt.Printf("Probe failed: %v\n", err)
+				os.Exit(exitCodeFor(err))
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print the result as JSON")
+	return cmd
+}
+
+// printAlbumQualities probes the tracks of an album and prints their best
+// available quality to w, as a table or as JSON.
+func printAlbumQualities(w io.Writer, client *api.Client, albumID string, asJSON bool) error {
+	result, err := engine.New(client).ProbeAlbumQualities(albumID)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+
+	fmt.Fprintf(w, "%s - %s\n\n", result.Artist, result.Title)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "DISC\tTRACK\tTITLE\tFORMAT\tBIT DEPTH\tSAMPLE RATE")
+	for _, t := range result.Tracks {
+		if t.Error != "" {
+			fmt.Fprintf(tw, "%d\t%d\t%s\tunavailable\t-\t-\n", t.DiscNumber, t.TrackNumber, t.Title)
+			continue
+		}
+		fmt.Fprintf(tw, "%d\t%d\t%s\t%d\t%d bit\t%g kHz\n", t.DiscNumber, t.TrackNumber, t.Title, t.FormatID, t.BitDepth, t.SamplingRate)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/engine"
+)
+
+// qualityFlag is a --quality value accepting a quality ID or "best".
+type qualityFlag int
+
+func (q *qualityFlag) String() string {
+	if int(*q) == engine.QualityBest {
+		return "best"
+	}
+	return strconv.Itoa(int(*q))
+}
+
+func (q *qualityFlag) Set(s string) error {
+	v, err := engine.ParseQuality(s)
+	if err != nil {
+		return err
+	}
+	*q = qualityFlag(v)
+	return nil
+}
+
+func (q *qualityFlag) Type() string { return "quality" }
+
+// addQualityFlag registers the --quality/-q flag on cmd, bound to flagQuality.
+func addQualityFlag(cmd *cobra.Command) {
+	flagQuality = 6
+	cmd.Flags().VarP((*qualityFlag)(&flagQuality), "quality", "q",
+		"Quality ID (5=MP3, 6=FLAC 16bit, 7=FLAC 24bit, 27=FLAC 24bit>96), mp3, cd, hires or best (highest the subscription and track allow)")
+	cmd.RegisterFlagCompletionFunc("quality", completeQuality)
+}
+
+// qualityCompletions are the --quality values offered by shell completion, with descriptions.
+var qualityCompletions = []string{
+	"5\tMP3 320",
+	"6\tFLAC 16-bit (CD)",
+	"7\tFLAC 24-bit up to 96kHz",
+	"27\tFLAC 24-bit above 96kHz",
+	"mp3\tMP3 320 (5)",
+	"cd\tFLAC 16-bit (6)",
+	"hires\tHighest Hi-Res FLAC (27)",
+	"best\tHighest the subscription and track allow",
+}
+
+// completeQuality suggests --quality values starting with the typed prefix.
+func completeQuality(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var matches []string
+	for _, c := range qualityCompletions {
+		if strings.HasPrefix(c, strings.ToLower(toComplete)) {
+			matches = append(matches, c)
+		}
+	}
+	return matches, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestCompleteQuality(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{prefix: "2", want: []string{"27\tFLAC 24-bit above 96kHz"}},
+		{prefix: "H", want: []string{"hires\tHighest Hi-Res FLAC (27)"}},
+		{prefix: "m", want: []string{"mp3\tMP3 320 (5)"}},
+		{prefix: "x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			got, directive := completeQuality(nil, nil, tt.prefix)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("completeQuality(%q) = %q, want %q", tt.prefix, got, tt.want)
+			}
+			if directive != cobra.ShellCompDirectiveNoFileComp {
+				t.Errorf("directive = %v, want NoFileComp", directive)
+			}
+		})
+	}
+
+	if got, _ := completeQuality(nil, nil, ""); len(got) != len(qualityCompletions) {
+		t.Errorf("empty prefix offered %d values, want all %d", len(got), len(qualityCompletions))
+	}
+}
+
+func TestQualityCompletionsAreAccepted(t *testing.T) {
+	for _, c := range qualityCompletions {
+		value, _, _ := strings.Cut(c, "\t")
+		var q qualityFlag
+		if err := q.Set(value); err != nil {
+			t.Errorf("completion %q is rejected by --quality: %v", value, err)
+		}
+	}
+}
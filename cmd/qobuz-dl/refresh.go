@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/engine"
+)
+
+// newRefreshCmd creates the refresh command that updates tags and covers of
+// downloaded albums from current Qobuz metadata without re-downloading audio.
+func newRefreshCmd() *cobra.Command {
+	var (
+		apply       bool
+		onlyMissing bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "refresh [dir]",
+		Short: "Update tags and covers of downloaded albums without re-downloading audio (dry run unless --apply)",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			dirs, err := engine.FindAlbumDirs(args[0])
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			if len(dirs) == 0 {
+				fmt.Println("No albums found.")
+				return
+			}
+
+			client, err := setupClient(false)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(exitCodeFor(err))
+			}
+			eng := engine.New(client)
+			eng.Tagger.OnlyFillMissing = onlyMissing
+
+			failed := 0
+			for _, dir := range dirs {
+				result, err := eng.RefreshAlbum(dir, apply)
+				if err != nil {
+					fmt.Printf("[Error] %s: %v\n", dir, err)
+					failed++
+					continue
+				}
+
+				fmt.Printf("\n[Album] %s (%s)\n", result.Title, result.AlbumID)
+				for _, c := range result.Changes {
+					fmt.Printf("  %s: %s %q -> %q\n", filepath.Base(c.Path), c.Field, c.Old, c.New)
+				}
+				if result.CoverUpdated {
+					fmt.Println("  cover: higher resolution available")
+				}
+				for _, s := range result.Skipped {
+					fmt.Printf("  [Skip] %s (%s)\n", filepath.Base(s.Path), s.Reason)
+				}
+				if len(result.Changes) == 0 && !result.CoverUpdated {
+					fmt.Println("  up to date")
+				} else if apply {
+					fmt.Printf("  %d files re-tagged\n", result.Retagged)
+				}
+			}
+
+			if !apply {
+				fmt.Println("\nDry run. Run again with --apply to write the changes.")
+			}
+			if failed > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&apply, "apply", false, "Write the updated tags and covers instead of only listing them")
+	cmd.Flags().BoolVar(&onlyMissing, "tag-only-missing", false, "Only fill in tags and covers that are missing, keeping existing values")
+	return cmd
+}
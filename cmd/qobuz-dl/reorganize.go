@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/engine"
+)
+
+// newReorganizeCmd creates the reorganize command that renames downloaded files
+// to the current naming layout based on their embedded tags.
+func newReorganizeCmd() *cobra.Command {
+	var apply bool
+
+	cmd := &cobra.Command{
+		Use:   "reorganize [dir]",
+		Short: "Rename downloaded files to the current layout using their tags (dry run unless --apply)",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			plan, err := engine.PlanReorganize(args[0])
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			for _, m := range plan.Moves {
+				fmt.Printf("[Move] %s\n    -> %s\n", m.From, m.To)
+			}
+			for _, s := range plan.Skipped {
+				fmt.Printf("[Skip] %s (%s)\n", s.Path, s.Reason)
+			}
+
+			if len(plan.Moves) == 0 {
+				fmt.Println("Nothing to reorganize.")
+				return
+			}
+
+			if !apply {
+				fmt.Printf("\n%d files would be moved. Run again with --apply to move them.\n", len(plan.Moves))
+				return
+			}
+
+			if err := engine.ApplyReorganize(plan.Moves); err != nil {
+				fmt.Printf("Reorganize failed: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("\n%d files moved.\n", len(plan.Moves))
+		},
+	}
+
+	cmd.Flags().BoolVar(&apply, "apply", false, "Perform the moves instead of only listing them")
+	return cmd
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/config"
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/engine"
+)
+
+// newSearchCmd creates the search command that lists albums and tracks ranked by relevance.
+func newSearchCmd() *cobra.Command {
+	var (
+		limit    int
+		asJSON   bool
+		resType  string
+		download string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "search [query]",
+		Short: "Search albums and tracks, best matches first",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if resType != "" && resType != string(api.TypeAlbum) && resType != string(api.TypeTrack) {
+				fmt.Printf("Invalid --type %q (use album or track)\n", resType)
+				os.Exit(1)
+			}
+
+			stdout := os.Stdout
+			if asJSON {
+				stdout = messagesToStderr()
+			}
+
+			client, err := setupClient(false)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(exitCodeFor(err))
+			}
+
+			results, err := client.Search(args[0], limit)
+			if err != nil {
+				fmt.Printf("Search failed: %v\n", err)
+				os.Exit(exitCodeFor(err))
+			}
+			results = filterSearchResults(results, api.ResourceType(resType))
+
+			printSearchResults(stdout, results, asJSON)
+			if asJSON || len(results) == 0 || download == "" {
+				return
+			}
+			selected, err := parseSelection(download, len(results))
+			if err != nil {
+				fmt.Printf("Invalid --download: %v\n", err)
+				os.Exit(1)
+			}
+			downloadSearchResults(client, results, selected)
+		},
+	}
+
+	cmd.Flags().IntVarP(&limit, "limit", "l", 10, "Maximum results per type")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print the results as JSON")
+	cmd.Flags().StringVar(&resType, "type", "", "Only list results of this type (album or track)")
+	cmd.Flags().StringVar(&download, "download", "", "Download the listed results by number, e.g. 1-5 or 1,3,7-9")
+	addQualityFlag(cmd)
+	cmd.Flags().StringVarP(&flagOutputDir, "output", "o", ".", "Output directory for --download")
+	addThreadsFlag(cmd)
+	return cmd
+}
+
+// printSearchResults prints results to w, as a numbered table or as JSON.
+func printSearchResults(w io.Writer, results []api.SearchResult, asJSON bool) {
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(results)
+		return
+	}
+
+	if len(results) == 0 {
+		fmt.Fprintln(w, "No results")
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tTYPE\tID\tARTIST\tTITLE")
+	for i, r := range results {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", i+1, r.Type, r.ID, r.Artist, r.Title)
+	}
+	tw.Flush()
+}
+
+// filterSearchResults keeps the results of resType, or all of them if resType is empty.
+func filterSearchResults(results []api.SearchResult, resType api.ResourceType) []api.SearchResult {
+	if resType == "" {
+		return results
+	}
+	var filtered []api.SearchResult
+	for _, r := range results {
+		if r.Type == resType {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
+
+// parseSelection parses result numbers such as "3", "1-5" or "1,3,7-9" into
+// zero-based indexes, in the order given and without duplicates.
+// Every number must be between 1 and count.
+func parseSelection(spec string, count int) ([]int, error) {
+	var indexes []int
+	seen := make(map[int]bool)
+	for part := range strings.SplitSeq(spec, ",") {
+		part = strings.TrimSpace(part)
+		from, to, isRange := strings.Cut(part, "-")
+		first, err := strconv.Atoi(strings.TrimSpace(from))
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a result number or range", part)
+		}
+		last := first
+		if isRange {
+			if last, err = strconv.Atoi(strings.TrimSpace(to)); err != nil {
+				return nil, fmt.Errorf("%q is not a result number or range", part)
+			}
+		}
+		if first > last {
+			return nil, fmt.Errorf("range %q is reversed", part)
+		}
+		if first < 1 || last > count {
+			return nil, fmt.Errorf("%q is outside the %d results", part, count)
+		}
+		for n := first; n <= last; n++ {
+			if !seen[n] {
+				seen[n] = true
+				indexes = append(indexes, n-1)
+			}
+		}
+	}
+	return indexes, nil
+}
+
+// downloadSearchResults downloads the selected results through the download
+// queue and reports the outcome of each one.
+func downloadSearchResults(client *api.Client, results []api.SearchResult, selected []int) {
+	eng := engine.New(client)
+	if cfg, err := config.LoadConfig(); err == nil {
+		eng.MaxPathLength = cfg.MaxPathLength
+		eng.LongPaths = cfg.LongPaths
+		eng.Tagger.WriteSource = !cfg.DisableSourceTags
+		eng.GroupByInitial = cfg.GroupByInitial
+		if err := applyFileNaming(eng, cfg); err != nil {
+			fmt.Printf("Invalid config: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	applyThreads(eng)
+
+	result := runSearchDownloads(eng, results, selected)
+	failed := result.Failed()
+	fmt.Printf("\nSearch download complete: %d succeeded, %d failed\n", result.Succeeded(), len(failed))
+	if len(failed) > 0 {
+		if result.Succeeded() > 0 {
+			os.Exit(exitPartial)
+		}
+		os.Exit(exitCodeFor(failed[0].Err))
+	}
+}
+
+// runSearchDownloads queues the selected results on eng, runs the queue and
+// prints the outcome of each result.
+func runSearchDownloads(eng *engine.Engine, results []api.SearchResult, selected []int) *engine.QueueResult {
+	queue := eng.NewQueue(flagQuality, flagOutputDir)
+	for _, i := range selected {
+		queue.Add(engine.Job{Type: results[i].Type, ID: results[i].ID})
+	}
+	fmt.Printf("\nDownloading %d results\n", queue.Len())
+	result := queue.Run(context.Background())
+
+	fmt.Println()
+	for n, res := range result.Results {
+		r := results[selected[n]]
+		status := "OK"
+		if res.Err != nil {
+			status = fmt.Sprintf("Failed: %v", res.Err)
+		}
+		fmt.Printf("  #%d %s - %s: %s\n", selected[n]+1, r.Artist, r.Title, status)
+	}
+	return result
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/engine"
+)
+
+func TestParseSelection(t *testing.T) {
+	tests := []struct {
+		spec    string
+		count   int
+		want    []int
+		wantErr string
+	}{
+		{spec: "3", count: 5, want: []int{2}},
+		{spec: "1-5", count: 5, want: []int{0, 1, 2, 3, 4}},
+		{spec: "1,3,7-9", count: 10, want: []int{0, 2, 6, 7, 8}},
+		{spec: " 4 , 2 - 3 ", count: 5, want: []int{3, 1, 2}},
+		{spec: "1-3,2-4", count: 5, want: []int{0, 1, 2, 3}}, // Overlaps once
+		{spec: "0", count: 5, wantErr: "outside the 5 results"},
+		{spec: "4-6", count: 5, wantErr: "outside the 5 results"},
+		{spec: "1", count: 0, wantErr: "outside the 0 results"},
+		{spec: "5-1", count: 5, wantErr: "reversed"},
+		{spec: "a-b", count: 5, wantErr: "not a result number"},
+		{spec: "1,", count: 5, wantErr: "not a result number"},
+		{spec: "-2", count: 5, wantErr: "not a result number"},
+	}
+	for _, tt := range tests {
+		got, err := parseSelection(tt.spec, tt.count)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseSelection(%q, %d) = %v, %v; want error %q", tt.spec, tt.count, got, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || !slices.Equal(got, tt.want) {
+			t.Errorf("parseSelection(%q, %d) = %v, %v; want %v", tt.spec, tt.count, got, err, tt.want)
+		}
+	}
+}
+
+// newSearchServer serves catalog/search with the given albums and one track,
+// and album downloads of every album except missing.
+func newSearchServer(t *testing.T, albums []string, missing string) (*httptest.Server, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var requested []string // album/get requests in order
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/catalog/search":
+			var resp api.SearchResponse
+			for i, id := range albums {
+				album := api.AlbumMetadata{ID: id, Title: fmt.Sprintf("Album %d", i+1)}
+				album.Artist.Name = "Band"
+				resp.Albums.Items = append(resp.Albums.Items, album)
+			}
+			resp.Tracks.Items = []api.TrackMetadata{{ID: 999, Title: "Album 1"}}
+			json.NewEncoder(w).Encode(resp)
+		case "/album/get":
+			id := r.URL.Query().Get("album_id")
+			mu.Lock()
+			requested = append(requested, id)
+			mu.Unlock()
+			if id == missing {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `{"status":"error","code":404,"message":"Album not found"}`)
+				return
+			}
+			album := api.AlbumMetadata{ID: id, Title: "Album " + id, TracksCount: 1, MediaCount: 1}
+			album.Artist.Name = "Band"
+			track := api.TrackMetadata{ID: 1, Title: "Song", TrackNumber: 1, MediaNumber: 1}
+			track.Performer.Name = "Band"
+			album.Tracks.Items = []api.TrackMetadata{track}
+			album.Tracks.Total = 1
+			json.NewEncoder(w).Encode(album)
+		case "/track/getFileUrl":
+			fmt.Fprintf(w, `{"url":"http://%s/file/1.flac","mime_type":"audio/flac","bit_depth":16}`, r.Host)
+		case "/file/1.flac":
+			w.Write([]byte("fLaC audio"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(requested)
+	}
+}
+
+func TestRunSearchDownloads(t *testing.T) {
+	albums := []string{"alb1", "alb2", "alb3", "alb4", "alb5"}
+	tests := []struct {
+		name       string
+		spec       string
+		missing    string // Album that fails to download
+		wantFailed []string
+	}{
+		{name: "top three", spec: "1-3"},
+		{name: "list and range", spec: "5,2-3"},
+		{name: "one failure", spec: "1-4", missing: "alb2", wantFailed: []string{"alb2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, requested := newSearchServer(t, albums, tt.missing)
+			client := api.NewClient("app", "secret")
+			client.HTTP.SetBaseURL(srv.URL)
+
+			results, err := client.Search("Album", 10)
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+			results = filterSearchResults(results, api.TypeAlbum)
+			if len(results) != len(albums) {
+				t.Fatalf("%d album results, want %d", len(results), len(albums))
+			}
+			selected, err := parseSelection(tt.spec, len(results))
+			if err != nil {
+				t.Fatalf("parseSelection: %v", err)
+			}
+
+			flagQuality, flagOutputDir = 6, t.TempDir()
+			result := runSearchDownloads(engine.New(client), results, selected)
+
+			var want []string
+			for _, i := range selected {
+				want = append(want, results[i].ID)
+			}
+			if got := requested(); !slices.Equal(got, want) {
+				t.Errorf("downloaded albums %v, want the selected %v in order", got, want)
+			}
+			if len(result.Results) != len(selected) {
+				t.Fatalf("%d outcomes, want one per selected result", len(result.Results))
+			}
+			var failed []string
+			for n, res := range result.Results {
+				if res.Job.Type != api.TypeAlbum || res.Job.ID != want[n] {
+					t.Errorf("outcome %d is for %s, want album %s", n, res.Job, want[n])
+				}
+				if res.Err != nil {
+					failed = append(failed, res.Job.ID)
+				}
+			}
+			if !slices.Equal(failed, tt.wantFailed) || result.Succeeded() != len(selected)-len(tt.wantFailed) {
+				t.Errorf("failed %v with %d succeeded, want %v failed", failed, result.Succeeded(), tt.wantFailed)
+			}
+		})
+	}
+}
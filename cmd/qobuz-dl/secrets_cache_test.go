@@ -0,0 +1,62 @@
+package main
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/config"
+)
+
+func TestCachedSecret(t *testing.T) {
+	fresh := config.NewSecretsCache("web", []string{"old", "good"})
+	stale := config.NewSecretsCache("web", []string{"old", "good"})
+	stale.FetchedAt = time.Now().Add(-config.SecretsCacheTTL - time.Hour)
+
+	tests := []struct {
+		name         string
+		cache        *config.SecretsCache
+		wantAppID    string
+		wantSecret   string
+		wantRequests int
+	}{
+		{name: "fresh cache with a valid candidate", cache: fresh, wantAppID: "web", wantSecret: "good", wantRequests: 2},
+		{name: "fresh cache without a valid candidate", cache: config.NewSecretsCache("web", []string{"old", "older"}), wantRequests: 2},
+		{name: "expired cache", cache: stale},
+		{name: "no cache"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				q := r.URL.Query()
+				sum := md5.Sum([]byte(fmt.Sprintf("trackgetFileUrlformat_id%sintent%strack_id%s%sgood",
+					q.Get("format_id"), q.Get("intent"), q.Get("track_id"), q.Get("request_ts"))))
+				w.Header().Set("Content-Type", "application/json")
+				if r.Header.Get("X-App-Id") != "web" || q.Get("request_sig") != hex.EncodeToString(sum[:]) {
+					w.WriteHeader(http.StatusBadRequest)
+					w.Write([]byte(`{"status":"error","code":400,"message":"Invalid Request Signature parameter (request_sig)"}`))
+					return
+				}
+				w.Write([]byte(`{"url":"https://example.com/track.mp3","format_id":5}`))
+			}))
+			defer srv.Close()
+
+			client := api.NewClient("saved", "")
+			client.HTTP.SetBaseURL(srv.URL)
+			appID, secret := cachedSecret(client, tt.cache)
+			if appID != tt.wantAppID || secret != tt.wantSecret {
+				t.Errorf("cachedSecret() = %q, %q; want %q, %q", appID, secret, tt.wantAppID, tt.wantSecret)
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("made %d validation requests, want %d", requests, tt.wantRequests)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/engine"
+)
+
+// threadsAuto is the flagThreads value of --threads auto.
+const threadsAuto = -1
+
+// maxThreads is the most download threads per album, also the ceiling of --threads auto.
+const maxThreads = 10
+
+// threadsFlag is a --threads value accepting a thread count or "auto".
+type threadsFlag int
+
+func (t *threadsFlag) String() string {
+	if int(*t) == threadsAuto {
+		return "auto"
+	}
+	return strconv.Itoa(int(*t))
+}
+
+func (t *threadsFlag) Set(s string) error {
+	if s == "auto" {
+		*t = threadsAuto
+		return nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 || n > maxThreads {
+		return fmt.Errorf("invalid thread count %q (use 1-%d or auto)", s, maxThreads)
+	}
+	*t = threadsFlag(n)
+	return nil
+}
+
+func (t *threadsFlag) Type() string { return "threads" }
+
+// addThreadsFlag registers the --threads/-n flag on cmd, bound to flagThreads.
+func addThreadsFlag(cmd *cobra.Command) {
+	flagThreads = 3
+	cmd.Flags().VarP((*threadsFlag)(&flagThreads), "threads", "n",
+		fmt.Sprintf("Number of concurrent download threads (1-%d), or auto to adjust them to the measured throughput", maxThreads))
+	cmd.RegisterFlagCompletionFunc("threads", cobra.FixedCompletions([]string{"auto", "1", "3", "5", strconv.Itoa(maxThreads)}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// applyThreads sets the engine's download threads from flagThreads.
+func applyThreads(eng *engine.Engine) {
+	switch {
+	case flagThreads == threadsAuto:
+		eng.SetConcurrency(maxThreads)
+		eng.AutoConcurrency = true
+	case flagThreads > 0:
+		eng.SetConcurrency(flagThreads)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestThreadsFlagSet(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{value: "auto", want: threadsAuto},
+		{value: "1", want: 1},
+		{value: "3", want: 3},
+		{value: strconv.Itoa(maxThreads), want: maxThreads},
+		{value: strconv.Itoa(maxThreads + 1), wantErr: true},
+		{value: "0", wantErr: true},
+		{value: "-1", wantErr: true},
+		{value: "many", wantErr: true},
+	}
+	for _, tt := range tests {
+		f := threadsFlag(3)
+		err := f.Set(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Set(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			if int(f) != 3 {
+				t.Errorf("Set(%q) changed the value to %d after failing", tt.value, f)
+			}
+			continue
+		}
+		if int(f) != tt.want {
+			t.Errorf("Set(%q) = %d, want %d", tt.value, f, tt.want)
+		}
+		if got := f.String(); got != tt.value {
+			t.Errorf("String() after Set(%q) = %q", tt.value, got)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/engine"
+)
+
+// newUpgradeCoversCmd creates the upgrade-covers command that replaces low
+// resolution embedded covers with the original size cover.
+func newUpgradeCoversCmd() *cobra.Command {
+	var apply bool
+
+	cmd := &cobra.Command{
+		Use:   "upgrade-covers [dir]",
+		Short: "Replace low resolution embedded covers with the original size (dry run unless --apply)",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			dirs, err := engine.FindAlbumDirs(args[0])
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			if len(dirs) == 0 {
+				fmt.Println("No albums found.")
+				return
+			}
+
+			client, err := setupClient(false)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(exitCodeFor(err))
+			}
+			eng := engine.New(client)
+
+			failed := 0
+			for _, dir := range dirs {
+				result, err := eng.UpgradeCovers(dir, apply)
+				if err != nil {
+					fmt.Printf("[Error] %s: %v\n", dir, err)
+					failed++
+					continue
+				}
+
+				fmt.Printf("\n[Album] %s (%s), original cover %dx%d\n", result.Title, result.AlbumID, result.Width, result.Height)
+				for _, path := range result.Outdated {
+					fmt.Printf("  %s: smaller cover\n", filepath.Base(path))
+				}
+				if result.CoverFile {
+					fmt.Println("  cover.jpg: smaller or missing")
+				}
+				for _, s := range result.Skipped {
+					fmt.Printf("  [Skip] %s (%s)\n", filepath.Base(s.Path), s.Reason)
+				}
+				if len(result.Outdated) == 0 && !result.CoverFile {
+					fmt.Println("  already at full resolution")
+				} else if apply {
+					fmt.Printf("  %d files upgraded\n", result.Upgraded)
+				}
+			}
+
+			if !apply {
+				fmt.Println("\nDry run. Run again with --apply to replace the covers.")
+			}
+			if failed > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&apply, "apply", false, "Embed the larger covers and update cover.jpg instead of only listing them")
+	return cmd
+}
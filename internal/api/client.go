@@ -4,14 +4,19 @@ package api
 
 import (
 	"crypto/md5"
+	"crypto/x509"
 	"encoding/hex"
-	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
+	"os"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/imroc/req/v3"
+	"golang.org/x/text/language"
 )
 
 // API constants for Qobuz service.
@@ -30,8 +35,24 @@ type Client struct {
 	UserToken   string      // User authentication token
 	UseProxy    bool        // Whether to use proxy site (default true)
 	currentBase string      // Current base URL in use
+
+	appIDCandidates []string     // Fallback App IDs tried when the current one is rejected
+	clockOffset     atomic.Int64 // Server time minus local time (ns), learned from signature rejections
+	locale          string       // Language of catalog metadata (empty = server default)
+	intent          string       // track/getFileUrl intent, IntentStream or IntentDownload
 }
 
+// File URL intents. Purchases are requested with IntentDownload, the variant the
+// web store uses, so the purchased format is delivered rather than the streaming one.
+const (
+	IntentStream   = "stream"
+	IntentDownload = "download"
+)
+
+// DefaultAppIDCandidates are historically valid web player App IDs, tried before
+// scraping the web player when the current App ID is rate-limited or blocked.
+var DefaultAppIDCandidates = []string{"798273057"}
+
 // NewClient creates a new Qobuz API client with the given credentials.
 // The client is configured with default headers and base URL.
 // By default, it tries the proxy site first.
@@ -41,6 +62,7 @@ func NewClient(appID, appSecret string) *Client {
 		AppSecret: appSecret,
 		HTTP:      req.NewClient(),
 		UseProxy:  true,
+		intent:    IntentStream,
 	}
 
 	// Start with proxy by default
@@ -101,6 +123,120 @@ func (c *Client) SetProxy(proxyURL string) error {
 	return nil
 }
 
+// ForceHTTP1 restricts API and download requests to HTTP/1.1. Some proxies
+// mishandle HTTP/2 and make transfers stall; forcing HTTP/1.1 avoids them.
+func (c *Client) ForceHTTP1() {
+	c.HTTP.EnableForceHTTP1()
+}
+
+// SetInsecureSkipVerify disables (or restores) TLS certificate verification for
+// API and download requests. Only meant for TLS-intercepting proxies whose CA
+// can't be trusted otherwise: it exposes credentials to any man in the middle.
+func (c *Client) SetInsecureSkipVerify(skip bool) {
+	if skip {
+		c.HTTP.EnableInsecureSkipVerify()
+	} else {
+		c.HTTP.DisableInsecureSkipVerify()
+	}
+}
+
+// SetRootCAs trusts the PEM certificates in path, in addition to the system
+// roots, for API and download requests. This lets proxies with a private CA
+// work without disabling verification.
+func (c *Client) SetRootCAs(path string) error {
+	pool, err := LoadCertPool(path)
+	if err != nil {
+		return err
+	}
+	c.HTTP.GetTLSClientConfig().RootCAs = pool
+	return nil
+}
+
+// LoadCertPool returns the system root certificates plus the PEM certificates
+// in path. It fails if the file holds no certificate.
+func LoadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no PEM certificate found in %s", path)
+	}
+	return pool, nil
+}
+
+// SetAppID changes the App ID sent with every request.
+func (c *Client) SetAppID(appID string) {
+	c.AppID = appID
+	c.HTTP.SetCommonHeader("X-App-Id", appID)
+}
+
+// SetAppIDCandidates sets the fallback App IDs tried by FindValidAppID, in order.
+func (c *Client) SetAppIDCandidates(appIDs []string) {
+	c.appIDCandidates = appIDs
+}
+
+// protectedHeaders are set by the client itself and cannot be overridden by extra headers.
+var protectedHeaders = map[string]bool{
+	"X-App-Id":          true,
+	"X-User-Auth-Token": true,
+}
+
+// SetExtraHeader adds a header sent with every API and download request, e.g. for
+// a reverse proxy or gateway. The X-App-Id and X-User-Auth-Token headers are
+// managed by the client and are rejected.
+func (c *Client) SetExtraHeader(key, value string) error {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return fmt.Errorf("empty header name")
+	}
+	if protectedHeaders[http.CanonicalHeaderKey(key)] {
+		return fmt.Errorf("header %s is managed by the client and cannot be overridden", key)
+	}
+	c.HTTP.SetCommonHeader(key, value)
+	return nil
+}
+
+// SetLocale sets the language catalog metadata (titles, names) is requested in,
+// as a BCP 47 tag such as "ja" or "en-US". Qobuz returns localized or
+// romanized titles where it has them. An empty lang restores the server default.
+func (c *Client) SetLocale(lang string) error {
+	lang = strings.TrimSpace(lang)
+	if lang == "" {
+		c.locale = ""
+		return nil
+	}
+	tag, err := language.Parse(lang)
+	if err != nil {
+		return fmt.Errorf("invalid locale %q: %w", lang, err)
+	}
+	c.locale = tag.String()
+	return nil
+}
+
+// metadataRequest starts a catalog metadata request, carrying the locale set
+// by SetLocale as the lang query parameter and the Accept-Language header.
+func (c *Client) metadataRequest() *req.Request {
+	r := c.HTTP.R()
+	if c.locale != "" {
+		r.SetQueryParam("lang", c.locale).SetHeader("Accept-Language", c.locale)
+	}
+	return r
+}
+
+// SetDownloadIntent makes track URLs be requested with the download intent used
+// for purchases instead of the streaming one. Preview URLs always use streaming.
+func (c *Client) SetDownloadIntent(enabled bool) {
+	c.intent = IntentStream
+	if enabled {
+		c.intent = IntentDownload
+	}
+}
+
 // SetUserToken sets the user authentication token for subsequent requests.
 func (c *Client) SetUserToken(token string) {
 	c.UserToken = token
@@ -136,7 +272,7 @@ func (c *Client) loginInternal(email, password string) (*LoginResponse, error) {
 	}
 
 	if resp.IsErrorState() {
-		return nil, fmt.Errorf("login failed: %s", resp.String())
+		return nil, fmt.Errorf("login failed: %w", newAPIError(resp))
 	}
 
 	c.SetUserToken(result.UserAuthToken)
@@ -144,18 +280,53 @@ func (c *Client) loginInternal(email, password string) (*LoginResponse, error) {
 	return &result, nil
 }
 
+// GetUserInfo retrieves the logged-in user's account and subscription details.
+func (c *Client) GetUserInfo() (*UserInfo, error) {
+	var result UserInfo
+	resp, err := c.HTTP.R().
+		SetSuccessResult(&result).
+		Get("user/get")
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.IsErrorState() {
+		return nil, newAPIError(resp)
+	}
+
+	return &result, nil
+}
+
+// QualityRank orders quality IDs from lowest (0) to highest.
+// Unknown IDs rank above all known tiers.
+func QualityRank(formatID int) int {
+	for i, q := range qualityOrder {
+		if q == formatID {
+			return len(qualityOrder) - 1 - i
+		}
+	}
+	return len(qualityOrder)
+}
+
 // ValidateSecret checks if the current AppSecret is valid by testing the API.
 // Returns true if the secret works, false otherwise.
 func (c *Client) ValidateSecret() bool {
+	return c.CheckSecret() == nil
+}
+
+// CheckSecret is ValidateSecret returning the failure, so that a secret
+// rejected by Qobuz (an *APIError) can be told apart from a connection problem.
+func (c *Client) CheckSecret() error {
 	if c.AppSecret == "" {
-		return false
+		return fmt.Errorf("no app secret")
 	}
 	// Test track ID: Daft Punk - Technologic (public track for validation)
 	testTrackID := "5966783"
 	formatID := 5 // MP3 quality for quick validation
 
 	_, err := c.GetTrackURL(testTrackID, formatID)
-	return err == nil
+	return err
 }
 
 // FindValidSecret iterates through potential secrets and finds one that works.
@@ -182,15 +353,77 @@ func (c *Client) FindValidSecret(secrets []string) (string, error) {
 	return "", fmt.Errorf("no valid secret found in provided list")
 }
 
+// FindValidAppID tries secrets with the current App ID first and then with each
+// App ID candidate, returning the first working App ID and secret pair.
+// The client is left configured with that pair; on failure the original App ID is restored.
+func (c *Client) FindValidAppID(secrets []string) (string, string, error) {
+	if len(secrets) == 0 {
+		return "", "", fmt.Errorf("no secrets to test")
+	}
+
+	original := c.AppID
+	tried := make(map[string]bool)
+	for _, appID := range append([]string{original}, c.appIDCandidates...) {
+		if appID == "" || tried[appID] {
+			continue
+		}
+		tried[appID] = true
+
+		c.SetAppID(appID)
+		if secret, err := c.FindValidSecret(secrets); err == nil {
+			return appID, secret, nil
+		}
+	}
+
+	c.SetAppID(original)
+	return "", "", fmt.Errorf("no valid App ID and secret pair among %d App IDs", len(tried))
+}
+
 // GetTrackURL retrieves the download URL for a track with the specified quality.
 // Quality IDs: 5=MP3, 6=FLAC 16-bit, 7=FLAC 24-bit ≤96kHz, 27=FLAC 24-bit >96kHz.
 // This endpoint requires a signed request using the app secret.
 func (c *Client) GetTrackURL(trackID string, formatID int) (*TrackURLResponse, error) {
-	ts := time.Now().Unix()
+	return c.signedTrackURL(trackID, formatID, false)
+}
+
+// GetTrackPreviewURL retrieves the URL of a track's 30-second preview clip (MP3).
+// The request is sent without the user token, so Qobuz answers with the sample
+// it serves to visitors regardless of the account's subscription.
+func (c *Client) GetTrackPreviewURL(trackID string) (*TrackURLResponse, error) {
+	result, err := c.signedTrackURL(trackID, 5, true)
+	if err != nil {
+		return nil, err
+	}
+	if result.URL == "" {
+		return nil, fmt.Errorf("no preview available for track %s", trackID)
+	}
+	return result, nil
+}
+
+// signedTrackURL performs a track/getFileUrl request, retrying once with a
+// corrected timestamp when the signature is rejected for clock skew.
+func (c *Client) signedTrackURL(trackID string, formatID int, anonymous bool) (*TrackURLResponse, error) {
+	result, resp, err := c.getTrackURL(trackID, formatID, anonymous)
+	if err != nil && resp != nil && isSignatureRejection(err) && c.syncClock(resp) {
+		// The local clock is skewed; retry once with the corrected timestamp
+		result, _, err = c.getTrackURL(trackID, formatID, anonymous)
+	}
+	return result, err
+}
+
+// getTrackURL performs a single signed track/getFileUrl request, without the
+// user token if anonymous is set.
+// The response is returned with API errors so the caller can read the server time.
+func (c *Client) getTrackURL(trackID string, formatID int, anonymous bool) (*TrackURLResponse, *req.Response, error) {
+	ts := c.requestTimestamp()
+	intent := c.intent
+	if anonymous || intent == "" {
+		intent = IntentStream
+	}
 
 	// Build signature: concatenate endpoint, params, timestamp, and secret
-	rawSig := fmt.Sprintf("trackgetFileUrlformat_id%dintentstreamtrack_id%s%d%s",
-		formatID, trackID, ts, c.AppSecret)
+	rawSig := fmt.Sprintf("trackgetFileUrlformat_id%dintent%strack_id%s%d%s",
+		formatID, intent, trackID, ts, c.AppSecret)
 
 	hash := md5.Sum([]byte(rawSig))
 	sig := hex.EncodeToString(hash[:])
@@ -200,24 +433,72 @@ func (c *Client) GetTrackURL(trackID string, formatID int) (*TrackURLResponse, e
 		"request_sig": sig,
 		"track_id":    trackID,
 		"format_id":   strconv.Itoa(formatID),
-		"intent":      "stream",
+		"intent":      intent,
 	}
 
 	var result TrackURLResponse
-	resp, err := c.HTTP.R().
+	r := c.HTTP.R()
+	if anonymous {
+		r.SetHeader("X-User-Auth-Token", "") // Overrides the common header
+	}
+	resp, err := r.
 		SetQueryParams(params).
 		SetSuccessResult(&result).
 		Get("track/getFileUrl")
 
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if resp.IsErrorState() {
-		return nil, errors.New(resp.String())
+		return nil, resp, newAPIError(resp)
+	}
+	if !anonymous && (result.URL == "" || result.Sample) {
+		unavailable := &UnavailableError{TrackID: trackID, FormatID: formatID, Sample: result.URL != ""}
+		for _, r := range result.Restrictions {
+			unavailable.Restrictions = append(unavailable.Restrictions, r.Code)
+		}
+		return nil, resp, unavailable
 	}
 
-	return &result, nil
+	return &result, resp, nil
+}
+
+// clockSkewThreshold is the minimum difference from the server clock that is
+// corrected. The Date header has one second resolution.
+const clockSkewThreshold = 5 * time.Second
+
+// requestTimestamp returns the Unix time used to sign requests, corrected by
+// the offset learned from the server clock.
+func (c *Client) requestTimestamp() int64 {
+	return time.Now().Add(time.Duration(c.clockOffset.Load())).Unix()
+}
+
+// syncClock updates the clock offset from the response's Date header.
+// Reports whether the offset changed enough to be worth retrying.
+func (c *Client) syncClock(resp *req.Response) bool {
+	serverTime, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return false
+	}
+	offset := time.Until(serverTime)
+	current := time.Duration(c.clockOffset.Load())
+	if (offset - current).Abs() < clockSkewThreshold {
+		return false
+	}
+	c.clockOffset.Store(int64(offset))
+	return true
+}
+
+// isSignatureRejection reports whether err is Qobuz rejecting the request
+// signature or timestamp, which happens when the local clock is skewed.
+func isSignatureRejection(err error) bool {
+	apiErr, ok := AsAPIError(err)
+	if !ok || apiErr.StatusCode != http.StatusBadRequest {
+		return false
+	}
+	msg := strings.ToLower(apiErr.Message)
+	return strings.Contains(msg, "request_sig") || strings.Contains(msg, "request_ts") || strings.Contains(msg, "signature")
 }
 
 // qualityOrder defines the quality hierarchy from highest to lowest.
@@ -266,11 +547,21 @@ func (c *Client) GetTrackURLWithFallback(trackID string, requestedFormatID int)
 	return nil, 0, fmt.Errorf("all quality fallbacks failed (tried %v): %w", qualities, lastErr)
 }
 
-// GetTrack retrieves metadata for a single track by its ID.
+// trackGetExtra asks track/get to embed the full album block (genre, dates,
+// artist, cover) rather than the bare summary.
+const trackGetExtra = "albumsFromSameArtist"
+
+// GetTrack retrieves metadata for a single track by its ID, with the full album
+// block the tagger needs. Should track/get still return an album without its
+// title, artist or cover, the album is fetched with album/get; failing that,
+// the error is returned.
 func (c *Client) GetTrack(trackID string) (*TrackMetadata, error) {
 	var result TrackMetadata
-	resp, err := c.HTTP.R().
-		SetQueryParam("track_id", trackID).
+	resp, err := c.metadataRequest().
+		SetQueryParams(map[string]string{
+			"track_id": trackID,
+			"extra":    trackGetExtra,
+		}).
 		SetSuccessResult(&result).
 		Get("track/get")
 
@@ -279,27 +570,209 @@ func (c *Client) GetTrack(trackID string) (*TrackMetadata, error) {
 	}
 
 	if resp.IsErrorState() {
-		return nil, errors.New(resp.String())
+		return nil, newAPIError(resp)
+	}
+
+	if result.Album != nil && result.Album.ID != "" && !result.Album.hasTaggingFields() {
+		album, err := c.GetAlbum(result.Album.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to complete album metadata for track %s: %w", trackID, err)
+		}
+		result.Album = album
 	}
 
 	return &result, nil
 }
 
-// GetAlbum retrieves metadata for an album by its ID, including all tracks.
-func (c *Client) GetAlbum(albumID string) (*AlbumMetadata, error) {
-	var result AlbumMetadata
-	resp, err := c.HTTP.R().
-		SetQueryParam("album_id", albumID).
+// upcSearchLimit is the number of search results inspected for a barcode match.
+const upcSearchLimit = 20
+
+// normalizeUPC strips separators and leading zeros so that UPC-A (12 digits)
+// and EAN-13 forms of the same barcode compare equal.
+func normalizeUPC(upc string) string {
+	upc = strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, upc)
+	return strings.TrimLeft(upc, "0")
+}
+
+// GetAlbumByUPC finds the album with the given UPC/EAN barcode using album/search.
+// Returns an error if no album or more than one album carries the barcode.
+func (c *Client) GetAlbumByUPC(upc string) (*AlbumMetadata, error) {
+	want := normalizeUPC(upc)
+	if want == "" {
+		return nil, fmt.Errorf("invalid UPC: %q", upc)
+	}
+
+	var result AlbumSearchResponse
+	resp, err := c.metadataRequest().
+		SetQueryParams(map[string]string{
+			"query": upc,
+			"limit": strconv.Itoa(upcSearchLimit),
+		}).
 		SetSuccessResult(&result).
-		Get("album/get")
+		Get("album/search")
 
 	if err != nil {
 		return nil, err
 	}
 
 	if resp.IsErrorState() {
-		return nil, errors.New(resp.String())
+		return nil, newAPIError(resp)
 	}
 
-	return &result, nil
+	var matches []AlbumMetadata
+	for _, album := range result.Albums.Items {
+		if normalizeUPC(album.UPC) == want {
+			matches = append(matches, album)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no album found for UPC %s", upc)
+	case 1:
+		return &matches[0], nil
+	default:
+		candidates := make([]string, len(matches))
+		for i, m := range matches {
+			candidates[i] = fmt.Sprintf("%s (%s - %s)", m.ID, m.Artist.Name, m.Title)
+		}
+		return nil, fmt.Errorf("multiple albums match UPC %s: %s", upc, strings.Join(candidates, ", "))
+	}
+}
+
+// albumTracksPageSize is the number of tracks requested per album/get page.
+const albumTracksPageSize = 500
+
+// GetAlbum retrieves metadata for an album by its ID, including all tracks.
+// Large albums return tracks in pages; remaining pages are fetched until the
+// reported total is reached and appended to Tracks.Items.
+func (c *Client) GetAlbum(albumID string) (*AlbumMetadata, error) {
+	var album *AlbumMetadata
+	offset := 0
+
+	for {
+		var page AlbumMetadata
+		resp, err := c.metadataRequest().
+			SetQueryParams(map[string]string{
+				"album_id": albumID,
+				"limit":    strconv.Itoa(albumTracksPageSize),
+				"offset":   strconv.Itoa(offset),
+			}).
+			SetSuccessResult(&page).
+			Get("album/get")
+
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.IsErrorState() {
+			return nil, newAPIError(resp)
+		}
+
+		if album == nil {
+			album = &page
+		} else {
+			album.Tracks.Items = append(album.Tracks.Items, page.Tracks.Items...)
+		}
+
+		offset += len(page.Tracks.Items)
+		if len(page.Tracks.Items) == 0 || offset >= page.Tracks.Total {
+			break
+		}
+	}
+
+	return album, nil
+}
+
+// playlistTracksPageSize is the number of tracks requested per playlist/get page.
+const playlistTracksPageSize = 500
+
+// GetPlaylist retrieves a playlist and all of its tracks by playlist ID.
+// Track pages are fetched until the reported total is reached.
+func (c *Client) GetPlaylist(playlistID string) (*PlaylistMetadata, error) {
+	var playlist *PlaylistMetadata
+	offset := 0
+
+	for {
+		var page PlaylistMetadata
+		resp, err := c.metadataRequest().
+			SetQueryParams(map[string]string{
+				"playlist_id": playlistID,
+				"extra":       "tracks",
+				"limit":       strconv.Itoa(playlistTracksPageSize),
+				"offset":      strconv.Itoa(offset),
+			}).
+			SetSuccessResult(&page).
+			Get("playlist/get")
+
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.IsErrorState() {
+			return nil, newAPIError(resp)
+		}
+
+		if playlist == nil {
+			playlist = &page
+		} else {
+			playlist.Tracks.Items = append(playlist.Tracks.Items, page.Tracks.Items...)
+		}
+
+		offset += len(page.Tracks.Items)
+		if len(page.Tracks.Items) == 0 || offset >= page.Tracks.Total {
+			break
+		}
+	}
+
+	return playlist, nil
+}
+
+// artistAlbumsPageSize is the number of albums requested per artist/get page.
+const artistAlbumsPageSize = 100
+
+// GetArtist retrieves an artist and their full album list by artist ID.
+// Album pages are fetched until the reported total is reached.
+func (c *Client) GetArtist(artistID string) (*ArtistMetadata, error) {
+	var artist *ArtistMetadata
+	offset := 0
+
+	for {
+		var page ArtistMetadata
+		resp, err := c.metadataRequest().
+			SetQueryParams(map[string]string{
+				"artist_id": artistID,
+				"extra":     "albums",
+				"limit":     strconv.Itoa(artistAlbumsPageSize),
+				"offset":    strconv.Itoa(offset),
+			}).
+			SetSuccessResult(&page).
+			Get("artist/get")
+
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.IsErrorState() {
+			return nil, newAPIError(resp)
+		}
+
+		if artist == nil {
+			artist = &page
+		} else {
+			artist.Albums.Items = append(artist.Albums.Items, page.Albums.Items...)
+		}
+
+		offset += len(page.Albums.Items)
+		if len(page.Albums.Items) == 0 || offset >= page.Albums.Total {
+			break
+		}
+	}
+
+	return artist, nil
 }
@@ -0,0 +1,798 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// trackGetResponse is a trimmed track/get response as Qobuz returns it, with
+// the album summary carrying everything but its track list.
+const trackGetResponse = `{
+	"id": 19512574,
+	"title": "Dreams",
+	"version": "2004 Remaster",
+	"isrc": "USWB10400049",
+	"duration": 257,
+	"track_number": 2,
+	"media_number": 1,
+	"maximum_bit_depth": 24,
+	"maximum_sampling_rate": 96,
+	"performer": {"id": 2059, "name": "Fleetwood Mac"},
+	"album": {
+		"id": "0603497863212",
+		"title": "Rumours",
+		"upc": "0603497863212",
+		"release_date_original": "1977-02-04",
+		"artist": {"id": 2059, "name": "Fleetwood Mac"},
+		"genre": {"id": 119, "name": "Rock"},
+		"image": {
+			"small": "https://static.qobuz.com/images/covers/12/32/0603497863212_230.jpg",
+			"large": "https://static.qobuz.com/images/covers/12/32/0603497863212_600.jpg"
+		},
+		"tracks_count": 11,
+		"media_count": 1
+	}
+}`
+
+// trackGetNoImage is a track/get response whose album summary lacks the cover.
+const trackGetNoImage = `{
+	"id": 1,
+	"title": "Song",
+	"performer": {"name": "Artist"},
+	"album": {"id": "abc", "title": "Album", "artist": {"name": "Artist"}}
+}`
+
+const albumGetResponse = `{
+	"id": "abc",
+	"title": "Album",
+	"artist": {"name": "Artist"},
+	"genre": {"name": "Jazz"},
+	"release_date_original": "2001-01-01",
+	"image": {"large": "https://static.qobuz.com/images/covers/ab/c/abc_600.jpg"},
+	"tracks": {"items": [{"id": 1, "title": "Song"}], "total": 1}
+}`
+
+func TestGetTrack(t *testing.T) {
+	tests := []struct {
+		name          string
+		track         string
+		trackStatus   int
+		albumStatus   int
+		wantErr       bool
+		wantAlbumGet  bool
+		wantGenre     string
+		wantImage     bool
+		wantAlbumName string
+	}{
+		{
+			name:          "complete album summary needs no album/get",
+			track:         trackGetResponse,
+			wantGenre:     "Rock",
+			wantImage:     true,
+			wantAlbumName: "Rumours",
+		},
+		{
+			name:          "missing cover completes the album",
+			track:         trackGetNoImage,
+			wantAlbumGet:  true,
+			wantGenre:     "Jazz",
+			wantImage:     true,
+			wantAlbumName: "Album",
+		},
+		{
+			name:         "failed album/get is an error",
+			track:        trackGetNoImage,
+			albumStatus:  http.StatusNotFound,
+			wantAlbumGet: true,
+			wantErr:      true,
+		},
+		{
+			name:        "failed track/get is an error",
+			trackStatus: http.StatusNotFound,
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			albumGets := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch r.URL.Path {
+				case "/track/get":
+					if extra := r.URL.Query().Get("extra"); extra != trackGetExtra {
+						t.Errorf("track/get extra = %q, want %q", extra, trackGetExtra)
+					}
+					if tt.trackStatus != 0 {
+						w.WriteHeader(tt.trackStatus)
+						w.Write([]byte(`{"status":"error","code":404,"message":"No result matching given argument"}`))
+						return
+					}
+					w.Write([]byte(tt.track))
+				case "/album/get":
+					albumGets++
+					if tt.albumStatus != 0 {
+						w.WriteHeader(tt.albumStatus)
+						w.Write([]byte(`{"status":"error","code":404,"message":"No result matching given argument"}`))
+						return
+					}
+					w.Write([]byte(albumGetResponse))
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer srv.Close()
+
+			c := NewClient("app", "secret")
+			c.HTTP.SetBaseURL(srv.URL)
+
+			track, err := c.GetTrack("1")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetTrack() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (albumGets > 0) != tt.wantAlbumGet {
+				t.Errorf("album/get called %d times, want called = %v", albumGets, tt.wantAlbumGet)
+			}
+			if err != nil {
+				return
+			}
+
+			album := track.Album
+			if album == nil {
+				t.Fatal("track has no album")
+			}
+			if album.Title != tt.wantAlbumName {
+				t.Errorf("album title = %q, want %q", album.Title, tt.wantAlbumName)
+			}
+			genre := ""
+			if album.Genre != nil {
+				genre = album.Genre.Name
+			}
+			if genre != tt.wantGenre {
+				t.Errorf("genre = %q, want %q", genre, tt.wantGenre)
+			}
+			if (album.Image.Large != "") != tt.wantImage {
+				t.Errorf("image = %q, want present = %v", album.Image.Large, tt.wantImage)
+			}
+		})
+	}
+}
+
+func TestHasTaggingFields(t *testing.T) {
+	full := func() *AlbumMetadata {
+		a := &AlbumMetadata{Title: "Album"}
+		a.Artist.Name = "Artist"
+		a.Image.Large = "https://example.com/cover.jpg"
+		return a
+	}
+	tests := []struct {
+		name   string
+		modify func(*AlbumMetadata)
+		want   bool
+	}{
+		{name: "genre and dates are optional", modify: func(*AlbumMetadata) {}, want: true},
+		{name: "missing title", modify: func(a *AlbumMetadata) { a.Title = "" }},
+		{name: "missing artist", modify: func(a *AlbumMetadata) { a.Artist.Name = "" }},
+		{name: "missing cover", modify: func(a *AlbumMetadata) { a.Image.Large = "" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := full()
+			tt.modify(a)
+			if got := a.hasTaggingFields(); got != tt.want {
+				t.Errorf("hasTaggingFields() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetAlbumPagination(t *testing.T) {
+	tests := []struct {
+		name      string
+		total     int // Tracks on the album
+		pageSize  int // Most tracks the server returns per request
+		reported  int // Reported tracks.total, if different from total
+		wantPages int
+	}{
+		{name: "single page", total: 12, pageSize: albumTracksPageSize, wantPages: 1},
+		{name: "exact page boundary", total: 2 * albumTracksPageSize, pageSize: albumTracksPageSize, wantPages: 2},
+		{name: "many pages", total: 1203, pageSize: albumTracksPageSize, wantPages: 3},
+		{name: "server caps page size", total: 120, pageSize: 50, wantPages: 3},
+		{name: "overstated total stops on empty page", total: 30, pageSize: 50, reported: 80, wantPages: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reported := tt.total
+			if tt.reported != 0 {
+				reported = tt.reported
+			}
+			pages := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				pages++
+				offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+				limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+				end := min(offset+min(limit, tt.pageSize), tt.total)
+
+				page := AlbumMetadata{ID: "abc", Title: "Album"}
+				page.Tracks.Total = reported
+				for id := offset; id < end; id++ {
+					page.Tracks.Items = append(page.Tracks.Items, TrackMetadata{ID: id})
+				}
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(&page)
+			}))
+			defer srv.Close()
+
+			c := NewClient("app", "secret")
+			c.HTTP.SetBaseURL(srv.URL)
+
+			album, err := c.GetAlbum("abc")
+			if err != nil {
+				t.Fatalf("GetAlbum: %v", err)
+			}
+			if pages != tt.wantPages {
+				t.Errorf("fetched %d pages, want %d", pages, tt.wantPages)
+			}
+			if len(album.Tracks.Items) != tt.total {
+				t.Fatalf("got %d tracks, want %d", len(album.Tracks.Items), tt.total)
+			}
+			for i, track := range album.Tracks.Items {
+				if track.ID != i {
+					t.Fatalf("track %d has ID %d, pages were not concatenated in order", i, track.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestGetAlbumByUPC(t *testing.T) {
+	// album/search matches the query loosely, so unrelated albums come back too
+	const searchResponse = `{"albums": {"total": 4, "items": [
+		{"id": "a1", "title": "Rumours", "upc": "0603497863212", "artist": {"name": "Fleetwood Mac"}},
+		{"id": "a2", "title": "Rumours (Deluxe)", "upc": "0603497863229", "artist": {"name": "Fleetwood Mac"}},
+		{"id": "b1", "title": "Tusk", "upc": "0081227966225", "artist": {"name": "Fleetwood Mac"}},
+		{"id": "b2", "title": "Tusk (Reissue)", "upc": "081227966225", "artist": {"name": "Fleetwood Mac"}}
+	]}}`
+
+	tests := []struct {
+		name    string
+		upc     string
+		wantID  string
+		wantErr string
+	}{
+		{name: "exact match", upc: "0603497863212", wantID: "a1"},
+		{name: "leading zeros dropped", upc: "603497863212", wantID: "a1"},
+		{name: "separators ignored", upc: "0 603497-863212", wantID: "a1"},
+		{name: "no match", upc: "1234567890128", wantErr: "no album found"},
+		{name: "multiple matches", upc: "0081227966225", wantErr: "b1 (Fleetwood Mac - Tusk), b2"},
+		{name: "invalid", upc: "n/a", wantErr: "invalid UPC"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var query string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/album/search" {
+					http.NotFound(w, r)
+					return
+				}
+				query = r.URL.Query().Get("query")
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(searchResponse))
+			}))
+			defer srv.Close()
+
+			c := NewClient("app", "secret")
+			c.HTTP.SetBaseURL(srv.URL)
+
+			album, err := c.GetAlbumByUPC(tt.upc)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("GetAlbumByUPC() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetAlbumByUPC: %v", err)
+			}
+			if album.ID != tt.wantID {
+				t.Errorf("album ID = %q, want %q", album.ID, tt.wantID)
+			}
+			if query != tt.upc {
+				t.Errorf("search query = %q, want %q", query, tt.upc)
+			}
+		})
+	}
+}
+
+// signingServer accepts track/getFileUrl requests signed with one of the valid
+// App ID and secret pairs, recording every attempted pair in order.
+func signingServer(t *testing.T, valid map[string]string, attempts *[]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		appID := r.Header.Get("X-App-Id")
+		secret, ok := valid[appID]
+		raw := fmt.Sprintf("trackgetFileUrlformat_id%sintent%strack_id%s%s%s",
+			q.Get("format_id"), q.Get("intent"), q.Get("track_id"), q.Get("request_ts"), secret)
+		sum := md5.Sum([]byte(raw))
+
+		w.Header().Set("Content-Type", "application/json")
+		if !ok || q.Get("request_sig") != hex.EncodeToString(sum[:]) {
+			*attempts = append(*attempts, appID+" rejected")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"status":"error","code":400,"message":"Invalid Request Signature parameter (request_sig)"}`))
+			return
+		}
+		*attempts = append(*attempts, appID+" accepted")
+		w.Write([]byte(`{"url":"https://example.com/track.mp3","format_id":5}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestFindValidAppID(t *testing.T) {
+	secrets := []string{"s1", "s2"}
+	tests := []struct {
+		name         string
+		appID        string
+		candidates   []string
+		valid        map[string]string
+		wantAppID    string
+		wantSecret   string
+		wantAttempts []string
+	}{
+		{
+			name:         "current App ID works",
+			appID:        "current",
+			candidates:   []string{"fallback"},
+			valid:        map[string]string{"current": "s2", "fallback": "s1"},
+			wantAppID:    "current",
+			wantSecret:   "s2",
+			wantAttempts: []string{"current rejected", "current accepted"},
+		},
+		{
+			name:         "blocked App ID falls back in order",
+			appID:        "current",
+			candidates:   []string{"old1", "old2", "old3"},
+			valid:        map[string]string{"old2": "s1", "old3": "s1"},
+			wantAppID:    "old2",
+			wantSecret:   "s1",
+			wantAttempts: []string{"current rejected", "current rejected", "old1 rejected", "old1 rejected", "old2 accepted"},
+		},
+		{
+			name:         "empty and repeated candidates skipped",
+			appID:        "current",
+			candidates:   []string{"", "current", "old1"},
+			valid:        map[string]string{"old1": "s2"},
+			wantAppID:    "old1",
+			wantSecret:   "s2",
+			wantAttempts: []string{"current rejected", "current rejected", "old1 rejected", "old1 accepted"},
+		},
+		{
+			name:         "no current App ID",
+			candidates:   []string{"old1"},
+			valid:        map[string]string{"old1": "s1"},
+			wantAppID:    "old1",
+			wantSecret:   "s1",
+			wantAttempts: []string{"old1 accepted"},
+		},
+		{
+			name:         "nothing works",
+			appID:        "current",
+			candidates:   []string{"old1"},
+			valid:        map[string]string{},
+			wantAttempts: []string{"current rejected", "current rejected", "old1 rejected", "old1 rejected"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts []string
+			srv := signingServer(t, tt.valid, &attempts)
+			c := NewClient(tt.appID, "")
+			c.HTTP.SetBaseURL(srv.URL)
+			c.SetAppIDCandidates(tt.candidates)
+
+			appID, secret, err := c.FindValidAppID(secrets)
+			if (err != nil) != (tt.wantAppID == "") {
+				t.Fatalf("FindValidAppID() error = %v", err)
+			}
+			if appID != tt.wantAppID || secret != tt.wantSecret {
+				t.Errorf("FindValidAppID() = %q, %q; want %q, %q", appID, secret, tt.wantAppID, tt.wantSecret)
+			}
+			if !slices.Equal(attempts, tt.wantAttempts) {
+				t.Errorf("attempts = %v, want %v", attempts, tt.wantAttempts)
+			}
+			wantClientAppID := tt.wantAppID
+			if err != nil {
+				wantClientAppID = tt.appID // Restored
+			}
+			if c.AppID != wantClientAppID || c.AppSecret != tt.wantSecret {
+				t.Errorf("client left with %q, %q; want %q, %q", c.AppID, c.AppSecret, wantClientAppID, tt.wantSecret)
+			}
+		})
+	}
+
+	if _, _, err := NewClient("current", "").FindValidAppID(nil); err == nil {
+		t.Error("FindValidAppID(nil) succeeded")
+	}
+}
+
+func TestSetExtraHeader(t *testing.T) {
+	tests := []struct {
+		key, value string
+		wantErr    bool
+	}{
+		{key: "X-Forwarded-For", value: "203.0.113.7"},
+		{key: " proxy-authorization ", value: "Basic dXNlcjpwYXNz"},
+		{key: "x-app-id", value: "999999999", wantErr: true},
+		{key: "X-User-Auth-Token", value: "stolen", wantErr: true},
+		{key: " ", value: "x", wantErr: true},
+	}
+
+	var got []http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Clone())
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(albumGetResponse))
+	}))
+	defer srv.Close()
+
+	c := NewClient("123456789", "secret")
+	c.SetUserToken("token")
+	c.HTTP.SetBaseURL(srv.URL)
+	for _, tt := range tests {
+		if err := c.SetExtraHeader(tt.key, tt.value); (err != nil) != tt.wantErr {
+			t.Errorf("SetExtraHeader(%q) error = %v, want error %v", tt.key, err, tt.wantErr)
+		}
+	}
+
+	// Both API calls and file downloads go through the client
+	if _, err := c.GetAlbum("abc"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.HTTP.R().Get(srv.URL + "/file/1.flac"); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("server saw %d requests, want 2", len(got))
+	}
+	for i, h := range got {
+		if h.Get("X-Forwarded-For") != "203.0.113.7" || h.Get("Proxy-Authorization") != "Basic dXNlcjpwYXNz" {
+			t.Errorf("request %d is missing the extra headers: %v", i, h)
+		}
+		if h.Get("X-App-Id") != "123456789" || h.Get("X-User-Auth-Token") != "token" {
+			t.Errorf("request %d has overridden credentials: %v", i, h)
+		}
+	}
+}
+
+func TestGetTrackURLClockSkew(t *testing.T) {
+	tests := []struct {
+		name         string
+		skew         time.Duration // Server clock minus local clock
+		wantRequests int           // For two GetTrackURL calls
+	}{
+		{name: "clock in sync", wantRequests: 2},
+		{name: "small skew tolerated", skew: 3 * time.Second, wantRequests: 2},
+		{name: "local clock behind", skew: time.Hour, wantRequests: 3},
+		{name: "local clock ahead", skew: -2 * time.Hour, wantRequests: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				now := time.Now().Add(tt.skew)
+				w.Header().Set("Date", now.UTC().Format(http.TimeFormat))
+				w.Header().Set("Content-Type", "application/json")
+
+				ts, _ := strconv.ParseInt(r.URL.Query().Get("request_ts"), 10, 64)
+				if d := now.Unix() - ts; d > 60 || d < -60 {
+					w.WriteHeader(http.StatusBadRequest)
+					w.Write([]byte(`{"status":"error","code":400,"message":"Invalid Request Signature parameter (request_sig)"}`))
+					return
+				}
+				w.Write([]byte(`{"url":"https://example.com/track.flac","format_id":27}`))
+			}))
+			defer srv.Close()
+
+			c := NewClient("app", "secret")
+			c.HTTP.SetBaseURL(srv.URL)
+			// The offset learned on the first call is reused by the second
+			for i := range 2 {
+				if _, err := c.GetTrackURL("1", 27); err != nil {
+					t.Fatalf("GetTrackURL #%d: %v", i, err)
+				}
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("server saw %d requests, want %d", requests, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestGetTrackURLRejectionWithoutSkew(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"status":"error","code":400,"message":"Invalid Request Signature parameter (request_sig)"}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient("app", "wrong-secret")
+	c.HTTP.SetBaseURL(srv.URL)
+	_, err := c.GetTrackURL("1", 27)
+	if apiErr, ok := AsAPIError(err); !ok || apiErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("GetTrackURL error = %v, want the signature rejection", err)
+	}
+	if requests != 1 {
+		t.Errorf("server saw %d requests, want 1: a rejection not caused by skew is not retried", requests)
+	}
+}
+
+func TestForceHTTP1(t *testing.T) {
+	tests := []struct {
+		name      string
+		force     bool
+		wantProto string
+	}{
+		{name: "default negotiates HTTP/2", wantProto: "HTTP/2.0"},
+		{name: "forced HTTP/1.1", force: true, wantProto: "HTTP/1.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var protos []string
+			srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				protos = append(protos, r.URL.Path+" "+r.Proto)
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(trackGetResponse))
+			}))
+			srv.EnableHTTP2 = true
+			srv.StartTLS()
+			defer srv.Close()
+
+			c := NewClient("app", "secret")
+			c.HTTP.SetBaseURL(srv.URL)
+			c.SetInsecureSkipVerify(true) // Test certificate
+			if tt.force {
+				c.ForceHTTP1()
+			}
+
+			// An API call and a file download share the transport
+			if _, err := c.GetTrack("19512574"); err != nil {
+				t.Fatalf("GetTrack: %v", err)
+			}
+			if _, err := c.HTTP.R().Get(srv.URL + "/file/1.flac"); err != nil {
+				t.Fatalf("download: %v", err)
+			}
+			want := []string{"/track/get " + tt.wantProto, "/file/1.flac " + tt.wantProto}
+			if !slices.Equal(protos, want) {
+				t.Errorf("requests = %q, want %q", protos, want)
+			}
+		})
+	}
+}
+
+func TestSetLocale(t *testing.T) {
+	tests := []struct {
+		name     string
+		lang     string
+		wantLang string // Sent as the lang parameter and Accept-Language, "" for none
+		wantErr  bool
+	}{
+		{name: "language", lang: "ja", wantLang: "ja"},
+		{name: "region normalized", lang: " en-us ", wantLang: "en-US"},
+		{name: "unset", lang: ""},
+		{name: "invalid", lang: "not a locale", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make(map[string]string)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				lang := r.URL.Query().Get("lang")
+				if header := r.Header.Get("Accept-Language"); header != lang {
+					t.Errorf("%s: Accept-Language %q differs from lang %q", r.URL.Path, header, lang)
+				}
+				got[r.URL.Path] = lang
+				w.Header().Set("Content-Type", "application/json")
+				switch r.URL.Path {
+				case "/track/get":
+					w.Write([]byte(trackGetResponse))
+				case "/album/get":
+					w.Write([]byte(`{"id":"alb1","title":"Album","tracks":{"total":0,"items":[]}}`))
+				case "/track/getFileUrl":
+					w.Write([]byte(`{"url":"https://example.com/track.flac","format_id":6}`))
+				}
+			}))
+			defer srv.Close()
+
+			c := NewClient("app", "secret")
+			c.HTTP.SetBaseURL(srv.URL)
+			err := c.SetLocale(tt.lang)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetLocale(%q) error = %v, want error %v", tt.lang, err, tt.wantErr)
+			}
+
+			if _, err := c.GetTrack("19512574"); err != nil {
+				t.Fatalf("GetTrack: %v", err)
+			}
+			if _, err := c.GetAlbum("alb1"); err != nil {
+				t.Fatalf("GetAlbum: %v", err)
+			}
+			if _, err := c.GetTrackURL("19512574", 6); err != nil {
+				t.Fatalf("GetTrackURL: %v", err)
+			}
+			for _, path := range []string{"/track/get", "/album/get"} {
+				if got[path] != tt.wantLang {
+					t.Errorf("%s sent lang %q, want %q", path, got[path], tt.wantLang)
+				}
+			}
+			if got["/track/getFileUrl"] != "" {
+				t.Errorf("signed track URL request sent lang %q", got["/track/getFileUrl"])
+			}
+		})
+	}
+}
+
+func TestSetInsecureSkipVerify(t *testing.T) {
+	tests := []struct {
+		name    string
+		skips   []bool // Successive SetInsecureSkipVerify calls
+		wantErr bool
+	}{
+		{name: "verified by default", wantErr: true},
+		{name: "skipped", skips: []bool{true}},
+		{name: "restored", skips: []bool{true, false}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(trackGetResponse))
+			}))
+			defer srv.Close()
+
+			c := NewClient("app", "secret")
+			c.HTTP.SetBaseURL(srv.URL) // Self-signed certificate
+			for _, skip := range tt.skips {
+				c.SetInsecureSkipVerify(skip)
+			}
+
+			// API calls and file downloads share the transport
+			_, apiErr := c.GetTrack("19512574")
+			_, downloadErr := c.HTTP.R().Get(srv.URL + "/file/1.flac")
+			for what, err := range map[string]error{"API call": apiErr, "download": downloadErr} {
+				if (err != nil) != tt.wantErr {
+					t.Errorf("%s error = %v, want error %v", what, err, tt.wantErr)
+				}
+				if err != nil && !strings.Contains(err.Error(), "certificate") {
+					t.Errorf("%s error = %v, want a certificate error", what, err)
+				}
+			}
+		})
+	}
+}
+
+// newCA returns a certificate authority and a certificate for 127.0.0.1
+// signed by it, with the CA certificate PEM encoded.
+func newCA(t *testing.T, name string) (caPEM []byte, leaf tls.Certificate) {
+	t.Helper()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "proxy"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, ca, &leafKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf = tls.Certificate{Certificate: [][]byte{leafDER}, PrivateKey: leafKey}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}), leaf
+}
+
+func TestSetRootCAs(t *testing.T) {
+	caPEM, leaf := newCA(t, "Proxy CA")
+	otherPEM, _ := newCA(t, "Other CA")
+	tests := []struct {
+		name        string
+		bundle      []byte // CA file contents, nil for no file
+		wantLoadErr string
+		wantReqErr  bool
+	}{
+		{name: "signing CA", bundle: caPEM},
+		{name: "bundle with the signing CA", bundle: append(slices.Clone(otherPEM), caPEM...)},
+		{name: "unrelated CA", bundle: otherPEM, wantReqErr: true},
+		{name: "not PEM", bundle: []byte("not a certificate"), wantLoadErr: "no PEM certificate"},
+		{name: "missing file", wantLoadErr: "failed to read CA certificate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(trackGetResponse))
+			}))
+			srv.TLS = &tls.Config{Certificates: []tls.Certificate{leaf}}
+			srv.StartTLS()
+			defer srv.Close()
+
+			path := filepath.Join(t.TempDir(), "ca.pem")
+			if tt.bundle != nil {
+				if err := os.WriteFile(path, tt.bundle, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			c := NewClient("app", "secret")
+			c.HTTP.SetBaseURL(srv.URL)
+			err := c.SetRootCAs(path)
+			if tt.wantLoadErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantLoadErr) {
+					t.Errorf("SetRootCAs() = %v, want %q", err, tt.wantLoadErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SetRootCAs: %v", err)
+			}
+
+			// API calls and file downloads share the transport
+			_, apiErr := c.GetTrack("19512574")
+			_, downloadErr := c.HTTP.R().Get(srv.URL + "/file/1.flac")
+			for what, err := range map[string]error{"API call": apiErr, "download": downloadErr} {
+				if (err != nil) != tt.wantReqErr {
+					t.Errorf("%s error = %v, want error %v", what, err, tt.wantReqErr)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/imroc/req/v3"
+)
+
+// APIError is a non-2xx response from the Qobuz API.
+// Callers can inspect StatusCode with errors.As instead of matching message text.
+type APIError struct {
+	StatusCode int    // HTTP status code
+	Code       int    // Qobuz error code from the response body (0 if absent)
+	Message    string // Error message from the response body (or the raw body)
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("qobuz api: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("qobuz api: %s (HTTP %d)", e.Message, e.StatusCode)
+}
+
+// IsAuth reports whether the request was rejected for missing or invalid credentials.
+func (e *APIError) IsAuth() bool {
+	return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+}
+
+// IsNotFound reports whether the requested resource does not exist.
+func (e *APIError) IsNotFound() bool {
+	return e.StatusCode == http.StatusNotFound
+}
+
+// newAPIError builds an APIError from an error response.
+// Qobuz returns {"status":"error","code":...,"message":"..."}; other bodies are kept verbatim.
+func newAPIError(resp *req.Response) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+
+	body := strings.TrimSpace(resp.String())
+	var parsed struct {
+		Code    json.Number `json:"code"`
+		Message string      `json:"message"`
+	}
+	if json.Unmarshal([]byte(body), &parsed) == nil && parsed.Message != "" {
+		apiErr.Message = parsed.Message
+		if code, err := parsed.Code.Int64(); err == nil {
+			apiErr.Code = int(code)
+		}
+	} else {
+		apiErr.Message = body
+	}
+	return apiErr
+}
+
+// UnavailableError reports that track/getFileUrl returned no full-length file
+// for a track, listing the restrictions Qobuz gave as the reason.
+type UnavailableError struct {
+	TrackID      string
+	FormatID     int
+	Sample       bool     // Only the 30-second preview was offered
+	Restrictions []string // Restriction codes, e.g. TrackRestrictedByRightHolders
+}
+
+// Error implements the error interface.
+func (e *UnavailableError) Error() string {
+	what := "no file URL"
+	if e.Sample {
+		what = "only a preview"
+	}
+	if len(e.Restrictions) == 0 {
+		return fmt.Sprintf("track %s: %s returned for format %d", e.TrackID, what, e.FormatID)
+	}
+	return fmt.Sprintf("track %s: %s returned for format %d (%s)", e.TrackID, what, e.FormatID, strings.Join(e.Restrictions, ", "))
+}
+
+// AsAPIError returns the APIError wrapped in err, if any.
+func AsAPIError(err error) (*APIError, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr, true
+	}
+	return nil, false
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// FeaturedTypes lists the album lists served by album/getFeatured.
+var FeaturedTypes = []string{
+	"new-releases",
+	"new-releases-full",
+	"press-awards",
+	"best-sellers",
+	"editor-picks",
+	"most-streamed",
+	"most-featured",
+	"ideal-discography",
+	"qobuzissims",
+	"recent-releases",
+}
+
+// featuredPageSize is the number of albums requested per album/getFeatured page.
+const featuredPageSize = 100
+
+// FeaturedResponse is a page of album/getFeatured.
+type FeaturedResponse struct {
+	Albums struct {
+		Items  []AlbumMetadata `json:"items"`
+		Total  int             `json:"total"`
+		Offset int             `json:"offset"`
+		Limit  int             `json:"limit"`
+	} `json:"albums"`
+}
+
+// GetFeatured returns up to limit albums of a featured list (new releases, press
+// awards, ...). genreID restricts the list to one genre; 0 means all genres.
+// Pages are fetched until limit albums are collected or the list ends.
+func (c *Client) GetFeatured(featuredType string, genreID int, limit int) ([]AlbumMetadata, error) {
+	if !slices.Contains(FeaturedTypes, featuredType) {
+		return nil, fmt.Errorf("unknown featured type %q", featuredType)
+	}
+
+	var albums []AlbumMetadata
+	for len(albums) < limit {
+		params := map[string]string{
+			"type":   featuredType,
+			"limit":  strconv.Itoa(min(featuredPageSize, limit-len(albums))),
+			"offset": strconv.Itoa(len(albums)),
+		}
+		if genreID > 0 {
+			params["genre_id"] = strconv.Itoa(genreID)
+		}
+
+		var page FeaturedResponse
+		resp, err := c.metadataRequest().
+			SetQueryParams(params).
+			SetSuccessResult(&page).
+			Get("album/getFeatured")
+
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.IsErrorState() {
+			return nil, newAPIError(resp)
+		}
+
+		albums = append(albums, page.Albums.Items...)
+		if len(page.Albums.Items) == 0 || len(albums) >= page.Albums.Total {
+			break
+		}
+	}
+
+	return albums, nil
+}
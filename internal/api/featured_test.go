@@ -0,0 +1,113 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// featuredServer serves album/getFeatured pages from a list of total albums,
+// recording the query of every request.
+func featuredServer(t *testing.T, total int, queries *[]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		*queries = append(*queries, fmt.Sprintf("type=%s genre=%s offset=%s limit=%s", q.Get("type"), q.Get("genre_id"), q.Get("offset"), q.Get("limit")))
+		offset, _ := strconv.Atoi(q.Get("offset"))
+		limit, _ := strconv.Atoi(q.Get("limit"))
+
+		var page FeaturedResponse
+		page.Albums.Total, page.Albums.Offset, page.Albums.Limit = total, offset, limit
+		for i := offset; i < min(offset+limit, total); i++ {
+			page.Albums.Items = append(page.Albums.Items, AlbumMetadata{ID: strconv.Itoa(i)})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(page)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGetFeatured(t *testing.T) {
+	tests := []struct {
+		name        string
+		genreID     int
+		limit       int
+		total       int
+		wantAlbums  int
+		wantQueries []string
+	}{
+		{
+			name: "single page", limit: 30, total: 250, wantAlbums: 30,
+			wantQueries: []string{"type=new-releases genre= offset=0 limit=30"},
+		},
+		{
+			name: "paginated", limit: 150, total: 250, wantAlbums: 150,
+			wantQueries: []string{"type=new-releases genre= offset=0 limit=100", "type=new-releases genre= offset=100 limit=50"},
+		},
+		{
+			name: "list ends before limit", limit: 500, total: 120, wantAlbums: 120,
+			wantQueries: []string{"type=new-releases genre= offset=0 limit=100", "type=new-releases genre= offset=100 limit=100"},
+		},
+		{
+			name: "genre filter", genreID: 112, limit: 10, total: 250, wantAlbums: 10,
+			wantQueries: []string{"type=new-releases genre=112 offset=0 limit=10"},
+		},
+		{
+			name: "empty list", limit: 10, wantQueries: []string{"type=new-releases genre= offset=0 limit=10"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var queries []string
+			srv := featuredServer(t, tt.total, &queries)
+			c := NewClient("app", "secret")
+			c.HTTP.SetBaseURL(srv.URL)
+
+			albums, err := c.GetFeatured("new-releases", tt.genreID, tt.limit)
+			if err != nil {
+				t.Fatalf("GetFeatured: %v", err)
+			}
+			if len(albums) != tt.wantAlbums {
+				t.Errorf("got %d albums, want %d", len(albums), tt.wantAlbums)
+			}
+			for i, album := range albums {
+				if album.ID != strconv.Itoa(i) {
+					t.Fatalf("album %d has ID %q: pages out of order", i, album.ID)
+				}
+			}
+			if !slices.Equal(queries, tt.wantQueries) {
+				t.Errorf("requests = %q, want %q", queries, tt.wantQueries)
+			}
+		})
+	}
+}
+
+func TestGetFeaturedErrors(t *testing.T) {
+	var queries []string
+	srv := featuredServer(t, 10, &queries)
+	c := NewClient("app", "secret")
+	c.HTTP.SetBaseURL(srv.URL)
+	if _, err := c.GetFeatured("top-secret", 0, 10); err == nil {
+		t.Error("GetFeatured succeeded for an unknown type")
+	}
+	if len(queries) != 0 {
+		t.Errorf("unknown type sent %d requests", len(queries))
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"status":"error","code":400,"message":"Invalid argument: genre_id"}`))
+	}))
+	defer failing.Close()
+	c.HTTP.SetBaseURL(failing.URL)
+	_, err := c.GetFeatured("press-awards", 999, 10)
+	if apiErr, ok := AsAPIError(err); !ok || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("GetFeatured error = %v, want the API error", err)
+	}
+}
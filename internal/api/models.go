@@ -1,5 +1,7 @@
 package api
 
+import "strings"
+
 // LoginResponse represents the response from the user/login endpoint.
 type LoginResponse struct {
 	UserAuthToken string `json:"user_auth_token"`
@@ -9,6 +11,64 @@ type LoginResponse struct {
 	} `json:"user"`
 }
 
+// UserInfo contains the account details returned by user/get.
+type UserInfo struct {
+	ID         int    `json:"id"`
+	Email      string `json:"email"`
+	Credential struct {
+		Label       string `json:"label"`
+		Description string `json:"description"`
+		Parameters  *struct {
+			ShortLabel        string `json:"short_label"`
+			LossyStreaming    bool   `json:"lossy_streaming"`
+			LosslessStreaming bool   `json:"lossless_streaming"`
+			HiresStreaming    bool   `json:"hires_streaming"`
+		} `json:"parameters"`
+	} `json:"credential"`
+	Subscription *Subscription `json:"subscription"` // nil for accounts that never subscribed
+}
+
+// Subscription describes the account's current or last subscription.
+type Subscription struct {
+	Offer       string `json:"offer"`       // Plan name, e.g. "studio" or "trial"
+	Periodicity string `json:"periodicity"` // "monthly", "annual", ...
+	StartDate   string `json:"start_date"`  // YYYY-MM-DD
+	EndDate     string `json:"end_date"`    // YYYY-MM-DD
+	IsCanceled  bool   `json:"is_canceled"`
+}
+
+// PreviewOnly reports whether the account has no streaming rights, as with free
+// accounts or lapsed subscriptions: Qobuz then only serves 30-second previews.
+func (u *UserInfo) PreviewOnly() bool {
+	return u.MaxFormatID() == 0
+}
+
+// IsTrial reports whether the account is on a trial subscription.
+func (u *UserInfo) IsTrial() bool {
+	if u.Subscription != nil && strings.Contains(strings.ToLower(u.Subscription.Offer), "trial") {
+		return true
+	}
+	return strings.Contains(strings.ToLower(u.Credential.Label), "trial")
+}
+
+// MaxFormatID returns the highest quality ID the subscription can stream.
+// Returns 0 when the account has no streaming rights (e.g. free accounts).
+func (u *UserInfo) MaxFormatID() int {
+	p := u.Credential.Parameters
+	switch {
+	case p == nil:
+		return 0
+	case p.HiresStreaming:
+		return 27
+	case p.LosslessStreaming:
+		return 6
+	case p.LossyStreaming:
+		return 5
+	default:
+		return 0
+	}
+}
+
 // TrackURLResponse contains the download URL and format information for a track.
 type TrackURLResponse struct {
 	URL          string  `json:"url"`
@@ -16,22 +76,39 @@ type TrackURLResponse struct {
 	SamplingRate float64 `json:"sampling_rate"`
 	BitDepth     int     `json:"bit_depth"`
 	Duration     int     `json:"duration"`
+	Sample       bool    `json:"sample"` // The URL serves a 30-second preview, not the full track
+
+	Restrictions []struct {
+		Code string `json:"code"`
+	} `json:"restrictions"` // Why the requested file is limited or unavailable
 }
 
 // TrackMetadata contains all metadata for a single track.
 type TrackMetadata struct {
 	Title     string         `json:"title"`
 	Version   string         `json:"version"`
+	Work      string         `json:"work"` // Classical work the track belongs to, when Qobuz provides one
+	ISRC      string         `json:"isrc"`
 	Album     *AlbumMetadata `json:"album"`
 	Performer struct {
 		Name string `json:"name"`
 	} `json:"performer"`
-	MaximumSamplingRate float64 `json:"maximum_sampling_rate"`
-	ID                  int     `json:"id"`
-	Duration            int     `json:"duration"`
-	TrackNumber         int     `json:"track_number"`
-	MediaNumber         int     `json:"media_number"`
-	MaximumBitDepth     int     `json:"maximum_bit_depth"`
+	MaximumSamplingRate float64    `json:"maximum_sampling_rate"`
+	ID                  int        `json:"id"`
+	Duration            int        `json:"duration"`
+	TrackNumber         int        `json:"track_number"`
+	MediaNumber         int        `json:"media_number"`
+	MaximumBitDepth     int        `json:"maximum_bit_depth"`
+	AudioInfo           *AudioInfo `json:"audio_info"`
+}
+
+// AudioInfo carries precomputed loudness data (ReplayGain 2.0, -18 LUFS reference).
+// Fields are nil when Qobuz does not provide them.
+type AudioInfo struct {
+	ReplayGainTrackGain *float64 `json:"replaygain_track_gain"`
+	ReplayGainTrackPeak *float64 `json:"replaygain_track_peak"`
+	ReplayGainAlbumGain *float64 `json:"replaygain_album_gain"`
+	ReplayGainAlbumPeak *float64 `json:"replaygain_album_peak"`
 }
 
 // AlbumMetadata contains all metadata for an album.
@@ -41,17 +118,87 @@ type AlbumMetadata struct {
 	} `json:"genre"`
 	ID                string `json:"id"`
 	Title             string `json:"title"`
+	UPC               string `json:"upc"`
 	ReleaseDateOrg    string `json:"release_date_original"`
 	ReleaseDateStream string `json:"release_date_stream"`
 	Artist            struct {
 		Name string `json:"name"`
 	} `json:"artist"`
 	Tracks struct {
-		Items []TrackMetadata `json:"items"`
+		Items  []TrackMetadata `json:"items"`
+		Total  int             `json:"total"`
+		Offset int             `json:"offset"`
+		Limit  int             `json:"limit"`
 	} `json:"tracks"`
 	Image struct {
 		Small string `json:"small"`
 		Large string `json:"large"`
+		Back  string `json:"back"` // Back cover, when the label provided one
 	} `json:"image"`
-	Duration int `json:"duration"`
+	Goodies         []Goodie `json:"goodies"`
+	Duration        int      `json:"duration"`          // Total duration in seconds
+	MaximumBitDepth int      `json:"maximum_bit_depth"` // Highest bit depth of the tracks (0 = unknown)
+	TracksCount     int      `json:"tracks_count"`      // Number of tracks on all discs
+	MediaCount      int      `json:"media_count"`       // Number of discs
+	Media           []Medium `json:"media,omitempty"`   // Per-disc details, returned for some box sets
+}
+
+// Medium is one disc of a multi-disc album. Its image is empty unless the
+// label provided distinct disc artwork.
+type Medium struct {
+	Number int `json:"media_number"`
+	Image  struct {
+		Large string `json:"large"`
+	} `json:"image"`
+}
+
+// Goodie is an extra file attached to an album, such as a digital booklet.
+type Goodie struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	URL         string `json:"url"`
+	OriginalURL string `json:"original_url"`
+}
+
+// hasTaggingFields reports whether the album carries the fields the tagger cannot
+// do without: its title, artist and cover. Genre and dates are optional tags.
+func (a *AlbumMetadata) hasTaggingFields() bool {
+	return a.Title != "" &&
+		a.Artist.Name != "" &&
+		a.Image.Large != ""
+}
+
+// AlbumSearchResponse is the response of album/search.
+type AlbumSearchResponse struct {
+	Albums struct {
+		Items []AlbumMetadata `json:"items"`
+		Total int             `json:"total"`
+	} `json:"albums"`
+}
+
+// PlaylistMetadata contains a playlist and its tracks.
+// Unlike album tracks, each track carries its own album block.
+type PlaylistMetadata struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Owner struct {
+		Name string `json:"name"`
+	} `json:"owner"`
+	Tracks struct {
+		Items []TrackMetadata `json:"items"`
+		Total int             `json:"total"`
+	} `json:"tracks"`
+}
+
+// ArtistMetadata contains an artist and a page of their albums.
+type ArtistMetadata struct {
+	Name   string `json:"name"`
+	Albums struct {
+		Items  []AlbumMetadata `json:"items"`
+		Total  int             `json:"total"`
+		Offset int             `json:"offset"`
+		Limit  int             `json:"limit"`
+	} `json:"albums"`
+	ID int `json:"id"`
 }
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestUserInfoMaxFormatID(t *testing.T) {
+	tests := []struct {
+		name       string
+		credential string
+		want       int
+	}{
+		{name: "no parameters", credential: `{"label": "Free"}`, want: 0},
+		{name: "no streaming rights", credential: `{"parameters": {}}`, want: 0},
+		{name: "lossy", credential: `{"parameters": {"lossy_streaming": true}}`, want: 5},
+		{name: "lossless", credential: `{"parameters": {"lossy_streaming": true, "lossless_streaming": true}}`, want: 6},
+		{name: "hi-res", credential: `{"parameters": {"lossless_streaming": true, "hires_streaming": true}}`, want: 27},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var info UserInfo
+			if err := json.Unmarshal([]byte(`{"credential": `+tt.credential+`}`), &info); err != nil {
+				t.Fatal(err)
+			}
+			if got := info.MaxFormatID(); got != tt.want {
+				t.Errorf("MaxFormatID() = %d, want %d", got, tt.want)
+			}
+			if got := info.PreviewOnly(); got != (tt.want == 0) {
+				t.Errorf("PreviewOnly() = %v, want %v", got, tt.want == 0)
+			}
+		})
+	}
+}
+
+func TestUserInfoSubscription(t *testing.T) {
+	tests := []struct {
+		name        string
+		userInfo    string // user/get response
+		wantTrial   bool
+		wantPreview bool
+		wantEnd     string
+	}{
+		{
+			name:     "paid subscription",
+			userInfo: `{"credential": {"label": "Studio", "parameters": {"lossless_streaming": true}}, "subscription": {"offer": "studio", "periodicity": "monthly", "start_date": "2024-01-01", "end_date": "2026-12-01"}}`,
+			wantEnd:  "2026-12-01",
+		},
+		{
+			name:      "trial offer",
+			userInfo:  `{"credential": {"label": "Studio", "parameters": {"hires_streaming": true}}, "subscription": {"offer": "Trial", "end_date": "2026-11-01"}}`,
+			wantTrial: true,
+			wantEnd:   "2026-11-01",
+		},
+		{
+			name:      "trial credential without subscription",
+			userInfo:  `{"credential": {"label": "Sublime Trial", "parameters": {"lossless_streaming": true}}}`,
+			wantTrial: true,
+		},
+		{
+			name:        "never subscribed",
+			userInfo:    `{"credential": {"label": "Free"}}`,
+			wantPreview: true,
+		},
+		{
+			name:        "cancelled and lapsed",
+			userInfo:    `{"credential": {"label": "Studio", "parameters": {}}, "subscription": {"offer": "studio", "is_canceled": true, "end_date": "2024-01-01"}}`,
+			wantPreview: true,
+			wantEnd:     "2024-01-01",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var info UserInfo
+			if err := json.Unmarshal([]byte(tt.userInfo), &info); err != nil {
+				t.Fatal(err)
+			}
+			if got := info.IsTrial(); got != tt.wantTrial {
+				t.Errorf("IsTrial() = %v, want %v", got, tt.wantTrial)
+			}
+			if got := info.PreviewOnly(); got != tt.wantPreview {
+				t.Errorf("PreviewOnly() = %v, want %v", got, tt.wantPreview)
+			}
+			end := ""
+			if info.Subscription != nil {
+				end = info.Subscription.EndDate
+			}
+			if end != tt.wantEnd {
+				t.Errorf("subscription end date = %q, want %q", end, tt.wantEnd)
+			}
+		})
+	}
+}
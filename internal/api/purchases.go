@@ -0,0 +1,64 @@
+package api
+
+import "strconv"
+
+// purchasesPageSize is the number of albums and tracks requested per
+// purchase/getUserPurchases page.
+const purchasesPageSize = 100
+
+// Purchases lists the albums and individual tracks bought by the user.
+type Purchases struct {
+	Albums []AlbumMetadata
+	Tracks []TrackMetadata
+}
+
+// purchasesResponse is a page of purchase/getUserPurchases. Both lists are
+// paged together with the same limit and offset.
+type purchasesResponse struct {
+	Albums struct {
+		Items []AlbumMetadata `json:"items"`
+		Total int             `json:"total"`
+	} `json:"albums"`
+	Tracks struct {
+		Items []TrackMetadata `json:"items"`
+		Total int             `json:"total"`
+	} `json:"tracks"`
+}
+
+// GetUserPurchases retrieves every album and track purchased by the logged-in user.
+// Pages are fetched until both lists reach their reported totals. A user without
+// purchases gets empty lists and no error.
+func (c *Client) GetUserPurchases() (*Purchases, error) {
+	purchases := &Purchases{}
+	offset := 0
+
+	for {
+		var page purchasesResponse
+		resp, err := c.metadataRequest().
+			SetQueryParams(map[string]string{
+				"limit":  strconv.Itoa(purchasesPageSize),
+				"offset": strconv.Itoa(offset),
+			}).
+			SetSuccessResult(&page).
+			Get("purchase/getUserPurchases")
+
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.IsErrorState() {
+			return nil, newAPIError(resp)
+		}
+
+		purchases.Albums = append(purchases.Albums, page.Albums.Items...)
+		purchases.Tracks = append(purchases.Tracks, page.Tracks.Items...)
+
+		offset += purchasesPageSize
+		received := len(page.Albums.Items) + len(page.Tracks.Items)
+		if received == 0 || (offset >= page.Albums.Total && offset >= page.Tracks.Total) {
+			break
+		}
+	}
+
+	return purchases, nil
+}
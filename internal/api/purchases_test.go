@@ -0,0 +1,172 @@
+package api
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// purchasesServer serves purchase/getUserPurchases pages of albums and tracks,
+// recording the query of every request. The reported totals may exceed the
+// items actually served.
+func purchasesServer(t *testing.T, albums, tracks, reported int, queries *[]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		*queries = append(*queries, fmt.Sprintf("offset=%s limit=%s", q.Get("offset"), q.Get("limit")))
+		offset, _ := strconv.Atoi(q.Get("offset"))
+		limit, _ := strconv.Atoi(q.Get("limit"))
+
+		var page purchasesResponse
+		page.Albums.Total, page.Tracks.Total = max(albums, reported), max(tracks, reported)
+		for i := offset; i < min(offset+limit, albums); i++ {
+			page.Albums.Items = append(page.Albums.Items, AlbumMetadata{ID: "alb" + strconv.Itoa(i)})
+		}
+		for i := offset; i < min(offset+limit, tracks); i++ {
+			page.Tracks.Items = append(page.Tracks.Items, TrackMetadata{ID: i})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(page)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGetUserPurchases(t *testing.T) {
+	tests := []struct {
+		name        string
+		albums      int
+		tracks      int
+		reported    int // Total reported for both lists, if larger than the items
+		wantQueries []string
+	}{
+		{
+			name:        "no purchases",
+			wantQueries: []string{"offset=0 limit=100"},
+		},
+		{
+			name: "single page", albums: 12, tracks: 3,
+			wantQueries: []string{"offset=0 limit=100"},
+		},
+		{
+			name: "exactly one page", albums: 100,
+			wantQueries: []string{"offset=0 limit=100"},
+		},
+		{
+			name: "albums span pages", albums: 150, tracks: 30,
+			wantQueries: []string{"offset=0 limit=100", "offset=100 limit=100"},
+		},
+		{
+			name: "tracks outlast albums", albums: 5, tracks: 230,
+			wantQueries: []string{"offset=0 limit=100", "offset=100 limit=100", "offset=200 limit=100"},
+		},
+		{
+			name: "totals overstated", albums: 3, reported: 1000,
+			wantQueries: []string{"offset=0 limit=100", "offset=100 limit=100"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var queries []string
+			srv := purchasesServer(t, tt.albums, tt.tracks, tt.reported, &queries)
+			c := NewClient("app", "secret")
+			c.HTTP.SetBaseURL(srv.URL)
+
+			purchases, err := c.GetUserPurchases()
+			if err != nil {
+				t.Fatalf("GetUserPurchases: %v", err)
+			}
+			if len(purchases.Albums) != tt.albums || len(purchases.Tracks) != tt.tracks {
+				t.Errorf("got %d albums and %d tracks, want %d and %d", len(purchases.Albums), len(purchases.Tracks), tt.albums, tt.tracks)
+			}
+			for i, album := range purchases.Albums {
+				if album.ID != "alb"+strconv.Itoa(i) {
+					t.Fatalf("album %d has ID %q: pages out of order", i, album.ID)
+				}
+			}
+			for i, track := range purchases.Tracks {
+				if track.ID != i {
+					t.Fatalf("track %d has ID %d: pages out of order", i, track.ID)
+				}
+			}
+			if !slices.Equal(queries, tt.wantQueries) {
+				t.Errorf("requests = %q, want %q", queries, tt.wantQueries)
+			}
+		})
+	}
+}
+
+func TestGetUserPurchasesError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"status":"error","code":401,"message":"User authentication is required."}`))
+	}))
+	defer srv.Close()
+	c := NewClient("app", "secret")
+	c.HTTP.SetBaseURL(srv.URL)
+
+	purchases, err := c.GetUserPurchases()
+	if apiErr, ok := AsAPIError(err); !ok || apiErr.StatusCode != http.StatusUnauthorized || purchases != nil {
+		t.Errorf("GetUserPurchases() = %v, %v; want the API error", purchases, err)
+	}
+}
+
+func TestSetDownloadIntent(t *testing.T) {
+	tests := []struct {
+		name    string
+		intents []bool // Successive SetDownloadIntent calls
+		preview bool   // Request the preview URL instead of the full track
+		want    string
+	}{
+		{name: "stream by default", want: IntentStream},
+		{name: "download", intents: []bool{true}, want: IntentDownload},
+		{name: "restored", intents: []bool{true, false}, want: IntentStream},
+		{name: "previews always stream", intents: []bool{true}, preview: true, want: IntentStream},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var intent string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				q := r.URL.Query()
+				intent = q.Get("intent")
+				raw := fmt.Sprintf("trackgetFileUrlformat_id%sintent%strack_id%s%ssecret",
+					q.Get("format_id"), intent, q.Get("track_id"), q.Get("request_ts"))
+				sum := md5.Sum([]byte(raw))
+
+				w.Header().Set("Content-Type", "application/json")
+				if q.Get("request_sig") != hex.EncodeToString(sum[:]) {
+					w.WriteHeader(http.StatusBadRequest)
+					w.Write([]byte(`{"status":"error","code":400,"message":"Invalid Request Signature parameter (request_sig)"}`))
+					return
+				}
+				w.Write([]byte(`{"url":"https://example.com/track.flac","format_id":6}`))
+			}))
+			defer srv.Close()
+			c := NewClient("app", "secret")
+			c.HTTP.SetBaseURL(srv.URL)
+			for _, enabled := range tt.intents {
+				c.SetDownloadIntent(enabled)
+			}
+
+			var err error
+			if tt.preview {
+				_, err = c.GetTrackPreviewURL("1")
+			} else {
+				_, err = c.GetTrackURL("1", 6)
+			}
+			if err != nil {
+				t.Fatalf("signed request rejected: %v", err)
+			}
+			if intent != tt.want {
+				t.Errorf("intent = %q, want %q", intent, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// SearchResponse is the response of catalog/search.
+type SearchResponse struct {
+	Albums struct {
+		Items []AlbumMetadata `json:"items"`
+		Total int             `json:"total"`
+	} `json:"albums"`
+	Tracks struct {
+		Items []TrackMetadata `json:"items"`
+		Total int             `json:"total"`
+	} `json:"tracks"`
+}
+
+// SearchResult is a single album or track returned by Search.
+type SearchResult struct {
+	Type   ResourceType `json:"type"`
+	ID     string       `json:"id"`
+	Title  string       `json:"title"`
+	Artist string       `json:"artist"`
+	Score  float64      `json:"score"` // Relevance to the query, higher is better
+}
+
+// artistMatchBoost is added to the score when the result's artist appears in the query.
+const artistMatchBoost = 0.25
+
+// Search queries the catalog for albums and tracks matching query and returns
+// them ranked by relevance instead of API order. limit applies per type.
+func (c *Client) Search(query string, limit int) ([]SearchResult, error) {
+	var result SearchResponse
+	resp, err := c.metadataRequest().
+		SetQueryParams(map[string]string{
+			"query": query,
+			"limit": strconv.Itoa(limit),
+		}).
+		SetSuccessResult(&result).
+		Get("catalog/search")
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.IsErrorState() {
+		return nil, newAPIError(resp)
+	}
+
+	var results []SearchResult
+	for _, album := range result.Albums.Items {
+		results = append(results, SearchResult{
+			Type:   TypeAlbum,
+			ID:     album.ID,
+			Title:  album.Title,
+			Artist: album.Artist.Name,
+		})
+	}
+	for _, track := range result.Tracks.Items {
+		results = append(results, SearchResult{
+			Type:   TypeTrack,
+			ID:     strconv.Itoa(track.ID),
+			Title:  track.Title,
+			Artist: track.Performer.Name,
+		})
+	}
+
+	return rankResults(query, results), nil
+}
+
+// rankResults scores each result against query and sorts them best first.
+// The score is the title similarity (normalized edit distance), taking the
+// better of the bare title and "artist title" so queries naming the artist
+// still match, plus a boost when the artist appears in the query. Ties keep
+// API order.
+func rankResults(query string, results []SearchResult) []SearchResult {
+	q := normalizeSearchText(query)
+	for i := range results {
+		title := normalizeSearchText(results[i].Title)
+		artist := normalizeSearchText(results[i].Artist)
+
+		score := max(similarity(q, title), similarity(q, strings.TrimSpace(artist+" "+title)))
+		if artist != "" && containsWords(q, artist) {
+			score += artistMatchBoost
+		}
+		results[i].Score = score
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	return results
+}
+
+// normalizeSearchText lowercases s, drops punctuation and collapses whitespace.
+func normalizeSearchText(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			return unicode.ToLower(r)
+		case unicode.IsSpace(r):
+			return ' '
+		}
+		return -1
+	}, s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// containsWords reports whether the words of needle appear consecutively in haystack.
+func containsWords(haystack, needle string) bool {
+	return strings.Contains(" "+haystack+" ", " "+needle+" ")
+}
+
+// similarity returns 1 minus the edit distance between a and b divided by the
+// longer length, so identical strings score 1 and unrelated ones approach 0.
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+// levenshtein computes the edit distance between a and b.
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
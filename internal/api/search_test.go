@@ -0,0 +1,109 @@
+package api
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRankResults(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		results []SearchResult // In API order; IDs name the expected ranking
+		want    []string
+	}{
+		{
+			name:  "artist in query wins a common title",
+			query: "hello adele",
+			results: []SearchResult{
+				{ID: "richie", Title: "Hello", Artist: "Lionel Richie"},
+				{ID: "adele", Title: "Hello", Artist: "Adele"},
+			},
+			want: []string{"adele", "richie"},
+		},
+		{
+			name:  "artist before title",
+			query: "Adele - Hello",
+			results: []SearchResult{
+				{ID: "richie", Title: "Hello", Artist: "Lionel Richie"},
+				{ID: "adele", Title: "Hello", Artist: "Adele"},
+			},
+			want: []string{"adele", "richie"},
+		},
+		{
+			name:  "exact title before versions",
+			query: "bohemian rhapsody",
+			results: []SearchResult{
+				{ID: "gershwin", Title: "Rhapsody in Blue", Artist: "George Gershwin"},
+				{ID: "live", Title: "Bohemian Rhapsody (Live Aid)", Artist: "Queen"},
+				{ID: "studio", Title: "Bohemian Rhapsody", Artist: "Queen"},
+			},
+			want: []string{"studio", "live", "gershwin"},
+		},
+		{
+			name:  "punctuation and case ignored",
+			query: "dont stop me now",
+			results: []SearchResult{
+				{ID: "other", Title: "Don't Stop", Artist: "Fleetwood Mac"},
+				{ID: "queen", Title: "Don't Stop Me Now", Artist: "Queen"},
+			},
+			want: []string{"queen", "other"},
+		},
+		{
+			name:  "ties keep API order",
+			query: "intro",
+			results: []SearchResult{
+				{ID: "first", Title: "Intro", Artist: "The xx"},
+				{ID: "second", Title: "Intro", Artist: "M83"},
+			},
+			want: []string{"first", "second"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ranked := rankResults(tt.query, tt.results)
+			var got []string
+			for i, r := range ranked {
+				got = append(got, r.ID)
+				if i > 0 && r.Score > ranked[i-1].Score {
+					t.Errorf("result %d scores %v, above the previous %v", i, r.Score, ranked[i-1].Score)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ranking = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{a: "", b: "", want: 1},
+		{a: "hello", b: "hello", want: 1},
+		{a: "hello", b: "", want: 0},
+		{a: "kitten", b: "sitting", want: 1 - 3.0/7},
+		{a: "café", b: "cafe", want: 0.75}, // Runes, not bytes
+	}
+	for _, tt := range tests {
+		if got := similarity(tt.a, tt.b); got != tt.want {
+			t.Errorf("similarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeSearchText(t *testing.T) {
+	tests := map[string]string{
+		"Don't Stop Me Now":        "dont stop me now",
+		"  AC/DC -  Back in Black": "acdc back in black",
+		"Beyoncé":                  "beyoncé",
+		"":                         "",
+	}
+	for in, want := range tests {
+		if got := normalizeSearchText(in); got != want {
+			t.Errorf("normalizeSearchText(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
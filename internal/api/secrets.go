@@ -1,92 +1,340 @@
 package api
 
 import (
+	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/imroc/req/v3"
 )
 
+// Qobuz web player hosts used to scrape the App ID and secrets.
+const (
+	PlayURLDirect = "https://play.qobuz.com"       // Direct Qobuz web player
+	PlayURLProxy  = "https://play-qobuz.wenqi.icu" // Cloudflare Workers proxy
+)
+
+// Defaults for retrying transient failures while scraping the web player.
+const (
+	DefaultSecretsRetries = 2                // Extra attempts per request
+	DefaultSecretsBackoff = time.Second      // Delay before the first retry, doubled after each
+	DefaultSecretsTimeout = 30 * time.Second // Per-request timeout
+)
+
+// Default size limits for the scraped pages. The bundle is a few MB; anything far
+// larger means the host is misbehaving and is not read into memory.
+const (
+	DefaultSecretsMaxPageBytes   = 1 << 20  // Login page
+	DefaultSecretsMaxBundleBytes = 32 << 20 // bundle.js
+)
+
+// ResponseTooLargeError is returned when a scraped page exceeds its size limit.
+type ResponseTooLargeError struct {
+	URL   string
+	Limit int64 // Maximum size in bytes
+}
+
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("response from %s exceeds the %d byte limit", e.URL, e.Limit)
+}
+
 // Regular expressions for extracting secrets from Qobuz web player bundle.
+// Each field has several candidate patterns, tried in order, so that small
+// changes to the minified bundle don't break extraction outright.
 var (
-	// bundleURLRegex finds the bundle.js URL in the login page.
-	bundleURLRegex = regexp.MustCompile(`(?i)<script[^>]+src=['"]([^'"]*bundle[^'"]*\.js)['"]`)
-	// appIDRegex extracts the app ID from the bundle.
-	appIDRegex = regexp.MustCompile(`production:{api:{appId:"(?P<app_id>\d{9})",appSecret:"\w{32}"`)
-	// seedTimezoneRegex finds seed values paired with timezone names.
-	seedTimezoneRegex = regexp.MustCompile(`[a-z]\.initialSeed\("(?P<seed>[\w=]+)",window\.utimezone\.(?P<timezone>[a-z]+)\)`)
-	// infoExtrasRegex finds additional info and extras for secret construction.
-	infoExtrasRegex = regexp.MustCompile(`name:"\w+/(?P<timezone>[a-zA-Z]+)",info:"(?P<info>[\w=]+)",extras:"(?P<extras>[\w=]+)"`)
+	// bundleURLRegexes find the bundle.js URL in the login page.
+	bundleURLRegexes = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)<script[^>]+src=['"]([^'"]*bundle[^'"]*\.js)['"]`),
+		regexp.MustCompile(`(?i)<script[^>]+src=['"]([^'"]*/js/main[^'"]*\.js)['"]`),
+	}
+	// appIDRegexes extract the app ID from the bundle.
+	appIDRegexes = []*regexp.Regexp{
+		regexp.MustCompile(`production:{api:{appId:"(?P<app_id>\d{9})",appSecret:"\w{32}"`),
+		regexp.MustCompile(`production:\s*{\s*api:\s*{\s*appId:\s*"(?P<app_id>\d{9})"`),
+		regexp.MustCompile(`"?appId"?\s*:\s*"(?P<app_id>\d{9})"`),
+	}
+	// seedTimezoneRegexes find seed values paired with timezone names.
+	seedTimezoneRegexes = []*regexp.Regexp{
+		regexp.MustCompile(`[a-z]\.initialSeed\("(?P<seed>[\w=]+)",window\.utimezone\.(?P<timezone>[a-z]+)\)`),
+		regexp.MustCompile(`\w+\.initialSeed\(\s*["'](?P<seed>[\w=]+)["']\s*,\s*window\.utimezone\.(?P<timezone>[a-z]+)\s*\)`),
+	}
+	// infoExtrasRegexes find additional info and extras for secret construction.
+	infoExtrasRegexes = []*regexp.Regexp{
+		regexp.MustCompile(`name:"\w+/(?P<timezone>[a-zA-Z]+)",info:"(?P<info>[\w=]+)",extras:"(?P<extras>[\w=]+)"`),
+		regexp.MustCompile(`"?name"?\s*:\s*"\w+/(?P<timezone>[a-zA-Z]+)"\s*,\s*"?info"?\s*:\s*"(?P<info>[\w=]+)"\s*,\s*"?extras"?\s*:\s*"(?P<extras>[\w=]+)"`),
+	}
 )
 
-// FetchSecrets scrapes the App ID and potential secrets from the Qobuz web player.
-// It fetches the login page, locates the bundle.js, and extracts credentials.
-// Returns the App ID, a list of potential secrets, and any error encountered.
+// Secret extraction stages, reported in SecretsDiagnostic.Stage when one fails.
+const (
+	StageLoginPage  = "login page"
+	StageBundleURL  = "bundle url"
+	StageBundle     = "bundle download"
+	StageAppID      = "app id"
+	StageSeeds      = "seeds"
+	StageInfoExtras = "info/extras"
+	StageDecode     = "decode"
+)
+
+// SecretsDiagnostic describes how secret extraction went, so breakage caused by
+// web player changes can be reported precisely.
+type SecretsDiagnostic struct {
+	Host         string   // Web player host scraped
+	Stage        string   // Stage that failed, empty on success
+	BundleURL    string   // Resolved bundle URL
+	AppIDPattern int      // Index of the app ID pattern that matched, -1 if none
+	SeedMatches  int      // Seed/timezone pairs found
+	InfoMatches  int      // Info/extras entries found
+	Secrets      int      // Secrets successfully decoded
+	Warnings     []string // Non-fatal problems, such as undecodable secrets
+}
+
+// String summarizes the diagnostic on one line.
+func (d *SecretsDiagnostic) String() string {
+	s := fmt.Sprintf("host=%s app_id_pattern=%d seeds=%d info_extras=%d secrets=%d",
+		d.Host, d.AppIDPattern, d.SeedMatches, d.InfoMatches, d.Secrets)
+	if len(d.Warnings) > 0 {
+		s += fmt.Sprintf(" warnings=[%s]", strings.Join(d.Warnings, "; "))
+	}
+	return s
+}
+
+// SecretsError is returned when secret extraction fails, carrying the diagnostic.
+type SecretsError struct {
+	Diagnostic *SecretsDiagnostic
+	Err        error
+}
+
+func (e *SecretsError) Error() string {
+	return fmt.Sprintf("%s stage failed: %v (%s)", e.Diagnostic.Stage, e.Err, e.Diagnostic)
+}
+
+func (e *SecretsError) Unwrap() error { return e.Err }
+
+// SecretsFetcher scrapes the App ID and secrets from a list of web player hosts.
+// Client and Hosts can be replaced, e.g. to point at a local server serving a
+// canned login page and bundle.
+type SecretsFetcher struct {
+	Client  *req.Client   // HTTP client used for the login page and bundle
+	Hosts   []string      // Web player base URLs, tried in order
+	Retries int           // Extra attempts for transient failures (network errors, 5xx)
+	Backoff time.Duration // Delay before the first retry, doubled after each
+	Timeout time.Duration // Per-request timeout (0 = none)
+
+	MaxPageBytes   int64 // Size limit of the login page (0 = none)
+	MaxBundleBytes int64 // Size limit of bundle.js (0 = none)
+}
+
+// NewSecretsFetcher creates a fetcher for the real Qobuz web player.
 // proxyURL is optional; pass empty string to use direct connection.
 // useProxySite controls whether to try the CDN proxy first.
-func FetchSecrets(proxyURL string, useProxySite bool) (string, []string, error) {
+func NewSecretsFetcher(proxyURL string, useProxySite bool) *SecretsFetcher {
 	client := req.NewClient()
 	if proxyURL != "" {
 		client.SetProxyURL(proxyURL)
 	}
 
-	// Determine base URL
-	playBaseURL := "https://play.qobuz.com"
-	playBaseURLProxy := "https://play-qobuz.wenqi.icu"
-
-	// Try proxy first if enabled
+	hosts := []string{PlayURLDirect}
 	if useProxySite {
-		appID, secrets, err := fetchSecretsFromHost(client, playBaseURLProxy)
+		hosts = []string{PlayURLProxy, PlayURLDirect}
+	}
+	return &SecretsFetcher{
+		Client:  client,
+		Hosts:   hosts,
+		Retries: DefaultSecretsRetries,
+		Backoff: DefaultSecretsBackoff,
+		Timeout: DefaultSecretsTimeout,
+
+		MaxPageBytes:   DefaultSecretsMaxPageBytes,
+		MaxBundleBytes: DefaultSecretsMaxBundleBytes,
+	}
+}
+
+// Fetch tries each host in order and returns the App ID and secrets from the first
+// that succeeds, or the error of the last host.
+func (f *SecretsFetcher) Fetch() (string, []string, error) {
+	if len(f.Hosts) == 0 {
+		return "", nil, fmt.Errorf("no web player hosts configured")
+	}
+
+	var (
+		appID   string
+		secrets []string
+		err     error
+	)
+	for i, host := range f.Hosts {
+		appID, secrets, err = f.fetchFromHost(host)
 		if err == nil {
 			return appID, secrets, nil
 		}
-		fmt.Println("CDN proxy failed for secrets, falling back to direct...")
+		if i < len(f.Hosts)-1 {
+			fmt.Printf("Fetching secrets from %s failed, falling back to %s...\n", host, f.Hosts[i+1])
+		}
 	}
+	return appID, nil, err
+}
 
-	// Direct connection
-	return fetchSecretsFromHost(client, playBaseURL)
+// FetchSecrets scrapes the App ID and potential secrets from the Qobuz web player.
+// It fetches the login page, locates the bundle.js, and extracts credentials.
+// Returns the App ID, a list of potential secrets, and any error encountered.
+// Extraction failures are returned as *SecretsError.
+// proxyURL is optional; pass empty string to use direct connection.
+// useProxySite controls whether to try the CDN proxy first.
+func FetchSecrets(proxyURL string, useProxySite bool) (string, []string, error) {
+	return NewSecretsFetcher(proxyURL, useProxySite).Fetch()
 }
 
-// fetchSecretsFromHost fetches secrets from a specific host.
-func fetchSecretsFromHost(client *req.Client, baseURL string) (string, []string, error) {
+// fetchFromHost fetches secrets from a specific host.
+func (f *SecretsFetcher) fetchFromHost(baseURL string) (string, []string, error) {
+	diag := &SecretsDiagnostic{Host: baseURL, AppIDPattern: -1}
+	fail := func(stage string, err error) (string, []string, error) {
+		diag.Stage = stage
+		return "", nil, &SecretsError{Diagnostic: diag, Err: err}
+	}
+
 	// 1. Get Login Page to find bundle URL
-	resp, err := client.R().Get(baseURL + "/login")
+	page, status, err := f.get(baseURL+"/login", f.MaxPageBytes)
 	if err != nil {
-		return "", nil, err
+		return fail(StageLoginPage, err)
 	}
 
-	matches := bundleURLRegex.FindStringSubmatch(resp.String())
-	if len(matches) < 2 {
-		return "", nil, fmt.Errorf("bundle URL not found (status %d)", resp.StatusCode)
+	bundleURL := firstSubmatch(bundleURLRegexes, page)
+	if bundleURL == "" {
+		return fail(StageBundleURL, fmt.Errorf("bundle URL not found (status %d)", status))
 	}
-	bundleURL := matches[1]
 	if !strings.HasPrefix(bundleURL, "http://") && !strings.HasPrefix(bundleURL, "https://") {
 		if !strings.HasPrefix(bundleURL, "/") {
 			bundleURL = "/" + bundleURL
 		}
 		bundleURL = baseURL + bundleURL
 	}
+	diag.BundleURL = bundleURL
 
 	// 2. Get Bundle JS
-	resp, err = client.R().Get(bundleURL)
+	bundle, _, err := f.get(bundleURL, f.MaxBundleBytes)
 	if err != nil {
-		return "", nil, err
+		return fail(StageBundle, err)
+	}
+
+	appID, secrets, err := extractSecrets(bundle, diag)
+	if err != nil {
+		return appID, nil, &SecretsError{Diagnostic: diag, Err: err}
+	}
+	return appID, secrets, nil
+}
+
+// transientError marks a failed request worth retrying.
+type transientError struct{ err error }
+
+func (e *transientError) Error() string { return e.err.Error() }
+func (e *transientError) Unwrap() error { return e.err }
+
+// get fetches url and returns its body and status code, retrying transient
+// failures with exponential backoff. Network errors, timeouts, 429 and 5xx
+// responses are retried; any other response is returned as is for the caller
+// to parse. Bodies larger than limit bytes (if positive) fail with
+// *ResponseTooLargeError without being read in full.
+func (f *SecretsFetcher) get(url string, limit int64) (string, int, error) {
+	backoff := f.Backoff
+	for attempt := 0; ; attempt++ {
+		body, status, err := f.getOnce(url, limit)
+		var transient *transientError
+		if err == nil || !errors.As(err, &transient) || attempt >= f.Retries {
+			return body, status, err
+		}
+		fmt.Printf("Fetching %s failed (%v), retrying in %s...\n", url, err, backoff)
+		time.Sleep(backoff)
+		backoff *= 2
 	}
-	bundleContent := resp.String()
+}
 
+// getOnce performs a single request, classifying failures as transient or not.
+func (f *SecretsFetcher) getOnce(url string, limit int64) (string, int, error) {
+	ctx := context.Background()
+	if f.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.Timeout)
+		defer cancel()
+	}
+
+	resp, err := f.Client.R().SetContext(ctx).DisableAutoReadResponse().Get(url)
+	if err != nil {
+		return "", 0, &transientError{err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+		return "", 0, &transientError{fmt.Errorf("HTTP %d", resp.StatusCode)}
+	}
+	if limit > 0 && resp.ContentLength > limit {
+		return "", 0, &ResponseTooLargeError{URL: url, Limit: limit}
+	}
+
+	body := io.Reader(resp.Body)
+	if limit > 0 {
+		body = io.LimitReader(resp.Body, limit+1) // One byte over tells a too large body apart
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", 0, &transientError{err}
+	}
+	if limit > 0 && int64(len(data)) > limit {
+		return "", 0, &ResponseTooLargeError{URL: url, Limit: limit}
+	}
+	return string(data), resp.StatusCode, nil
+}
+
+// firstSubmatch returns the first capture group of the first pattern that matches s.
+func firstSubmatch(patterns []*regexp.Regexp, s string) string {
+	for _, re := range patterns {
+		if m := re.FindStringSubmatch(s); len(m) >= 2 {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// allSubmatches returns all matches of the first pattern that matches s at least once.
+func allSubmatches(patterns []*regexp.Regexp, s string) [][]string {
+	for _, re := range patterns {
+		if m := re.FindAllStringSubmatch(s, -1); len(m) > 0 {
+			return m
+		}
+	}
+	return nil
+}
+
+// extractSecrets extracts the App ID and secrets from the bundle contents,
+// recording progress in diag. On failure diag.Stage names the failing stage.
+func extractSecrets(bundleContent string, diag *SecretsDiagnostic) (string, []string, error) {
 	// 3. Extract App ID
-	appIDMatches := appIDRegex.FindStringSubmatch(bundleContent)
-	if len(appIDMatches) < 2 {
+	var appID string
+	for i, re := range appIDRegexes {
+		if m := re.FindStringSubmatch(bundleContent); len(m) >= 2 {
+			appID = m[1]
+			diag.AppIDPattern = i
+			break
+		}
+	}
+	if appID == "" {
+		diag.Stage = StageAppID
 		return "", nil, fmt.Errorf("app ID not found in bundle")
 	}
-	appID := appIDMatches[1]
 
 	// 4. Extract Secrets
 	// Logic ported from bundle.py
 	// a. Find seeds and timezones
-	seedMatches := seedTimezoneRegex.FindAllStringSubmatch(bundleContent, -1)
+	seedMatches := allSubmatches(seedTimezoneRegexes, bundleContent)
+	diag.SeedMatches = len(seedMatches)
+	if len(seedMatches) == 0 {
+		diag.Stage = StageSeeds
+		return appID, nil, fmt.Errorf("no seeds found in bundle")
+	}
 
 	secretsMap := make(map[string][]string) // timezone -> [seed]
 	var timezones []string
@@ -97,14 +345,21 @@ func fetchSecretsFromHost(client *req.Client, baseURL string) (string, []string,
 		}
 		seed := m[1]
 		timezone := m[2]
+		if _, ok := secretsMap[timezone]; !ok {
+			timezones = append(timezones, timezone)
+		}
 		secretsMap[timezone] = []string{seed}
-		timezones = append(timezones, timezone)
 	}
 
 	// b. Find info and extras
 	// bundle.py constructs a regex joining capitalized timezones.
 	// We just scan all and match against our map.
-	infoMatches := infoExtrasRegex.FindAllStringSubmatch(bundleContent, -1)
+	infoMatches := allSubmatches(infoExtrasRegexes, bundleContent)
+	diag.InfoMatches = len(infoMatches)
+	if len(infoMatches) == 0 {
+		diag.Stage = StageInfoExtras
+		return appID, nil, fmt.Errorf("no info/extras found in bundle")
+	}
 
 	for _, m := range infoMatches {
 		if len(m) < 4 {
@@ -122,15 +377,18 @@ func fetchSecretsFromHost(client *req.Client, baseURL string) (string, []string,
 	}
 
 	var validSecrets []string
-	for _, parts := range secretsMap {
+	for _, timezone := range timezones {
+		parts := secretsMap[timezone]
 		if len(parts) != 3 {
 			// Needs seed, info, extras
+			diag.Warnings = append(diag.Warnings, fmt.Sprintf("%s: no matching info/extras", timezone))
 			continue
 		}
 		seed, info, extras := parts[0], parts[1], parts[2]
 		combined := seed + info + extras
 
 		if len(combined) <= 44 {
+			diag.Warnings = append(diag.Warnings, fmt.Sprintf("%s: combined secret too short", timezone))
 			continue
 		}
 		// Python logic: base64.standard_b64decode("".join(secrets[secret_pair])[:-44])
@@ -139,15 +397,17 @@ func fetchSecretsFromHost(client *req.Client, baseURL string) (string, []string,
 
 		decodedBytes, err := base64.StdEncoding.DecodeString(toDecode)
 		if err != nil {
-			fmt.Printf("Base64 decode failed for %s: %v\n", parts[0], err)
+			diag.Warnings = append(diag.Warnings, fmt.Sprintf("%s: base64 decode failed: %v", timezone, err))
 			continue
 		}
 
 		secret := string(decodedBytes)
 		validSecrets = append(validSecrets, secret)
 	}
+	diag.Secrets = len(validSecrets)
 
 	if len(validSecrets) == 0 {
+		diag.Stage = StageDecode
 		return appID, nil, fmt.Errorf("no valid secrets extracted")
 	}
 
@@ -0,0 +1,460 @@
+package api
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/imroc/req/v3"
+)
+
+// secretParts encodes secret the way the web player bundle stores it: base64
+// followed by 44 filler characters, split into seed, info and extras.
+func secretParts(secret string) (seed, info, extras string) {
+	combined := base64.StdEncoding.EncodeToString([]byte(secret)) + strings.Repeat("A", 44)
+	third := len(combined) / 3
+	return combined[:third], combined[third : 2*third], combined[2*third:]
+}
+
+// minifiedBundle builds a bundle snippet in the compact style of older web
+// player releases, with one secret per timezone.
+func minifiedBundle(appID string, secrets map[string]string, timezones ...string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `var config={production:{api:{appId:"%s",appSecret:"%032d"}}};`, appID, 0)
+	for _, tz := range timezones {
+		seed, _, _ := secretParts(secrets[tz])
+		fmt.Fprintf(&b, `t.initialSeed("%s",window.utimezone.%s);`, seed, tz)
+	}
+	for _, tz := range timezones {
+		_, info, extras := secretParts(secrets[tz])
+		fmt.Fprintf(&b, `{offset:"GMT",name:"Europe/%s",info:"%s",extras:"%s"},`, strings.ToUpper(tz[:1])+tz[1:], info, extras)
+	}
+	return b.String()
+}
+
+// spacedBundle builds the same snippet in the formatted style of later releases,
+// with quoted keys and whitespace that the original patterns didn't allow.
+func spacedBundle(appID string, secrets map[string]string, timezones ...string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "production: {\n  api: {\n    appId: \"%s\",\n    appSecret: getSecret()\n  }\n}\n", appID)
+	for _, tz := range timezones {
+		seed, _, _ := secretParts(secrets[tz])
+		fmt.Fprintf(&b, "seeder.initialSeed( '%s', window.utimezone.%s );\n", seed, tz)
+	}
+	for _, tz := range timezones {
+		_, info, extras := secretParts(secrets[tz])
+		fmt.Fprintf(&b, "{ \"name\": \"Europe/%s\", \"info\": \"%s\", \"extras\": \"%s\" },\n", strings.ToUpper(tz[:1])+tz[1:], info, extras)
+	}
+	return b.String()
+}
+
+func TestExtractSecrets(t *testing.T) {
+	secrets := map[string]string{
+		"berlin": "0123456789abcdef0123456789abcdef",
+		"london": "fedcba9876543210fedcba9876543210",
+	}
+	seed, _, _ := secretParts(secrets["berlin"])
+
+	tests := []struct {
+		name         string
+		bundle       string
+		wantAppID    string
+		wantSecrets  []string
+		wantStage    string
+		wantPattern  int
+		wantWarnings int
+	}{
+		{
+			name:        "minified bundle",
+			bundle:      minifiedBundle("123456789", secrets, "berlin", "london"),
+			wantAppID:   "123456789",
+			wantSecrets: []string{secrets["berlin"], secrets["london"]},
+			wantPattern: 0,
+		},
+		{
+			name:        "formatted bundle",
+			bundle:      spacedBundle("987654321", secrets, "london"),
+			wantAppID:   "987654321",
+			wantSecrets: []string{secrets["london"]},
+			wantPattern: 1,
+		},
+		{
+			name:         "timezone without info/extras",
+			bundle:       minifiedBundle("123456789", secrets, "berlin") + `t.initialSeed("abc",window.utimezone.tokyo);`,
+			wantAppID:    "123456789",
+			wantSecrets:  []string{secrets["berlin"]},
+			wantWarnings: 1,
+		},
+		{
+			name:        "no app ID",
+			bundle:      strings.Replace(minifiedBundle("123456789", secrets, "berlin"), "appId", "id", 1),
+			wantStage:   StageAppID,
+			wantPattern: -1,
+		},
+		{
+			name:      "no seeds",
+			bundle:    `production:{api:{appId:"123456789",appSecret:"x"}}`,
+			wantAppID: "123456789",
+			wantStage: StageSeeds,
+		},
+		{
+			name:      "no info/extras",
+			bundle:    fmt.Sprintf(`production:{api:{appId:"123456789"}} t.initialSeed("%s",window.utimezone.berlin);`, seed),
+			wantAppID: "123456789",
+			wantStage: StageInfoExtras,
+		},
+		{
+			name: "undecodable secret",
+			bundle: `production:{api:{appId:"123456789"}} t.initialSeed("A",window.utimezone.berlin);` +
+				`name:"Europe/Berlin",info:"` + strings.Repeat("A", 30) + `",extras:"` + strings.Repeat("B", 30) + `"`,
+			wantAppID:    "123456789",
+			wantStage:    StageDecode,
+			wantWarnings: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diag := &SecretsDiagnostic{AppIDPattern: -1}
+			appID, got, err := extractSecrets(tt.bundle, diag)
+			if (err != nil) != (tt.wantStage != "") || diag.Stage != tt.wantStage {
+				t.Fatalf("extractSecrets() error = %v, stage %q; want stage %q", err, diag.Stage, tt.wantStage)
+			}
+			if appID != tt.wantAppID {
+				t.Errorf("app ID = %q, want %q", appID, tt.wantAppID)
+			}
+			if !slices.Equal(got, tt.wantSecrets) {
+				t.Errorf("secrets = %q, want %q", got, tt.wantSecrets)
+			}
+			if tt.wantStage == "" && diag.AppIDPattern != tt.wantPattern {
+				t.Errorf("app ID pattern = %d, want %d", diag.AppIDPattern, tt.wantPattern)
+			}
+			if diag.Secrets != len(tt.wantSecrets) || len(diag.Warnings) != tt.wantWarnings {
+				t.Errorf("diagnostic = %s, want %d secrets and %d warnings", diag, len(tt.wantSecrets), tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestSecretsErrorUnwrap(t *testing.T) {
+	cause := errors.New("app ID not found in bundle")
+	err := error(&SecretsError{Diagnostic: &SecretsDiagnostic{Host: "https://play.example", Stage: StageAppID}, Err: cause})
+	if !errors.Is(err, cause) {
+		t.Error("SecretsError does not unwrap to its cause")
+	}
+	if msg := err.Error(); !strings.Contains(msg, StageAppID) || !strings.Contains(msg, "https://play.example") {
+		t.Errorf("Error() = %q, want the stage and host", msg)
+	}
+}
+
+// webPlayer serves a canned login page linking to scriptSrc and the bundle at
+// bundlePath. The bundle path may be empty for a login page without bundle.
+type webPlayer struct {
+	scriptSrc  string
+	bundlePath string
+	bundle     string
+}
+
+func (p *webPlayer) start(t *testing.T) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			src := strings.ReplaceAll(p.scriptSrc, "{host}", srv.URL)
+			fmt.Fprintf(w, `<html><head><script src="%s"></script></head></html>`, src)
+		case p.bundlePath:
+			w.Write([]byte(p.bundle))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// testFetcher returns a fetcher for hosts without retry delays.
+func testFetcher(hosts ...string) *SecretsFetcher {
+	return &SecretsFetcher{Client: req.NewClient(), Hosts: hosts}
+}
+
+func TestSecretsFetcherFetch(t *testing.T) {
+	secrets := map[string]string{"berlin": "0123456789abcdef0123456789abcdef"}
+	bundle := minifiedBundle("123456789", secrets, "berlin")
+
+	tests := []struct {
+		name      string
+		players   []*webPlayer
+		wantStage string // Stage of the last host's failure; "" = success
+	}{
+		{
+			name:    "relative bundle URL",
+			players: []*webPlayer{{scriptSrc: "/resources/8.1.0/bundle.js", bundlePath: "/resources/8.1.0/bundle.js", bundle: bundle}},
+		},
+		{
+			name:    "bundle URL without leading slash",
+			players: []*webPlayer{{scriptSrc: "resources/bundle.js", bundlePath: "/resources/bundle.js", bundle: bundle}},
+		},
+		{
+			name:    "absolute bundle URL",
+			players: []*webPlayer{{scriptSrc: "{host}/static/bundle.js", bundlePath: "/static/bundle.js", bundle: bundle}},
+		},
+		{
+			name:    "main.js bundle name",
+			players: []*webPlayer{{scriptSrc: "/js/main.abc123.js", bundlePath: "/js/main.abc123.js", bundle: bundle}},
+		},
+		{
+			name: "falls back to the next host",
+			players: []*webPlayer{
+				{scriptSrc: "/app.js"},
+				{scriptSrc: "/bundle.js", bundlePath: "/bundle.js", bundle: bundle},
+			},
+		},
+		{
+			name:      "no bundle in login page",
+			players:   []*webPlayer{{scriptSrc: "/app.js"}},
+			wantStage: StageBundleURL,
+		},
+		{
+			name:      "missing bundle",
+			players:   []*webPlayer{{scriptSrc: "/bundle.js"}},
+			wantStage: StageAppID, // The 404 page has no app ID
+		},
+		{
+			name:      "changed bundle format",
+			players:   []*webPlayer{{scriptSrc: "/bundle.js", bundlePath: "/bundle.js", bundle: "console.log('new player')"}},
+			wantStage: StageAppID,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hosts []string
+			for _, p := range tt.players {
+				hosts = append(hosts, p.start(t).URL)
+			}
+
+			appID, got, err := testFetcher(hosts...).Fetch()
+			if tt.wantStage != "" {
+				var secretsErr *SecretsError
+				if !errors.As(err, &secretsErr) || secretsErr.Diagnostic.Stage != tt.wantStage {
+					t.Fatalf("Fetch() error = %v, want a %q stage failure", err, tt.wantStage)
+				}
+				if secretsErr.Diagnostic.Host != hosts[len(hosts)-1] {
+					t.Errorf("diagnostic host = %q, want %q", secretsErr.Diagnostic.Host, hosts[len(hosts)-1])
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Fetch: %v", err)
+			}
+			if appID != "123456789" || !slices.Equal(got, []string{secrets["berlin"]}) {
+				t.Errorf("Fetch() = %q, %q", appID, got)
+			}
+		})
+	}
+}
+
+func TestSecretsFetcherRetry(t *testing.T) {
+	secrets := map[string]string{"berlin": "0123456789abcdef0123456789abcdef"}
+	bundle := minifiedBundle("123456789", secrets, "berlin")
+
+	tests := []struct {
+		name         string
+		failures     map[string][]string // Failure modes served by path before succeeding
+		bundle       string
+		wantStage    string // Failure stage; "" = success
+		wantRequests map[string]int
+	}{
+		{
+			name:         "login page 503 once",
+			failures:     map[string][]string{"/login": {"503"}},
+			wantRequests: map[string]int{"/login": 2, "/bundle.js": 1},
+		},
+		{
+			name:         "bundle 500 twice",
+			failures:     map[string][]string{"/bundle.js": {"500", "500"}},
+			wantRequests: map[string]int{"/login": 1, "/bundle.js": 3},
+		},
+		{
+			name:         "rate limited",
+			failures:     map[string][]string{"/login": {"429"}},
+			wantRequests: map[string]int{"/login": 2, "/bundle.js": 1},
+		},
+		{
+			name:         "connection dropped",
+			failures:     map[string][]string{"/bundle.js": {"drop"}},
+			wantRequests: map[string]int{"/login": 1, "/bundle.js": 2},
+		},
+		{
+			name:         "timeout",
+			failures:     map[string][]string{"/login": {"slow"}},
+			wantRequests: map[string]int{"/login": 2, "/bundle.js": 1},
+		},
+		{
+			name:         "retries exhausted",
+			failures:     map[string][]string{"/login": {"503", "503", "503"}},
+			wantStage:    StageLoginPage,
+			wantRequests: map[string]int{"/login": 3},
+		},
+		{
+			name:         "bundle format changed is not retried",
+			bundle:       "console.log('new player')",
+			wantStage:    StageAppID,
+			wantRequests: map[string]int{"/login": 1, "/bundle.js": 1},
+		},
+		{
+			name:         "client error is not retried",
+			failures:     map[string][]string{"/login": {"404"}},
+			wantStage:    StageBundleURL,
+			wantRequests: map[string]int{"/login": 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			requests := make(map[string]int)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				n := requests[r.URL.Path]
+				requests[r.URL.Path]++
+				mu.Unlock()
+
+				if failures := tt.failures[r.URL.Path]; n < len(failures) {
+					switch mode := failures[n]; mode {
+					case "drop":
+						conn, _, _ := w.(http.Hijacker).Hijack()
+						conn.Close()
+						return
+					case "slow":
+						time.Sleep(200 * time.Millisecond)
+					default:
+						code, _ := strconv.Atoi(mode)
+						w.WriteHeader(code)
+						return
+					}
+				}
+				switch r.URL.Path {
+				case "/login":
+					w.Write([]byte(`<html><script src="/bundle.js"></script></html>`))
+				case "/bundle.js":
+					if tt.bundle != "" {
+						w.Write([]byte(tt.bundle))
+					} else {
+						w.Write([]byte(bundle))
+					}
+				}
+			}))
+			defer srv.Close()
+
+			f := testFetcher(srv.URL)
+			f.Retries = 2
+			f.Backoff = time.Millisecond
+			f.Timeout = 100 * time.Millisecond
+			appID, got, err := f.Fetch()
+
+			if tt.wantStage != "" {
+				var secretsErr *SecretsError
+				if !errors.As(err, &secretsErr) || secretsErr.Diagnostic.Stage != tt.wantStage {
+					t.Errorf("Fetch() error = %v, want a %q stage failure", err, tt.wantStage)
+				}
+			} else if err != nil || appID != "123456789" || !slices.Equal(got, []string{secrets["berlin"]}) {
+				t.Errorf("Fetch() = %q, %q, %v", appID, got, err)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if !maps.Equal(requests, tt.wantRequests) {
+				t.Errorf("requests = %v, want %v", requests, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestSecretsFetcherSizeLimits(t *testing.T) {
+	secrets := map[string]string{"berlin": "0123456789abcdef0123456789abcdef"}
+	bundle := minifiedBundle("123456789", secrets, "berlin")
+	login := `<html><script src="/bundle.js"></script></html>`
+	huge := bundle + strings.Repeat(" ", 1<<20)
+
+	tests := []struct {
+		name      string
+		login     string
+		bundle    string
+		chunked   bool  // Stream the bodies without Content-Length
+		maxPage   int64 // MaxPageBytes
+		maxBundle int64 // MaxBundleBytes
+		wantStage string
+	}{
+		{name: "within limits", login: login, bundle: bundle, maxPage: int64(len(login)), maxBundle: int64(len(bundle))},
+		{name: "no limits", login: login, bundle: huge},
+		{name: "oversized bundle", login: login, bundle: huge, maxPage: 1 << 10, maxBundle: 1 << 16, wantStage: StageBundle},
+		{name: "oversized streamed bundle", login: login, bundle: huge, chunked: true, maxPage: 1 << 10, maxBundle: 1 << 16, wantStage: StageBundle},
+		{name: "bundle one byte over", login: login, bundle: bundle, chunked: true, maxBundle: int64(len(bundle)) - 1, wantStage: StageBundle},
+		{name: "oversized login page", login: login + strings.Repeat(" ", 1<<20), bundle: bundle, maxPage: 1 << 10, wantStage: StageLoginPage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			requests := make(map[string]int)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				requests[r.URL.Path]++
+				mu.Unlock()
+				body := tt.login
+				if r.URL.Path == "/bundle.js" {
+					body = tt.bundle
+				}
+				if tt.chunked {
+					w.(http.Flusher).Flush()
+				} else {
+					w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+				}
+				w.Write([]byte(body))
+			}))
+			defer srv.Close()
+
+			f := testFetcher(srv.URL)
+			f.Retries = 2
+			f.Backoff = time.Millisecond
+			f.MaxPageBytes = tt.maxPage
+			f.MaxBundleBytes = tt.maxBundle
+			appID, got, err := f.Fetch()
+
+			if tt.wantStage == "" {
+				if err != nil || appID != "123456789" || !slices.Equal(got, []string{secrets["berlin"]}) {
+					t.Errorf("Fetch() = %q, %q, %v", appID, got, err)
+				}
+				return
+			}
+			var secretsErr *SecretsError
+			if !errors.As(err, &secretsErr) || secretsErr.Diagnostic.Stage != tt.wantStage {
+				t.Fatalf("Fetch() error = %v, want a %q stage failure", err, tt.wantStage)
+			}
+			var tooLarge *ResponseTooLargeError
+			if !errors.As(err, &tooLarge) {
+				t.Fatalf("Fetch() error = %v, want a *ResponseTooLargeError", err)
+			}
+			wantURL, wantLimit := srv.URL+"/bundle.js", tt.maxBundle
+			if tt.wantStage == StageLoginPage {
+				wantURL, wantLimit = srv.URL+"/login", tt.maxPage
+			}
+			if tooLarge.URL != wantURL || tooLarge.Limit != wantLimit {
+				t.Errorf("error = %+v, want URL %s and limit %d", tooLarge, wantURL, wantLimit)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for path, n := range requests {
+				if n != 1 {
+					t.Errorf("%s requested %d times, want an oversized response not to be retried", path, n)
+				}
+			}
+		})
+	}
+}
@@ -2,13 +2,15 @@ package api
 
 import (
 	"fmt"
+	"net/url"
 	"regexp"
 )
 
 // urlRegex matches various Qobuz URL formats and extracts resource type and ID.
-// Supports: www.qobuz.com, open.qobuz.com, play.qobuz.com with optional locale prefix.
+// Supports: www.qobuz.com, open.qobuz.com, play.qobuz.com with optional locale prefix
+// (e.g. fr-fr, en-us, zh-hans-cn) and slugs in any script.
 var urlRegex = regexp.MustCompile(`(?:https:\/\/(?:w{3}|open|play)\.qobuz\.com)?` +
-	`(?:\/[a-z]{2}-[a-z]{2})?\/(album|artist|track|playlist|label)(?:\/[-\w\d]+)?\/([\w\d]+)`)
+	`(?:\/(?i:[a-z]{2}(?:-[a-z0-9]+)*))?\/(album|artist|track|playlist|label)(?:\/[^\/?#]+)?\/([\w\d]+)`)
 
 // ResourceType represents the type of Qobuz resource (album, track, etc.).
 type ResourceType string
@@ -24,8 +26,12 @@ const (
 
 // ParseURL extracts the resource type and ID from a Qobuz URL.
 // Supports URLs from www.qobuz.com, open.qobuz.com, and play.qobuz.com.
+// Percent-encoded URLs are decoded before matching.
 // Returns an error if the URL format is not recognized.
 func ParseURL(input string) (ResourceType, string, error) {
+	if decoded, err := url.PathUnescape(input); err == nil {
+		input = decoded
+	}
 	matches := urlRegex.FindStringSubmatch(input)
 	if len(matches) == 3 {
 		return ResourceType(matches[1]), matches[2], nil
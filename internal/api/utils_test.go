@@ -0,0 +1,43 @@
+package api
+
+import "testing"
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       string
+		wantType ResourceType
+		wantID   string
+		wantErr  bool
+	}{
+		{name: "open album", in: "https://open.qobuz.com/album/0603497863212", wantType: TypeAlbum, wantID: "0603497863212"},
+		{name: "play track", in: "https://play.qobuz.com/track/19512574", wantType: TypeTrack, wantID: "19512574"},
+		{name: "store album with slug", in: "https://www.qobuz.com/fr-fr/album/rumours-fleetwood-mac/0603497863212", wantType: TypeAlbum, wantID: "0603497863212"},
+		{name: "play artist", in: "https://play.qobuz.com/artist/2059", wantType: TypeArtist, wantID: "2059"},
+		{name: "extended locale", in: "https://www.qobuz.com/zh-hans-cn/album/some-album/abc123def", wantType: TypeAlbum, wantID: "abc123def"},
+		{name: "upper-case locale", in: "https://www.qobuz.com/EN-US/album/x/abc123", wantType: TypeAlbum, wantID: "abc123"},
+		{name: "percent-encoded slug", in: "https://www.qobuz.com/jp-ja/album/%E3%81%82%E3%81%84%E3%81%86/p0d4nlxm3r7fa", wantType: TypeAlbum, wantID: "p0d4nlxm3r7fa"},
+		{name: "unicode slug", in: "https://www.qobuz.com/fr-fr/album/clair-de-lune-débussy/xyz789", wantType: TypeAlbum, wantID: "xyz789"},
+		{name: "encoded slashes", in: "https%3A%2F%2Fopen.qobuz.com%2Fplaylist%2F1234", wantType: TypePlaylist, wantID: "1234"},
+		{name: "label with query", in: "https://play.qobuz.com/label/1234?ref=share", wantType: TypeLabel, wantID: "1234"},
+		{name: "bare ID", in: "19512574", wantErr: true},
+		{name: "other site", in: "https://example.com/", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotType, gotID, err := ParseURL(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseURL(%q) = %q, %q; want error", tt.in, gotType, gotID)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseURL(%q) error = %v", tt.in, err)
+			}
+			if gotType != tt.wantType || gotID != tt.wantID {
+				t.Errorf("ParseURL(%q) = %q, %q; want %q, %q", tt.in, gotType, gotID, tt.wantType, tt.wantID)
+			}
+		})
+	}
+}
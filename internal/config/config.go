@@ -4,8 +4,10 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 // Config holds application-level settings.
@@ -15,6 +17,21 @@ type Config struct {
 	Quality int    `json:"quality"`  // Audio quality: 5=MP3, 6=FLAC 16bit, 7=FLAC 24bit, 27=Hi-Res
 	NoSave  bool   `json:"nosave"`   // If true, don't save credentials
 	OgCover bool   `json:"og_cover"` // If true, download original quality cover
+
+	MaxPathLength int  `json:"max_path_length"` // Maximum output path length (0 = platform default)
+	LongPaths     bool `json:"long_paths"`      // Use the Windows \\?\ long-path prefix
+
+	GroupByInitial bool `json:"group_by_initial"` // Group album folders under the album artist's initial (A/, B/, #/)
+
+	DisableSourceTags bool `json:"disable_source_tags"` // Don't write SOURCE/ENCODEDBY provenance tags
+	FlacPadding       int  `json:"flac_padding"`        // FLAC padding bytes for fast retags (0 = default 8192, negative = none)
+
+	CredentialStore string `json:"credential_store"` // "file" (default) or "keyring" for the OS keychain
+
+	SingleTrackTemplate string `json:"single_track_template"` // File name of single tracks, e.g. "{artist}/{album}/{title}"
+	AlbumTrackTemplate  string `json:"album_track_template"`  // File name of album tracks, e.g. "{discnumber}-{tracknumber} {title}"
+
+	CoverFilenames []string `json:"cover_filenames"` // Names the album cover is saved under (default ["cover.jpg"])
 }
 
 // Account holds user authentication credentials.
@@ -26,6 +43,30 @@ type Account struct {
 	AppSecret      string   `json:"app_secret"`
 	UserID         int      `json:"user_id"`
 	PendingSecrets []string `json:"-"` // Temporary storage, not persisted to disk
+
+	SecretsCache *SecretsCache `json:"secrets_cache,omitempty"` // Candidates of the last secrets scrape
+}
+
+// SecretsCacheTTL is how long scraped secret candidates are reused before the
+// web player is scraped again.
+const SecretsCacheTTL = 7 * 24 * time.Hour
+
+// SecretsCache holds every candidate secret of the last web player scrape, so a
+// secret that stops working can be replaced without downloading the bundle again.
+type SecretsCache struct {
+	AppID     string    `json:"app_id"`     // App ID scraped along with the secrets
+	Secrets   []string  `json:"secrets"`    // Candidate secrets
+	FetchedAt time.Time `json:"fetched_at"` // When the candidates were scraped
+}
+
+// NewSecretsCache records freshly scraped candidates.
+func NewSecretsCache(appID string, secrets []string) *SecretsCache {
+	return &SecretsCache{AppID: appID, Secrets: secrets, FetchedAt: time.Now()}
+}
+
+// Fresh reports whether the cache holds candidates younger than SecretsCacheTTL.
+func (c *SecretsCache) Fresh() bool {
+	return c != nil && c.AppID != "" && len(c.Secrets) > 0 && time.Since(c.FetchedAt) < SecretsCacheTTL
 }
 
 // getExeDir returns the directory where the executable is located.
@@ -38,6 +79,11 @@ func getExeDir() string {
 	return filepath.Dir(exe)
 }
 
+// GetConfigDir returns the directory holding the configuration and account files.
+func GetConfigDir() string {
+	return getExeDir()
+}
+
 // GetConfigPath returns the path to the configuration file.
 func GetConfigPath() string {
 	return filepath.Join(getExeDir(), "config.json")
@@ -68,8 +114,29 @@ func LoadConfig() (*Config, error) {
 }
 
 // LoadAccount loads saved account credentials from disk.
-// Returns an empty Account if the file doesn't exist.
+// With the keyring credential store, the token and password are read from the
+// system keyring unless account.json still holds them. A keyring that cannot be
+// read (locked or missing keychain) leaves them empty, as with the file store.
+// Returns an empty Account if nothing is stored.
 func LoadAccount() (*Account, error) {
+	acc, err := loadAccountFile()
+	if err != nil {
+		return nil, err
+	}
+
+	if useKeyring() {
+		if acc.UserToken == "" {
+			acc.UserToken, _ = loadKeyringItem(keyringUserToken)
+		}
+		if acc.Password == "" {
+			acc.Password, _ = loadKeyringItem(keyringPassword)
+		}
+	}
+	return acc, nil
+}
+
+// loadAccountFile reads account.json, returning an empty Account if it doesn't exist.
+func loadAccountFile() (*Account, error) {
 	path := GetAccountPath()
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		return &Account{}, nil
@@ -87,10 +154,121 @@ func LoadAccount() (*Account, error) {
 }
 
 // SaveAccount persists account credentials to disk with restricted permissions (0600).
+// Non-empty fields of the stored account are kept when acc leaves them empty, so a
+// run that only learned part of the credentials doesn't blank out a saved token.
+// The file is replaced atomically through a temporary file.
+// With the keyring credential store, the token and password go to the system
+// keyring and are left out of account.json. If the keyring is unavailable or
+// rejects them, everything is written to the file.
 func SaveAccount(acc *Account) error {
-	data, err := json.MarshalIndent(acc, "", "  ")
+	if saved, err := LoadAccount(); err == nil {
+		acc = mergeAccount(saved, acc)
+	}
+	return writeAccount(acc)
+}
+
+// mergeAccount returns acc with its empty fields filled from saved.
+// The user credentials are only carried over when both belong to the same user.
+func mergeAccount(saved, acc *Account) *Account {
+	merged := *acc
+	sameUser := (acc.Email == "" || saved.Email == "" || acc.Email == saved.Email) &&
+		(acc.UserID == 0 || saved.UserID == 0 || acc.UserID == saved.UserID)
+	if sameUser {
+		merged.Email = firstNonEmpty(acc.Email, saved.Email)
+		merged.Password = firstNonEmpty(acc.Password, saved.Password)
+		merged.UserToken = firstNonEmpty(acc.UserToken, saved.UserToken)
+		if merged.UserID == 0 {
+			merged.UserID = saved.UserID
+		}
+	}
+	if merged.AppID == "" {
+		merged.AppID = saved.AppID
+		merged.AppSecret = firstNonEmpty(acc.AppSecret, saved.AppSecret)
+	} else if merged.AppSecret == "" && merged.AppID == saved.AppID {
+		merged.AppSecret = saved.AppSecret
+	}
+	if merged.SecretsCache == nil {
+		merged.SecretsCache = saved.SecretsCache
+	}
+	return &merged
+}
+
+// firstNonEmpty returns the first non-empty string.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// writeAccount stores acc as is, replacing the saved account.
+func writeAccount(acc *Account) error {
+	stored := *acc
+	if useKeyring() && storeKeyringCredentials(acc) == nil {
+		stored.UserToken = ""
+		stored.Password = ""
+	}
+
+	data, err := json.MarshalIndent(&stored, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(GetAccountPath(), data, 0600)
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// over path, so readers never see a partially written file.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(GetAccountPath(), data, 0600)
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // No-op after a successful rename
+
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// ClearAccount removes the stored user credentials (email, password, token and user ID).
+// If keepApp is true the App ID and secret are kept so the next login skips fetching them.
+// profile selects a named account; only the default profile ("") is currently supported.
+func ClearAccount(profile string, keepApp bool) error {
+	if profile != "" {
+		return fmt.Errorf("profile %q not found: named profiles are not supported", profile)
+	}
+
+	path := GetAccountPath()
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil // Nothing stored
+	}
+
+	acc, err := loadAccountFile()
+	if err != nil {
+		acc = &Account{} // Unreadable file is replaced with an empty account
+	}
+
+	cleared := &Account{}
+	if keepApp {
+		cleared.AppID = acc.AppID
+		cleared.AppSecret = acc.AppSecret
+		cleared.SecretsCache = acc.SecretsCache
+	}
+	return writeAccount(cleared)
 }
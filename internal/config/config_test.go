@@ -0,0 +1,226 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestClearAccount(t *testing.T) {
+	stored := &Account{
+		Email:        "user@example.com",
+		Password:     "secret",
+		UserToken:    "token-123",
+		UserID:       7,
+		AppID:        "app",
+		AppSecret:    "app-secret",
+		SecretsCache: NewSecretsCache("app", []string{"s1", "s2"}),
+	}
+
+	tests := []struct {
+		name     string
+		store    string
+		keepApp  bool
+		wantApp  bool
+		noFile   bool   // No account.json before clearing
+		contents string // Raw account.json before clearing, instead of stored
+	}{
+		{name: "clear everything", store: CredentialStoreFile},
+		{name: "keep app credentials", store: CredentialStoreFile, keepApp: true, wantApp: true},
+		{name: "keyring items removed", store: CredentialStoreKeyring, keepApp: true, wantApp: true},
+		{name: "corrupt file replaced", store: CredentialStoreFile, keepApp: true, contents: "{not json"},
+		{name: "nothing stored", store: CredentialStoreFile, noFile: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ring := &memKeyring{items: map[string]string{}}
+			useTestStore(t, tt.store, ring)
+			switch {
+			case tt.contents != "":
+				if err := os.WriteFile(GetAccountPath(), []byte(tt.contents), 0600); err != nil {
+					t.Fatal(err)
+				}
+			case !tt.noFile:
+				acc := *stored
+				if err := SaveAccount(&acc); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := ClearAccount("", tt.keepApp); err != nil {
+				t.Fatalf("ClearAccount: %v", err)
+			}
+			if tt.noFile {
+				if _, err := os.Stat(GetAccountPath()); !os.IsNotExist(err) {
+					t.Errorf("ClearAccount created account.json: %v", err)
+				}
+				return
+			}
+
+			var acc Account
+			if err := json.Unmarshal([]byte(readAccountFile(t)), &acc); err != nil {
+				t.Fatalf("account.json is not a valid account: %v", err)
+			}
+			loaded, err := LoadAccount()
+			if err != nil {
+				t.Fatalf("LoadAccount: %v", err)
+			}
+			if loaded.Email != "" || loaded.Password != "" || loaded.UserToken != "" || loaded.UserID != 0 {
+				t.Errorf("user credentials left after clearing: %+v", loaded)
+			}
+			if len(ring.items) != 0 {
+				t.Errorf("keyring items left after clearing: %v", ring.items)
+			}
+			if gotApp := loaded.AppID == stored.AppID && loaded.AppSecret == stored.AppSecret && loaded.SecretsCache.Fresh(); gotApp != tt.wantApp {
+				t.Errorf("app credentials kept = %v, want %v", gotApp, tt.wantApp)
+			}
+		})
+	}
+}
+
+func TestClearAccountUnknownProfile(t *testing.T) {
+	if err := ClearAccount("work", false); err == nil {
+		t.Error("ClearAccount succeeded for an unsupported profile")
+	}
+}
+
+func TestSecretsCacheFresh(t *testing.T) {
+	tests := []struct {
+		name  string
+		cache *SecretsCache
+		want  bool
+	}{
+		{name: "just scraped", cache: NewSecretsCache("app", []string{"s1"}), want: true},
+		{name: "nearly expired", cache: &SecretsCache{AppID: "app", Secrets: []string{"s1"}, FetchedAt: time.Now().Add(-SecretsCacheTTL + time.Hour)}, want: true},
+		{name: "expired", cache: &SecretsCache{AppID: "app", Secrets: []string{"s1"}, FetchedAt: time.Now().Add(-SecretsCacheTTL - time.Hour)}},
+		{name: "no candidates", cache: NewSecretsCache("app", nil)},
+		{name: "no app ID", cache: NewSecretsCache("", []string{"s1"})},
+		{name: "nil"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cache.Fresh(); got != tt.want {
+				t.Errorf("Fresh() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecretsCacheSaved(t *testing.T) {
+	useTestStore(t, CredentialStoreFile, &memKeyring{items: map[string]string{}})
+	cache := NewSecretsCache("app", []string{"s1", "s2"})
+	if err := SaveAccount(&Account{AppID: "app", AppSecret: "s2", SecretsCache: cache}); err != nil {
+		t.Fatal(err)
+	}
+	// A later save without candidates keeps the cached ones
+	if err := SaveAccount(&Account{AppID: "app", AppSecret: "s2", UserToken: "token"}); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadAccount()
+	if err != nil {
+		t.Fatalf("LoadAccount: %v", err)
+	}
+	got := loaded.SecretsCache
+	if got == nil || got.AppID != cache.AppID || !slices.Equal(got.Secrets, cache.Secrets) || !got.FetchedAt.Equal(cache.FetchedAt) {
+		t.Fatalf("loaded secrets cache = %+v, want %+v", got, cache)
+	}
+	if !got.Fresh() {
+		t.Error("loaded secrets cache is not fresh")
+	}
+}
+
+func TestMergeAccount(t *testing.T) {
+	saved := &Account{Email: "user@example.com", Password: "pw", UserToken: "token", UserID: 7, AppID: "app", AppSecret: "secret"}
+
+	tests := []struct {
+		name string
+		acc  *Account
+		want *Account
+	}{
+		{
+			name: "empty fields keep saved values",
+			acc:  &Account{},
+			want: saved,
+		},
+		{
+			name: "new token replaces saved one",
+			acc:  &Account{UserToken: "fresh"},
+			want: &Account{Email: "user@example.com", Password: "pw", UserToken: "fresh", UserID: 7, AppID: "app", AppSecret: "secret"},
+		},
+		{
+			name: "other user drops saved credentials",
+			acc:  &Account{Email: "other@example.com", UserToken: "other-token"},
+			want: &Account{Email: "other@example.com", UserToken: "other-token", AppID: "app", AppSecret: "secret"},
+		},
+		{
+			name: "other user ID drops saved credentials",
+			acc:  &Account{UserID: 8, UserToken: "other-token"},
+			want: &Account{UserToken: "other-token", UserID: 8, AppID: "app", AppSecret: "secret"},
+		},
+		{
+			name: "same App ID keeps saved secret",
+			acc:  &Account{AppID: "app"},
+			want: saved,
+		},
+		{
+			name: "new App ID does not inherit secret",
+			acc:  &Account{AppID: "new-app"},
+			want: &Account{Email: "user@example.com", Password: "pw", UserToken: "token", UserID: 7, AppID: "new-app"},
+		},
+		{
+			name: "new secret for saved App ID",
+			acc:  &Account{AppSecret: "new-secret"},
+			want: &Account{Email: "user@example.com", Password: "pw", UserToken: "token", UserID: 7, AppID: "app", AppSecret: "new-secret"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := *saved
+			if got := mergeAccount(saved, tt.acc); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeAccount() = %+v, want %+v", got, tt.want)
+			}
+			if !reflect.DeepEqual(*saved, before) {
+				t.Error("mergeAccount() modified the saved account")
+			}
+		})
+	}
+}
+
+func TestSaveAccountAtomic(t *testing.T) {
+	useTestStore(t, CredentialStoreFile, &memKeyring{items: map[string]string{}})
+	if err := SaveAccount(&Account{Email: "user@example.com", UserToken: "token", AppID: "app", AppSecret: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	// A run that only learned a new secret must not blank out the token
+	if err := SaveAccount(&Account{AppID: "app", AppSecret: "new-secret"}); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadAccount()
+	if err != nil {
+		t.Fatalf("LoadAccount: %v", err)
+	}
+	if loaded.Email != "user@example.com" || loaded.UserToken != "token" || loaded.AppSecret != "new-secret" {
+		t.Errorf("loaded account = %+v", loaded)
+	}
+
+	info, err := os.Stat(GetAccountPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("account.json permissions = %o, want 600", perm)
+	}
+	leftovers, err := filepath.Glob(filepath.Join(filepath.Dir(GetAccountPath()), ".account.json.*.tmp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leftovers) != 0 {
+		t.Errorf("temporary files left: %v", leftovers)
+	}
+}
@@ -0,0 +1,194 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Credential store backends selectable via Config.CredentialStore.
+const (
+	CredentialStoreFile    = "file"    // Everything in account.json (default)
+	CredentialStoreKeyring = "keyring" // Token and password in the OS keychain
+)
+
+// keyringService is the service name credentials are stored under.
+const keyringService = "qobuz-dl-go"
+
+// Keyring item names.
+const (
+	keyringUserToken = "user_auth_token"
+	keyringPassword  = "password"
+)
+
+// errKeyringUnavailable is returned when no supported keychain tool is installed.
+var errKeyringUnavailable = errors.New("system keyring unavailable")
+
+// errKeyringNotFound is returned by get when the item does not exist.
+var errKeyringNotFound = errors.New("keyring item not found")
+
+// keyring stores secrets by name in the operating system's credential store.
+type keyring interface {
+	Available() bool
+	Get(name string) (string, error)
+	Set(name, value string) error
+	Delete(name string) error
+}
+
+// systemKeyring is the keyring used by SaveAccount and LoadAccount.
+// It is a variable so it can be replaced by an in-memory implementation.
+var systemKeyring keyring = newSystemKeyring()
+
+// newSystemKeyring returns the keyring backend for the current platform:
+// the macOS Keychain via security(1), the Windows Credential Manager, or the
+// Secret Service (libsecret) via secret-tool(1). Other platforms get a backend
+// that is never available.
+func newSystemKeyring() keyring {
+	switch runtime.GOOS {
+	case "darwin":
+		return macKeyring{}
+	case "windows":
+		return newWindowsKeyring()
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return secretToolKeyring{}
+	default:
+		return unavailableKeyring{}
+	}
+}
+
+// useKeyring reports whether credentials should go to the system keyring.
+// The file store is used when the keyring is not configured or not available;
+// callers also fall back to it when a keyring operation fails (for example a
+// locked or missing keychain).
+func useKeyring() bool {
+	cfg, err := LoadConfig()
+	if err != nil || cfg.CredentialStore != CredentialStoreKeyring {
+		return false
+	}
+	return systemKeyring.Available()
+}
+
+// storeKeyringItem writes value to the keyring, deleting the item when value is empty.
+func storeKeyringItem(name, value string) error {
+	if value == "" {
+		if err := systemKeyring.Delete(name); err != nil && !errors.Is(err, errKeyringNotFound) {
+			return err
+		}
+		return nil
+	}
+	return systemKeyring.Set(name, value)
+}
+
+// storeKeyringCredentials writes the token and password of acc to the keyring.
+func storeKeyringCredentials(acc *Account) error {
+	if err := storeKeyringItem(keyringUserToken, acc.UserToken); err != nil {
+		return fmt.Errorf("failed to store token in keyring: %w", err)
+	}
+	if err := storeKeyringItem(keyringPassword, acc.Password); err != nil {
+		return fmt.Errorf("failed to store password in keyring: %w", err)
+	}
+	return nil
+}
+
+// loadKeyringItem reads an item from the keyring; a missing item yields "".
+func loadKeyringItem(name string) (string, error) {
+	value, err := systemKeyring.Get(name)
+	if errors.Is(err, errKeyringNotFound) {
+		return "", nil
+	}
+	return value, err
+}
+
+// runKeyringTool runs a keychain command, feeding stdin, and returns trimmed stdout.
+func runKeyringTool(stdin string, name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %s", name, msg)
+		}
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return strings.TrimRight(stdout.String(), "\r\n"), nil
+}
+
+// macKeyring stores generic passwords in the macOS Keychain.
+type macKeyring struct{}
+
+func (macKeyring) Available() bool {
+	_, err := exec.LookPath("security")
+	return err == nil
+}
+
+func (macKeyring) Get(name string) (string, error) {
+	out, err := runKeyringTool("", "security", "find-generic-password", "-s", keyringService, "-a", name, "-w")
+	if err != nil {
+		return "", errKeyringNotFound
+	}
+	return out, nil
+}
+
+func (macKeyring) Set(name, value string) error {
+	// The command is read from stdin by interactive mode (-i), so the secret
+	// never appears in the process list; -U updates an existing item
+	command := fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n",
+		securityQuote(keyringService), securityQuote(name), securityQuote(value))
+	_, err := runKeyringTool(command, "security", "-i")
+	return err
+}
+
+// securityQuote quotes s as one argument for security(1) interactive mode.
+func securityQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+func (macKeyring) Delete(name string) error {
+	if _, err := runKeyringTool("", "security", "delete-generic-password", "-s", keyringService, "-a", name); err != nil {
+		return errKeyringNotFound
+	}
+	return nil
+}
+
+// secretToolKeyring stores secrets through the freedesktop Secret Service (libsecret).
+type secretToolKeyring struct{}
+
+func (secretToolKeyring) Available() bool {
+	_, err := exec.LookPath("secret-tool")
+	return err == nil
+}
+
+func (secretToolKeyring) Get(name string) (string, error) {
+	out, err := runKeyringTool("", "secret-tool", "lookup", "service", keyringService, "account", name)
+	if err != nil || out == "" {
+		return "", errKeyringNotFound
+	}
+	return out, nil
+}
+
+func (secretToolKeyring) Set(name, value string) error {
+	// The secret is read from stdin so it never appears in the process list
+	_, err := runKeyringTool(value, "secret-tool", "store", "--label", keyringService+" "+name,
+		"service", keyringService, "account", name)
+	return err
+}
+
+func (secretToolKeyring) Delete(name string) error {
+	_, err := runKeyringTool("", "secret-tool", "clear", "service", keyringService, "account", name)
+	return err
+}
+
+// unavailableKeyring is used on platforms without a supported keychain tool.
+type unavailableKeyring struct{}
+
+func (unavailableKeyring) Available() bool                 { return false }
+func (unavailableKeyring) Get(name string) (string, error) { return "", errKeyringUnavailable }
+func (unavailableKeyring) Set(name, value string) error    { return errKeyringUnavailable }
+func (unavailableKeyring) Delete(name string) error        { return errKeyringUnavailable }
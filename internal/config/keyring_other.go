@@ -0,0 +1,6 @@
+//go:build !windows
+
+package config
+
+// newWindowsKeyring is only reachable on Windows.
+func newWindowsKeyring() keyring { return unavailableKeyring{} }
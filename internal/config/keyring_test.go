@@ -0,0 +1,151 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+// memKeyring is an in-memory keyring; failing makes every operation fail like
+// a locked or missing keychain.
+type memKeyring struct {
+	items   map[string]string
+	failing bool
+}
+
+var errKeychainLocked = errors.New("keychain locked")
+
+func (k *memKeyring) Available() bool { return true }
+
+func (k *memKeyring) Get(name string) (string, error) {
+	if k.failing {
+		return "", errKeychainLocked
+	}
+	value, ok := k.items[name]
+	if !ok {
+		return "", errKeyringNotFound
+	}
+	return value, nil
+}
+
+func (k *memKeyring) Set(name, value string) error {
+	if k.failing {
+		return errKeychainLocked
+	}
+	k.items[name] = value
+	return nil
+}
+
+func (k *memKeyring) Delete(name string) error {
+	if k.failing {
+		return errKeychainLocked
+	}
+	if _, ok := k.items[name]; !ok {
+		return errKeyringNotFound
+	}
+	delete(k.items, name)
+	return nil
+}
+
+// useTestStore writes a config selecting store next to the test binary, swaps
+// in ring as the system keyring and removes both files afterwards.
+func useTestStore(t *testing.T, store string, ring keyring) {
+	t.Helper()
+	data, err := json.Marshal(&Config{CredentialStore: store})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(GetConfigPath(), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	saved := systemKeyring
+	systemKeyring = ring
+	t.Cleanup(func() {
+		systemKeyring = saved
+		os.Remove(GetConfigPath())
+		os.Remove(GetAccountPath())
+	})
+}
+
+func readAccountFile(t *testing.T) string {
+	t.Helper()
+	data, err := os.ReadFile(GetAccountPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestKeyringCredentialStore(t *testing.T) {
+	tests := []struct {
+		name       string
+		store      string
+		ring       *memKeyring
+		wantInRing bool // Token and password end up in the keyring
+		wantInFile bool // Token and password end up in account.json
+	}{
+		{name: "keyring", store: CredentialStoreKeyring, ring: &memKeyring{items: map[string]string{}}, wantInRing: true},
+		{name: "file store ignores keyring", store: CredentialStoreFile, ring: &memKeyring{items: map[string]string{}}, wantInFile: true},
+		{name: "locked keyring falls back to file", store: CredentialStoreKeyring, ring: &memKeyring{items: map[string]string{}, failing: true}, wantInFile: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTestStore(t, tt.store, tt.ring)
+
+			acc := &Account{Email: "user@example.com", Password: "p4ss\"word", UserToken: "token-123", UserID: 7}
+			if err := SaveAccount(acc); err != nil {
+				t.Fatalf("SaveAccount: %v", err)
+			}
+
+			file := readAccountFile(t)
+			if got := strings.Contains(file, "token-123"); got != tt.wantInFile {
+				t.Errorf("token in account.json = %v, want %v", got, tt.wantInFile)
+			}
+			if got := tt.ring.items[keyringUserToken] == "token-123" && tt.ring.items[keyringPassword] == acc.Password; got != tt.wantInRing {
+				t.Errorf("credentials in keyring = %v, want %v", got, tt.wantInRing)
+			}
+
+			loaded, err := LoadAccount()
+			if err != nil {
+				t.Fatalf("LoadAccount: %v", err)
+			}
+			if loaded.UserToken != acc.UserToken || loaded.Password != acc.Password || loaded.Email != acc.Email {
+				t.Errorf("LoadAccount = %+v, want %+v", loaded, acc)
+			}
+		})
+	}
+}
+
+func TestLoadAccountWithUnreadableKeyring(t *testing.T) {
+	ring := &memKeyring{items: map[string]string{}}
+	useTestStore(t, CredentialStoreKeyring, ring)
+
+	if err := SaveAccount(&Account{Email: "user@example.com", UserToken: "token-123"}); err != nil {
+		t.Fatal(err)
+	}
+	ring.failing = true // Keychain locked after saving
+
+	loaded, err := LoadAccount()
+	if err != nil {
+		t.Fatalf("LoadAccount failed with a locked keyring: %v", err)
+	}
+	if loaded.Email != "user@example.com" || loaded.UserToken != "" {
+		t.Errorf("LoadAccount = %+v, want the file fields and no token", loaded)
+	}
+}
+
+func TestSecurityQuote(t *testing.T) {
+	tests := map[string]string{
+		"token":    `"token"`,
+		`a"b`:      `"a\"b"`,
+		`back\sl`:  `"back\\sl"`,
+		"with one": `"with one"`,
+	}
+	for in, want := range tests {
+		if got := securityQuote(in); got != want {
+			t.Errorf("securityQuote(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
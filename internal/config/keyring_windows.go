@@ -0,0 +1,112 @@
+package config
+
+import (
+	"errors"
+	"syscall"
+	"unsafe"
+)
+
+// Credential Manager API (advapi32).
+var (
+	advapi32       = syscall.NewLazyDLL("advapi32.dll")
+	procCredReadW  = advapi32.NewProc("CredReadW")
+	procCredWriteW = advapi32.NewProc("CredWriteW")
+	procCredDelete = advapi32.NewProc("CredDeleteW")
+	procCredFree   = advapi32.NewProc("CredFree")
+)
+
+const (
+	credTypeGeneric         = 1
+	credPersistLocalMachine = 2
+	errorNotFound           = syscall.Errno(1168)
+)
+
+// credential mirrors the Win32 CREDENTIALW structure.
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        syscall.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+// windowsKeyring stores generic credentials in the Windows Credential Manager,
+// under the target "qobuz-dl-go:<name>".
+type windowsKeyring struct{}
+
+func newWindowsKeyring() keyring { return windowsKeyring{} }
+
+// target returns the UTF-16 credential target name of an item.
+func (windowsKeyring) target(name string) (*uint16, error) {
+	return syscall.UTF16PtrFromString(keyringService + ":" + name)
+}
+
+func (windowsKeyring) Available() bool {
+	return advapi32.Load() == nil && procCredReadW.Find() == nil
+}
+
+func (k windowsKeyring) Get(name string) (string, error) {
+	target, err := k.target(name)
+	if err != nil {
+		return "", err
+	}
+	var cred *credential
+	r, _, callErr := procCredReadW.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred)))
+	if r == 0 {
+		if errors.Is(callErr, errorNotFound) {
+			return "", errKeyringNotFound
+		}
+		return "", callErr
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+
+	blob := unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize)
+	return string(blob), nil
+}
+
+func (k windowsKeyring) Set(name, value string) error {
+	target, err := k.target(name)
+	if err != nil {
+		return err
+	}
+	user, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return err
+	}
+	blob := []byte(value)
+	cred := credential{
+		Type:               credTypeGeneric,
+		TargetName:         target,
+		UserName:           user,
+		CredentialBlobSize: uint32(len(blob)),
+		Persist:            credPersistLocalMachine,
+	}
+	if len(blob) > 0 {
+		cred.CredentialBlob = &blob[0]
+	}
+	if r, _, callErr := procCredWriteW.Call(uintptr(unsafe.Pointer(&cred)), 0); r == 0 {
+		return callErr
+	}
+	return nil
+}
+
+func (k windowsKeyring) Delete(name string) error {
+	target, err := k.target(name)
+	if err != nil {
+		return err
+	}
+	if r, _, callErr := procCredDelete.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0); r == 0 {
+		if errors.Is(callErr, errorNotFound) {
+			return errKeyringNotFound
+		}
+		return callErr
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+// album_delay.go pauses between the albums of long artist and batch runs,
+// keeping multi-hour jobs well under the API rate limits.
+package engine
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// waitAlbumDelay sleeps for AlbumDelay before the next album of a bulk run.
+// It returns ctx's error as soon as ctx is cancelled instead of waiting out the delay.
+func (e *Engine) waitAlbumDelay(ctx context.Context) error {
+	if e.AlbumDelay <= 0 {
+		return ctx.Err()
+	}
+	fmt.Printf("[Delay] Waiting %s before the next album\n", e.AlbumDelay)
+	timer := time.NewTimer(e.AlbumDelay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
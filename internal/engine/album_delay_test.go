@@ -0,0 +1,110 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+func TestWaitAlbumDelay(t *testing.T) {
+	tests := []struct {
+		name        string
+		delay       time.Duration
+		cancel      string // "before" or "during" the wait, "" for never
+		wantErr     error
+		wantMinWait time.Duration
+	}{
+		{name: "no delay"},
+		{name: "delay", delay: 100 * time.Millisecond, wantMinWait: 100 * time.Millisecond},
+		{name: "cancelled during delay", delay: time.Hour, cancel: "during", wantErr: context.Canceled},
+		{name: "cancelled without delay", cancel: "before", wantErr: context.Canceled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			switch tt.cancel {
+			case "before":
+				cancel()
+			case "during":
+				time.AfterFunc(50*time.Millisecond, cancel)
+			}
+			e := &Engine{AlbumDelay: tt.delay}
+
+			start := time.Now()
+			err := e.waitAlbumDelay(ctx)
+			elapsed := time.Since(start)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("waitAlbumDelay() = %v, want %v", err, tt.wantErr)
+			}
+			if elapsed < tt.wantMinWait || elapsed > tt.wantMinWait+5*time.Second {
+				t.Errorf("waited %v, want %v", elapsed, tt.wantMinWait)
+			}
+		})
+	}
+}
+
+func TestBulkRunAlbumDelay(t *testing.T) {
+	const delay = 150 * time.Millisecond
+	runs := []struct {
+		name string
+		run  func(ctx context.Context, e *Engine, outputDir string) error
+	}{
+		{name: "artist", run: func(ctx context.Context, e *Engine, outputDir string) error {
+			return e.DownloadArtist(ctx, "art1", 6, outputDir)
+		}},
+		{name: "queue", run: func(ctx context.Context, e *Engine, outputDir string) error {
+			queue := e.NewQueue(6, outputDir)
+			for _, id := range []string{"alb1", "alb2", "alb3"} {
+				queue.Add(Job{Type: api.TypeAlbum, ID: id})
+			}
+			result := queue.Run(ctx)
+			if failed := result.Failed(); len(failed) > 0 {
+				return failed[0].Err
+			}
+			return nil
+		}},
+	}
+	for _, run := range runs {
+		t.Run(run.name, func(t *testing.T) {
+			fake := newFakeQobuz(t)
+			for i, id := range []string{"alb1", "alb2", "alb3"} {
+				fake.addAlbum(id, "Album "+id, "Band", 100*(i+1), 1)
+			}
+			fake.addArtist("art1", "Band", "alb1", "alb2", "alb3")
+
+			// A delay separates each pair of albums
+			e := fake.engine()
+			e.AlbumDelay = delay
+			start := time.Now()
+			if err := run.run(context.Background(), e, t.TempDir()); err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			if elapsed := time.Since(start); elapsed < 2*delay {
+				t.Errorf("3 albums took %v, want at least two %v delays", elapsed, delay)
+			}
+
+			// Cancelling during a delay stops the run without waiting it out
+			e = fake.engine()
+			e.AlbumDelay = time.Hour
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			before := fake.count("/album/get")
+			time.AfterFunc(300*time.Millisecond, cancel)
+			start = time.Now()
+			err := run.run(ctx, e, t.TempDir())
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("cancelled run = %v, want %v", err, context.Canceled)
+			}
+			if elapsed := time.Since(start); elapsed > 10*time.Second {
+				t.Errorf("cancelled run took %v, want it to stop during the delay", elapsed)
+			}
+			if n := fake.count("/album/get") - before; n != 1 {
+				t.Errorf("%d albums fetched before cancelling, want 1", n)
+			}
+		})
+	}
+}
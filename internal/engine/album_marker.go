@@ -0,0 +1,113 @@
+// album_marker.go keeps a Qobuz album ID marker in every album folder, so a
+// re-download after an artist rename or metadata change reuses the existing
+// folder instead of creating a near-duplicate next to it.
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// albumDirIndex maps album IDs to the folders holding their marker, per output
+// directory. Each output directory is scanned once, on first use.
+type albumDirIndex struct {
+	mu    sync.Mutex
+	roots map[string]map[string]string // Output directory -> album ID -> album folder
+}
+
+// readAlbumMarker returns the album ID stored in dir's marker, or "" if none.
+func readAlbumMarker(dir string) string {
+	data, err := os.ReadFile(filepath.Join(dir, albumIDMarker))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// writeAlbumMarker stores albumID in dir's marker unless it already holds it.
+func writeAlbumMarker(dir, albumID string) error {
+	if readAlbumMarker(dir) == albumID {
+		return nil
+	}
+	return os.WriteFile(filepath.Join(dir, albumIDMarker), []byte(albumID+"\n"), 0644)
+}
+
+// scanAlbumMarkers indexes the marked album folders directly under root and,
+// for artist initial grouping, one level deeper.
+func scanAlbumMarkers(root string) map[string]string {
+	dirs := make(map[string]string)
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return dirs
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(root, entry.Name())
+		if id := readAlbumMarker(dir); id != "" {
+			dirs[id] = dir
+			continue
+		}
+		children, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, child := range children {
+			if !child.IsDir() {
+				continue
+			}
+			sub := filepath.Join(dir, child.Name())
+			if id := readAlbumMarker(sub); id != "" {
+				if _, taken := dirs[id]; !taken {
+					dirs[id] = sub
+				}
+			}
+		}
+	}
+	return dirs
+}
+
+// find returns the existing folder of albumID under root.
+func (x *albumDirIndex) find(root, albumID string) (string, bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	dirs := x.load(root)
+	dir, ok := dirs[albumID]
+	if ok && readAlbumMarker(dir) != albumID {
+		delete(dirs, albumID) // Moved or deleted since the scan
+		return "", false
+	}
+	return dir, ok
+}
+
+// add records dir as the folder of albumID under root.
+func (x *albumDirIndex) add(root, albumID, dir string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.load(root)[albumID] = dir
+}
+
+// load returns the index of root, scanning it on first use. x.mu must be held.
+func (x *albumDirIndex) load(root string) map[string]string {
+	if x.roots == nil {
+		x.roots = make(map[string]map[string]string)
+	}
+	dirs, ok := x.roots[root]
+	if !ok {
+		dirs = scanAlbumMarkers(root)
+		x.roots[root] = dirs
+	}
+	return dirs
+}
+
+// existingAlbumDir returns the folder of a previous download of albumID under
+// root, when ReuseAlbumDirs is set.
+func (e *Engine) existingAlbumDir(root, albumID string) (string, bool) {
+	if !e.ReuseAlbumDirs || albumID == "" {
+		return "", false
+	}
+	return e.albumDirs.find(root, albumID)
+}
@@ -0,0 +1,95 @@
+package engine
+
+import (
+	"context"
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScanAlbumMarkers(t *testing.T) {
+	root := t.TempDir()
+	for dir, id := range map[string]string{
+		"Band - Album":           "alb1",
+		"B/Band - Other":         "alb2",
+		"Old Name - Album":       "alb3",
+		"Old Name - Album/Disc1": "nested", // Inside a marked folder, not scanned
+		"C/D/Too Deep":           "deep",
+		"Unmarked":               "",
+	} {
+		path := filepath.Join(root, dir)
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if id != "" {
+			if err := writeAlbumMarker(path, id); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	want := map[string]string{
+		"alb1": filepath.Join(root, "Band - Album"),
+		"alb2": filepath.Join(root, "B", "Band - Other"),
+		"alb3": filepath.Join(root, "Old Name - Album"),
+	}
+	if got := scanAlbumMarkers(root); !maps.Equal(got, want) {
+		t.Errorf("scanAlbumMarkers() = %v, want %v", got, want)
+	}
+}
+
+func TestDownloadAlbumReusesMarkedFolder(t *testing.T) {
+	tests := []struct {
+		name      string
+		reuse     bool
+		move      bool // Move the first download's folder before re-downloading
+		wantReuse bool
+	}{
+		{name: "renamed artist reuses folder", reuse: true, wantReuse: true},
+		{name: "moved folder is found", reuse: true, move: true, wantReuse: true},
+		{name: "reuse disabled", reuse: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeQobuz(t)
+			album := fake.addAlbum("alb1", "Album", "Old Name", 100, 2)
+			outputDir := t.TempDir()
+
+			first, err := fake.engine().DownloadAlbum(context.Background(), "alb1", 6, outputDir)
+			if err != nil || len(first.Success) != 2 {
+				t.Fatalf("first DownloadAlbum() = %v, %v", first, err)
+			}
+			if got := readAlbumMarker(first.AlbumDir); got != "alb1" {
+				t.Fatalf("marker = %q, want alb1", got)
+			}
+			existing := first.AlbumDir
+			if tt.move {
+				existing = filepath.Join(outputDir, "Sorted", "Album")
+				if err := os.MkdirAll(filepath.Dir(existing), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Rename(first.AlbumDir, existing); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			album.Artist.Name = "New Name" // The planned folder name changes
+			e := fake.engine()
+			e.ReuseAlbumDirs = tt.reuse
+			second, err := e.DownloadAlbum(context.Background(), "alb1", 6, outputDir)
+			if err != nil {
+				t.Fatalf("second DownloadAlbum: %v", err)
+			}
+			if reused := second.AlbumDir == existing; reused != tt.wantReuse {
+				t.Errorf("second download went to %s, reuse of %s = %v, want %v", second.AlbumDir, existing, reused, tt.wantReuse)
+			}
+			if tt.wantReuse && (len(second.Skipped) != 2 || len(second.Success) != 0) {
+				t.Errorf("got %d downloaded and %d skipped, want the existing tracks skipped", len(second.Success), len(second.Skipped))
+			}
+			if got := readAlbumMarker(second.AlbumDir); got != "alb1" {
+				t.Errorf("marker of the second download = %q, want alb1", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+// album_prefetch.go loads album metadata ahead of time for artist downloads,
+// so the next albums' details are ready by the time their tracks start.
+package engine
+
+import (
+	"context"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+// albumFetch is an album metadata request started ahead of time.
+type albumFetch struct {
+	done  chan struct{} // Closed once album and err are set
+	album *api.AlbumMetadata
+	err   error
+}
+
+// prefetchAlbums fetches the metadata of albumIDs in the background with up to
+// workers concurrent API requests. getAlbum picks up the results; fetches not
+// started when ctx is cancelled complete with ctx's error.
+func (e *Engine) prefetchAlbums(ctx context.Context, albumIDs []string, workers int) {
+	var ids []string
+	var fetches []*albumFetch
+	for _, id := range albumIDs {
+		f := &albumFetch{done: make(chan struct{})}
+		if _, loaded := e.albumFetches.LoadOrStore(id, f); !loaded {
+			ids = append(ids, id)
+			fetches = append(fetches, f)
+		}
+	}
+
+	jobs := make(chan int)
+	for range min(workers, len(ids)) {
+		go func() {
+			for i := range jobs {
+				release, err := e.acquireAPI(ctx)
+				if err == nil {
+					fetches[i].album, fetches[i].err = e.Client.GetAlbum(ids[i])
+					release()
+				} else {
+					fetches[i].err = err
+				}
+				close(fetches[i].done)
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for i := range ids {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				for _, f := range fetches[i:] {
+					f.err = ctx.Err()
+					close(f.done)
+				}
+				return
+			}
+		}
+	}()
+}
+
+// getAlbum returns the album metadata, waiting for a prefetch of it if one was
+// started. Failed prefetches are retried with a direct request.
+func (e *Engine) getAlbum(albumID string) (*api.AlbumMetadata, error) {
+	if v, ok := e.albumFetches.LoadAndDelete(albumID); ok {
+		f := v.(*albumFetch)
+		<-f.done
+		if f.err == nil {
+			return f.album, nil
+		}
+	}
+	return e.Client.GetAlbum(albumID)
+}
+
+// dropAlbumPrefetches forgets prefetched albums that were never used.
+func (e *Engine) dropAlbumPrefetches(albumIDs []string) {
+	for _, id := range albumIDs {
+		e.albumFetches.Delete(id)
+	}
+}
@@ -0,0 +1,88 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+func TestPrefetchAlbums(t *testing.T) {
+	tests := []struct {
+		name     string
+		workers  int
+		apiLimit int
+		wantPeak int32
+	}{
+		{name: "one worker", workers: 1, wantPeak: 1},
+		{name: "three workers", workers: 3, wantPeak: 3},
+		{name: "more workers than albums", workers: 10, wantPeak: 6},
+		{name: "API limit applies", workers: 6, apiLimit: 2, wantPeak: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var counter peakCounter
+			var requests atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				counter.run(30 * time.Millisecond)
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprintf(w, `{"id":%q,"title":"Album %s"}`, r.URL.Query().Get("album_id"), r.URL.Query().Get("album_id"))
+			}))
+			defer srv.Close()
+
+			client := api.NewClient("app", "secret")
+			client.HTTP.SetBaseURL(srv.URL)
+			e := New(client)
+			e.APIConcurrency = tt.apiLimit
+
+			ids := []string{"a1", "a2", "a3", "a4", "a5", "a6"}
+			e.prefetchAlbums(context.Background(), ids, tt.workers)
+			for _, id := range ids {
+				album, err := e.getAlbum(id)
+				if err != nil || album.ID != id {
+					t.Fatalf("getAlbum(%q) = %+v, %v", id, album, err)
+				}
+			}
+
+			if peak := counter.peak.Load(); peak != tt.wantPeak {
+				t.Errorf("peak concurrent album requests = %d, want %d", peak, tt.wantPeak)
+			}
+			if n := requests.Load(); n != int32(len(ids)) {
+				t.Errorf("server saw %d album requests, want %d", n, len(ids))
+			}
+		})
+	}
+}
+
+func TestPrefetchAlbumsCancelled(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id":%q}`, r.URL.Query().Get("album_id"))
+	}))
+	defer srv.Close()
+
+	client := api.NewClient("app", "secret")
+	client.HTTP.SetBaseURL(srv.URL)
+	e := New(client)
+
+	// Albums whose prefetch was cancelled are fetched directly
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	e.prefetchAlbums(ctx, []string{"a1", "a2"}, 2)
+	for _, id := range []string{"a1", "a2"} {
+		if album, err := e.getAlbum(id); err != nil || album.ID != id {
+			t.Errorf("getAlbum(%q) = %+v, %v", id, album, err)
+		}
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("server saw %d album requests, want 2", n)
+	}
+}
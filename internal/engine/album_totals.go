@@ -0,0 +1,66 @@
+// album_totals.go derives album-level aggregates (track and disc counts,
+// total duration) written next to the per-track numbering tags.
+package engine
+
+import (
+	"strconv"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+// albumTotals holds the album aggregates for one track. Zero means unknown.
+type albumTotals struct {
+	DiscTracks int // Tracks on the track's disc
+	Discs      int // Discs in the album
+	Seconds    int // Album duration in seconds
+}
+
+// albumTotalsFor computes the aggregates for track from the album's track list,
+// falling back to the album's counters when the list is not included (as in the
+// album block of a single track).
+func albumTotalsFor(track *api.TrackMetadata, album *api.AlbumMetadata) albumTotals {
+	totals := albumTotals{Discs: album.MediaCount, Seconds: album.Duration}
+
+	for _, item := range album.Tracks.Items {
+		if item.MediaNumber == track.MediaNumber {
+			totals.DiscTracks++
+		}
+		totals.Discs = max(totals.Discs, item.MediaNumber)
+	}
+	// Without a track list, the album's track count is the disc's only if there is one disc
+	if totals.DiscTracks == 0 && totals.Discs <= 1 {
+		totals.DiscTracks = album.TracksCount
+	}
+	return totals
+}
+
+// trackTotal returns the TRACKTOTAL value, or "" if unknown.
+func (a albumTotals) trackTotal() string {
+	return positiveString(a.DiscTracks)
+}
+
+// discTotal returns the DISCTOTAL value, or "" if unknown.
+func (a albumTotals) discTotal() string {
+	return positiveString(a.Discs)
+}
+
+// duration returns the ALBUMDURATION value in seconds, or "" if unknown.
+func (a albumTotals) duration() string {
+	return positiveString(a.Seconds)
+}
+
+// withTotal formats an ID3 TRCK/TPOS value: "n/total", or "n" if total is unknown.
+func withTotal(n, total int) string {
+	if total > 0 {
+		return strconv.Itoa(n) + "/" + strconv.Itoa(total)
+	}
+	return strconv.Itoa(n)
+}
+
+// positiveString formats n, or returns "" when n is not positive.
+func positiveString(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
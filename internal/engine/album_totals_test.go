@@ -0,0 +1,140 @@
+package engine
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+
+	"github.com/bogem/id3v2/v2"
+	"github.com/go-flac/go-flac"
+)
+
+// boxSet returns an album with tracksPerDisc tracks on each disc.
+func boxSet(tracksPerDisc ...int) *api.AlbumMetadata {
+	album := &api.AlbumMetadata{Title: "Box", Duration: 1800}
+	for disc, n := range tracksPerDisc {
+		for i := range n {
+			album.Tracks.Items = append(album.Tracks.Items, api.TrackMetadata{TrackNumber: i + 1, MediaNumber: disc + 1})
+		}
+		album.TracksCount += n
+	}
+	album.MediaCount = len(tracksPerDisc)
+	return album
+}
+
+func TestAlbumTotalsFor(t *testing.T) {
+	summary := &api.AlbumMetadata{TracksCount: 11, MediaCount: 1, Duration: 2340}
+	multiDiscSummary := &api.AlbumMetadata{TracksCount: 30, MediaCount: 2}
+
+	tests := []struct {
+		name  string
+		track api.TrackMetadata
+		album *api.AlbumMetadata
+		want  albumTotals
+	}{
+		{name: "first disc", track: api.TrackMetadata{MediaNumber: 1}, album: boxSet(3, 2), want: albumTotals{DiscTracks: 3, Discs: 2, Seconds: 1800}},
+		{name: "second disc", track: api.TrackMetadata{MediaNumber: 2}, album: boxSet(3, 2), want: albumTotals{DiscTracks: 2, Discs: 2, Seconds: 1800}},
+		{name: "summary without track list", track: api.TrackMetadata{MediaNumber: 1}, album: summary, want: albumTotals{DiscTracks: 11, Discs: 1, Seconds: 2340}},
+		{name: "multi-disc summary has no disc track count", track: api.TrackMetadata{MediaNumber: 1}, album: multiDiscSummary, want: albumTotals{Discs: 2}},
+		{name: "nothing known", album: &api.AlbumMetadata{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := albumTotalsFor(&tt.track, tt.album); got != tt.want {
+				t.Errorf("albumTotalsFor() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// totalTags returns the numbering and album aggregate tags of a FLAC or MP3
+// file, keyed by their Vorbis comment or ID3 frame names.
+func totalTags(t *testing.T, path string) map[string]string {
+	t.Helper()
+	tags := make(map[string]string)
+	if filepath.Ext(path) == ".mp3" {
+		tag, err := id3v2.Open(path, id3v2.Options{Parse: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tag.Close()
+		for _, id := range []string{"TRCK", "TPOS"} {
+			if v := tag.GetTextFrame(id).Text; v != "" {
+				tags[id] = v
+			}
+		}
+		for _, f := range tag.GetFrames("TXXX") {
+			if udf, ok := f.(id3v2.UserDefinedTextFrame); ok && slices.Contains([]string{"TOTALTRACKS", "TOTALDISCS", "ALBUMDURATION"}, udf.Description) {
+				tags[udf.Description] = udf.Value
+			}
+		}
+		return tags
+	}
+
+	f, err := flac.ParseFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, block := range f.Meta {
+		if block.Type != flac.VorbisComment {
+			continue
+		}
+		cmts, err := ParseVorbisComment(block.Data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, key := range []string{"TRACKNUMBER", "DISCNUMBER", "TRACKTOTAL", "TOTALTRACKS", "DISCTOTAL", "TOTALDISCS", "ALBUMDURATION"} {
+			if v := cmts.Get(key); v != "" {
+				tags[key] = v
+			}
+		}
+	}
+	return tags
+}
+
+func TestAlbumTotalTags(t *testing.T) {
+	tests := []struct {
+		name        string
+		file        string
+		writeTotals bool
+		want        map[string]string
+	}{
+		{
+			name: "FLAC", file: "track.flac", writeTotals: true,
+			want: map[string]string{"TRACKNUMBER": "2", "DISCNUMBER": "2", "TRACKTOTAL": "2", "TOTALTRACKS": "2", "DISCTOTAL": "2", "TOTALDISCS": "2", "ALBUMDURATION": "1800"},
+		},
+		{
+			name: "MP3", file: "track.mp3", writeTotals: true,
+			want: map[string]string{"TRCK": "2/2", "TPOS": "2/2", "TOTALTRACKS": "2", "TOTALDISCS": "2", "ALBUMDURATION": "1800"},
+		},
+		{name: "FLAC disabled", file: "track.flac", want: map[string]string{"TRACKNUMBER": "2", "DISCNUMBER": "2"}},
+		{name: "MP3 disabled", file: "track.mp3", want: map[string]string{"TRCK": "2", "TPOS": "2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			data := buildTestFLAC(1, 64)
+			if filepath.Ext(path) == ".mp3" {
+				data = make([]byte, 128)
+			}
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			tagger := NewTagger()
+			tagger.WriteTotals = tt.writeTotals
+			album := boxSet(3, 2)
+			track := &api.TrackMetadata{Title: "Track", TrackNumber: 2, MediaNumber: 2}
+			if err := tagger.WriteTags(path, track, album, nil); err != nil {
+				t.Fatalf("WriteTags: %v", err)
+			}
+			if got := totalTags(t, path); !maps.Equal(got, tt.want) {
+				t.Errorf("tags = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
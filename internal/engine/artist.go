@@ -0,0 +1,158 @@
+// artist.go provides discography downloads for a single artist.
+// Albums can be filtered by release date so repeated runs only fetch new releases.
+package engine
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+// releaseDateLayout is the date format Qobuz uses for release dates (YYYY-MM-DD).
+const releaseDateLayout = "2006-01-02"
+
+// parseReleaseDate parses a Qobuz release date string.
+// Returns false if the date is missing or malformed.
+func parseReleaseDate(s string) (time.Time, bool) {
+	if s == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(releaseDateLayout, s)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// filterAlbumsSince returns the albums released on or after since.
+// Albums without a parseable original release date are returned separately
+// so the caller can warn about them. A zero since keeps every album.
+func filterAlbumsSince(albums []api.AlbumMetadata, since time.Time) (kept, undated []api.AlbumMetadata) {
+	if since.IsZero() {
+		return albums, nil
+	}
+
+	for _, album := range albums {
+		released, ok := parseReleaseDate(album.ReleaseDateOrg)
+		if !ok {
+			undated = append(undated, album)
+			continue
+		}
+		if !released.Before(since) {
+			kept = append(kept, album)
+		}
+	}
+	return kept, undated
+}
+
+// DownloadArtist downloads every album of an artist into outputDir.
+// If e.Since is set, only albums released on or after that date are downloaded.
+// If e.MinBitDepth is set, albums below that bit depth are skipped.
+// Completed albums are recorded in SyncStateFile in outputDir and skipped by
+// later runs unless e.IgnoreSyncState is set.
+func (e *Engine) DownloadArtist(ctx context.Context, artistID string, quality int, outputDir string) error {
+	artist, err := e.Client.GetArtist(artistID)
+	if err != nil {
+		return fmt.Errorf("failed to get artist metadata: %w", err)
+	}
+
+	albums, undated := filterAlbumsSince(artist.Albums.Items, e.Since)
+	for _, album := range undated {
+		fmt.Printf("Warning: skipping %q (missing release date)\n", album.Title)
+	}
+
+	if !e.Since.IsZero() {
+		fmt.Printf("Artist: %s (%d of %d albums since %s)\n",
+			artist.Name, len(albums), len(artist.Albums.Items), e.Since.Format(releaseDateLayout))
+	} else {
+		fmt.Printf("Artist: %s (%d albums)\n", artist.Name, len(albums))
+	}
+
+	if e.MinBitDepth > 0 {
+		var below []api.AlbumMetadata
+		albums, below = e.filterAlbumsByBitDepth(albums)
+		e.reportBelowBitDepth(below)
+		if len(below) > 0 {
+			fmt.Printf("%d albums left after the %d-bit minimum\n", len(albums), e.MinBitDepth)
+		}
+	}
+
+	stateDir := e.resolveOutputDir(outputDir)
+	state := loadSyncState(stateDir)
+	if !e.IgnoreSyncState {
+		total := len(albums)
+		albums = state.pending(artistID, albums)
+		if done := total - len(albums); done > 0 {
+			fmt.Printf("Resuming: %d of %d albums completed in an earlier run (use --force to re-check them)\n", done, total)
+		}
+	}
+
+	if e.MetadataWorkers > 0 && len(albums) > 1 {
+		ids := make([]string, 0, len(albums))
+		for _, album := range albums {
+			ids = append(ids, album.ID)
+		}
+		prefetchCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		e.prefetchAlbums(prefetchCtx, ids, e.MetadataWorkers)
+		defer e.dropAlbumPrefetches(ids)
+	}
+
+	prefetched := 1 // The first album fetches its own cover
+	failed := 0
+	for i, album := range albums {
+		if i > 0 {
+			if err := e.waitAlbumDelay(ctx); err != nil {
+				return err
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// Fetch the covers of the next albums while this one downloads
+		if e.ParallelCovers > 0 {
+			end := min(i+1+min(e.ParallelCovers, maxCoverPrefetchAhead), len(albums))
+			if prefetched < end {
+				var urls []string
+				for _, next := range albums[prefetched:end] {
+					if next.Image.Large != "" {
+						urls = append(urls, next.Image.Large)
+					}
+				}
+				e.prefetchCovers(ctx, urls, e.ParallelCovers)
+				prefetched = end
+			}
+		}
+
+		fmt.Printf("\n[%d/%d] %s\n", i+1, len(albums), album.Title)
+		result, err := e.DownloadAlbum(ctx, album.ID, quality, outputDir)
+		if err == nil && len(result.Failed) > 0 {
+			err = &PartialError{Failed: len(result.Failed), Total: len(result.Failed) + len(result.Success), Unit: "tracks"}
+		} else if err != nil {
+			fmt.Printf("Album download failed: %v\n", err)
+		}
+		if err != nil {
+			failed++
+			if e.FailFast {
+				return &StoppedError{Item: fmt.Sprintf("album %q", album.Title), Err: err}
+			}
+			continue
+		}
+
+		if e.Preview {
+			continue // Preview clips don't complete the album
+		}
+		state.markCompleted(artistID, artist.Name, album.ID, album.Title)
+		if err := state.save(stateDir); err != nil {
+			fmt.Printf("Warning: failed to save %s: %v\n", SyncStateFile, err)
+		}
+	}
+
+	if failed > 0 {
+		return &PartialError{Failed: failed, Total: len(albums), Unit: "albums"}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+func TestFilterAlbumsSince(t *testing.T) {
+	albums := []api.AlbumMetadata{
+		{ID: "old", ReleaseDateOrg: "2019-12-31"},
+		{ID: "boundary", ReleaseDateOrg: "2020-01-01"},
+		{ID: "new", ReleaseDateOrg: "2023-06-15"},
+		{ID: "undated"},
+		{ID: "malformed", ReleaseDateOrg: "2021"},
+	}
+	ids := func(albums []api.AlbumMetadata) []string {
+		var out []string
+		for _, a := range albums {
+			out = append(out, a.ID)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name        string
+		since       time.Time
+		wantKept    []string
+		wantUndated []string
+	}{
+		{
+			name:     "zero date keeps every album",
+			wantKept: []string{"old", "boundary", "new", "undated", "malformed"},
+		},
+		{
+			name:        "release on the date is kept",
+			since:       time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+			wantKept:    []string{"boundary", "new"},
+			wantUndated: []string{"undated", "malformed"},
+		},
+		{
+			name:        "future date keeps nothing dated",
+			since:       time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+			wantUndated: []string{"undated", "malformed"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, undated := filterAlbumsSince(albums, tt.since)
+			if got := ids(kept); !slices.Equal(got, tt.wantKept) {
+				t.Errorf("kept = %v, want %v", got, tt.wantKept)
+			}
+			if got := ids(undated); !slices.Equal(got, tt.wantUndated) {
+				t.Errorf("undated = %v, want %v", got, tt.wantUndated)
+			}
+		})
+	}
+}
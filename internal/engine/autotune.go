@@ -0,0 +1,161 @@
+// autotune.go adjusts the number of album download threads to the observed
+// throughput. It starts with a few threads and adds one while the aggregate
+// rate keeps improving, undoes a step that did not help and halves the count
+// when downloads fail under load: rate limiting (429), server errors (5xx) and
+// broken or stalled transfers. Failures unrelated to load are ignored.
+package engine
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+// Controller settings for automatic concurrency.
+const (
+	autotuneStart    = 2               // Threads used before anything is measured
+	autotuneInterval = 3 * time.Second // Measurement period between adjustments
+	autotuneGain     = 1.10            // Throughput must rise by 10% to justify another thread
+)
+
+// concurrencyTuner limits how many album workers take tasks and adapts the
+// limit to the throughput measured over each interval.
+type concurrencyTuner struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	limit    int     // Workers allowed to take tasks
+	max      int     // Upper bound for limit
+	bytes    int64   // Bytes received in the current interval
+	failed   bool    // A download failed in the current interval
+	lastRate float64 // Throughput (bytes/s) of the previous interval
+	grew     bool    // The previous adjustment added a thread
+	drained  bool    // No tasks are left, waiting workers may exit
+}
+
+// newConcurrencyTuner creates a tuner allowing up to max workers.
+func newConcurrencyTuner(max int) *concurrencyTuner {
+	t := &concurrencyTuner{limit: min(autotuneStart, max), max: max}
+	t.cond = sync.NewCond(&t.mu)
+	return t
+}
+
+// wait blocks worker id until it is within the limit or the tasks run out.
+func (t *concurrencyTuner) wait(id int) {
+	t.mu.Lock()
+	for id >= t.limit && !t.drained {
+		t.cond.Wait()
+	}
+	t.mu.Unlock()
+}
+
+// drain releases every waiting worker once the last task was taken.
+func (t *concurrencyTuner) drain() {
+	t.mu.Lock()
+	t.drained = true
+	t.mu.Unlock()
+	t.cond.Broadcast()
+}
+
+// received records downloaded bytes.
+func (t *concurrencyTuner) received(n int64) {
+	t.mu.Lock()
+	t.bytes += n
+	t.mu.Unlock()
+}
+
+// isLoadFailure reports whether err is a download failure that more threads can
+// cause: HTTP 429 or 5xx, a transport error or a stalled transfer. Unavailable
+// tracks, other HTTP errors and local failures such as tagging are not.
+func isLoadFailure(err error) bool {
+	overloaded := func(code int) bool {
+		return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+	}
+
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		return overloaded(statusErr.StatusCode)
+	}
+	if apiErr, ok := api.AsAPIError(err); ok {
+		return overloaded(apiErr.StatusCode)
+	}
+	if errors.Is(err, errStalled) {
+		return true
+	}
+	var netErr net.Error
+	var urlErr *url.Error
+	return errors.As(err, &netErr) || errors.As(err, &urlErr)
+}
+
+// fail records a failed download.
+func (t *concurrencyTuner) fail() {
+	t.mu.Lock()
+	t.failed = true
+	t.mu.Unlock()
+}
+
+// adjust ends a measurement interval of length elapsed and updates the limit.
+func (t *concurrencyTuner) adjust(elapsed time.Duration) {
+	t.mu.Lock()
+	rate := float64(t.bytes) / elapsed.Seconds()
+	failed := t.failed
+	t.bytes, t.failed = 0, false
+	t.mu.Unlock()
+
+	t.tune(rate, failed)
+}
+
+// tune moves the limit for an interval with throughput rate. Failures halve
+// the limit; otherwise a thread is added while the rate improves by
+// autotuneGain, and an added thread that did not help is taken back.
+func (t *concurrencyTuner) tune(rate float64, failed bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch {
+	case failed:
+		t.limit = max(t.limit/2, 1)
+		t.lastRate = 0 // Measure again from the reduced limit
+		t.grew = false
+	case rate > 0 && rate >= t.lastRate*autotuneGain:
+		t.lastRate = rate
+		t.grew = t.limit < t.max
+		if t.grew {
+			t.limit++
+			t.cond.Broadcast()
+		}
+	case t.grew:
+		t.limit-- // The last thread added did not raise throughput
+		t.grew = false
+	default:
+		t.lastRate = rate
+	}
+}
+
+// current returns the current limit.
+func (t *concurrencyTuner) current() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.limit
+}
+
+// run adjusts the limit every autotuneInterval until stop is closed.
+func (t *concurrencyTuner) run(stop <-chan struct{}) {
+	ticker := time.NewTicker(autotuneInterval)
+	defer ticker.Stop()
+
+	last := time.Now()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			t.adjust(now.Sub(last))
+			last = now
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+func TestConcurrencyTunerTune(t *testing.T) {
+	type step struct {
+		rate   float64
+		failed bool
+		want   int // Limit after the step
+	}
+	tests := []struct {
+		name  string
+		max   int
+		steps []step
+	}{
+		{
+			name:  "adds threads while throughput improves",
+			max:   10,
+			steps: []step{{rate: 100, want: 3}, {rate: 120, want: 4}, {rate: 140, want: 5}},
+		},
+		{
+			name:  "takes back a thread that did not help",
+			max:   10,
+			steps: []step{{rate: 100, want: 3}, {rate: 105, want: 2}, {rate: 105, want: 2}},
+		},
+		{
+			name:  "stops at the maximum",
+			max:   3,
+			steps: []step{{rate: 100, want: 3}, {rate: 200, want: 3}},
+		},
+		{
+			name:  "halves on failure and measures again",
+			max:   10,
+			steps: []step{{rate: 100, want: 3}, {rate: 200, want: 4}, {rate: 300, failed: true, want: 2}, {rate: 10, want: 3}},
+		},
+		{
+			name:  "never drops below one thread",
+			max:   10,
+			steps: []step{{failed: true, want: 1}, {failed: true, want: 1}},
+		},
+		{
+			name:  "idle interval keeps the limit",
+			max:   10,
+			steps: []step{{rate: 0, want: 2}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tuner := newConcurrencyTuner(tt.max)
+			for i, s := range tt.steps {
+				tuner.tune(s.rate, s.failed)
+				if got := tuner.current(); got != s.want {
+					t.Fatalf("step %d: limit = %d, want %d", i, got, s.want)
+				}
+			}
+		})
+	}
+}
+
+func TestIsLoadFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "rate limited", err: fmt.Errorf("download failed after retry: %w", &httpStatusError{StatusCode: 429}), want: true},
+		{name: "server error", err: &httpStatusError{StatusCode: 503}, want: true},
+		{name: "api rate limit", err: &api.APIError{StatusCode: 429}, want: true},
+		{name: "transport error", err: &url.Error{Op: "Get", URL: "https://cdn", Err: errors.New("connection reset")}, want: true},
+		{name: "stalled transfer", err: fmt.Errorf("resume interrupted at 10 bytes: %w", errStalled), want: true},
+		{name: "expired url", err: &httpStatusError{StatusCode: 403}},
+		{name: "unavailable track", err: &api.UnavailableError{}},
+		{name: "api not found", err: &api.APIError{StatusCode: 404}},
+		{name: "local failure", err: errors.New("failed to finalize download")},
+		{name: "cancelled", err: context.Canceled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isLoadFailure(tt.err); got != tt.want {
+				t.Errorf("isLoadFailure(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+// bitdepth.go skips releases below a minimum bit depth, for libraries that
+// should only hold hi-res audio. Depths missing from the metadata are probed.
+package engine
+
+import (
+	"fmt"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+// meetsMinBitDepth reports whether depth satisfies minimum.
+// A zero minimum or an unknown depth (0) always passes.
+func meetsMinBitDepth(depth, minimum int) bool {
+	return minimum <= 0 || depth <= 0 || depth >= minimum
+}
+
+// albumMaxBitDepth returns the highest bit depth of an album: the album's own
+// maximum, or else the highest of its tracks. Returns 0 if unknown.
+func albumMaxBitDepth(album *api.AlbumMetadata) int {
+	if album.MaximumBitDepth > 0 {
+		return album.MaximumBitDepth
+	}
+	depth := 0
+	for _, track := range album.Tracks.Items {
+		depth = max(depth, track.MaximumBitDepth)
+	}
+	return depth
+}
+
+// trackBitDepth returns the maximum bit depth of a track, probing the
+// available formats when the metadata doesn't say. Returns 0 if unknown.
+func (e *Engine) trackBitDepth(track api.TrackMetadata) int {
+	if track.MaximumBitDepth > 0 {
+		return track.MaximumBitDepth
+	}
+	return e.probeTrack(track).BitDepth
+}
+
+// filterAlbumsByBitDepth splits albums into those that may reach
+// e.MinBitDepth and those below it. Albums listed without a bit depth are
+// looked up; albums whose depth stays unknown are kept.
+func (e *Engine) filterAlbumsByBitDepth(albums []api.AlbumMetadata) (kept, below []api.AlbumMetadata) {
+	if e.MinBitDepth <= 0 {
+		return albums, nil
+	}
+
+	for _, album := range albums {
+		depth := albumMaxBitDepth(&album)
+		if depth == 0 {
+			if full, err := e.getAlbum(album.ID); err == nil {
+				depth = albumMaxBitDepth(full)
+			}
+		}
+		album.MaximumBitDepth = depth
+		if meetsMinBitDepth(depth, e.MinBitDepth) {
+			kept = append(kept, album)
+		} else {
+			below = append(below, album)
+		}
+	}
+	return kept, below
+}
+
+// reportBelowBitDepth prints the albums skipped by filterAlbumsByBitDepth.
+func (e *Engine) reportBelowBitDepth(below []api.AlbumMetadata) {
+	for _, album := range below {
+		fmt.Printf("Skipping %q (%d-bit, below the %d-bit minimum)\n", album.Title, album.MaximumBitDepth, e.MinBitDepth)
+	}
+}
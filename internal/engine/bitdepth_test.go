@@ -0,0 +1,111 @@
+package engine
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+func TestMeetsMinBitDepth(t *testing.T) {
+	tests := []struct {
+		depth, minimum int
+		want           bool
+	}{
+		{depth: 24, minimum: 24, want: true},
+		{depth: 16, minimum: 24, want: false},
+		{depth: 24, minimum: 16, want: true},
+		{depth: 16, minimum: 0, want: true},
+		{depth: 0, minimum: 24, want: true}, // Unknown depth is kept
+	}
+	for _, tt := range tests {
+		if got := meetsMinBitDepth(tt.depth, tt.minimum); got != tt.want {
+			t.Errorf("meetsMinBitDepth(%d, %d) = %v, want %v", tt.depth, tt.minimum, got, tt.want)
+		}
+	}
+}
+
+func TestFilterAlbumsByBitDepth(t *testing.T) {
+	fake := newFakeQobuz(t)
+	// Full album lookups for listings without a bit depth
+	fake.addAlbum("hires-tracks", "Hi-Res Tracks", "Artist", 100, 2).Tracks.Items[1].MaximumBitDepth = 24
+	fake.addAlbum("cd-tracks", "CD Tracks", "Artist", 200, 2)
+
+	listing := []api.AlbumMetadata{
+		{ID: "hires", Title: "Hi-Res", MaximumBitDepth: 24},
+		{ID: "cd", Title: "CD", MaximumBitDepth: 16},
+		{ID: "hires-tracks", Title: "Hi-Res Tracks"},
+		{ID: "cd-tracks", Title: "CD Tracks"},
+		{ID: "unknown", Title: "Unknown"}, // Lookup fails
+	}
+	tests := []struct {
+		name      string
+		minimum   int
+		wantKept  []string
+		wantBelow []string
+	}{
+		{name: "no minimum", wantKept: []string{"hires", "cd", "hires-tracks", "cd-tracks", "unknown"}},
+		{name: "hi-res only", minimum: 24, wantKept: []string{"hires", "hires-tracks", "unknown"}, wantBelow: []string{"cd", "cd-tracks"}},
+		{name: "cd or better", minimum: 16, wantKept: []string{"hires", "cd", "hires-tracks", "cd-tracks", "unknown"}},
+	}
+	ids := func(albums []api.AlbumMetadata) []string {
+		var out []string
+		for _, a := range albums {
+			out = append(out, a.ID)
+		}
+		return out
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := fake.engine()
+			e.MinBitDepth = tt.minimum
+			kept, below := e.filterAlbumsByBitDepth(slices.Clone(listing))
+			if got := ids(kept); !slices.Equal(got, tt.wantKept) {
+				t.Errorf("kept %v, want %v", got, tt.wantKept)
+			}
+			if got := ids(below); !slices.Equal(got, tt.wantBelow) {
+				t.Errorf("below %v, want %v", got, tt.wantBelow)
+			}
+			for _, album := range below {
+				if album.MaximumBitDepth != 16 {
+					t.Errorf("%s reported at %d bits, want 16", album.ID, album.MaximumBitDepth)
+				}
+			}
+		})
+	}
+}
+
+func TestDownloadAlbumMinBitDepth(t *testing.T) {
+	fake := newFakeQobuz(t)
+	album := fake.addAlbum("alb1", "Mixed", "Artist", 100, 3)
+	album.Tracks.Items[0].MaximumBitDepth = 24
+	album.Tracks.Items[2].MaximumBitDepth = 0 // Probed: the fake serves 16-bit files
+
+	tests := []struct {
+		name        string
+		minimum     int
+		wantSuccess int
+		wantSkipped []string
+	}{
+		{name: "no minimum", wantSuccess: 3},
+		{name: "hi-res only", minimum: 24, wantSuccess: 1, wantSkipped: []string{"Track 2", "Track 3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := fake.engine()
+			e.MinBitDepth = tt.minimum
+			result, err := e.DownloadAlbum(context.Background(), "alb1", 27, t.TempDir())
+			if err != nil {
+				t.Fatalf("DownloadAlbum: %v", err)
+			}
+			var skipped []string
+			for _, tr := range result.Skipped {
+				skipped = append(skipped, tr.Title)
+			}
+			if len(result.Success) != tt.wantSuccess || !slices.Equal(skipped, tt.wantSkipped) {
+				t.Errorf("downloaded %d and skipped %v, want %d and %v", len(result.Success), skipped, tt.wantSuccess, tt.wantSkipped)
+			}
+		})
+	}
+}
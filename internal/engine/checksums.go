@@ -0,0 +1,171 @@
+// checksums.go writes and verifies a sha256sum-compatible manifest in album folders.
+// Hashes cover the final, tagged files, so the manifest can be checked later with
+// verify-checksums or `sha256sum -c`. Every track is tagged after it is downloaded,
+// which rewrites its header, so the files are hashed once tagging is done rather
+// than by teeing the download stream, whose hash would never match the file on disk.
+package engine
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ChecksumFile is the manifest written to each album folder.
+const ChecksumFile = "checksums.sha256"
+
+// Checksum verification outcomes.
+const (
+	ChecksumOK       = "OK"
+	ChecksumMismatch = "FAILED"
+	ChecksumMissing  = "MISSING"
+)
+
+// ChecksumResult is the verification outcome for one manifest entry.
+type ChecksumResult struct {
+	Path   string `json:"path"`
+	Status string `json:"status"`
+}
+
+// hashFile returns the hex SHA-256 of the file at path.
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// readChecksumManifest parses a manifest into file name -> hash.
+// A missing manifest yields an empty map.
+func readChecksumManifest(path string) (map[string]string, error) {
+	entries := make(map[string]string)
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// "<hash>  <name>" (text mode) or "<hash> *<name>" (binary mode)
+		hash, name, ok := strings.Cut(scanner.Text(), " ")
+		if !ok || len(hash) != sha256.Size*2 {
+			continue
+		}
+		name = strings.TrimPrefix(strings.TrimPrefix(name, " "), "*")
+		entries[name] = strings.ToLower(hash)
+	}
+	return entries, scanner.Err()
+}
+
+// writeAlbumChecksums updates the manifest in the album folder with the files of
+// result. Newly downloaded tracks and the cover files in covers are rehashed; skipped tracks keep
+// their existing entry or are hashed if they have none. Entries for files that
+// no longer exist are dropped.
+func writeAlbumChecksums(result *AlbumResult, covers []string) error {
+	manifestPath := filepath.Join(result.AlbumDir, ChecksumFile)
+	entries, err := readChecksumManifest(manifestPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", ChecksumFile, err)
+	}
+
+	update := func(path string, force bool) error {
+		name := filepath.Base(path)
+		if _, ok := entries[name]; ok && !force {
+			return nil
+		}
+		hash, err := hashFile(path)
+		if err != nil {
+			return err
+		}
+		entries[name] = hash
+		return nil
+	}
+
+	for _, tr := range result.Success {
+		if err := update(tr.Path, true); err != nil {
+			return err
+		}
+	}
+	for _, tr := range result.Skipped {
+		if err := update(tr.Path, false); err != nil {
+			return err
+		}
+	}
+	for _, cover := range covers {
+		if fileExists(cover) {
+			if err := update(cover, true); err != nil {
+				return err
+			}
+		}
+	}
+
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		if fileExists(filepath.Join(result.AlbumDir, name)) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s  %s\n", entries[name], name)
+	}
+	return os.WriteFile(manifestPath, []byte(b.String()), 0644)
+}
+
+// fileExists reports whether path exists.
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// VerifyChecksums checks every file listed in dir's manifest against its hash.
+func VerifyChecksums(dir string) ([]ChecksumResult, error) {
+	manifestPath := filepath.Join(dir, ChecksumFile)
+	if !fileExists(manifestPath) {
+		return nil, fmt.Errorf("no %s in %s", ChecksumFile, dir)
+	}
+	entries, err := readChecksumManifest(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", ChecksumFile, err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := make([]ChecksumResult, 0, len(names))
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		status := ChecksumOK
+		hash, err := hashFile(path)
+		switch {
+		case os.IsNotExist(err):
+			status = ChecksumMissing
+		case err != nil || hash != entries[name]:
+			status = ChecksumMismatch
+		}
+		results = append(results, ChecksumResult{Path: path, Status: status})
+	}
+	return results, nil
+}
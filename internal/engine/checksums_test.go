@@ -0,0 +1,111 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteAlbumChecksums(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	// sha256("a"), sha256("b") and sha256("c")
+	const (
+		hashA = "ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb"
+		hashB = "3e23e8160039594a33894f6564e1b1348bbd7a0088d42c4acb73eeaed59c009d"
+		hashC = "2e7d2c03a9507ae265ecf5b5356885a53393a2029d241394997265a1a25aefc6"
+	)
+
+	fresh := write("01. New.flac", "a")
+	skipped := write("02. Old.flac", "b")
+	cover := write("cover.jpg", "c")
+	// A stale hash for the skipped track is kept; the deleted track is dropped
+	manifest := strings.Join([]string{
+		strings.Repeat("0", 64) + "  02. Old.flac",
+		strings.Repeat("1", 64) + "  03. Deleted.flac",
+	}, "\n") + "\n"
+	write(ChecksumFile, manifest)
+
+	result := &AlbumResult{
+		AlbumDir: dir,
+		Success:  []TrackResult{{Path: fresh}},
+		Skipped:  []TrackResult{{Path: skipped}},
+	}
+	if err := writeAlbumChecksums(result, []string{cover, filepath.Join(dir, "folder.jpg")}); err != nil {
+		t.Fatalf("writeAlbumChecksums() error = %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, ChecksumFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := hashA + "  01. New.flac\n" +
+		strings.Repeat("0", 64) + "  02. Old.flac\n" +
+		hashC + "  cover.jpg\n"
+	if string(got) != want {
+		t.Errorf("manifest =\n%s\nwant\n%s", got, want)
+	}
+
+	// A skipped track without an entry is hashed
+	os.WriteFile(filepath.Join(dir, ChecksumFile), nil, 0644)
+	if err := writeAlbumChecksums(result, nil); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = os.ReadFile(filepath.Join(dir, ChecksumFile))
+	if !strings.Contains(string(got), hashB+"  02. Old.flac\n") {
+		t.Errorf("manifest %q lacks the hash of the skipped track", got)
+	}
+}
+
+func TestVerifyChecksums(t *testing.T) {
+	const hashA = "ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb"
+
+	tests := []struct {
+		name     string
+		content  *string // nil leaves the file missing
+		manifest string
+		want     string
+	}{
+		{name: "intact file", content: ptr("a"), manifest: hashA + "  track.flac\n", want: ChecksumOK},
+		{name: "binary mode entry", content: ptr("a"), manifest: strings.ToUpper(hashA) + " *track.flac\n", want: ChecksumOK},
+		{name: "tampered file", content: ptr("tampered"), manifest: hashA + "  track.flac\n", want: ChecksumMismatch},
+		{name: "missing file", manifest: hashA + "  track.flac\n", want: ChecksumMissing},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.content != nil {
+				if err := os.WriteFile(filepath.Join(dir, "track.flac"), []byte(*tt.content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := os.WriteFile(filepath.Join(dir, ChecksumFile), []byte(tt.manifest), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			results, err := VerifyChecksums(dir)
+			if err != nil {
+				t.Fatalf("VerifyChecksums() error = %v", err)
+			}
+			if len(results) != 1 || results[0].Status != tt.want {
+				t.Errorf("VerifyChecksums() = %+v, want status %s", results, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyChecksumsWithoutManifest(t *testing.T) {
+	if _, err := VerifyChecksums(t.TempDir()); err == nil {
+		t.Error("VerifyChecksums() succeeded without a manifest")
+	}
+}
+
+func ptr(s string) *string { return &s }
@@ -0,0 +1,106 @@
+// cover_cache.go provides a small in-memory LRU cache for cover art.
+// Tracks sharing an album image URL reuse the cached bytes instead of refetching.
+package engine
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// defaultCoverCacheSize is the number of covers kept in memory (original covers are ~1-5 MB each).
+const defaultCoverCacheSize = 16
+
+// coverCache is a thread-safe LRU cache of cover image data keyed by image URL.
+type coverCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List               // Front = most recently used
+	entries  map[string]*list.Element // URL -> element holding *coverEntry
+}
+
+// coverEntry is a cached cover image.
+type coverEntry struct {
+	url  string
+	data []byte
+}
+
+// newCoverCache creates a cover cache holding at most capacity images.
+func newCoverCache(capacity int) *coverCache {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &coverCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Get returns the cached cover for url, marking it as recently used.
+func (c *coverCache) Get(url string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[url]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*coverEntry).data, true
+}
+
+// Put stores a cover, evicting the least recently used entry when full.
+func (c *coverCache) Put(url string, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[url]; ok {
+		elem.Value.(*coverEntry).data = data
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[url] = c.order.PushFront(&coverEntry{url: url, data: data})
+
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*coverEntry).url)
+	}
+}
+
+// Len returns the number of cached covers.
+func (c *coverCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// maxCoverPrefetchAhead bounds how many upcoming albums have their covers
+// prefetched, so prefetched covers are not evicted before they are used.
+const maxCoverPrefetchAhead = defaultCoverCacheSize / 2
+
+// prefetchCovers downloads covers into the cover cache in the background using
+// up to workers goroutines. Failures are ignored: the album download fetches
+// the cover again and reports the error.
+func (e *Engine) prefetchCovers(ctx context.Context, urls []string, workers int) {
+	jobs := make(chan string)
+	for i := 0; i < min(workers, len(urls)); i++ {
+		go func() {
+			for url := range jobs {
+				_, _ = e.downloadCover(url)
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for _, url := range urls {
+			select {
+			case jobs <- url:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
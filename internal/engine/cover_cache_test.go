@@ -0,0 +1,111 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+func TestCoverCacheLRU(t *testing.T) {
+	c := newCoverCache(2)
+	c.Put("a", []byte("A"))
+	c.Put("b", []byte("B"))
+	c.Get("a") // "b" is now the least recently used
+	c.Put("c", []byte("C"))
+
+	tests := []struct {
+		url    string
+		wantOK bool
+	}{
+		{url: "a", wantOK: true},
+		{url: "b", wantOK: false},
+		{url: "c", wantOK: true},
+	}
+	for _, tt := range tests {
+		if _, ok := c.Get(tt.url); ok != tt.wantOK {
+			t.Errorf("Get(%q) ok = %v, want %v", tt.url, ok, tt.wantOK)
+		}
+	}
+	if c.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", c.Len())
+	}
+}
+
+func TestDownloadCoverRepeatedURLHitsCache(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte("jpeg"))
+	}))
+	defer srv.Close()
+
+	e := New(api.NewClient("", ""))
+	url := srv.URL + "/images/covers/ab/cd/album_600.jpg"
+	for i := range 3 {
+		data, err := e.downloadCover(url)
+		if err != nil || string(data) != "jpeg" {
+			t.Fatalf("downloadCover() #%d = %q, %v", i, data, err)
+		}
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("server saw %d requests, want 1", n)
+	}
+}
+
+func TestPrefetchCovers(t *testing.T) {
+	tests := []struct {
+		name     string
+		workers  int
+		wantPeak int32
+	}{
+		{name: "serial", workers: 1, wantPeak: 1},
+		{name: "two workers", workers: 2, wantPeak: 2},
+		{name: "more workers than covers", workers: 8, wantPeak: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var counter peakCounter
+			var requests atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				counter.run(50 * time.Millisecond)
+				w.Write([]byte(r.URL.Path))
+			}))
+			defer srv.Close()
+
+			e := New(api.NewClient("", ""))
+			var urls []string
+			for i := range 4 {
+				urls = append(urls, fmt.Sprintf("%s/covers/album%d.jpg", srv.URL, i))
+			}
+			e.prefetchCovers(context.Background(), urls, tt.workers)
+
+			deadline := time.Now().Add(5 * time.Second)
+			for e.covers.Len() < len(urls) {
+				if time.Now().After(deadline) {
+					t.Fatalf("%d of %d covers cached", e.covers.Len(), len(urls))
+				}
+				time.Sleep(5 * time.Millisecond)
+			}
+			if peak := counter.peak.Load(); peak != tt.wantPeak {
+				t.Errorf("peak concurrent cover requests = %d, want %d", peak, tt.wantPeak)
+			}
+
+			// The album downloads are served from the cache
+			for _, url := range urls {
+				if _, err := e.downloadCover(url); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if n := requests.Load(); n != int32(len(urls)) {
+				t.Errorf("server saw %d requests, want %d", n, len(urls))
+			}
+		})
+	}
+}
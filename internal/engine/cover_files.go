@@ -0,0 +1,100 @@
+// cover_files.go saves the album cover next to the tracks. Players look for
+// different names (cover.jpg, folder.jpg, front.jpg, AlbumArt.jpg), so the
+// cover can be saved under several; extra names are hard links to the first
+// file where the file system allows it, and copies otherwise.
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultCoverFilename is the cover file name used when none is configured.
+const DefaultCoverFilename = "cover.jpg"
+
+// CheckCoverFilenames reports an error if a cover file name is empty, contains
+// a path separator or characters not allowed in file names, or is repeated.
+func CheckCoverFilenames(names []string) error {
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		switch {
+		case strings.TrimSpace(name) == "":
+			return fmt.Errorf("empty cover file name")
+		case name == "." || name == ".." || illegalCharsRegex.MatchString(name):
+			return fmt.Errorf("invalid cover file name %q", name)
+		case seen[strings.ToLower(name)]:
+			return fmt.Errorf("duplicate cover file name %q", name)
+		}
+		seen[strings.ToLower(name)] = true
+	}
+	return nil
+}
+
+// coverFilenames returns the names the cover is saved under, the first being
+// the one read back when comparing covers.
+func (e *Engine) coverFilenames() []string {
+	if len(e.CoverFilenames) == 0 {
+		return []string{DefaultCoverFilename}
+	}
+	return e.CoverFilenames
+}
+
+// coverPath returns the path of the primary cover file in dir.
+func (e *Engine) coverPath(dir string) string {
+	return filepath.Join(dir, e.coverFilenames()[0])
+}
+
+// coverPaths returns the paths of every cover file in dir.
+func (e *Engine) coverPaths(dir string) []string {
+	names := e.coverFilenames()
+	paths := make([]string, len(names))
+	for i, name := range names {
+		paths[i] = filepath.Join(dir, name)
+	}
+	return paths
+}
+
+// saveCoverFile writes data to the primary cover file in dir and links or
+// copies it to the other configured names.
+func (e *Engine) saveCoverFile(dir string, data []byte) error {
+	paths := e.coverPaths(dir)
+	if err := os.WriteFile(paths[0], data, 0644); err != nil {
+		return err
+	}
+	for _, path := range paths[1:] {
+		if err := linkOrCopy(paths[0], path, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// linkOrCopy replaces dst with a hard link to src, falling back to writing
+// data when links are not supported (FAT/exFAT drives, some network shares).
+func linkOrCopy(src, dst string, data []byte) error {
+	if same, err := sameFile(src, dst); err == nil && same {
+		return nil // Already linked, updated through src
+	}
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if os.Link(src, dst) == nil {
+		return nil
+	}
+	return os.WriteFile(dst, data, 0644)
+}
+
+// sameFile reports whether a and b are the same file on disk.
+func sameFile(a, b string) (bool, error) {
+	sa, err := os.Stat(a)
+	if err != nil {
+		return false, err
+	}
+	sb, err := os.Stat(b)
+	if err != nil {
+		return false, err
+	}
+	return os.SameFile(sa, sb), nil
+}
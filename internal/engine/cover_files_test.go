@@ -0,0 +1,124 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckCoverFilenames(t *testing.T) {
+	tests := []struct {
+		names   []string
+		wantErr string
+	}{
+		{names: nil},
+		{names: []string{"cover.jpg", "folder.jpg", "front.jpg", "AlbumArt.jpg"}},
+		{names: []string{"cover.jpg", " "}, wantErr: "empty"},
+		{names: []string{"art/cover.jpg"}, wantErr: "invalid"},
+		{names: []string{`art\cover.jpg`}, wantErr: "invalid"},
+		{names: []string{".."}, wantErr: "invalid"},
+		{names: []string{"cover?.jpg"}, wantErr: "invalid"},
+		{names: []string{"Folder.jpg", "folder.JPG"}, wantErr: "duplicate"},
+	}
+	for _, tt := range tests {
+		err := CheckCoverFilenames(tt.names)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("CheckCoverFilenames(%q) = %v, want nil", tt.names, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("CheckCoverFilenames(%q) = %v, want %q", tt.names, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSaveCoverFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		names    []string
+		existing map[string]string // Files in the folder before saving
+	}{
+		{name: "default", names: nil},
+		{name: "several names", names: []string{"cover.jpg", "folder.jpg", "AlbumArt.jpg"}},
+		{name: "replaces stale files", names: []string{"front.jpg", "folder.jpg"}, existing: map[string]string{"front.jpg": "old", "folder.jpg": "older"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, data := range tt.existing {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			e := &Engine{CoverFilenames: tt.names}
+
+			// Saving twice checks that every name follows the updated cover
+			for _, data := range [][]byte{[]byte("first cover"), []byte("second cover")} {
+				if err := e.saveCoverFile(dir, data); err != nil {
+					t.Fatalf("saveCoverFile: %v", err)
+				}
+				for _, path := range e.coverPaths(dir) {
+					if got, err := os.ReadFile(path); err != nil || !bytes.Equal(got, data) {
+						t.Errorf("%s = %q, %v; want %q", filepath.Base(path), got, err, data)
+					}
+				}
+			}
+			if len(tt.names) == 0 {
+				if _, err := os.Stat(filepath.Join(dir, DefaultCoverFilename)); err != nil {
+					t.Errorf("default cover file missing: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestDownloadAlbumCoverFilenames(t *testing.T) {
+	tests := []struct {
+		name  string
+		names []string
+		want  []string
+	}{
+		{name: "default", want: []string{"cover.jpg"}},
+		{name: "per player", names: []string{"cover.jpg", "folder.jpg", "front.jpg", "AlbumArt.jpg"}, want: []string{"cover.jpg", "folder.jpg", "front.jpg", "AlbumArt.jpg"}},
+		{name: "without cover.jpg", names: []string{"folder.jpg"}, want: []string{"folder.jpg"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeQobuz(t)
+			fake.addAlbum("alb1", "Album", "Band", 100, 1)
+			e := fake.engine()
+			e.CoverFilenames = tt.names
+
+			result, err := e.DownloadAlbum(context.Background(), "alb1", 6, t.TempDir())
+			if err != nil {
+				t.Fatalf("DownloadAlbum: %v", err)
+			}
+			var images []string
+			entries, err := os.ReadDir(result.AlbumDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, entry := range entries {
+				if filepath.Ext(entry.Name()) == ".jpg" {
+					images = append(images, entry.Name())
+				}
+			}
+			for _, name := range tt.want {
+				if got, err := os.ReadFile(filepath.Join(result.AlbumDir, name)); err != nil || !bytes.Equal(got, fake.cover) {
+					t.Errorf("%s does not hold the cover (%v)", name, err)
+				}
+			}
+			if len(images) != len(tt.want) {
+				t.Errorf("images in the album folder = %v, want %v", images, tt.want)
+			}
+			if n := fake.count("/covers/alb1_org.jpg") + fake.count("/covers/alb1_600.jpg"); n != 1 {
+				t.Errorf("cover downloaded %d times, want once for every name", n)
+			}
+		})
+	}
+}
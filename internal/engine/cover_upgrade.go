@@ -0,0 +1,274 @@
+// cover_upgrade.go replaces low resolution cover art embedded in downloaded
+// albums with the original size cover, leaving every other tag untouched.
+package engine
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bogem/id3v2/v2"
+	"github.com/go-flac/go-flac"
+)
+
+// CoverUpgrade reports the cover upgrade of one album folder.
+type CoverUpgrade struct {
+	Dir       string        `json:"dir"`
+	AlbumID   string        `json:"album_id"`
+	Title     string        `json:"title"`
+	Width     int           `json:"width"`      // Width of the original cover
+	Height    int           `json:"height"`     // Height of the original cover
+	Outdated  []string      `json:"outdated"`   // Files whose embedded cover is smaller or missing
+	CoverFile bool          `json:"cover_file"` // cover.jpg is smaller or missing
+	Upgraded  int           `json:"upgraded"`   // Files rewritten (0 in dry run)
+	Skipped   []SkippedFile `json:"skipped"`
+}
+
+// UpgradeCovers fetches the original size cover of the album in dir and, when
+// apply is true, embeds it in every file whose front cover is smaller, replacing
+// the old picture rather than adding a second one, and updates cover.jpg.
+// Files already at the original resolution are left alone.
+func (e *Engine) UpgradeCovers(dir string, apply bool) (*CoverUpgrade, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CoverUpgrade{Dir: dir}
+	files := make(map[string]*FileTags)
+	var paths []string
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if entry.IsDir() || !isAudioFile(path) {
+			continue
+		}
+		tags, err := ReadTags(path)
+		if err != nil {
+			result.Skipped = append(result.Skipped, SkippedFile{Path: path, Reason: err.Error()})
+			continue
+		}
+		files[path] = tags
+		paths = append(paths, path)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no readable audio files in %s", dir)
+	}
+
+	album, err := e.lookupAlbum(dir, paths, files)
+	if err != nil {
+		return nil, err
+	}
+	result.AlbumID = album.ID
+	result.Title = album.Title
+	if album.Image.Large == "" {
+		return nil, fmt.Errorf("album %s has no cover", album.ID)
+	}
+
+	coverData, err := e.downloadCover(album.Image.Large)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download cover: %w", err)
+	}
+	result.Width, result.Height = imageSize(coverData)
+	if result.Width == 0 {
+		return nil, fmt.Errorf("failed to decode the downloaded cover")
+	}
+	smaller := func(w, h int) bool {
+		return w < result.Width || h < result.Height
+	}
+
+	for _, path := range paths {
+		w, h, err := embeddedCoverSize(path)
+		if err != nil {
+			result.Skipped = append(result.Skipped, SkippedFile{Path: path, Reason: err.Error()})
+			continue
+		}
+		if smaller(w, h) {
+			result.Outdated = append(result.Outdated, path)
+		}
+	}
+	if data, err := os.ReadFile(e.coverPath(dir)); err != nil || smaller(imageSize(data)) {
+		result.CoverFile = true
+	}
+
+	if !apply {
+		return result, nil
+	}
+	for _, path := range result.Outdated {
+		if err := e.Tagger.ReplaceCover(path, coverData); err != nil {
+			result.Skipped = append(result.Skipped, SkippedFile{Path: path, Reason: err.Error()})
+			continue
+		}
+		result.Upgraded++
+	}
+	if result.CoverFile {
+		if err := e.saveCoverFile(dir, coverData); err != nil {
+			return result, fmt.Errorf("failed to save cover: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// imageSize returns the dimensions of an encoded image, or zeros if it cannot be decoded.
+func imageSize(data []byte) (int, int) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, 0
+	}
+	return cfg.Width, cfg.Height
+}
+
+// embeddedCoverSize returns the dimensions of the largest front cover embedded
+// in an audio file, or zeros if it has none.
+func embeddedCoverSize(path string) (int, int, error) {
+	var images [][]byte
+	switch containerOf(path) {
+	case ".mp3":
+		tag, err := id3v2.Open(path, id3v2.Options{Parse: true, ParseFrames: []string{"Attached picture"}})
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to open mp3 file: %w", err)
+		}
+		defer tag.Close()
+		for _, f := range tag.GetFrames(tag.CommonID("Attached picture")) {
+			if pic, ok := f.(id3v2.PictureFrame); ok && pic.PictureType == id3v2.PTFrontCover {
+				images = append(images, pic.Picture)
+			}
+		}
+	case ".ogg":
+		cmts, err := readOggVorbisComment(path)
+		if err != nil {
+			return 0, 0, err
+		}
+		for _, v := range cmts.GetAll(oggPictureKey) {
+			if pic, err := decodeOggPicture(v); err == nil && pic.PictureType == PictureTypeCoverFront {
+				images = append(images, pic.ImageData)
+			}
+		}
+	default:
+		f, err := os.Open(path)
+		if err != nil {
+			return 0, 0, err
+		}
+		defer f.Close()
+		meta, err := flac.ParseMetadata(f)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to parse flac file: %w", err)
+		}
+		for _, block := range meta.Meta {
+			if block.Type != flac.Picture {
+				continue
+			}
+			if pic, err := ParsePicture(block.Data); err == nil && pic.PictureType == PictureTypeCoverFront {
+				images = append(images, pic.ImageData)
+			}
+		}
+	}
+
+	var width, height int
+	for _, data := range images {
+		if w, h := imageSize(data); w*h > width*height {
+			width, height = w, h
+		}
+	}
+	return width, height, nil
+}
+
+// ReplaceCover swaps the embedded front cover of an audio file for coverData,
+// removing every existing front cover and keeping all other tags and pictures.
+func (t *Tagger) ReplaceCover(filePath string, coverData []byte) error {
+	pic := NewPicture()
+	pic.Description = "Cover"
+	pic.ImageData = coverData
+	pic.detectDimensions()
+
+	switch containerOf(filePath) {
+	case ".mp3":
+		tag, err := id3v2.Open(filePath, id3v2.Options{Parse: true})
+		if err != nil {
+			return fmt.Errorf("failed to open mp3 file: %w", err)
+		}
+		defer tag.Close()
+
+		frames := tag.GetFrames(tag.CommonID("Attached picture"))
+		tag.DeleteFrames(tag.CommonID("Attached picture"))
+		for _, f := range frames {
+			if old, ok := f.(id3v2.PictureFrame); ok && old.PictureType != id3v2.PTFrontCover {
+				tag.AddAttachedPicture(old)
+			}
+		}
+		tag.AddAttachedPicture(id3v2.PictureFrame{
+			Encoding:    id3v2.EncodingUTF8,
+			MimeType:    pic.MIME,
+			PictureType: id3v2.PTFrontCover,
+			Description: pic.Description,
+			Picture:     coverData,
+		})
+		if err := tag.Save(); err != nil {
+			return fmt.Errorf("failed to save mp3 tags: %w", err)
+		}
+		return nil
+
+	case ".ogg":
+		err := writeOggVorbisComment(filePath, func(cmts *VorbisComment) {
+			cmts.Comments = slices.DeleteFunc(cmts.Comments, func(c string) bool {
+				k, v, _ := strings.Cut(c, "=")
+				if !strings.EqualFold(k, oggPictureKey) {
+					return false
+				}
+				old, err := decodeOggPicture(v)
+				return err == nil && old.PictureType == PictureTypeCoverFront
+			})
+			cmts.Add(oggPictureKey, base64.StdEncoding.EncodeToString(pic.Marshal()))
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save tags: %w", err)
+		}
+		return nil
+
+	default:
+		f, err := flac.ParseFile(filePath)
+		if err != nil {
+			return fmt.Errorf("failed to parse flac file: %w", err)
+		}
+		oldSize := flacMetadataSize(f.Meta)
+		f.Meta = slices.DeleteFunc(f.Meta, func(block *flac.MetaDataBlock) bool {
+			if block.Type != flac.Picture {
+				return false
+			}
+			old, err := ParsePicture(block.Data)
+			return err == nil && old.PictureType == PictureTypeCoverFront
+		})
+		f.Meta = append(f.Meta, &flac.MetaDataBlock{Type: flac.Picture, Data: pic.Marshal()})
+		if err := t.saveFlac(filePath, f, oldSize); err != nil {
+			return fmt.Errorf("failed to save tags: %w", err)
+		}
+		return nil
+	}
+}
+
+// containerOf returns the tagging container of an audio file (".flac", ".mp3"
+// or ".ogg" for Opus and Vorbis), from its magic bytes or else its extension.
+func containerOf(path string) string {
+	if format, err := DetectFormat(path); err == nil && format != FormatUnknown {
+		return string(format)
+	}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".opus":
+		return ".ogg"
+	default:
+		return ext
+	}
+}
+
+// decodeOggPicture decodes a METADATA_BLOCK_PICTURE comment value.
+func decodeOggPicture(value string) (*Picture, error) {
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePicture(data)
+}
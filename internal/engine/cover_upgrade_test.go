@@ -0,0 +1,174 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+
+	"github.com/bogem/id3v2/v2"
+)
+
+// embeddedPictures returns the pictures embedded in an audio file in order.
+func embeddedPictures(t *testing.T, path string) []*Picture {
+	t.Helper()
+	switch filepath.Ext(path) {
+	case ".mp3":
+		tag, err := id3v2.Open(path, id3v2.Options{Parse: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tag.Close()
+		var pictures []*Picture
+		for _, f := range tag.GetFrames(tag.CommonID("Attached picture")) {
+			if pic, ok := f.(id3v2.PictureFrame); ok {
+				pictures = append(pictures, &Picture{PictureType: uint32(pic.PictureType), ImageData: pic.Picture})
+			}
+		}
+		return pictures
+	case ".opus":
+		cmts, err := readOggVorbisComment(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var pictures []*Picture
+		for _, v := range cmts.GetAll(oggPictureKey) {
+			pic, err := decodeOggPicture(v)
+			if err != nil {
+				t.Fatalf("decodeOggPicture: %v", err)
+			}
+			pictures = append(pictures, pic)
+		}
+		return pictures
+	default:
+		return flacPictures(t, path)
+	}
+}
+
+func TestReplaceCover(t *testing.T) {
+	oldCover := testJPEG(t, 8, 8)
+	newCover := testJPEG(t, 32, 32)
+	back := testJPEG(t, 6, 4)
+
+	tests := []struct {
+		name string
+		file string
+		data []byte
+	}{
+		{name: "flac", file: "track.flac", data: buildTestFLAC(2, 64)},
+		{name: "mp3", file: "track.mp3", data: testMP3},
+		{name: "opus", file: "track.opus", data: buildTestOgg(true, []byte("audio"))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, tt.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			album := &api.AlbumMetadata{Title: "Album"}
+			track := &api.TrackMetadata{Title: "Track", Album: album}
+			tagger := NewTagger()
+			if err := tagger.WriteTags(path, track, album, oldCover, newExtraPicture(back, PictureTypeCoverBack, "Back Cover")); err != nil {
+				t.Fatalf("WriteTags: %v", err)
+			}
+
+			// Replacing twice must still leave a single front cover
+			for range 2 {
+				if err := tagger.ReplaceCover(path, newCover); err != nil {
+					t.Fatalf("ReplaceCover: %v", err)
+				}
+			}
+
+			var fronts, backs int
+			for _, pic := range embeddedPictures(t, path) {
+				switch pic.PictureType {
+				case PictureTypeCoverFront:
+					fronts++
+					if !bytes.Equal(pic.ImageData, newCover) {
+						t.Error("front cover is not the replacement")
+					}
+				case PictureTypeCoverBack:
+					backs++
+					if !bytes.Equal(pic.ImageData, back) {
+						t.Error("back cover changed")
+					}
+				}
+			}
+			if fronts != 1 || backs != 1 {
+				t.Errorf("got %d front and %d back covers, want 1 and 1", fronts, backs)
+			}
+			tags, err := ReadTags(path)
+			if err != nil {
+				t.Fatalf("ReadTags: %v", err)
+			}
+			if tags.Title != "Track" || tags.Album != "Album" {
+				t.Errorf("tags changed: title %q, album %q", tags.Title, tags.Album)
+			}
+		})
+	}
+}
+
+func TestUpgradeCovers(t *testing.T) {
+	fake := newFakeQobuz(t)
+	fake.addAlbum("alb1", "Album", "Band", 100, 2)
+	result, err := fake.engine().DownloadAlbum(context.Background(), "alb1", 6, t.TempDir())
+	if err != nil || len(result.Success) != 2 {
+		t.Fatalf("DownloadAlbum() = %v, %v", result, err)
+	}
+	dir := result.AlbumDir
+	smallCover := fake.cover
+
+	// The catalog now has a larger original cover
+	bigCover := testJPEG(t, 32, 32)
+	fake.covers["/covers/alb1_org.jpg"] = bigCover
+	e := fake.engine() // Without the covers cached by the download
+
+	tests := []struct {
+		name         string
+		apply        bool
+		wantOutdated int
+		wantCoverJPG bool
+		wantUpgraded int
+		wantEmbedded []byte // Front cover of every file afterwards
+	}{
+		{name: "dry run", wantOutdated: 2, wantCoverJPG: true, wantEmbedded: smallCover},
+		{name: "apply", apply: true, wantOutdated: 2, wantCoverJPG: true, wantUpgraded: 2, wantEmbedded: bigCover},
+		{name: "already upgraded", apply: true, wantEmbedded: bigCover},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upgrade, err := e.UpgradeCovers(dir, tt.apply)
+			if err != nil {
+				t.Fatalf("UpgradeCovers: %v", err)
+			}
+			if upgrade.AlbumID != "alb1" || upgrade.Width != 32 || upgrade.Height != 32 {
+				t.Errorf("album %q with cover %dx%d, want alb1 with 32x32", upgrade.AlbumID, upgrade.Width, upgrade.Height)
+			}
+			if len(upgrade.Outdated) != tt.wantOutdated || upgrade.CoverFile != tt.wantCoverJPG || upgrade.Upgraded != tt.wantUpgraded {
+				t.Errorf("outdated %d, cover.jpg %v, upgraded %d; want %d, %v, %d",
+					len(upgrade.Outdated), upgrade.CoverFile, upgrade.Upgraded, tt.wantOutdated, tt.wantCoverJPG, tt.wantUpgraded)
+			}
+			if len(upgrade.Skipped) != 0 {
+				t.Errorf("skipped files: %v", upgrade.Skipped)
+			}
+			for _, tr := range result.Success {
+				if !bytes.Equal(frontCover(t, tr.Path), tt.wantEmbedded) {
+					t.Errorf("%s has the wrong front cover", filepath.Base(tr.Path))
+				}
+				if n := len(embeddedPictures(t, tr.Path)); n != 1 {
+					t.Errorf("%s has %d pictures, want 1", filepath.Base(tr.Path), n)
+				}
+			}
+			coverFile, err := os.ReadFile(e.coverPath(dir))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(coverFile, tt.wantEmbedded) {
+				t.Error("cover.jpg does not match the embedded cover")
+			}
+		})
+	}
+}
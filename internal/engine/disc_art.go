@@ -0,0 +1,50 @@
+// disc_art.go handles per-disc artwork of multi-disc box sets. Discs without
+// their own image fall back to the album cover.
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+// fetchDiscCovers downloads the artwork of every disc that has its own image,
+// saves it as discN.jpg in albumDir and returns the images keyed by disc number.
+// Images identical to the album cover URL and failed downloads are skipped.
+func (e *Engine) fetchDiscCovers(album *api.AlbumMetadata, albumDir string) map[int][]byte {
+	if len(album.Media) < 2 {
+		return nil
+	}
+
+	covers := make(map[int][]byte)
+	for _, medium := range album.Media {
+		url := medium.Image.Large
+		if url == "" || url == album.Image.Large || medium.Number < 1 {
+			continue
+		}
+		data, err := e.downloadCover(url)
+		if err != nil {
+			fmt.Printf("Warning: failed to download disc %d artwork: %v\n", medium.Number, err)
+			continue
+		}
+		covers[medium.Number] = e.embeddedCover(data)
+		discPath := filepath.Join(albumDir, fmt.Sprintf("disc%d.jpg", medium.Number))
+		if err := os.WriteFile(discPath, data, 0644); err != nil {
+			fmt.Printf("Warning: failed to save %s: %v\n", filepath.Base(discPath), err)
+			continue
+		}
+		e.applyReleaseMtime(album, discPath)
+	}
+	return covers
+}
+
+// discCover returns the artwork to embed in a track of the given disc:
+// the disc's own image if there is one, otherwise the album cover.
+func discCover(discCovers map[int][]byte, mediaNumber int, albumCover []byte) []byte {
+	if data, ok := discCovers[mediaNumber]; ok {
+		return data
+	}
+	return albumCover
+}
@@ -0,0 +1,81 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+func TestDownloadAlbumDiscArt(t *testing.T) {
+	tests := []struct {
+		name          string
+		discArt       bool
+		wantDiscFiles []string
+	}{
+		{name: "disc art", discArt: true, wantDiscFiles: []string{"disc1.jpg"}},
+		{name: "album cover only"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeQobuz(t)
+			discImage := testJPEG(t, 12, 12)
+			fake.covers["/covers/disc1_org.jpg"] = discImage
+
+			album := fake.addAlbum("box", "Box Set", "Band", 100, 3)
+			album.MediaCount = 3
+			for i := range album.Tracks.Items {
+				album.Tracks.Items[i].MediaNumber = i + 1
+				album.Tracks.Items[i].TrackNumber = 1
+			}
+			// Disc 1 has its own image, disc 2 repeats the album cover, disc 3 has none
+			album.Media = make([]api.Medium, 3)
+			for i := range album.Media {
+				album.Media[i].Number = i + 1
+			}
+			album.Media[0].Image.Large = fake.srv.URL + "/covers/disc1_600.jpg"
+			album.Media[1].Image.Large = album.Image.Large
+
+			e := fake.engine()
+			e.DiscArt = tt.discArt
+			outputDir := t.TempDir()
+			result, err := e.DownloadAlbum(context.Background(), "box", 6, outputDir)
+			if err != nil {
+				t.Fatalf("DownloadAlbum: %v", err)
+			}
+			if len(result.Success) != 3 {
+				t.Fatalf("%d tracks downloaded, want 3", len(result.Success))
+			}
+
+			for _, tr := range result.Success {
+				tags, err := ReadTags(tr.Path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				want := fake.cover
+				if tt.discArt && tags.DiscNumber == 1 {
+					want = discImage
+				}
+				if got := frontCover(t, tr.Path); !bytes.Equal(got, want) {
+					t.Errorf("disc %d track embeds a %d byte cover, want %d bytes", tags.DiscNumber, len(got), len(want))
+				}
+			}
+
+			plan, err := e.PlanAlbum("box", outputDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var discFiles []string
+			matches, _ := filepath.Glob(filepath.Join(plan.AlbumDir, "disc*.jpg"))
+			for _, m := range matches {
+				discFiles = append(discFiles, filepath.Base(m))
+			}
+			if !slices.Equal(discFiles, tt.wantDiscFiles) {
+				t.Errorf("disc images saved = %v, want %v", discFiles, tt.wantDiscFiles)
+			}
+		})
+	}
+}
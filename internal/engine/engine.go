@@ -5,14 +5,17 @@ package engine
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/imroc/req/v3"
@@ -25,7 +28,59 @@ import (
 type Engine struct {
 	Client      *api.Client
 	Tagger      *Tagger
-	Concurrency int // Number of concurrent downloads (default: 3)
+	Concurrency int           // Number of concurrent downloads per album (default: 3)
+	Since       time.Time     // Only download artist albums released on or after this date (zero = all)
+	MinBitDepth int           // Skip artist albums and album tracks below this bit depth (0 = no minimum)
+	AlbumDelay  time.Duration // Pause between the albums of artist and batch runs (0 = none)
+
+	MaxPathLength int           // Maximum output path length (0 = platform default, MAX_PATH on Windows)
+	LongPaths     bool          // Use the Windows \\?\ long-path prefix instead of shortening names
+	ForceExt      string        // Output file extension override (e.g. ".mp3"); empty = match delivered format
+	QuickVerify   bool          // Check FLAC downloads for truncation and retry on mismatch
+	IfExists      string        // Strategy for existing output files (IfExists*; empty = skip)
+	EmbedExtraArt bool          // Embed the back cover and image booklet pages besides the front cover
+	DiscArt       bool          // Save per-disc artwork (disc1.jpg, ...) and embed it in that disc's tracks
+	SquareCover   bool          // Center-crop non-square covers before embedding (cover.jpg keeps the original)
+	IdleTimeout   time.Duration // Abort a download receiving no data for this long (0 = disabled)
+
+	GlobalConcurrency   int  // Maximum simultaneous downloads across all albums (0 = unlimited)
+	WriteChecksums      bool // Write a checksums.sha256 manifest of the final, tagged files in each album folder
+	MatchByTags         bool // Recognize existing tracks by embedded ISRC/title tags regardless of file name
+	GroupByInitial      bool // Place album folders under the album artist's initial (A/, B/, #/)
+	ParallelCovers      int  // Covers of upcoming artist albums prefetched concurrently (0 = disabled)
+	MetadataWorkers     int  // Artist album metadata fetched concurrently ahead of the downloads (0 = disabled)
+	FailFast            bool // Stop artist, playlist and queue runs at the first failed item
+	KeepGoing           bool // Log tagging failures to .tag-errors.log in the album folder for later fixing
+	ConnectionsPerFile  int  // Parallel Range connections per track file (0 or 1 = single connection)
+	APIConcurrency      int  // Maximum simultaneous track URL and album metadata requests (0 = unlimited)
+	SetReleaseDateMtime bool // Set downloaded files' modification time to the original release date
+	ReuseAlbumDirs      bool // Download into the existing folder whose .album-id marker matches the album
+	IgnoreSyncState     bool // Re-check artist albums that .sync-state.json marks as completed
+	Preview             bool // Download 30-second preview clips instead of full tracks
+	VerifyTags          bool // Read tags back after writing them and mark tracks whose tags didn't stick
+	TrackSidecars       bool // Write a JSON metadata sidecar named like each downloaded audio file
+	AutoConcurrency     bool // Tune the per-album download threads to the observed throughput, up to Concurrency
+
+	OnProgressEvent func(ProgressEvent) // Receives per-track progress (nil = none); called from worker goroutines
+
+	SingleTrackTemplate string // File name template of single tracks, may contain folders (empty = DefaultSingleTrackTemplate)
+	AlbumTrackTemplate  string // File name template of album tracks (empty = DefaultAlbumTrackTemplate)
+
+	CoverFilenames []string // Names the album cover is saved under (empty = DefaultCoverFilename)
+
+	covers *coverCache // Recently downloaded covers keyed by image URL
+	probes *probeCache // Available quality per track ID
+
+	albumFetches sync.Map      // Prefetched album metadata (*albumFetch) keyed by album ID
+	albumDirs    albumDirIndex // Marked album folders found under each output directory
+
+	subscriptionOnce sync.Once     // Guards the one-time user info fetch
+	subscription     *api.UserInfo // Account details, nil if unavailable
+	warnOnce         sync.Once     // Guards the one-time subscription quality warning
+	globalOnce       sync.Once     // Guards creation of globalSem
+	globalSem        chan struct{} // Slots shared by all downloads when GlobalConcurrency > 0
+	apiOnce          sync.Once     // Guards creation of apiSem
+	apiSem           chan struct{} // Slots shared by API requests when APIConcurrency > 0
 }
 
 // New creates a new Engine instance with the given API client.
@@ -34,6 +89,11 @@ func New(client *api.Client) *Engine {
 		Client:      client,
 		Tagger:      NewTagger(),
 		Concurrency: 3, // Default concurrency
+		covers:      newCoverCache(defaultCoverCacheSize),
+		probes:      newProbeCache(),
+		IdleTimeout: defaultIdleTimeout,
+
+		ReuseAlbumDirs: true,
 	}
 }
 
@@ -79,12 +139,53 @@ func getFileExtensionFromMimeType(mimeType string) string {
 	}
 }
 
+// containerExtensions lists the file extensions compatible with each delivered container.
+var containerExtensions = map[string][]string{
+	".flac": {".flac", ".fla"},
+	".mp3":  {".mp3"},
+}
+
+// normalizeExt lowercases ext and ensures it has a leading dot.
+func normalizeExt(ext string) string {
+	ext = strings.ToLower(strings.TrimSpace(ext))
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
+
+// resolveExtension decides the output file extension and the container format used
+// for tagging. A forced extension always names the file, but tagging follows the
+// delivered container when the two disagree, since e.g. writing ID3 frames into a
+// FLAC stream would corrupt it. mismatch reports such incompatible overrides.
+func resolveExtension(mimeType, forced string) (ext, container string, mismatch bool) {
+	container = getFileExtensionFromMimeType(mimeType)
+	forced = normalizeExt(forced)
+	if forced == "" {
+		return container, container, false
+	}
+	for _, compatible := range containerExtensions[container] {
+		if forced == compatible {
+			return forced, container, false
+		}
+	}
+	return forced, container, true
+}
+
+// outputExtension resolves the extension for a delivered MIME type using the engine's ForceExt.
+func (e *Engine) outputExtension(mimeType string) (ext, container string, mismatch bool) {
+	return resolveExtension(mimeType, e.ForceExt)
+}
+
 // trackTask represents a single track download task.
 type trackTask struct {
 	Track     api.TrackMetadata
 	TrackPath string
 	FileName  string
 	Index     int
+	Existing  string // Existing file being replaced or resumed
+	Resume    bool   // Continue the existing file instead of downloading from scratch
+	Partial   string // Leftover .part file of an interrupted download, resumed if the format matches
 }
 
 // TrackStatus represents the download status of a track.
@@ -99,9 +200,12 @@ const (
 
 // trackState holds the current state of a track for display.
 type trackState struct {
-	FileName string
-	Status   TrackStatus
-	Progress int // 0-100
+	FileName      string
+	Status        TrackStatus
+	Progress      int    // 0-100
+	Received      int64  // Bytes downloaded, shown instead of Progress when Indeterminate
+	Indeterminate bool   // The server sent no Content-Length, so no percentage is known
+	Reason        string // Failure category of failed tracks
 }
 
 // displayConfig holds display configuration for cross-platform compatibility.
@@ -240,6 +344,29 @@ func makeProgressBar(percent int, width int) string {
 	return "[" + strings.Repeat("#", filled) + strings.Repeat("-", empty) + "]"
 }
 
+// makeActivityBar creates a progress bar with a marker bouncing across it,
+// for downloads whose size is unknown.
+func makeActivityBar(frame, width int) string {
+	const marker = "<=>"
+	span := max(width-len(marker), 1)
+	pos := frame % (2 * span)
+	if pos > span {
+		pos = 2*span - pos
+	}
+	pos = min(pos, width-len(marker))
+	return "[" + strings.Repeat("-", pos) + marker + strings.Repeat("-", width-len(marker)-pos) + "]"
+}
+
+// activityFrame returns the current animation frame of activity bars.
+func activityFrame() int {
+	return int(time.Now().UnixMilli() / 150)
+}
+
+// formatMegabytes formats a byte count as megabytes with one decimal ("12.3 MB").
+func formatMegabytes(n int64) string {
+	return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+}
+
 // displayState manages the terminal display state.
 type displayState struct {
 	buffer    bytes.Buffer
@@ -302,7 +429,8 @@ func (d *displayState) renderFinal(content string) {
 }
 
 // buildThreadLine builds a single thread status line with fixed width.
-func buildThreadLine(workerID int, songName string, progress int, isWorking bool, width int) string {
+// Tracks of unknown size show an activity bar and the megabytes received.
+func buildThreadLine(workerID int, state trackState, isWorking bool, width int) string {
 	// Layout: "  Thread N: " (fixed 12) + songName (variable) + " " + bar (12) + " " + percent (4)
 	// Example: "  Thread 1: Song Name Here      [####----] 100%"
 
@@ -332,15 +460,20 @@ func buildThreadLine(workerID int, songName string, progress int, isWorking bool
 	}
 
 	// Build components with exact widths
-	songPadded := padRight(songName, songWidth)
-	bar := makeProgressBar(progress, barWidth)
-	percentStr := fmt.Sprintf("%4d%%", progress) // Right-aligned percentage
+	songPadded := padRight(state.FileName, songWidth)
+	if state.Indeterminate {
+		bar := makeActivityBar(activityFrame(), barWidth)
+		sizeStr := fmt.Sprintf("%4dM", state.Received>>20) // Right-aligned megabytes
+		return prefix + songPadded + " " + bar + sizeStr
+	}
+	bar := makeProgressBar(state.Progress, barWidth)
+	percentStr := fmt.Sprintf("%4d%%", state.Progress) // Right-aligned percentage
 
 	return prefix + songPadded + " " + bar + percentStr
 }
 
 // buildSongLine builds a single song status line with fixed width.
-func buildSongLine(songName string, status TrackStatus, progress int, width int) string {
+func buildSongLine(state trackState, width int) string {
 	// Layout: "  " + songName (variable) + "  " + status (fixed 10)
 	// Example: "  01. Song Name Here              v Complete"
 
@@ -352,18 +485,26 @@ func buildSongLine(songName string, status TrackStatus, progress int, width int)
 		songWidth = 10
 	}
 
-	songPadded := padRight(songName, songWidth)
+	songPadded := padRight(state.FileName, songWidth)
 
 	var statusStr string
-	switch status {
+	switch state.Status {
 	case StatusQueued:
 		statusStr = "o Queued  "
 	case StatusDownloading:
-		statusStr = fmt.Sprintf("> %3d%%    ", progress)
+		if state.Indeterminate {
+			statusStr = fmt.Sprintf("> %-8s", formatMegabytes(state.Received))
+		} else {
+			statusStr = fmt.Sprintf("> %3d%%    ", state.Progress)
+		}
 	case StatusComplete:
 		statusStr = "v Complete"
 	case StatusFailed:
-		statusStr = "x Failed  "
+		label := failureLabels[state.Reason]
+		if label == "" {
+			label = "Failed"
+		}
+		statusStr = fmt.Sprintf("x %-8s", label)
 	default:
 		statusStr = "  Unknown "
 	}
@@ -393,14 +534,14 @@ func buildDisplayContent(
 		taskIdx := threadTasks[i]
 		isWorking := taskIdx >= 0 && taskIdx < len(tasks)
 
-		var songName string
-		var progress int
+		var state trackState
 		if isWorking {
-			songName = tasks[taskIdx].FileName
-			progress = threadProgress[i]
+			state = trackStates[taskIdx]
+			state.FileName = tasks[taskIdx].FileName
+			state.Progress = threadProgress[i]
 		}
 
-		line := buildThreadLine(i, songName, progress, isWorking, width)
+		line := buildThreadLine(i, state, isWorking, width)
 		buf.WriteString(line + "\n")
 	}
 
@@ -410,7 +551,7 @@ func buildDisplayContent(
 	buf.WriteString(separator + "\n")
 
 	for _, ts := range trackStates {
-		line := buildSongLine(ts.FileName, ts.Status, ts.Progress, width)
+		line := buildSongLine(ts, width)
 		buf.WriteString(line + "\n")
 	}
 
@@ -420,11 +561,30 @@ func buildDisplayContent(
 }
 
 // DownloadAlbum downloads an entire album with concurrent workers and progress display.
-func (e *Engine) DownloadAlbum(ctx context.Context, albumID string, quality int, outputDir string) error {
-	// 1. Get Album Metadata
-	album, err := e.Client.GetAlbum(albumID)
+// A QualityOverrideFile entry in outputDir replaces quality for this album.
+// The returned result lists every track as succeeded, failed or skipped.
+func (e *Engine) DownloadAlbum(ctx context.Context, albumID string, quality int, outputDir string) (*AlbumResult, error) {
+	quality = e.albumQuality(albumID, quality, outputDir)
+	if !e.Preview {
+		e.warnQualityCeiling(quality)
+	}
+
+	// Fail before any network activity if the output directory can't be written
+	if err := ensureWritable(e.resolveOutputDir(outputDir)); err != nil {
+		return nil, err
+	}
+
+	// 1. Get Album Metadata and resolve output paths
+	plan, err := e.PlanAlbum(albumID, outputDir)
 	if err != nil {
-		return fmt.Errorf("failed to get album metadata: %w", err)
+		return nil, err
+	}
+	album := plan.Album
+
+	result := &AlbumResult{
+		AlbumID:  albumID,
+		Title:    album.Title,
+		AlbumDir: plan.AlbumDir,
 	}
 
 	totalTracks := len(album.Tracks.Items)
@@ -442,10 +602,17 @@ func (e *Engine) DownloadAlbum(ctx context.Context, albumID string, quality int,
 	fmt.Println()
 
 	// 2. Prepare Album Directory
-	folderName := sanitizeFilename(fmt.Sprintf("%s - %s", album.Artist.Name, album.Title))
-	albumDir := filepath.Join(outputDir, folderName)
-	if err := os.MkdirAll(albumDir, 0755); err != nil {
-		return err
+	albumDir := plan.AlbumDir
+	if err := ensureWritable(albumDir); err != nil {
+		return nil, err
+	}
+	if plan.Reused {
+		fmt.Printf("[Folder] Using existing folder %s\n", albumDir)
+	}
+	if err := writeAlbumMarker(albumDir, album.ID); err != nil {
+		fmt.Printf("Warning: failed to write %s: %v\n", albumIDMarker, err)
+	} else if e.ReuseAlbumDirs {
+		e.albumDirs.add(plan.root, album.ID, albumDir)
 	}
 
 	// 3. Download Cover Art first
@@ -454,49 +621,86 @@ func (e *Engine) DownloadAlbum(ctx context.Context, albumID string, quality int,
 		fmt.Print("[Cover] Downloading... ")
 		coverData, err = e.downloadCover(album.Image.Large)
 		if err == nil {
-			_ = e.saveCoverFile(albumDir, coverData)
+			if e.saveCoverFile(albumDir, coverData) == nil {
+				e.applyReleaseMtime(album, e.coverPaths(albumDir)...)
+			}
+			coverData = e.embeddedCover(coverData)
 			fmt.Println("Done")
 		} else {
 			fmt.Println("Failed (continuing without cover)")
 		}
 	}
+	var extraPictures []*Picture
+	if e.EmbedExtraArt {
+		extraPictures = e.fetchExtraPictures(album)
+		if len(extraPictures) > 0 {
+			fmt.Printf("[Cover] %d additional images\n", len(extraPictures))
+		}
+	}
+	var discCovers map[int][]byte
+	if e.DiscArt {
+		discCovers = e.fetchDiscCovers(album, albumDir)
+		if len(discCovers) > 0 {
+			fmt.Printf("[Cover] %d disc images\n", len(discCovers))
+		}
+	}
 	fmt.Println()
 
+	if e.Preview {
+		return e.downloadAlbumPreviews(ctx, plan, coverData, result), nil
+	}
+
 	// 4. Build task queue
 	// Note: We'll determine actual file extension when we get the URL response from server
 	var tasks []trackTask
-	skipped := 0
-	for i, track := range album.Tracks.Items {
-		// Use base name without extension for skip check - check both .flac and .mp3
-		baseName := sanitizeFilename(fmt.Sprintf("%02d. %s", track.TrackNumber, track.Title))
-		flacPath := filepath.Join(albumDir, baseName+".flac")
-		mp3Path := filepath.Join(albumDir, baseName+".mp3")
-
-		// Check if already exists (either format)
-		if _, err := os.Stat(flacPath); err == nil {
-			skipped++
+	var index *tagIndex // Built on first use when MatchByTags is set
+	belowDepth := 0
+	partials := 0
+	for i, planned := range plan.Tracks {
+		track := planned.Track
+		if e.MinBitDepth > 0 && !meetsMinBitDepth(e.trackBitDepth(track), e.MinBitDepth) {
+			result.Skipped = append(result.Skipped, TrackResult{Title: track.Title})
+			e.emitProgress(&track, album.ID, PhaseSkipped, 0, 0, nil)
+			belowDepth++
 			continue
 		}
-		if _, err := os.Stat(mp3Path); err == nil {
-			skipped++
+		// Use base name without extension for skip check - check both .flac and .mp3
+		baseName := planned.BaseName
+		task := trackTask{
+			Track:    track,
+			FileName: baseName,
+			Index:    i + 1,
+		}
+		if path, skip := e.prepareTrackTask(albumDir, &task, quality, &index); skip {
+			result.Skipped = append(result.Skipped, TrackResult{Title: track.Title, Path: path})
+			e.emitProgress(&track, album.ID, PhaseSkipped, 0, 0, nil)
 			continue
 		}
+		if task.Partial != "" {
+			partials++
+		}
 
 		// FileName stores base name; actual extension determined at download time
-		tasks = append(tasks, trackTask{
-			Track:    track,
-			FileName: baseName,
-			Index:    i + 1,
-		})
+		tasks = append(tasks, task)
 	}
 
-	if skipped > 0 {
-		fmt.Printf("[Skip] %d tracks already exist\n\n", skipped)
+	if belowDepth > 0 {
+		fmt.Printf("[Skip] %d tracks below the %d-bit minimum\n", belowDepth, e.MinBitDepth)
+	}
+	if existing := len(result.Skipped) - belowDepth; existing > 0 {
+		fmt.Printf("[Skip] %d tracks already exist\n\n", existing)
+	}
+	if partials > 0 {
+		fmt.Printf("[Resume] %d partially downloaded tracks\n\n", partials)
 	}
 
 	if len(tasks) == 0 {
-		fmt.Println("[Done] All tracks already downloaded!")
-		return nil
+		if belowDepth == len(result.Skipped) {
+			fmt.Println("[Done] No tracks reach the minimum bit depth")
+		} else {
+			fmt.Println("[Done] All tracks already downloaded!")
+		}
+		return result, nil
 	}
 
 	// 5. Initialize track states for display
@@ -517,6 +721,8 @@ func (e *Engine) DownloadAlbum(ctx context.Context, albumID string, quality int,
 	}
 
 	var stateMu sync.Mutex
+	var extMismatch atomic.Bool // Set when ForceExt does not match a delivered container
+	taskResults := make([]TrackResult, len(tasks))
 	numWorkers := e.Concurrency
 	if numWorkers > len(tasks) {
 		numWorkers = len(tasks)
@@ -552,11 +758,35 @@ func (e *Engine) DownloadAlbum(ctx context.Context, albumID string, quality int,
 	taskChan := make(chan int, len(tasks)) // send task index
 	var wg sync.WaitGroup
 
+	// Queue every task up front, so an empty channel means no tasks are left
+	for i := range tasks {
+		taskChan <- i
+	}
+	close(taskChan)
+
+	// With automatic concurrency, only the first tuner.limit workers take tasks
+	var tuner *concurrencyTuner
+	stopTuner := make(chan struct{})
+	if e.AutoConcurrency {
+		tuner = newConcurrencyTuner(numWorkers)
+		go tuner.run(stopTuner)
+	}
+
 	for w := range numWorkers {
 		wg.Add(1)
 		go func(workerID int) {
 			defer wg.Done()
-			for taskIdx := range taskChan {
+			for {
+				if tuner != nil {
+					tuner.wait(workerID)
+				}
+				taskIdx, ok := <-taskChan
+				if !ok {
+					return
+				}
+				if tuner != nil && len(taskChan) == 0 {
+					tuner.drain()
+				}
 				task := tasks[taskIdx]
 
 				// Update state: downloading
@@ -568,38 +798,127 @@ func (e *Engine) DownloadAlbum(ctx context.Context, albumID string, quality int,
 				stateMu.Unlock()
 
 				// Get track URL with fallback qualities
-				urlInfo, _, err := e.Client.GetTrackURLWithFallback(strconv.Itoa(task.Track.ID), quality)
+				taskResults[taskIdx].Title = task.Track.Title
+				urlInfo, formatID, err := e.trackURL(ctx, strconv.Itoa(task.Track.ID), e.trackQuality(quality, &task.Track))
 				if err != nil {
 					stateMu.Lock()
+					taskResults[taskIdx].Err = err
 					trackStates[taskIdx].Status = StatusFailed
+					trackStates[taskIdx].Reason = FailureReason(err)
 					threadTasks[workerID] = -1
 					stateMu.Unlock()
+					e.emitProgress(&task.Track, album.ID, PhaseFailed, 0, 0, err)
 					continue
 				}
 
 				// Determine actual file extension from server response
-				ext := getFileExtensionFromMimeType(urlInfo.MimeType)
+				ext, container, mismatch := e.outputExtension(urlInfo.MimeType)
+				if mismatch {
+					extMismatch.Store(true)
+				}
 				trackPath := filepath.Join(albumDir, task.FileName+ext)
-
-				// Download with progress callback
-				err = e.downloadFileWithProgress(ctx, urlInfo.URL, trackPath, func(percent int) {
+				taskResults[taskIdx].Path = trackPath
+				taskResults[taskIdx].FormatID = formatID
+
+				// Download with progress callback; without a known size, show the bytes received
+				var counted int64 // Bytes of this track already reported to the tuner
+				setProgress := func(current, total int64) {
+					if tuner != nil {
+						if current < counted {
+							counted = 0 // Download restarted
+						}
+						tuner.received(current - counted)
+						counted = current
+					}
+					percent := 0
+					if total > 0 {
+						percent = int(min(current*100/total, 100))
+					}
 					stateMu.Lock()
 					threadProgress[workerID] = percent
 					trackStates[taskIdx].Progress = percent
+					trackStates[taskIdx].Received = current
+					trackStates[taskIdx].Indeterminate = total <= 0
 					stateMu.Unlock()
+					e.emitProgress(&task.Track, album.ID, PhaseDownloading, current, total, nil)
+				}
+				release, err := e.acquireDownload(ctx)
+				if err != nil {
+					stateMu.Lock()
+					taskResults[taskIdx].Err = err
+					trackStates[taskIdx].Status = StatusFailed
+					trackStates[taskIdx].Reason = FailureReason(err)
+					threadTasks[workerID] = -1
+					stateMu.Unlock()
+					e.emitProgress(&task.Track, album.ID, PhaseFailed, 0, 0, err)
+					continue
+				}
+				// Download into a .part file and rename it once complete, so an
+				// interrupted run leaves a file the next run can resume
+				target := partialPath(trackPath)
+				if task.Resume && task.Existing == trackPath {
+					target = trackPath // Resume the existing file in place
+				}
+				if task.Partial != "" && task.Partial != target {
+					os.Remove(task.Partial) // Left by a download in another format
+				}
+				resume := target == trackPath || task.Partial == target
+				err = e.fetchVerified(target, container, func() error {
+					if resume {
+						resume = false // A file failing verification is downloaded again
+						return e.resumeFile(ctx, urlInfo.URL, target, setProgress)
+					}
+					return e.downloadFileWithProgress(ctx, urlInfo.URL, target, setProgress, func() (string, error) {
+						release, err := e.acquireAPI(ctx)
+						if err != nil {
+							return "", err
+						}
+						fresh, err := e.Client.GetTrackURL(strconv.Itoa(task.Track.ID), formatID)
+						release()
+						if err != nil {
+							return "", err
+						}
+						return fresh.URL, nil
+					})
 				})
+				release()
+				if err == nil && target != trackPath {
+					if renameErr := os.Rename(target, trackPath); renameErr != nil {
+						err = fmt.Errorf("failed to finalize download: %w", renameErr)
+					}
+				}
+				if err == nil && task.Existing != "" && task.Existing != trackPath {
+					os.Remove(task.Existing) // Replaced by a file in another format
+				}
 
 				if err != nil {
+					if tuner != nil && ctx.Err() == nil && isLoadFailure(err) {
+						tuner.fail()
+					}
 					stateMu.Lock()
+					taskResults[taskIdx].Err = err
 					trackStates[taskIdx].Status = StatusFailed
+					trackStates[taskIdx].Reason = FailureReason(err)
 					threadTasks[workerID] = -1
 					stateMu.Unlock()
+					e.emitProgress(&task.Track, album.ID, PhaseFailed, 0, 0, err)
 					continue
 				}
 
 				// Tag the file
 				track := task.Track
-				_ = e.Tagger.WriteTags(trackPath, &track, album, coverData)
+				e.emitProgress(&track, album.ID, PhaseTagging, 0, 0, nil)
+				if tagErr := e.tagFile(trackPath, container, &track, album, discCover(discCovers, track.MediaNumber, coverData), extraPictures...); tagErr != nil {
+					taskResults[taskIdx].TagErr = tagErr
+					taskResults[taskIdx].TagError = tagErr.Error()
+				}
+				if e.TrackSidecars {
+					if err := writeTrackSidecar(trackPath, &track, album, urlInfo, formatID); err != nil && taskResults[taskIdx].TagErr == nil {
+						taskResults[taskIdx].TagErr = fmt.Errorf("failed to write sidecar: %w", err)
+						taskResults[taskIdx].TagError = taskResults[taskIdx].TagErr.Error()
+					}
+				}
+				e.applyReleaseMtime(album, trackPath)
 
 				// Update state: complete
 				stateMu.Lock()
@@ -607,18 +926,14 @@ func (e *Engine) DownloadAlbum(ctx context.Context, albumID string, quality int,
 				trackStates[taskIdx].Progress = 100
 				threadTasks[workerID] = -1
 				stateMu.Unlock()
+				e.emitProgress(&track, album.ID, PhaseDone, 0, 0, nil)
 			}
 		}(w)
 	}
 
-	// Send tasks by index
-	for i := range tasks {
-		taskChan <- i
-	}
-	close(taskChan)
-
 	// Wait for completion
 	wg.Wait()
+	close(stopTuner)
 	close(stopDisplay)
 	<-displayDone
 
@@ -627,70 +942,123 @@ func (e *Engine) DownloadAlbum(ctx context.Context, albumID string, quality int,
 	finalContent := buildDisplayContent(numWorkers, threadTasks, threadProgress, tasks, trackStates, displayWidth)
 	stateMu.Unlock()
 	display.renderFinal(finalContent)
+	if tuner != nil {
+		fmt.Printf("[Threads] Finished with %d of %d download threads\n", tuner.current(), numWorkers)
+	}
 
-	// Print summary
-	fmt.Println()
-	successCount := 0
-	failCount := 0
-	for _, ts := range trackStates {
+	// Collect results in track order
+	for i, ts := range trackStates {
 		if ts.Status == StatusComplete {
-			successCount++
-		} else if ts.Status == StatusFailed {
-			failCount++
+			result.Success = append(result.Success, taskResults[i])
+		} else {
+			taskResults[i].Reason = ts.Reason
+			result.Failed = append(result.Failed, taskResults[i])
 		}
 	}
 
+	if e.WriteChecksums && len(result.Success) > 0 {
+		if err := writeAlbumChecksums(result, e.coverPaths(result.AlbumDir)); err != nil {
+			fmt.Printf("Warning: failed to write %s: %v\n", ChecksumFile, err)
+		}
+	}
+	if e.KeepGoing {
+		if err := writeTagErrorLog(result); err != nil {
+			fmt.Printf("Warning: failed to write %s: %v\n", TagErrorLog, err)
+		}
+	}
+
+	// Print summary
+	fmt.Println()
 	summaryLines := []string{
 		"Download Complete!",
-		fmt.Sprintf("Success: %d  |  Failed: %d  |  Skipped: %d", successCount, failCount, skipped),
+		fmt.Sprintf("Success: %d  |  Failed: %d  |  Skipped: %d", len(result.Success), len(result.Failed), len(result.Skipped)),
+	}
+	if tagFailures := len(result.TagFailures()); tagFailures > 0 {
+		summaryLines = append(summaryLines, fmt.Sprintf("Tagging failed for %d tracks (audio kept)", tagFailures))
 	}
 	printBox(summaryLines, boxWidth)
 
-	return nil
+	if extMismatch.Load() {
+		fmt.Printf("Warning: forced extension %q does not match the delivered format; files were not converted\n", normalizeExt(e.ForceExt))
+	}
+
+	return result, nil
 }
 
-// downloadFileWithProgress downloads a file and reports progress as percentage.
-// Includes retry logic (1 retry) and cleanup of incomplete files on failure.
-func (e *Engine) downloadFileWithProgress(ctx context.Context, url, outputPath string, onProgress func(int)) error {
-	var lastErr error
+// maxURLRefreshes bounds how often a download re-fetches its signed URL after
+// the CDN edge rejects it.
+const maxURLRefreshes = 2
 
-	// Try up to 2 times (initial + 1 retry)
-	for attempt := 1; attempt <= 2; attempt++ {
-		var contentLength int64 = 0
+// httpStatusError is a non-2xx response to a file download.
+type httpStatusError struct {
+	StatusCode int
+	Status     string
+}
 
-		resp, err := e.Client.HTTP.R().
-			SetContext(ctx).
-			SetOutputFile(outputPath).
-			SetDownloadCallback(func(info req.DownloadInfo) {
-				if info.Response.ContentLength > 0 {
-					contentLength = info.Response.ContentLength
-					percent := int(float64(info.DownloadedSize) / float64(contentLength) * 100)
-					if percent > 100 {
-						percent = 100
-					}
-					if onProgress != nil {
-						onProgress(percent)
-					}
-				}
-			}).
-			Get(url)
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("http error: %s", e.Status)
+}
 
-		if err == nil && !resp.IsErrorState() {
+// isClientError reports whether err is a 4xx download response, which usually
+// means the signed URL expired or this CDN edge refuses it.
+func isClientError(err error) bool {
+	var statusErr *httpStatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode >= 400 && statusErr.StatusCode < 500
+}
+
+// downloadFileWithProgress downloads a file and reports progress in bytes.
+// total is -1 when the server does not send a Content-Length.
+// Includes retry logic (1 retry) that continues an interrupted transfer from the
+// bytes already on disk. When the CDN answers with a 4xx, refreshURL (if not nil)
+// is called for a freshly signed URL, which may point to another edge; these
+// refreshes do not count as retries. The incomplete file is removed on failure.
+func (e *Engine) downloadFileWithProgress(ctx context.Context, url, outputPath string, onProgress ProgressCallback, refreshURL func() (string, error)) error {
+	// Segments left by a killed run are not resumable (see segmentsSuffix)
+	os.Remove(outputPath + segmentsSuffix)
+
+	var lastErr error
+	refreshes := 0
+	resume := false // Continue the partial file left by an interrupted attempt
+
+	// Try up to 2 times (initial + 1 retry)
+	for attempt := 1; attempt <= 2; {
+		var err error
+		if resume {
+			err = e.resumeFile(ctx, url, outputPath, onProgress)
+		} else {
+			err = errRangeUnsupported
+			if e.ConnectionsPerFile > 1 {
+				err = e.getFileSegmented(ctx, url, outputPath, e.ConnectionsPerFile, onProgress)
+			}
+			if errors.Is(err, errRangeUnsupported) {
+				err = e.getFileWithProgress(ctx, url, outputPath, onProgress)
+			}
+		}
+		if err == nil {
 			return nil // Success
 		}
+		lastErr = err
 
-		// Record error
-		if err != nil {
-			lastErr = err
-		} else {
-			lastErr = fmt.Errorf("http error: %s", resp.Status)
+		if isClientError(err) {
+			if !resume {
+				os.Remove(outputPath) // Holds the error body, not audio
+			}
+			if refreshURL != nil && refreshes < maxURLRefreshes {
+				if fresh, refreshErr := refreshURL(); refreshErr == nil {
+					url = fresh
+					refreshes++
+					resume = fileSize(outputPath) > 0
+					continue
+				}
+			}
 		}
 
-		// If this was the first attempt, remove incomplete file and retry
+		// If this was the first attempt, pause and retry from the bytes received so far
 		if attempt == 1 {
-			os.Remove(outputPath)               // Cleanup before retry
 			time.Sleep(1000 * time.Millisecond) // Brief pause before retry
 		}
+		attempt++
+		resume = fileSize(outputPath) > 0
 	}
 
 	// Both attempts failed, ensure cleanup
@@ -698,27 +1066,78 @@ func (e *Engine) downloadFileWithProgress(ctx context.Context, url, outputPath s
 	return fmt.Errorf("download failed after retry: %w", lastErr)
 }
 
+// getFileWithProgress performs a single download of url into outputPath.
+func (e *Engine) getFileWithProgress(ctx context.Context, url, outputPath string, onProgress ProgressCallback) error {
+	var received int64
+
+	reqCtx, watchdog := newIdleWatchdog(ctx, e.IdleTimeout)
+	resp, err := e.Client.HTTP.R().
+		SetContext(reqCtx).
+		SetOutputFile(outputPath).
+		SetDownloadCallback(func(info req.DownloadInfo) {
+			if info.DownloadedSize > received {
+				received = info.DownloadedSize
+				watchdog.Touch()
+			}
+			if onProgress != nil {
+				onProgress(info.DownloadedSize, info.Response.ContentLength)
+			}
+		}).
+		Get(url)
+	watchdog.Stop()
+
+	if stalled := stallError(reqCtx); stalled != nil {
+		return stalled
+	}
+	if err != nil {
+		return err
+	}
+	if resp.IsErrorState() {
+		return &httpStatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	return nil
+}
+
+// fileSize returns the size of path, or 0 if it does not exist.
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
 func (e *Engine) downloadFile(ctx context.Context, url, outputPath string, onProgress ProgressCallback) error {
 	var lastErr error
 
 	// Try up to 2 times (initial + 1 retry)
 	for attempt := 1; attempt <= 2; attempt++ {
+		var received int64
+
+		reqCtx, watchdog := newIdleWatchdog(ctx, e.IdleTimeout)
 		resp, err := e.Client.HTTP.R().
-			SetContext(ctx).
+			SetContext(reqCtx).
 			SetOutputFile(outputPath).
 			SetDownloadCallback(func(info req.DownloadInfo) {
+				if info.DownloadedSize > received {
+					received = info.DownloadedSize
+					watchdog.Touch()
+				}
 				if onProgress != nil {
 					onProgress(info.DownloadedSize, info.Response.ContentLength)
 				}
 			}).
 			Get(url)
+		watchdog.Stop()
 
 		if err == nil && !resp.IsErrorState() {
 			return nil // Success
 		}
 
 		// Record error
-		if err != nil {
+		if stalled := stallError(reqCtx); stalled != nil {
+			lastErr = stalled
+		} else if err != nil {
 			lastErr = err
 		} else {
 			lastErr = fmt.Errorf("http error: %s", resp.Status)
@@ -742,95 +1161,213 @@ const (
 	staticQobuzHost = "https://static.qobuz.com"
 )
 
+// downloadCover returns the cover for url, serving repeated URLs from the cover cache.
 func (e *Engine) downloadCover(url string) ([]byte, error) {
-	// Try maximum quality (original)
-	maxUrl := strings.Replace(url, "_600.", "_org.", 1)
-
-	// Try CDN proxy first if enabled
-	if e.Client.UseProxy {
-		cdnUrl := strings.Replace(maxUrl, staticQobuzHost, staticCDNProxy, 1)
-		resp, err := e.Client.HTTP.R().Get(cdnUrl)
-		if err == nil && !resp.IsErrorState() {
-			return resp.Bytes(), nil
-		}
-		// CDN failed, try direct
-	}
-
-	// Try downloading max quality directly
-	resp, err := e.Client.HTTP.R().Get(maxUrl)
-	if err == nil && !resp.IsErrorState() {
-		return resp.Bytes(), nil
+	if data, ok := e.covers.Get(url); ok {
+		return data, nil
 	}
 
-	// Fallback to provided URL if max fails
-	resp, err = e.Client.HTTP.R().Get(url)
+	data, err := e.fetchCover(url)
 	if err != nil {
 		return nil, err
 	}
-	if resp.IsErrorState() {
-		return nil, fmt.Errorf("http error: %s", resp.Status)
+	e.covers.Put(url, data)
+	return data, nil
+}
+
+// coverSizes lists the cover image sizes tried in order, from the original
+// upload down to the 600px image every release has.
+var coverSizes = []string{"org", "max", "600"}
+
+// coverSizeRegex matches the size suffix of a Qobuz cover URL (e.g. "_600.jpg").
+var coverSizeRegex = regexp.MustCompile(`_([a-z0-9]+)(\.[a-z]+)$`)
+
+// coverCandidates returns the URLs to try for a cover, largest size first.
+// url itself is always the last candidate.
+func coverCandidates(url string) []string {
+	var candidates []string
+	if coverSizeRegex.MatchString(url) {
+		for _, size := range coverSizes {
+			candidate := coverSizeRegex.ReplaceAllString(url, "_"+size+"${2}")
+			if !slices.Contains(candidates, candidate) {
+				candidates = append(candidates, candidate)
+			}
+		}
+	}
+	if !slices.Contains(candidates, url) {
+		candidates = append(candidates, url)
 	}
-	return resp.Bytes(), nil
+	return candidates
 }
 
-func (e *Engine) saveCoverFile(dir string, data []byte) error {
-	coverPath := filepath.Join(dir, "cover.jpg")
-	return os.WriteFile(coverPath, data, 0644)
+// fetchCover downloads the highest quality cover available for url.
+// Each size is tried through the CDN proxy (if enabled) and then directly;
+// it only fails when every size failed from every host.
+func (e *Engine) fetchCover(url string) ([]byte, error) {
+	var lastErr error
+	for _, candidate := range coverCandidates(url) {
+		hosts := []string{candidate}
+		if cdnURL := strings.Replace(candidate, staticQobuzHost, staticCDNProxy, 1); e.Client.UseProxy && cdnURL != candidate {
+			hosts = []string{cdnURL, candidate}
+		}
+		for _, u := range hosts {
+			resp, err := e.Client.HTTP.R().Get(u)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			if resp.IsErrorState() {
+				lastErr = fmt.Errorf("http error: %s", resp.Status)
+				continue
+			}
+			return resp.Bytes(), nil
+		}
+	}
+	return nil, lastErr
 }
 
 // DownloadTrack downloads a track by ID to a local file.
 func (e *Engine) DownloadTrack(ctx context.Context, trackID string, quality int, outputDir string, onProgress ProgressCallback) error {
-	// 1. Fetch Track Metadata first
+	if !e.Preview {
+		e.warnQualityCeiling(quality)
+	}
+
+	// Fail before any network activity if the output directory can't be written
+	outputDir = e.resolveOutputDir(outputDir)
+	if err := ensureWritable(outputDir); err != nil {
+		return err
+	}
+
+	// 1. Fetch Track Metadata first (includes the full album block)
 	track, err := e.Client.GetTrack(trackID)
 	if err != nil {
 		return fmt.Errorf("failed to get track metadata: %w", err)
 	}
+	if track.Album == nil {
+		return fmt.Errorf("track %s has no album metadata", trackID)
+	}
+	if e.Preview {
+		return e.downloadTrackPreview(ctx, track, outputDir)
+	}
 
 	// 2. Fetch Track URL (with fallback)
-	info, _, err := e.Client.GetTrackURLWithFallback(trackID, quality)
+	quality = e.trackQuality(quality, track)
+	info, formatID, err := e.Client.GetTrackURLWithFallback(trackID, quality)
 	if err != nil {
 		return fmt.Errorf("failed to get track URL: %w", err)
 	}
 
 	// 3. Prepare Directory & Filename
 	// Use server-returned MimeType for accurate file extension
-	ext := getFileExtensionFromMimeType(info.MimeType)
-	fileName := sanitizeFilename(fmt.Sprintf("%s - %s", track.Performer.Name, track.Title)) + ext
-	outputPath := filepath.Join(outputDir, fileName)
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return err
+	ext, container, mismatch := e.outputExtension(info.MimeType)
+	if mismatch {
+		fmt.Printf("Warning: forced extension %q does not match the delivered %s stream; the file is not converted\n", ext, container)
+	}
+	folder, baseName := e.singleTrackName(track)
+	folder, baseName = fitPath(outputDir, folder, baseName, e.maxPathLength())
+	if folder != "" {
+		outputDir = filepath.Join(outputDir, folder)
+		if err := ensureWritable(outputDir); err != nil {
+			return err
+		}
 	}
+	outputPath := filepath.Join(outputDir, baseName+ext)
 
-	// 4. Download Audio
-	err = e.downloadFile(ctx, info.URL, outputPath, onProgress)
+	existing, exists := e.existingTrackPath(outputDir, baseName)
+	if !exists && e.MatchByTags {
+		existing, exists = buildTagIndex(outputDir).find(track)
+	}
+	action := actionDownload
+	if exists {
+		action = decideExisting(e.IfExists, existing, quality, track.MaximumBitDepth)
+		if action == actionSkip {
+			fmt.Printf("Skipping, already exists: %s\n", existing)
+			e.emitProgress(track, track.Album.ID, PhaseSkipped, 0, 0, nil)
+			return nil
+		}
+	}
+	if e.OnProgressEvent != nil {
+		display := onProgress
+		onProgress = func(current, total int64) {
+			e.emitProgress(track, track.Album.ID, PhaseDownloading, current, total, nil)
+			if display != nil {
+				display(current, total)
+			}
+		}
+	}
+
+	// 4. Download Audio, with the cover art fetched concurrently
+	artwork := e.fetchTrackArtwork(track.Album)
+	release, err := e.acquireDownload(ctx)
 	if err != nil {
 		return err
 	}
-
-	// 5. Download Cover Art (if available)
-	var coverData []byte
-	if track.Album != nil && track.Album.Image.Large != "" {
-		coverData, _ = e.downloadCover(track.Album.Image.Large)
+	err = e.fetchVerified(outputPath, container, func() error {
+		if action == actionResume && existing == outputPath {
+			return e.resumeFile(ctx, info.URL, outputPath, onProgress)
+		}
+		return e.downloadFile(ctx, info.URL, outputPath, onProgress)
+	})
+	release()
+	if err != nil {
+		e.emitProgress(track, track.Album.ID, PhaseFailed, 0, 0, err)
+		return err
 	}
-
-	// 6. Tagging
-	// Note: TrackMetadata has 'Album' embedded usually if fetched via GetTrack
-	// But our model definition in models.go might need checking if GetTrack response structure embeds full album.
-	// API response usually embeds partial album info.
-	if track.Album == nil {
-		// Fallback create dummy album
-		track.Album = &api.AlbumMetadata{Title: "Unknown Album"}
+	if exists && existing != outputPath {
+		os.Remove(existing) // Replaced by a file in another format
 	}
 
-	err = e.Tagger.WriteTags(outputPath, track, track.Album, coverData)
+	// 5. Wait for the cover art (a failed cover only leaves the file without one)
+	art := <-artwork
+
+	// 6. Tagging
+	e.emitProgress(track, track.Album.ID, PhaseTagging, 0, 0, nil)
+	err = e.tagFile(outputPath, container, track, track.Album, art.cover, art.extra...)
 	if err != nil {
 		// Just warn, don't fail download
 		fmt.Printf("Warning: Failed to tag file: %v\n", err)
+		if e.KeepGoing {
+			if logErr := appendTagErrorLog(filepath.Dir(outputPath), outputPath, err); logErr != nil {
+				fmt.Printf("Warning: failed to write %s: %v\n", TagErrorLog, logErr)
+			}
+		}
 	}
+	if e.TrackSidecars {
+		if err := writeTrackSidecar(outputPath, track, track.Album, info, formatID); err != nil {
+			fmt.Printf("Warning: failed to write sidecar: %v\n", err)
+		}
+	}
+	e.applyReleaseMtime(track.Album, outputPath)
+	e.emitProgress(track, track.Album.ID, PhaseDone, 0, 0, nil)
 
 	return nil
 }
 
+// trackArtwork is the artwork embedded in a single track.
+type trackArtwork struct {
+	cover []byte     // Front cover, nil if unavailable
+	extra []*Picture // Back cover and booklet pages when EmbedExtraArt is set
+}
+
+// fetchTrackArtwork downloads the artwork of album in the background so it
+// overlaps the audio download. The channel is buffered, so a caller that
+// returns early without receiving doesn't leak the goroutine.
+func (e *Engine) fetchTrackArtwork(album *api.AlbumMetadata) <-chan trackArtwork {
+	result := make(chan trackArtwork, 1)
+	go func() {
+		var art trackArtwork
+		if album.Image.Large != "" {
+			art.cover, _ = e.downloadCover(album.Image.Large)
+			art.cover = e.embeddedCover(art.cover)
+		}
+		if e.EmbedExtraArt {
+			art.extra = e.fetchExtraPictures(album)
+		}
+		result <- art
+	}()
+	return result
+}
+
 // StreamInfo contains information about the stream for setting HTTP headers.
 type StreamInfo struct {
 	MimeType string
@@ -840,6 +1377,13 @@ type StreamInfo struct {
 // Returns StreamInfo with the actual MIME type from the server.
 func (e *Engine) StreamTrack(ctx context.Context, trackID string, quality int, w io.Writer, onProgress ProgressCallback) (*StreamInfo, error) {
 	// 1. Get Track URL (with fallback)
+	if quality == QualityBest {
+		track, err := e.Client.GetTrack(trackID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get track metadata: %w", err)
+		}
+		quality = e.trackQuality(quality, track)
+	}
 	info, _, err := e.Client.GetTrackURLWithFallback(trackID, quality)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get track URL: %w", err)
@@ -849,17 +1393,23 @@ func (e *Engine) StreamTrack(ctx context.Context, trackID string, quality int, w
 		MimeType: info.MimeType,
 	}
 
-	// 2. Start Download to Writer
+	// 2. Start Download to Writer, aborting if the CDN stops sending data
+	reqCtx, watchdog := newIdleWatchdog(ctx, e.IdleTimeout)
 	resp, err := e.Client.HTTP.R().
-		SetContext(ctx).
-		SetOutput(w).
+		SetContext(reqCtx).
+		SetOutput(watchdog.Writer(w)).
 		SetDownloadCallback(func(info req.DownloadInfo) {
+			watchdog.Touch()
 			if onProgress != nil {
 				onProgress(info.DownloadedSize, info.Response.ContentLength)
 			}
 		}).
 		Get(info.URL)
+	watchdog.Stop()
 
+	if stalled := stallError(reqCtx); stalled != nil {
+		return streamInfo, stalled
+	}
 	if err != nil {
 		return streamInfo, fmt.Errorf("stream request failed: %w", err)
 	}
@@ -870,3 +1420,48 @@ func (e *Engine) StreamTrack(ctx context.Context, trackID string, quality int, w
 
 	return streamInfo, nil
 }
+
+// StreamTaggedTrack writes the track to w with tags and cover art embedded.
+// Tags can only be written to a complete file, so the track is downloaded to
+// a temporary directory first and copied to w once tagged.
+func (e *Engine) StreamTaggedTrack(ctx context.Context, trackID string, quality int, w io.Writer) (*StreamInfo, error) {
+	track, err := e.Client.GetTrack(trackID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get track metadata: %w", err)
+	}
+	if track.Album == nil {
+		return nil, fmt.Errorf("track %s has no album metadata", trackID)
+	}
+
+	dir, err := os.MkdirTemp("", "qobuz-dl-stream-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	var coverData []byte
+	if track.Album.Image.Large != "" {
+		coverData, _ = e.downloadCover(track.Album.Image.Large)
+		coverData = e.embeddedCover(coverData)
+	}
+
+	path, _, _, err := e.downloadTaggedTrack(ctx, dir, track.Album, PlannedTrack{Track: *track, BaseName: "track"}, quality, coverData)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	streamInfo := &StreamInfo{MimeType: "audio/flac"}
+	if strings.EqualFold(filepath.Ext(path), ".mp3") {
+		streamInfo.MimeType = "audio/mpeg"
+	}
+	if _, err := io.Copy(w, f); err != nil {
+		return streamInfo, err
+	}
+	return streamInfo, nil
+}
@@ -0,0 +1,209 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+func TestResolveExtension(t *testing.T) {
+	tests := []struct {
+		name          string
+		mimeType      string
+		forced        string
+		wantExt       string
+		wantContainer string
+		wantMismatch  bool
+	}{
+		{name: "flac as delivered", mimeType: "audio/flac", wantExt: ".flac", wantContainer: ".flac"},
+		{name: "mp3 as delivered", mimeType: "audio/mpeg", wantExt: ".mp3", wantContainer: ".mp3"},
+		{name: "unknown type defaults to flac", mimeType: "application/octet-stream", wantExt: ".flac", wantContainer: ".flac"},
+		{name: "compatible override", mimeType: "audio/flac", forced: "FLA", wantExt: ".fla", wantContainer: ".flac"},
+		{name: "override with dot", mimeType: "audio/mpeg", forced: " .MP3 ", wantExt: ".mp3", wantContainer: ".mp3"},
+		{name: "mp3 name on a flac stream is tagged as flac", mimeType: "audio/flac", forced: "mp3", wantExt: ".mp3", wantContainer: ".flac", wantMismatch: true},
+		{name: "flac name on an mp3 stream is tagged as mp3", mimeType: "audio/mpeg", forced: ".flac", wantExt: ".flac", wantContainer: ".mp3", wantMismatch: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ext, container, mismatch := resolveExtension(tt.mimeType, tt.forced)
+			if ext != tt.wantExt || container != tt.wantContainer || mismatch != tt.wantMismatch {
+				t.Errorf("resolveExtension(%q, %q) = %q, %q, %v; want %q, %q, %v",
+					tt.mimeType, tt.forced, ext, container, mismatch, tt.wantExt, tt.wantContainer, tt.wantMismatch)
+			}
+		})
+	}
+}
+
+func TestCoverCandidates(t *testing.T) {
+	tests := []struct {
+		url  string
+		want []string
+	}{
+		{
+			url:  "https://static.qobuz.com/images/covers/ab/cd/abc_600.jpg",
+			want: []string{"https://static.qobuz.com/images/covers/ab/cd/abc_org.jpg", "https://static.qobuz.com/images/covers/ab/cd/abc_max.jpg", "https://static.qobuz.com/images/covers/ab/cd/abc_600.jpg"},
+		},
+		{
+			url:  "https://static.qobuz.com/images/covers/ab/cd/abc_230.jpg",
+			want: []string{"https://static.qobuz.com/images/covers/ab/cd/abc_org.jpg", "https://static.qobuz.com/images/covers/ab/cd/abc_max.jpg", "https://static.qobuz.com/images/covers/ab/cd/abc_600.jpg", "https://static.qobuz.com/images/covers/ab/cd/abc_230.jpg"},
+		},
+		{
+			url:  "https://static.qobuz.com/images/covers/ab/cd/abc_org.jpg",
+			want: []string{"https://static.qobuz.com/images/covers/ab/cd/abc_org.jpg", "https://static.qobuz.com/images/covers/ab/cd/abc_max.jpg", "https://static.qobuz.com/images/covers/ab/cd/abc_600.jpg"},
+		},
+		{url: "https://example.com/cover.png", want: []string{"https://example.com/cover.png"}},
+	}
+	for _, tt := range tests {
+		if got := coverCandidates(tt.url); !slices.Equal(got, tt.want) {
+			t.Errorf("coverCandidates(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestFetchCoverSizeFallback(t *testing.T) {
+	tests := []struct {
+		name      string
+		available []string // Sizes the server has
+		want      string   // Size returned, empty for an error
+		wantTried []string
+	}{
+		{name: "original", available: []string{"org", "max", "600"}, want: "org", wantTried: []string{"org"}},
+		{name: "max when original fails", available: []string{"max", "600"}, want: "max", wantTried: []string{"org", "max"}},
+		{name: "600 as last resort", available: []string{"600"}, want: "600", wantTried: []string{"org", "max", "600"}},
+		{name: "every size fails", wantTried: []string{"org", "max", "600"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tried []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				size := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/covers/abc_"), ".jpg")
+				tried = append(tried, size)
+				if !slices.Contains(tt.available, size) {
+					http.NotFound(w, r)
+					return
+				}
+				w.Write([]byte(size))
+			}))
+			defer srv.Close()
+
+			e := New(api.NewClient("", ""))
+			data, err := e.fetchCover(srv.URL + "/covers/abc_600.jpg")
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("fetchCover() = %q, want error", data)
+				}
+			} else if err != nil || string(data) != tt.want {
+				t.Errorf("fetchCover() = %q, %v; want %q", data, err, tt.want)
+			}
+			if !slices.Equal(tried, tt.wantTried) {
+				t.Errorf("tried sizes %q, want %q", tried, tt.wantTried)
+			}
+		})
+	}
+}
+
+func TestDownloadAlbumWithoutCover(t *testing.T) {
+	fake := newFakeQobuz(t)
+	album := fake.addAlbum("alb1", "Album", "Band", 100, 2)
+	album.Image.Large = fake.srv.URL + "/missing/alb1_600.jpg" // Every size is a 404
+
+	e := fake.engine()
+	result, err := e.DownloadAlbum(context.Background(), "alb1", 6, t.TempDir())
+	if err != nil {
+		t.Fatalf("DownloadAlbum: %v", err)
+	}
+	if len(result.Success) != 2 {
+		t.Fatalf("%d tracks downloaded, want 2: %+v", len(result.Success), result)
+	}
+	for _, tr := range result.Success {
+		if tr.TagErr != nil {
+			t.Errorf("%s: TagErr = %v", filepath.Base(tr.Path), tr.TagErr)
+		}
+		if pics := flacPictures(t, tr.Path); len(pics) != 0 {
+			t.Errorf("%s has %d pictures, want none", filepath.Base(tr.Path), len(pics))
+		}
+	}
+}
+
+func TestDownloadFileURLRefresh(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789abcdef"), 4096)
+	tests := []struct {
+		name          string
+		brokenEdge    string // "/edge1" behaviour
+		wantRefreshes int
+		wantErr       bool
+		wantRanges    []string // Range headers received by /edge2
+	}{
+		{name: "403 then fresh edge", brokenEdge: "forbidden", wantRefreshes: 1, wantRanges: []string{""}},
+		{name: "403 mid-stream resumes on the fresh edge", brokenEdge: "cut then forbidden", wantRefreshes: 1, wantRanges: []string{"bytes=32768-"}},
+		{name: "every edge refuses", brokenEdge: "always forbidden", wantRefreshes: maxURLRefreshes, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var ranges []string
+			edge1Requests := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				if r.URL.Path == "/edge2/track.flac" && tt.brokenEdge != "always forbidden" {
+					ranges = append(ranges, r.Header.Get("Range"))
+					http.ServeContent(w, r, "track.flac", time.Time{}, bytes.NewReader(data))
+					return
+				}
+				edge1Requests++
+				if tt.brokenEdge == "cut then forbidden" && edge1Requests == 1 {
+					// Send half the file, then drop the connection
+					w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+					w.Write(data[:len(data)/2])
+					w.(http.Flusher).Flush()
+					conn, _, _ := w.(http.Hijacker).Hijack()
+					conn.Close()
+					return
+				}
+				http.Error(w, "Forbidden", http.StatusForbidden)
+			}))
+			defer srv.Close()
+
+			refreshes := 0
+			refresh := func() (string, error) {
+				refreshes++
+				return srv.URL + "/edge2/track.flac", nil
+			}
+			e := New(api.NewClient("", ""))
+			path := filepath.Join(t.TempDir(), "track.flac")
+			err := e.downloadFileWithProgress(context.Background(), srv.URL+"/edge1/track.flac", path, nil, refresh)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("downloadFileWithProgress() error = %v, want error %v", err, tt.wantErr)
+			}
+			if refreshes != tt.wantRefreshes {
+				t.Errorf("URL refreshed %d times, want %d", refreshes, tt.wantRefreshes)
+			}
+			if !slices.Equal(ranges, tt.wantRanges) {
+				t.Errorf("fresh edge saw ranges %q, want %q", ranges, tt.wantRanges)
+			}
+			got, readErr := os.ReadFile(path)
+			if tt.wantErr {
+				if readErr == nil {
+					t.Error("incomplete file left behind")
+				}
+				return
+			}
+			if !bytes.Equal(got, data) {
+				t.Errorf("downloaded %d bytes, want the %d byte file", len(got), len(data))
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+// exists.go decides what to do when a track's output file already exists.
+// Besides skipping, files can be overwritten, upgraded to a higher bit depth
+// or resumed after an interrupted download.
+package engine
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Strategies for existing output files.
+const (
+	IfExistsSkip      = "skip"      // Keep the existing file (default)
+	IfExistsOverwrite = "overwrite" // Always download again
+	IfExistsUpgrade   = "upgrade"   // Download again if the requested quality has a higher bit depth
+	IfExistsResume    = "resume"    // Continue an interrupted download from where it stopped
+)
+
+// ValidIfExists reports whether s is a supported existing-file strategy.
+func ValidIfExists(s string) bool {
+	switch s {
+	case IfExistsSkip, IfExistsOverwrite, IfExistsUpgrade, IfExistsResume:
+		return true
+	}
+	return false
+}
+
+// existsAction is the decision taken for an existing output file.
+type existsAction int
+
+const (
+	actionDownload existsAction = iota // No usable file, download normally
+	actionSkip                         // Keep the existing file
+	actionResume                       // Append the missing bytes to the existing file
+)
+
+// partSuffix is appended to an album track's path while it downloads. The file
+// is renamed once complete, so a leftover .part marks an interrupted download.
+const partSuffix = ".part"
+
+// partialPath returns the in-progress path for a track's final output path.
+func partialPath(path string) string {
+	return path + partSuffix
+}
+
+// partialTrackPath returns the leftover .part file of an interrupted download of
+// baseName in albumDir, checking every extension the track may be saved under.
+func (e *Engine) partialTrackPath(albumDir, baseName string) (string, bool) {
+	exts := []string{".flac", ".mp3"}
+	if forced := normalizeExt(e.ForceExt); forced != "" {
+		exts = append(exts, forced)
+	}
+	for _, ext := range exts {
+		path := partialPath(filepath.Join(albumDir, baseName+ext))
+		if fileSize(path) > 0 {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// prepareTrackTask decides how an album track is downloaded: skipped when its
+// final file exists and the IfExists strategy keeps it (the path is returned),
+// resumed from a leftover .part file (task.Partial), or downloaded fresh.
+// Existing files being replaced or resumed in place are recorded in
+// task.Existing and task.Resume. With MatchByTags, *index is built on first use.
+func (e *Engine) prepareTrackTask(albumDir string, task *trackTask, quality int, index **tagIndex) (string, bool) {
+	track := &task.Track
+	path, ok := e.existingTrackPath(albumDir, task.FileName)
+	if !ok && e.MatchByTags {
+		if *index == nil {
+			*index = buildTagIndex(albumDir)
+		}
+		path, ok = (*index).find(track)
+	}
+	if ok {
+		action := decideExisting(e.IfExists, path, e.trackQuality(quality, track), track.MaximumBitDepth)
+		if action == actionSkip {
+			return path, true
+		}
+		task.Existing = path
+		task.Resume = action == actionResume
+	} else if e.IfExists != IfExistsOverwrite {
+		if part, found := e.partialTrackPath(albumDir, task.FileName); found {
+			task.Partial = part
+		}
+	}
+	return "", false
+}
+
+// qualityBitDepth returns the bit depth of a quality tier (0 for lossy MP3).
+func qualityBitDepth(quality int) int {
+	switch quality {
+	case 5:
+		return 0
+	case 6:
+		return 16
+	default:
+		return 24
+	}
+}
+
+// fileBitDepth returns the bit depth stored in an existing file's STREAMINFO,
+// or 0 for lossy or unreadable files.
+func fileBitDepth(path string) int {
+	if !strings.EqualFold(filepath.Ext(path), ".flac") {
+		return 0
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+
+	info, err := readFlacStreamInfo(f)
+	if err != nil {
+		return 0
+	}
+	return info.BitsPerSample
+}
+
+// decideExisting chooses what to do with an existing file at path. maxBitDepth is
+// the track's highest available bit depth (0 if unknown), which caps the depth
+// the requested quality can actually deliver.
+func decideExisting(strategy, path string, quality, maxBitDepth int) existsAction {
+	switch strategy {
+	case IfExistsOverwrite:
+		return actionDownload
+	case IfExistsUpgrade:
+		want := qualityBitDepth(quality)
+		if maxBitDepth > 0 && want > maxBitDepth {
+			want = maxBitDepth
+		}
+		if want > fileBitDepth(path) {
+			return actionDownload
+		}
+		return actionSkip
+	case IfExistsResume:
+		// Finished FLAC files pass the truncation check; anything else is resumed.
+		// Other formats cannot be checked and are treated as complete.
+		if !strings.EqualFold(filepath.Ext(path), ".flac") || QuickVerifyFLAC(path) == nil {
+			return actionSkip
+		}
+		return actionResume
+	default:
+		return actionSkip
+	}
+}
+
+// resumeFile downloads the remainder of url into outputPath using a Range request,
+// starting after the bytes already on disk. If the server ignores the range the
+// file is rewritten from the start. onProgress receives the overall byte counts.
+func (e *Engine) resumeFile(ctx context.Context, url, outputPath string, onProgress ProgressCallback) error {
+	stat, err := os.Stat(outputPath)
+	if err != nil {
+		return err
+	}
+	offset := stat.Size()
+
+	reqCtx, watchdog := newIdleWatchdog(ctx, e.IdleTimeout)
+	defer watchdog.Stop()
+
+	resp, err := e.Client.HTTP.R().
+		SetContext(reqCtx).
+		SetHeader("Range", fmt.Sprintf("bytes=%d-", offset)).
+		DisableAutoReadResponse().
+		Get(url)
+	if err != nil {
+		if stalled := stallError(reqCtx); stalled != nil {
+			return stalled
+		}
+		return fmt.Errorf("resume request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_WRONLY
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		flags |= os.O_APPEND
+	case http.StatusOK:
+		flags |= os.O_TRUNC // Range ignored, start over
+		offset = 0
+	case http.StatusRequestedRangeNotSatisfiable:
+		return nil // Nothing left to download
+	default:
+		return &httpStatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	f, err := os.OpenFile(outputPath, flags, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	total := int64(-1)
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
+	}
+
+	written := offset
+	buf := make([]byte, 32*1024)
+	for {
+		n, readErr := resp.Body.Read(buf)
+		if n > 0 {
+			watchdog.Touch()
+			if _, err := f.Write(buf[:n]); err != nil {
+				return err
+			}
+			written += int64(n)
+			if onProgress != nil {
+				onProgress(written, total)
+			}
+		}
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			if stalled := stallError(reqCtx); stalled != nil {
+				return fmt.Errorf("resume interrupted at %d bytes: %w", written, stalled)
+			}
+			return fmt.Errorf("resume interrupted at %d bytes: %w", written, readErr)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+func TestPrepareTrackTask(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, size int) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("01. Done.mp3", 10)
+	write("02. Partial.flac.part", 10)
+	write("03. Stale.mp3.part", 10)
+	write("03. Stale.mp3", 10) // Finished after an earlier interruption
+	write("04. Empty.flac.part", 0)
+
+	tests := []struct {
+		name        string
+		fileName    string
+		ifExists    string
+		wantSkip    bool
+		wantPartial string
+	}{
+		{name: "completed track is skipped", fileName: "01. Done", wantSkip: true},
+		{name: "partial track is resumed", fileName: "02. Partial", wantPartial: "02. Partial.flac.part"},
+		{name: "final file wins over a leftover part", fileName: "03. Stale", wantSkip: true},
+		{name: "empty part downloads fresh", fileName: "04. Empty"},
+		{name: "not started downloads fresh", fileName: "05. New"},
+		{name: "overwrite ignores partial", fileName: "02. Partial", ifExists: IfExistsOverwrite},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Engine{IfExists: tt.ifExists}
+			task := trackTask{Track: api.TrackMetadata{Title: tt.fileName}, FileName: tt.fileName}
+			var index *tagIndex
+
+			_, skip := e.prepareTrackTask(dir, &task, 6, &index)
+			if skip != tt.wantSkip {
+				t.Fatalf("skip = %v, want %v", skip, tt.wantSkip)
+			}
+			wantPartial := ""
+			if tt.wantPartial != "" {
+				wantPartial = filepath.Join(dir, tt.wantPartial)
+			}
+			if task.Partial != wantPartial {
+				t.Errorf("Partial = %q, want %q", task.Partial, wantPartial)
+			}
+		})
+	}
+}
+
+// withBitDepth returns a copy of a buildTestFLAC stream whose STREAMINFO
+// records bits bits per sample.
+func withBitDepth(data []byte, bits int) []byte {
+	out := append([]byte(nil), data...)
+	info := out[8:] // After "fLaC" and the block header
+	info[12] = info[12]&^0x01 | byte((bits-1)>>4)
+	info[13] = info[13]&0x0F | byte((bits-1)&0x0F)<<4
+	return out
+}
+
+func TestDecideExisting(t *testing.T) {
+	dir := t.TempDir()
+	flac16 := buildTestFLAC(4, 64)
+	files := map[string][]byte{
+		"cd.flac":        flac16,
+		"hires.flac":     withBitDepth(flac16, 24),
+		"truncated.flac": flac16[:len(flac16)-20],
+		"lossy.mp3":      []byte("ID3"),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		strategy    string
+		file        string
+		quality     int
+		maxBitDepth int
+		want        existsAction
+	}{
+		{name: "default skips", file: "cd.flac", quality: 27, want: actionSkip},
+		{name: "skip", strategy: IfExistsSkip, file: "truncated.flac", quality: 27, want: actionSkip},
+		{name: "overwrite", strategy: IfExistsOverwrite, file: "hires.flac", quality: 6, want: actionDownload},
+		{name: "upgrade CD to hi-res", strategy: IfExistsUpgrade, file: "cd.flac", quality: 7, maxBitDepth: 24, want: actionDownload},
+		{name: "upgrade unknown track depth", strategy: IfExistsUpgrade, file: "cd.flac", quality: 27, want: actionDownload},
+		{name: "upgrade capped by track depth", strategy: IfExistsUpgrade, file: "cd.flac", quality: 27, maxBitDepth: 16, want: actionSkip},
+		{name: "upgrade same depth", strategy: IfExistsUpgrade, file: "cd.flac", quality: 6, want: actionSkip},
+		{name: "upgrade hi-res kept", strategy: IfExistsUpgrade, file: "hires.flac", quality: 27, maxBitDepth: 24, want: actionSkip},
+		{name: "upgrade MP3 to CD", strategy: IfExistsUpgrade, file: "lossy.mp3", quality: 6, want: actionDownload},
+		{name: "upgrade MP3 to MP3", strategy: IfExistsUpgrade, file: "lossy.mp3", quality: 5, want: actionSkip},
+		{name: "resume complete file", strategy: IfExistsResume, file: "cd.flac", quality: 6, want: actionSkip},
+		{name: "resume truncated file", strategy: IfExistsResume, file: "truncated.flac", quality: 6, want: actionResume},
+		{name: "resume unverifiable format", strategy: IfExistsResume, file: "lossy.mp3", quality: 5, want: actionSkip},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.file)
+			if got := decideExisting(tt.strategy, path, tt.quality, tt.maxBitDepth); got != tt.want {
+				t.Errorf("decideExisting(%q, %s, %d, %d) = %v, want %v", tt.strategy, tt.file, tt.quality, tt.maxBitDepth, got, tt.want)
+			}
+		})
+	}
+	if got := fileBitDepth(filepath.Join(dir, "hires.flac")); got != 24 {
+		t.Errorf("fileBitDepth() = %d, want 24", got)
+	}
+}
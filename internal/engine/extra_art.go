@@ -0,0 +1,65 @@
+// extra_art.go collects additional album artwork (back cover, booklet pages)
+// for embedding alongside the front cover.
+package engine
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+// imageGoodieExts are goodie file extensions that can be embedded as pictures.
+// Other goodies (typically PDF booklets) are skipped.
+var imageGoodieExts = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+}
+
+// fetchExtraPictures downloads the album's back cover and image goodies.
+// Artwork that fails to download is skipped.
+func (e *Engine) fetchExtraPictures(album *api.AlbumMetadata) []*Picture {
+	var pictures []*Picture
+
+	if album.Image.Back != "" {
+		if data, err := e.downloadCover(album.Image.Back); err == nil {
+			pictures = append(pictures, newExtraPicture(data, PictureTypeCoverBack, "Back Cover"))
+		}
+	}
+
+	for i, goodie := range album.Goodies {
+		url := goodie.OriginalURL
+		if url == "" {
+			url = goodie.URL
+		}
+		if !imageGoodieExts[strings.ToLower(path.Ext(url))] {
+			continue
+		}
+
+		resp, err := e.Client.HTTP.R().Get(url)
+		if err != nil || resp.IsErrorState() {
+			continue
+		}
+		desc := goodie.Name
+		if desc == "" {
+			desc = fmt.Sprintf("Leaflet %d", i+1)
+		}
+		pictures = append(pictures, newExtraPicture(resp.Bytes(), PictureTypeLeaflet, desc))
+	}
+
+	return pictures
+}
+
+// newExtraPicture creates a picture of the given type, detecting its MIME type.
+func newExtraPicture(data []byte, pictureType uint32, description string) *Picture {
+	pic := NewPicture()
+	pic.MIME = http.DetectContentType(data)
+	pic.PictureType = pictureType
+	pic.Description = description
+	pic.ImageData = data
+	pic.detectDimensions()
+	return pic
+}
@@ -0,0 +1,125 @@
+package engine
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+
+	"github.com/go-flac/go-flac"
+)
+
+// flacPictures returns the Picture blocks of a FLAC file in order.
+func flacPictures(t *testing.T, path string) []*Picture {
+	t.Helper()
+	f, err := flac.ParseFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pictures []*Picture
+	for _, block := range f.Meta {
+		if block.Type != flac.Picture {
+			continue
+		}
+		pic, err := ParsePicture(block.Data)
+		if err != nil {
+			t.Fatalf("ParsePicture: %v", err)
+		}
+		pictures = append(pictures, pic)
+	}
+	return pictures
+}
+
+func TestWriteFlacTagsMultiplePictures(t *testing.T) {
+	cover := testJPEG(t, 8, 8)
+	back := newExtraPicture(testJPEG(t, 6, 4), PictureTypeCoverBack, "Back Cover")
+	page1 := newExtraPicture(testJPEG(t, 4, 6), PictureTypeLeaflet, "Booklet 1")
+	page2 := newExtraPicture(testJPEG(t, 2, 2), PictureTypeLeaflet, "Booklet 2")
+
+	tests := []struct {
+		name  string
+		extra []*Picture
+	}{
+		{name: "front cover only"},
+		{name: "back cover", extra: []*Picture{back}},
+		{name: "back cover and booklet", extra: []*Picture{back, page1, page2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "track.flac")
+			if err := os.WriteFile(path, buildTestFLAC(2, 64), 0644); err != nil {
+				t.Fatal(err)
+			}
+			tagger := NewTagger()
+			album := &api.AlbumMetadata{Title: "Album"}
+			track := &api.TrackMetadata{Title: "Track", Album: album}
+
+			want := append([]*Picture{{PictureType: PictureTypeCoverFront, Description: "Cover", ImageData: cover, Width: 8, Height: 8}}, tt.extra...)
+			for pass := range 2 { // Tagging again must not stack copies
+				if err := tagger.WriteFlacTags(path, track, album, cover, tt.extra...); err != nil {
+					t.Fatalf("WriteFlacTags: %v", err)
+				}
+				got := flacPictures(t, path)
+				if len(got) != len(want) {
+					t.Fatalf("pass %d: got %d pictures, want %d", pass, len(got), len(want))
+				}
+				for i, pic := range got {
+					w := want[i]
+					if pic.PictureType != w.PictureType || pic.Description != w.Description ||
+						pic.Width != w.Width || pic.Height != w.Height || string(pic.ImageData) != string(w.ImageData) {
+						t.Errorf("pass %d: picture %d = type %d %q %dx%d, want type %d %q %dx%d", pass, i,
+							pic.PictureType, pic.Description, pic.Width, pic.Height, w.PictureType, w.Description, w.Width, w.Height)
+					}
+				}
+			}
+			if err := QuickVerifyFLAC(path); err != nil {
+				t.Errorf("audio damaged by tagging: %v", err)
+			}
+		})
+	}
+}
+
+func TestFetchExtraPictures(t *testing.T) {
+	jpegData := testJPEG(t, 4, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/back.jpg", "/page.jpg", "/original.png":
+			w.Write(jpegData)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	album := &api.AlbumMetadata{Title: "Album"}
+	album.Image.Back = srv.URL + "/back.jpg"
+	album.Goodies = []api.Goodie{
+		{Name: "Booklet", URL: srv.URL + "/booklet.pdf"},
+		{Name: "Inlay", URL: srv.URL + "/page.jpg"},
+		{URL: srv.URL + "/small.jpg", OriginalURL: srv.URL + "/original.png"},
+		{Name: "Missing", URL: srv.URL + "/missing.jpg"},
+	}
+
+	e := New(api.NewClient("", ""))
+	got := e.fetchExtraPictures(album)
+
+	want := []struct {
+		pictureType uint32
+		description string
+	}{
+		{PictureTypeCoverBack, "Back Cover"},
+		{PictureTypeLeaflet, "Inlay"},
+		{PictureTypeLeaflet, "Leaflet 3"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d pictures, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		if got[i].PictureType != w.pictureType || got[i].Description != w.description || got[i].MIME != "image/jpeg" {
+			t.Errorf("picture %d = type %d %q %s, want type %d %q image/jpeg", i, got[i].PictureType, got[i].Description, got[i].MIME, w.pictureType, w.description)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+// failure.go sorts track download failures into categories, so the summary can
+// tell region and rights restrictions apart from subscription limits and
+// genuine authentication or network problems.
+package engine
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+// Failure categories reported in TrackResult.Reason.
+const (
+	FailureUnavailable  = "unavailable"  // Not available in this region or withdrawn by the rights holders
+	FailureSubscription = "subscription" // The subscription doesn't cover streaming this track
+	FailureAuth         = "auth"         // Missing, invalid or expired credentials
+	FailureNetwork      = "network"      // Connection problem or Qobuz server error
+	FailureCancelled    = "cancelled"    // The download was cancelled
+	FailureOther        = "error"        // Anything else (file system errors, ...)
+)
+
+// failureDescriptions are the user-facing descriptions of the failure categories.
+var failureDescriptions = map[string]string{
+	FailureUnavailable:  "not available in your region or withdrawn",
+	FailureSubscription: "not included in your subscription",
+	FailureAuth:         "authentication error",
+	FailureNetwork:      "network error",
+	FailureCancelled:    "cancelled",
+	FailureOther:        "error",
+}
+
+// failureLabels are the short failure labels of the progress display (up to 8 characters).
+var failureLabels = map[string]string{
+	FailureUnavailable:  "N/A",
+	FailureSubscription: "No plan",
+	FailureAuth:         "Auth",
+	FailureNetwork:      "Network",
+	FailureCancelled:    "Cancel",
+	FailureOther:        "Failed",
+}
+
+// FailureDescription returns the user-facing description of a failure category.
+func FailureDescription(reason string) string {
+	if desc, ok := failureDescriptions[reason]; ok {
+		return desc
+	}
+	return failureDescriptions[FailureOther]
+}
+
+// FailureReason returns the failure category of a track download error.
+func FailureReason(err error) string {
+	if err == nil {
+		return ""
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return FailureCancelled
+	}
+
+	var unavailable *api.UnavailableError
+	if errors.As(err, &unavailable) {
+		return restrictionReason(unavailable)
+	}
+
+	if apiErr, ok := api.AsAPIError(err); ok {
+		return apiErrorReason(apiErr)
+	}
+
+	var netErr net.Error
+	var urlErr *url.Error
+	if errors.As(err, &netErr) || errors.As(err, &urlErr) {
+		return FailureNetwork
+	}
+	return FailureOther
+}
+
+// restrictionReason maps the restrictions of a missing file URL to a category.
+// A preview-only answer means the account may only stream samples.
+func restrictionReason(err *api.UnavailableError) string {
+	for _, code := range err.Restrictions {
+		lower := strings.ToLower(code)
+		switch {
+		case strings.Contains(lower, "uncredentialed"):
+			return FailureAuth
+		case strings.Contains(lower, "ineligible"), strings.Contains(lower, "subscription"), strings.Contains(lower, "purchase"):
+			return FailureSubscription
+		}
+	}
+	if err.Sample {
+		return FailureSubscription
+	}
+	return FailureUnavailable
+}
+
+// apiErrorReason maps a Qobuz API error to a category, using the message to tell
+// rights and subscription refusals apart from rejected credentials.
+func apiErrorReason(err *api.APIError) string {
+	msg := strings.ToLower(err.Message)
+	switch {
+	case err.StatusCode >= 500:
+		return FailureNetwork
+	case strings.Contains(msg, "subscription"), strings.Contains(msg, "ineligible"), strings.Contains(msg, "not allowed to stream"):
+		return FailureSubscription
+	case strings.Contains(msg, "not available"), strings.Contains(msg, "country"), strings.Contains(msg, "region"),
+		strings.Contains(msg, "rights"), err.IsNotFound():
+		return FailureUnavailable
+	case err.StatusCode == http.StatusUnauthorized, strings.Contains(msg, "authentication"), strings.Contains(msg, "token"):
+		return FailureAuth
+	case err.StatusCode == http.StatusForbidden:
+		return FailureSubscription
+	}
+	return FailureOther
+}
@@ -0,0 +1,112 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+func TestFailureReasonFromResponses(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string // track/getFileUrl response
+		want   string
+	}{
+		{name: "region", status: 400, body: `{"status":"error","code":400,"message":"This track is not available in your country"}`, want: FailureUnavailable},
+		{name: "rights", status: 400, body: `{"status":"error","code":400,"message":"Streaming rights withdrawn"}`, want: FailureUnavailable},
+		{name: "not found", status: 404, body: `{"status":"error","code":404,"message":"No result matching given argument"}`, want: FailureUnavailable},
+		{name: "not streamable", status: 403, body: `{"status":"error","code":403,"message":"User is not allowed to stream this track"}`, want: FailureSubscription},
+		{name: "ineligible", status: 400, body: `{"status":"error","code":400,"message":"User ineligible for this format"}`, want: FailureSubscription},
+		{name: "forbidden", status: 403, body: `{"status":"error","code":403,"message":"Forbidden"}`, want: FailureSubscription},
+		{name: "login required", status: 401, body: `{"status":"error","code":401,"message":"User authentication is required."}`, want: FailureAuth},
+		{name: "expired token", status: 400, body: `{"status":"error","code":400,"message":"Invalid or expired user auth token"}`, want: FailureAuth},
+		{name: "server error", status: 502, body: `<html>Bad Gateway</html>`, want: FailureNetwork},
+		{name: "bad request", status: 400, body: `{"status":"error","code":400,"message":"Invalid argument: format_id"}`, want: FailureOther},
+		{name: "restricted by rights holders", status: 200, body: `{"restrictions":[{"code":"TrackRestrictedByRightHolders"}]}`, want: FailureUnavailable},
+		{name: "uncredentialed sample", status: 200, body: `{"url":"https://cdn/x","sample":true,"restrictions":[{"code":"UserUncredentialed"}]}`, want: FailureAuth},
+		{name: "sample of a restricted format", status: 200, body: `{"url":"https://cdn/x","sample":true,"restrictions":[{"code":"FormatRestrictedByFormatAvailability"}]}`, want: FailureSubscription},
+		{name: "ineligible user", status: 200, body: `{"restrictions":[{"code":"UserIneligible"}]}`, want: FailureSubscription},
+		{name: "bare sample", status: 200, body: `{"url":"https://cdn/x","sample":true}`, want: FailureSubscription},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer srv.Close()
+			client := api.NewClient("app", "secret")
+			client.HTTP.SetBaseURL(srv.URL)
+
+			_, err := client.GetTrackURL("1", 27)
+			if err == nil {
+				t.Fatal("GetTrackURL succeeded")
+			}
+			if got := FailureReason(fmt.Errorf("failed to get track URL: %w", err)); got != tt.want {
+				t.Errorf("FailureReason(%v) = %q, want %q", err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFailureReasonOtherErrors(t *testing.T) {
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	client := api.NewClient("app", "secret")
+	client.HTTP.SetBaseURL(closed.URL)
+	_, connErr := client.GetTrackURL("1", 27)
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "no error"},
+		{name: "connection refused", err: connErr, want: FailureNetwork},
+		{name: "cancelled", err: fmt.Errorf("download: %w", context.Canceled), want: FailureCancelled},
+		{name: "deadline", err: context.DeadlineExceeded, want: FailureCancelled},
+		{name: "file system", err: &os.PathError{Op: "open", Path: "x", Err: os.ErrPermission}, want: FailureOther},
+	}
+	for _, tt := range tests {
+		if got := FailureReason(tt.err); got != tt.want {
+			t.Errorf("%s: FailureReason(%v) = %q, want %q", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestFailedTrackReasonDisplayed(t *testing.T) {
+	fake := newFakeQobuz(t)
+	fake.addAlbum("alb1", "Album", "Band", 100, 2)
+	fake.unavailable[101] = true
+
+	result, err := fake.engine().DownloadAlbum(context.Background(), "alb1", 6, t.TempDir())
+	if err != nil {
+		t.Fatalf("DownloadAlbum: %v", err)
+	}
+	if len(result.Failed) != 1 || result.Failed[0].Reason != FailureUnavailable {
+		t.Fatalf("failed tracks %+v, want Track 2 unavailable", result.Failed)
+	}
+
+	for reason, label := range map[string]string{
+		FailureUnavailable:  "x N/A",
+		FailureSubscription: "x No plan",
+		FailureAuth:         "x Auth",
+		"":                  "x Failed",
+	} {
+		line := buildSongLine(trackState{FileName: "02. Track 2", Status: StatusFailed, Reason: reason}, 60)
+		if !strings.Contains(line, label) {
+			t.Errorf("song line %q for reason %q, want %q", line, reason, label)
+		}
+	}
+	if got := FailureDescription("bogus"); got != FailureDescription(FailureOther) {
+		t.Errorf("FailureDescription(unknown) = %q, want the generic description", got)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"strings"
 )
 
 // VorbisComment represents a FLAC Vorbis Comment metadata block.
@@ -88,6 +89,26 @@ func (vc *VorbisComment) Marshal() []byte {
 	return buf.Bytes()
 }
 
+// Get returns the first value of key (case-insensitive), or "" if absent.
+func (vc *VorbisComment) Get(key string) string {
+	if values := vc.GetAll(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// GetAll returns every value of key (case-insensitive) in order.
+func (vc *VorbisComment) GetAll(key string) []string {
+	var values []string
+	for _, c := range vc.Comments {
+		k, v, ok := strings.Cut(c, "=")
+		if ok && strings.EqualFold(k, key) {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
 // Add appends a new tag
 func (vc *VorbisComment) Add(key, value string) {
 	if value == "" {
@@ -96,6 +117,57 @@ func (vc *VorbisComment) Add(key, value string) {
 	vc.Comments = append(vc.Comments, fmt.Sprintf("%s=%s", key, value))
 }
 
+// Merge replaces every key present in updates with the values from updates,
+// keeping all other existing comments.
+func (vc *VorbisComment) Merge(updates *VorbisComment) {
+	keys := make(map[string]bool)
+	for _, c := range updates.Comments {
+		k, _, _ := strings.Cut(c, "=")
+		keys[strings.ToUpper(k)] = true
+	}
+
+	kept := vc.Comments[:0]
+	for _, c := range vc.Comments {
+		k, _, _ := strings.Cut(c, "=")
+		if !keys[strings.ToUpper(k)] {
+			kept = append(kept, c)
+		}
+	}
+	vc.Comments = append(kept, updates.Comments...)
+}
+
+// FillMissing adds the values from updates for keys that have no non-blank
+// value yet. Blank values of the filled keys are dropped; every other existing
+// comment is left untouched.
+func (vc *VorbisComment) FillMissing(updates *VorbisComment) {
+	present := make(map[string]bool)
+	for _, c := range vc.Comments {
+		k, v, _ := strings.Cut(c, "=")
+		if strings.TrimSpace(v) != "" {
+			present[strings.ToUpper(k)] = true
+		}
+	}
+
+	filled := make(map[string]bool)
+	var added []string
+	for _, c := range updates.Comments {
+		k, _, _ := strings.Cut(c, "=")
+		if !present[strings.ToUpper(k)] {
+			filled[strings.ToUpper(k)] = true
+			added = append(added, c)
+		}
+	}
+
+	kept := vc.Comments[:0]
+	for _, c := range vc.Comments {
+		k, _, _ := strings.Cut(c, "=")
+		if !filled[strings.ToUpper(k)] {
+			kept = append(kept, c)
+		}
+	}
+	vc.Comments = append(kept, added...)
+}
+
 // Picture Block
 type Picture struct {
 	MIME        string
@@ -132,6 +204,7 @@ const (
 	PictureTypePublisherLogotype  = 20
 )
 
+// NewPicture creates a front cover JPEG picture.
 func NewPicture() *Picture {
 	return &Picture{
 		PictureType: PictureTypeCoverFront,
@@ -139,6 +212,7 @@ func NewPicture() *Picture {
 	}
 }
 
+// Marshal serializes the picture into a FLAC Picture block body.
 func (p *Picture) Marshal() []byte {
 	buf := new(bytes.Buffer)
 
@@ -160,3 +234,47 @@ func (p *Picture) Marshal() []byte {
 
 	return buf.Bytes()
 }
+
+// ParsePicture parses a FLAC Picture block body.
+func ParsePicture(data []byte) (*Picture, error) {
+	buf := bytes.NewReader(data)
+	p := &Picture{}
+
+	readString := func(field string) (string, error) {
+		var length uint32
+		if err := binary.Read(buf, binary.BigEndian, &length); err != nil {
+			return "", fmt.Errorf("failed to read %s length: %w", field, err)
+		}
+		if int64(length) > int64(buf.Len()) {
+			return "", fmt.Errorf("%s length %d exceeds block size", field, length)
+		}
+		b := make([]byte, length)
+		if _, err := io.ReadFull(buf, b); err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", field, err)
+		}
+		return string(b), nil
+	}
+
+	if err := binary.Read(buf, binary.BigEndian, &p.PictureType); err != nil {
+		return nil, fmt.Errorf("failed to read picture type: %w", err)
+	}
+	var err error
+	if p.MIME, err = readString("mime"); err != nil {
+		return nil, err
+	}
+	if p.Description, err = readString("description"); err != nil {
+		return nil, err
+	}
+	for _, v := range []*uint32{&p.Width, &p.Height, &p.Depth, &p.ColorCount} {
+		if err := binary.Read(buf, binary.BigEndian, v); err != nil {
+			return nil, fmt.Errorf("failed to read picture dimensions: %w", err)
+		}
+	}
+	imageData, err := readString("image data")
+	if err != nil {
+		return nil, err
+	}
+	p.ImageData = []byte(imageData)
+
+	return p, nil
+}
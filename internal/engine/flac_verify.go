@@ -0,0 +1,304 @@
+// flac_verify.go provides a cheap truncation check for downloaded FLAC files.
+// Instead of decoding the audio, it locates the last frame from the end of the
+// file, validates its CRCs and compares where it ends with the STREAMINFO sample count.
+package engine
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	// verifyRetries is how many times a download failing quick verification is retried.
+	verifyRetries = 2
+	// defaultTailWindow is how much of the file end is scanned when STREAMINFO has no max frame size.
+	defaultTailWindow = 1 << 20
+	// flacFrameFooterSize is the size of the CRC-16 at the end of every frame.
+	flacFrameFooterSize = 2
+)
+
+// flacStreamInfo holds the STREAMINFO fields needed for verification.
+type flacStreamInfo struct {
+	MinBlockSize  uint16
+	MaxBlockSize  uint16
+	MaxFrameSize  uint32
+	BitsPerSample int
+	TotalSamples  uint64
+	AudioOffset   int64 // File offset of the first audio frame
+}
+
+// QuickVerifyFLAC checks that a FLAC file is not truncated by confirming that its
+// last frame is intact and ends exactly at the STREAMINFO total sample count.
+// Files whose STREAMINFO does not record a sample count are accepted.
+func QuickVerifyFLAC(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := readFlacStreamInfo(f)
+	if err != nil {
+		return err
+	}
+	if info.TotalSamples == 0 {
+		return nil // Unknown length, nothing to compare against
+	}
+
+	stat, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	window := int64(defaultTailWindow)
+	if info.MaxFrameSize > 0 {
+		window = int64(info.MaxFrameSize) * 2
+	}
+	start := max(stat.Size()-window, info.AudioOffset)
+
+	tail := make([]byte, stat.Size()-start)
+	if _, err := f.ReadAt(tail, start); err != nil && err != io.EOF {
+		return err
+	}
+
+	end, ok := lastFrameEnd(tail, info)
+	if !ok {
+		return fmt.Errorf("flac truncated: no intact final frame found")
+	}
+	if end != info.TotalSamples {
+		return fmt.Errorf("flac truncated: frames end at sample %d, expected %d", end, info.TotalSamples)
+	}
+
+	return nil
+}
+
+// readFlacStreamInfo reads STREAMINFO and skips the remaining metadata blocks.
+func readFlacStreamInfo(r io.ReadSeeker) (*flacStreamInfo, error) {
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(r, magic); err != nil || string(magic) != "fLaC" {
+		return nil, fmt.Errorf("not a FLAC file")
+	}
+
+	var info *flacStreamInfo
+	offset := int64(4)
+	for {
+		header := make([]byte, 4)
+		if _, err := io.ReadFull(r, header); err != nil {
+			return nil, fmt.Errorf("failed to read metadata block header: %w", err)
+		}
+		last := header[0]&0x80 != 0
+		blockType := header[0] & 0x7F
+		length := int64(header[1])<<16 | int64(header[2])<<8 | int64(header[3])
+		offset += 4 + length
+
+		if blockType == 0 {
+			data := make([]byte, length)
+			if _, err := io.ReadFull(r, data); err != nil || length < 18 {
+				return nil, fmt.Errorf("invalid STREAMINFO block")
+			}
+			info = &flacStreamInfo{
+				MinBlockSize: binary.BigEndian.Uint16(data[0:2]),
+				MaxBlockSize: binary.BigEndian.Uint16(data[2:4]),
+				MaxFrameSize: uint32(data[7])<<16 | uint32(data[8])<<8 | uint32(data[9]),
+				// Bits per sample minus one spans the last bit of byte 12 and the top nibble of byte 13
+				BitsPerSample: int((data[12]&0x01)<<4|data[13]>>4) + 1,
+				// Total samples are the low 36 bits of bytes 13-17
+				TotalSamples: uint64(data[13]&0x0F)<<32 | uint64(binary.BigEndian.Uint32(data[14:18])),
+			}
+		} else if _, err := r.Seek(length, io.SeekCurrent); err != nil {
+			return nil, err
+		}
+
+		if last {
+			break
+		}
+	}
+
+	if info == nil {
+		return nil, fmt.Errorf("missing STREAMINFO block")
+	}
+	info.AudioOffset = offset
+	return info, nil
+}
+
+// lastFrameEnd scans tail backwards for the final frame, i.e. a valid frame header
+// whose CRC-16 covers exactly the rest of the data. Returns the sample number
+// just past that frame.
+func lastFrameEnd(tail []byte, info *flacStreamInfo) (uint64, bool) {
+	if len(tail) < flacFrameFooterSize {
+		return 0, false
+	}
+	footer := uint16(tail[len(tail)-2])<<8 | uint16(tail[len(tail)-1])
+
+	for i := len(tail) - flacFrameFooterSize - 1; i >= 0; i-- {
+		if tail[i] != 0xFF || tail[i+1]&0xFE != 0xF8 {
+			continue
+		}
+		first, blockSize, ok := parseFlacFrameHeader(tail[i:], info)
+		if !ok {
+			continue
+		}
+		if flacCRC16(tail[i:len(tail)-flacFrameFooterSize]) == footer {
+			return first + uint64(blockSize), true
+		}
+	}
+
+	return 0, false
+}
+
+// parseFlacFrameHeader decodes a frame header at the start of data and returns the
+// frame's first sample number and block size. ok is false for invalid headers.
+func parseFlacFrameHeader(data []byte, info *flacStreamInfo) (first uint64, blockSize uint32, ok bool) {
+	if len(data) < 6 || data[0] != 0xFF || data[1]&0xFE != 0xF8 {
+		return 0, 0, false
+	}
+	variable := data[1]&0x01 != 0
+	blockCode := data[2] >> 4
+	rateCode := data[2] & 0x0F
+	channels := data[3] >> 4
+	sizeCode := (data[3] >> 1) & 0x07
+
+	if blockCode == 0 || rateCode == 0x0F || channels > 10 || sizeCode == 3 || data[3]&0x01 != 0 {
+		return 0, 0, false
+	}
+
+	// UTF-8 style coded frame or sample number
+	pos := 4
+	number, n, ok := readFlacCodedNumber(data[pos:])
+	if !ok {
+		return 0, 0, false
+	}
+	pos += n
+
+	switch {
+	case blockCode == 1:
+		blockSize = 192
+	case blockCode <= 5:
+		blockSize = 576 << (blockCode - 2)
+	case blockCode == 6:
+		if pos+1 > len(data) {
+			return 0, 0, false
+		}
+		blockSize = uint32(data[pos]) + 1
+		pos++
+	case blockCode == 7:
+		if pos+2 > len(data) {
+			return 0, 0, false
+		}
+		blockSize = uint32(binary.BigEndian.Uint16(data[pos:])) + 1
+		pos += 2
+	default:
+		blockSize = 256 << (blockCode - 8)
+	}
+
+	switch rateCode {
+	case 12:
+		pos++
+	case 13, 14:
+		pos += 2
+	}
+
+	if pos >= len(data) || flacCRC8(data[:pos]) != data[pos] {
+		return 0, 0, false
+	}
+
+	if variable {
+		return number, blockSize, true
+	}
+	// Fixed block size streams number frames; all but the last use the nominal size
+	return number * uint64(info.MaxBlockSize), blockSize, true
+}
+
+// readFlacCodedNumber decodes the UTF-8 like variable length number in a frame header.
+func readFlacCodedNumber(data []byte) (uint64, int, bool) {
+	if len(data) == 0 {
+		return 0, 0, false
+	}
+	b := data[0]
+	var length int
+	var value uint64
+	switch {
+	case b&0x80 == 0:
+		return uint64(b), 1, true
+	case b&0xE0 == 0xC0:
+		length, value = 2, uint64(b&0x1F)
+	case b&0xF0 == 0xE0:
+		length, value = 3, uint64(b&0x0F)
+	case b&0xF8 == 0xF0:
+		length, value = 4, uint64(b&0x07)
+	case b&0xFC == 0xF8:
+		length, value = 5, uint64(b&0x03)
+	case b&0xFE == 0xFC:
+		length, value = 6, uint64(b&0x01)
+	case b == 0xFE:
+		length, value = 7, 0
+	default:
+		return 0, 0, false
+	}
+	if len(data) < length {
+		return 0, 0, false
+	}
+	for _, c := range data[1:length] {
+		if c&0xC0 != 0x80 {
+			return 0, 0, false
+		}
+		value = value<<6 | uint64(c&0x3F)
+	}
+	return value, length, true
+}
+
+// flacCRC8 computes the frame header CRC-8 (polynomial 0x07).
+func flacCRC8(data []byte) byte {
+	var crc byte
+	for _, b := range data {
+		crc ^= b
+		for range 8 {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x07
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// flacCRC16 computes the frame CRC-16 (polynomial 0x8005).
+func flacCRC16(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for range 8 {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x8005
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// fetchVerified runs download and, when QuickVerify is enabled for FLAC output,
+// retries it up to verifyRetries times if the file is truncated. A file that
+// still fails verification is removed so it is not mistaken for a finished track.
+func (e *Engine) fetchVerified(path, container string, download func() error) error {
+	for attempt := 0; ; attempt++ {
+		if err := download(); err != nil {
+			return err
+		}
+		if !e.QuickVerify || container != ".flac" {
+			return nil
+		}
+		err := QuickVerifyFLAC(path)
+		if err == nil {
+			return nil
+		}
+		if attempt >= verifyRetries {
+			os.Remove(path)
+			return fmt.Errorf("verification failed after %d attempts: %w", attempt+1, err)
+		}
+	}
+}
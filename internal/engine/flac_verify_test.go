@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestQuickVerifyFLAC(t *testing.T) {
+	const frames, blockSize = 4, 64
+	data := buildTestFLAC(frames, blockSize)
+	frameSize := len(buildTestFLACFrame(0, blockSize))
+	audioOffset := len(data) - frames*frameSize
+
+	tests := []struct {
+		name    string
+		size    int // Bytes of data kept
+		wantErr bool
+	}{
+		{name: "complete", size: len(data)},
+		{name: "last byte missing", size: len(data) - 1, wantErr: true},
+		{name: "cut inside last frame", size: len(data) - frameSize/2, wantErr: true},
+		{name: "cut at frame boundary", size: len(data) - frameSize, wantErr: true},
+		{name: "cut inside frame header", size: audioOffset + frameSize + 3, wantErr: true},
+		{name: "metadata only", size: audioOffset, wantErr: true},
+		{name: "cut inside STREAMINFO", size: 20, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "track.flac")
+			if err := os.WriteFile(path, data[:tt.size], 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := QuickVerifyFLAC(path); (err != nil) != tt.wantErr {
+				t.Errorf("QuickVerifyFLAC() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFetchVerified(t *testing.T) {
+	full := buildTestFLAC(4, 64)
+	truncated := full[:len(full)-10]
+	errNetwork := errors.New("network error")
+
+	tests := []struct {
+		name        string
+		downloads   [][]byte // Content written by each attempt; nil fails the attempt
+		quickVerify bool
+		wantErr     bool
+		wantCalls   int
+	}{
+		{name: "complete first time", downloads: [][]byte{full}, quickVerify: true, wantCalls: 1},
+		{name: "truncated then complete", downloads: [][]byte{truncated, full}, quickVerify: true, wantCalls: 2},
+		{name: "always truncated", downloads: [][]byte{truncated, truncated, truncated}, quickVerify: true, wantErr: true, wantCalls: verifyRetries + 1},
+		{name: "verification disabled", downloads: [][]byte{truncated}, wantCalls: 1},
+		{name: "download error is not retried", downloads: [][]byte{nil}, quickVerify: true, wantErr: true, wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "track.flac")
+			e := New(nil)
+			e.QuickVerify = tt.quickVerify
+
+			calls := 0
+			err := e.fetchVerified(path, ".flac", func() error {
+				data := tt.downloads[calls]
+				calls++
+				if data == nil {
+					return errNetwork
+				}
+				return os.WriteFile(path, data, 0644)
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("fetchVerified() error = %v, want error %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("download ran %d times, want %d", calls, tt.wantCalls)
+			}
+			if _, statErr := os.Stat(path); tt.wantErr && statErr == nil {
+				t.Error("file left behind after failed download")
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+// format.go detects the container of an audio file from its leading bytes,
+// so files with a wrong or missing extension are still tagged correctly.
+package engine
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+)
+
+// Format is an audio container recognized by DetectFormat.
+type Format string
+
+// Supported containers. The values are the extensions of the matching tagger.
+const (
+	FormatUnknown Format = ""
+	FormatFLAC    Format = ".flac"
+	FormatMP3     Format = ".mp3"
+	FormatOgg     Format = ".ogg" // Ogg Opus or Ogg Vorbis
+)
+
+// DetectFormat sniffs the container of the file at path from its magic bytes:
+// "fLaC", "ID3" or an MPEG frame sync, and "OggS". Returns FormatUnknown for
+// anything else.
+func DetectFormat(path string) (Format, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return FormatUnknown, err
+	}
+	defer f.Close()
+
+	head := make([]byte, 4)
+	n, err := io.ReadFull(f, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return FormatUnknown, err
+	}
+	return detectFormat(head[:n]), nil
+}
+
+// detectFormat identifies a container from the first bytes of a file.
+func detectFormat(head []byte) Format {
+	switch {
+	case bytes.HasPrefix(head, []byte("fLaC")):
+		return FormatFLAC
+	case bytes.HasPrefix(head, []byte("OggS")):
+		return FormatOgg
+	case bytes.HasPrefix(head, []byte("ID3")):
+		return FormatMP3
+	case len(head) >= 2 && head[0] == 0xFF && head[1]&0xE0 == 0xE0:
+		// MPEG audio frame sync (11 set bits), an MP3 without an ID3v2 tag
+		return FormatMP3
+	default:
+		return FormatUnknown
+	}
+}
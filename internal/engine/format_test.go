@@ -0,0 +1,91 @@
+package engine
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+// testMP3 is an MPEG-1 Layer III frame header followed by silence, without an ID3 tag.
+var testMP3 = append([]byte{0xFF, 0xFB, 0x90, 0x64}, make([]byte, 413)...)
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want Format
+	}{
+		{name: "flac", data: buildTestFLAC(1, 64), want: FormatFLAC},
+		{name: "mp3 with id3", data: []byte("ID3\x04\x00\x00\x00\x00\x00\x00"), want: FormatMP3},
+		{name: "mp3 frame sync", data: testMP3, want: FormatMP3},
+		{name: "ogg", data: buildTestOgg(true, []byte("audio")), want: FormatOgg},
+		{name: "unknown", data: []byte("RIFF\x00\x00\x00\x00WAVE"), want: FormatUnknown},
+		{name: "too short", data: []byte("fL"), want: FormatUnknown},
+		{name: "empty", want: FormatUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "file")
+			if err := os.WriteFile(path, tt.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := DetectFormat(path)
+			if err != nil {
+				t.Fatalf("DetectFormat() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("DetectFormat() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := DetectFormat(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("DetectFormat() succeeded for a missing file")
+	}
+}
+
+func TestWriteTagsMisleadingExtension(t *testing.T) {
+	tests := []struct {
+		name   string
+		file   string
+		data   []byte
+		format Format
+	}{
+		{name: "mp3 named flac", file: "track.flac", data: testMP3, format: FormatMP3},
+		{name: "flac named mp3", file: "track.mp3", data: buildTestFLAC(1, 64), format: FormatFLAC},
+		{name: "opus named flac", file: "track.flac", data: buildTestOgg(true, []byte("audio")), format: FormatOgg},
+		{name: "flac without extension", file: "track", data: buildTestFLAC(1, 64), format: FormatFLAC},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, tt.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			album := &api.AlbumMetadata{Title: "Album"}
+			if err := NewTagger().WriteTags(path, &api.TrackMetadata{Title: "Track"}, album, nil); err != nil {
+				t.Fatalf("WriteTags: %v", err)
+			}
+
+			if got, _ := DetectFormat(path); got != tt.format {
+				t.Errorf("container after tagging = %q, want %q", got, tt.format)
+			}
+			tags, err := readTagsAs(path, string(tt.format))
+			if err != nil {
+				t.Fatalf("reading tags as %s: %v", tt.format, err)
+			}
+			if tags.Title != "Track" || tags.Album != "Album" {
+				t.Errorf("tags = %q, %q; want %q, %q", tags.Title, tags.Album, "Track", "Album")
+			}
+			if tt.format == FormatMP3 {
+				data, _ := os.ReadFile(path)
+				if !bytes.HasSuffix(data, testMP3) {
+					t.Error("MPEG frames changed")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,275 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+// buildTestFLAC returns a valid mono 16-bit 44.1 kHz FLAC stream of frames
+// verbatim-coded frames of blockSize samples each.
+func buildTestFLAC(frames, blockSize int) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("fLaC")
+
+	// STREAMINFO, marked as the last metadata block
+	info := make([]byte, 34)
+	binary.BigEndian.PutUint16(info[0:], uint16(blockSize))
+	binary.BigEndian.PutUint16(info[2:], uint16(blockSize))
+	total := uint64(frames * blockSize)
+	packed := uint64(44100)<<44 | uint64(0)<<41 | uint64(15)<<36 | total
+	binary.BigEndian.PutUint64(info[10:], packed)
+	buf.Write([]byte{0x80, 0, 0, byte(len(info))})
+	buf.Write(info)
+
+	for i := range frames {
+		buf.Write(buildTestFLACFrame(i, blockSize))
+	}
+	return buf.Bytes()
+}
+
+// buildTestFLACFrame encodes frame number n with a verbatim subframe.
+func buildTestFLACFrame(n, blockSize int) []byte {
+	frame := []byte{
+		0xFF, 0xF8, // Sync code, fixed block size
+		7<<4 | 9,    // 16-bit block size at the end of the header, 44.1 kHz
+		0<<4 | 4<<1, // Mono, 16 bits per sample
+	}
+	frame = append(frame, byte(n)) // Coded frame number (n < 128)
+	frame = binary.BigEndian.AppendUint16(frame, uint16(blockSize-1))
+	frame = append(frame, flacCRC8(frame))
+
+	frame = append(frame, 0x02) // Verbatim subframe
+	for s := range blockSize {
+		frame = binary.BigEndian.AppendUint16(frame, uint16(s))
+	}
+	return binary.BigEndian.AppendUint16(frame, flacCRC16(frame))
+}
+
+// testJPEG returns a small JPEG image of the given size.
+func testJPEG(t testing.TB, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height)), nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// fakeQobuz serves the parts of the Qobuz API and CDN the engine uses for
+// album and track downloads, from an in-memory catalog.
+type fakeQobuz struct {
+	srv   *httptest.Server
+	audio []byte // Served for every track file
+	cover []byte // Served for every cover image
+
+	mu          sync.Mutex
+	albums      map[string]*api.AlbumMetadata
+	tracks      map[int]*api.TrackMetadata
+	playlists   map[string]*api.PlaylistMetadata
+	artists     map[string]*api.ArtistMetadata
+	covers      map[string][]byte // Cover images by path, instead of cover
+	unavailable map[int]bool      // Tracks whose file URL request finds no file
+	requests    map[string]int    // Request count per path
+
+	onFile     func() // Called while serving each track file, if set
+	onTrackURL func() // Called while serving each track URL request, if set
+	onCover    func() // Called while serving each cover image, if set
+}
+
+// newFakeQobuz starts a fake server that is closed when the test ends.
+func newFakeQobuz(t testing.TB) *fakeQobuz {
+	f := &fakeQobuz{
+		audio:       buildTestFLAC(4, 64),
+		cover:       testJPEG(t, 8, 8),
+		albums:      make(map[string]*api.AlbumMetadata),
+		tracks:      make(map[int]*api.TrackMetadata),
+		playlists:   make(map[string]*api.PlaylistMetadata),
+		artists:     make(map[string]*api.ArtistMetadata),
+		covers:      make(map[string][]byte),
+		unavailable: make(map[int]bool),
+		requests:    make(map[string]int),
+	}
+	f.srv = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+// addAlbum adds an album with tracks numbered from firstTrackID.
+func (f *fakeQobuz) addAlbum(id, title, artist string, firstTrackID, tracks int) *api.AlbumMetadata {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	album := &api.AlbumMetadata{ID: id, Title: title, ReleaseDateOrg: "2020-05-01", TracksCount: tracks, MediaCount: 1}
+	album.Artist.Name = artist
+	album.Image.Large = f.srv.URL + "/covers/" + id + "_600.jpg"
+	for i := range tracks {
+		track := api.TrackMetadata{
+			ID:              firstTrackID + i,
+			Title:           fmt.Sprintf("Track %d", i+1),
+			TrackNumber:     i + 1,
+			MediaNumber:     1,
+			MaximumBitDepth: 16,
+		}
+		track.Performer.Name = artist
+		album.Tracks.Items = append(album.Tracks.Items, track)
+
+		withAlbum := track
+		summary := *album
+		summary.Tracks.Items = nil
+		withAlbum.Album = &summary
+		f.tracks[track.ID] = &withAlbum
+	}
+	album.Tracks.Total = tracks
+	f.albums[id] = album
+	return album
+}
+
+// addPlaylist adds a playlist of previously added tracks.
+func (f *fakeQobuz) addPlaylist(id, name string, trackIDs ...int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	playlist := &api.PlaylistMetadata{Name: name}
+	for _, trackID := range trackIDs {
+		playlist.Tracks.Items = append(playlist.Tracks.Items, *f.tracks[trackID])
+	}
+	playlist.Tracks.Total = len(trackIDs)
+	f.playlists[id] = playlist
+}
+
+// addArtist adds an artist whose discography lists previously added albums.
+func (f *fakeQobuz) addArtist(id, name string, albumIDs ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	artist := &api.ArtistMetadata{Name: name}
+	for _, albumID := range albumIDs {
+		summary := *f.albums[albumID]
+		summary.Tracks.Items = nil
+		artist.Albums.Items = append(artist.Albums.Items, summary)
+	}
+	artist.Albums.Total = len(albumIDs)
+	f.artists[id] = artist
+}
+
+// count returns how many requests were made to path.
+func (f *fakeQobuz) count(path string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests[path]
+}
+
+// engine returns an engine whose client talks to the fake server.
+func (f *fakeQobuz) engine() *Engine {
+	client := api.NewClient("app", "secret")
+	client.HTTP.SetBaseURL(f.srv.URL)
+	client.SetUserToken("token")
+	return New(client)
+}
+
+func (f *fakeQobuz) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.requests[r.URL.Path]++
+	f.mu.Unlock()
+
+	q := r.URL.Query()
+	switch {
+	case r.URL.Path == "/album/get":
+		f.mu.Lock()
+		album, ok := f.albums[q.Get("album_id")]
+		f.mu.Unlock()
+		if !ok {
+			writeAPIError(w, http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(album)
+	case r.URL.Path == "/track/get":
+		id, _ := strconv.Atoi(q.Get("track_id"))
+		f.mu.Lock()
+		track, ok := f.tracks[id]
+		f.mu.Unlock()
+		if !ok {
+			writeAPIError(w, http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(track)
+	case r.URL.Path == "/playlist/get":
+		f.mu.Lock()
+		playlist, ok := f.playlists[q.Get("playlist_id")]
+		f.mu.Unlock()
+		if !ok {
+			writeAPIError(w, http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(playlist)
+	case r.URL.Path == "/artist/get":
+		f.mu.Lock()
+		artist, ok := f.artists[q.Get("artist_id")]
+		f.mu.Unlock()
+		if !ok {
+			writeAPIError(w, http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(artist)
+	case r.URL.Path == "/track/getFileUrl":
+		if f.onTrackURL != nil {
+			f.onTrackURL()
+		}
+		id, _ := strconv.Atoi(q.Get("track_id"))
+		f.mu.Lock()
+		_, ok := f.tracks[id]
+		unavailable := f.unavailable[id]
+		f.mu.Unlock()
+		if !ok {
+			writeAPIError(w, http.StatusNotFound)
+			return
+		}
+		resp := api.TrackURLResponse{MimeType: "audio/flac", BitDepth: 16, SamplingRate: 44.1}
+		switch {
+		case unavailable:
+		case r.Header.Get("X-User-Auth-Token") == "": // Anonymous requests get the preview clip
+			resp.URL = fmt.Sprintf("%s/previews/%d.flac", f.srv.URL, id)
+			resp.Sample = true
+		default:
+			resp.URL = fmt.Sprintf("%s/file/%d.flac", f.srv.URL, id)
+		}
+		json.NewEncoder(w).Encode(resp)
+	case strings.HasPrefix(r.URL.Path, "/file/"), strings.HasPrefix(r.URL.Path, "/previews/"):
+		if f.onFile != nil {
+			f.onFile()
+		}
+		w.Write(f.audio)
+	case strings.HasPrefix(r.URL.Path, "/covers/"):
+		if f.onCover != nil {
+			f.onCover()
+		}
+		f.mu.Lock()
+		cover, ok := f.covers[r.URL.Path]
+		f.mu.Unlock()
+		if !ok {
+			cover = f.cover
+		}
+		w.Write(cover)
+	default:
+		writeAPIError(w, http.StatusNotFound)
+	}
+}
+
+// writeAPIError writes a Qobuz style error response.
+func writeAPIError(w http.ResponseWriter, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `{"status":"error","code":%d,"message":"%s"}`, status, http.StatusText(status))
+}
@@ -0,0 +1,38 @@
+// loudness.go converts Qobuz loudness metadata into R128 gain tags.
+// Values come from the API, so no audio needs to be decoded.
+package engine
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+// replayGainToR128Offset is the difference between the ReplayGain 2.0 reference
+// (-18 LUFS) and the EBU R128 reference (-23 LUFS) in dB.
+const replayGainToR128Offset = -5.0
+
+// r128Gain converts a ReplayGain gain in dB to an R128 gain tag value:
+// a Q7.8 fixed-point integer relative to -23 LUFS, clamped to int16.
+func r128Gain(replayGainDB float64) string {
+	q := math.Round((replayGainDB + replayGainToR128Offset) * 256)
+	q = math.Max(math.MinInt16, math.Min(math.MaxInt16, q))
+	return strconv.Itoa(int(q))
+}
+
+// r128Tags returns the R128_TRACK_GAIN and R128_ALBUM_GAIN values for a track,
+// or empty strings when the loudness is unknown.
+func r128Tags(track *api.TrackMetadata) (trackGain, albumGain string) {
+	info := track.AudioInfo
+	if info == nil {
+		return "", ""
+	}
+	if info.ReplayGainTrackGain != nil {
+		trackGain = r128Gain(*info.ReplayGainTrackGain)
+	}
+	if info.ReplayGainAlbumGain != nil {
+		albumGain = r128Gain(*info.ReplayGainAlbumGain)
+	}
+	return trackGain, albumGain
+}
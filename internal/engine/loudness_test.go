@@ -0,0 +1,72 @@
+package engine
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+func TestR128Gain(t *testing.T) {
+	tests := []struct {
+		replayGain float64
+		want       string
+	}{
+		{replayGain: 5, want: "0"}, // -18 LUFS reference + 5 dB is -23 LUFS
+		{replayGain: 0, want: "-1280"},
+		{replayGain: -7.3, want: "-3149"},
+		{replayGain: 6.5, want: "384"},
+		{replayGain: -200, want: "-32768"},
+		{replayGain: 200, want: "32767"},
+	}
+	for _, tt := range tests {
+		if got := r128Gain(tt.replayGain); got != tt.want {
+			t.Errorf("r128Gain(%v) = %s, want %s", tt.replayGain, got, tt.want)
+		}
+	}
+}
+
+func TestR128Tags(t *testing.T) {
+	tests := []struct {
+		name          string
+		track         string // track/get response
+		writeR128     bool
+		wantTrackGain string
+		wantAlbumGain string
+	}{
+		{
+			name:          "track and album gain",
+			track:         `{"title": "T", "audio_info": {"replaygain_track_gain": -7.3, "replaygain_track_peak": 0.98, "replaygain_album_gain": -6.5, "replaygain_album_peak": 1}}`,
+			writeR128:     true,
+			wantTrackGain: "-3149",
+			wantAlbumGain: "-2944",
+		},
+		{
+			name:          "track gain only",
+			track:         `{"title": "T", "audio_info": {"replaygain_track_gain": 0}}`,
+			writeR128:     true,
+			wantTrackGain: "-1280",
+		},
+		{name: "no loudness metadata", track: `{"title": "T"}`, writeR128: true},
+		{name: "empty audio_info", track: `{"title": "T", "audio_info": {}}`, writeR128: true},
+		{name: "disabled", track: `{"title": "T", "audio_info": {"replaygain_track_gain": -7.3}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var track api.TrackMetadata
+			if err := json.Unmarshal([]byte(tt.track), &track); err != nil {
+				t.Fatal(err)
+			}
+			tagger := NewTagger()
+			tagger.WriteR128 = tt.writeR128
+
+			cmts := tagger.vorbisCommentUpdates("", "FLAC", &track, &api.AlbumMetadata{})
+			if got := cmts.Get("R128_TRACK_GAIN"); got != tt.wantTrackGain {
+				t.Errorf("R128_TRACK_GAIN = %q, want %q", got, tt.wantTrackGain)
+			}
+			if got := cmts.Get("R128_ALBUM_GAIN"); got != tt.wantAlbumGain {
+				t.Errorf("R128_ALBUM_GAIN = %q, want %q", got, tt.wantAlbumGain)
+			}
+		})
+	}
+}
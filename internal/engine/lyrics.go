@@ -0,0 +1,359 @@
+// lyrics.go provides LRC parsing and synchronized lyrics embedding.
+// Lyrics are read from an .lrc sidecar next to the audio file, written as
+// an ID3v2 SYLT frame for MP3 and a LYRICS Vorbis comment for FLAC.
+// Qobuz does not serve lyrics, so sidecars are supplied by the user: either
+// placed before tagging or installed later with EmbedLyricsFile.
+package engine
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/bogem/id3v2/v2"
+	"github.com/go-flac/go-flac"
+)
+
+// LyricLine is a single timestamped lyric line.
+type LyricLine struct {
+	Time time.Duration
+	Text string
+}
+
+// lrcTimestampRegex matches LRC timestamps like [01:23.45] or [01:23:450].
+var lrcTimestampRegex = regexp.MustCompile(`\[(\d+):(\d{1,2})(?:[.:](\d{1,3}))?\]`)
+
+// lrcOffsetRegex matches the optional [offset:+/-ms] header.
+var lrcOffsetRegex = regexp.MustCompile(`(?i)^\[offset:\s*([+-]?\d+)\]`)
+
+// ParseLRC parses LRC content into lines sorted by timestamp.
+// Lines with multiple timestamps are expanded; metadata headers are ignored
+// except [offset:], which shifts every timestamp.
+func ParseLRC(content string) ([]LyricLine, error) {
+	var lines []LyricLine
+	var offset time.Duration
+
+	for _, raw := range strings.Split(content, "\n") {
+		raw = strings.TrimSpace(strings.TrimSuffix(raw, "\r"))
+		if raw == "" {
+			continue
+		}
+
+		if m := lrcOffsetRegex.FindStringSubmatch(raw); m != nil {
+			ms, _ := strconv.Atoi(m[1])
+			offset = time.Duration(ms) * time.Millisecond
+			continue
+		}
+
+		stamps := lrcTimestampRegex.FindAllStringSubmatchIndex(raw, -1)
+		if len(stamps) == 0 || stamps[0][0] != 0 {
+			continue // Metadata header or plain text
+		}
+
+		// Text follows the last leading timestamp
+		end := 0
+		var times []time.Duration
+		for _, loc := range stamps {
+			if loc[0] != end {
+				break
+			}
+			end = loc[1]
+			times = append(times, lrcTimestamp(raw, loc))
+		}
+		text := strings.TrimSpace(raw[end:])
+
+		for _, t := range times {
+			lines = append(lines, LyricLine{Time: t, Text: text})
+		}
+	}
+
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("no timestamped lyrics found")
+	}
+
+	for i := range lines {
+		lines[i].Time -= offset
+		if lines[i].Time < 0 {
+			lines[i].Time = 0
+		}
+	}
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].Time < lines[j].Time })
+
+	return lines, nil
+}
+
+// lrcTimestamp converts a timestamp match at loc into a duration.
+func lrcTimestamp(s string, loc []int) time.Duration {
+	minutes, _ := strconv.Atoi(s[loc[2]:loc[3]])
+	seconds, _ := strconv.Atoi(s[loc[4]:loc[5]])
+	d := time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second
+
+	if loc[6] >= 0 {
+		frac := s[loc[6]:loc[7]]
+		n, _ := strconv.Atoi(frac)
+		// Fraction is hundredths for 2 digits, milliseconds for 3
+		switch len(frac) {
+		case 1:
+			d += time.Duration(n) * 100 * time.Millisecond
+		case 2:
+			d += time.Duration(n) * 10 * time.Millisecond
+		default:
+			d += time.Duration(n) * time.Millisecond
+		}
+	}
+	return d
+}
+
+// plainLyrics returns the lyric text without timestamps.
+func plainLyrics(lines []LyricLine) string {
+	texts := make([]string, len(lines))
+	for i, l := range lines {
+		texts[i] = l.Text
+	}
+	return strings.Join(texts, "\n")
+}
+
+// LyricsSidecarPath returns the .lrc file sharing the audio file's base name.
+func LyricsSidecarPath(audioPath string) string {
+	return strings.TrimSuffix(audioPath, filepath.Ext(audioPath)) + ".lrc"
+}
+
+// readLRCSidecar reads the .lrc file sharing the audio file's base name.
+// Returns an empty string if no sidecar exists.
+func readLRCSidecar(audioPath string) string {
+	data, err := os.ReadFile(LyricsSidecarPath(audioPath))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// EmbedLyricsFile embeds the LRC lyrics in lrcPath into an already downloaded
+// FLAC or MP3 file, leaving its other tags untouched. Unless lrcPath already is
+// the audio file's sidecar, it is copied there so later re-tags keep the lyrics.
+func (t *Tagger) EmbedLyricsFile(audioPath, lrcPath string) error {
+	data, err := os.ReadFile(lrcPath)
+	if err != nil {
+		return fmt.Errorf("failed to read lyrics: %w", err)
+	}
+	lrc := string(data)
+	lines, err := ParseLRC(lrc)
+	if err != nil {
+		return fmt.Errorf("%s: %w", lrcPath, err)
+	}
+
+	sidecar := LyricsSidecarPath(audioPath)
+	if same, _ := sameFile(lrcPath, sidecar); !same {
+		if err := os.WriteFile(sidecar, data, 0644); err != nil {
+			return fmt.Errorf("failed to write lyrics sidecar: %w", err)
+		}
+	}
+
+	switch strings.ToLower(filepath.Ext(audioPath)) {
+	case ".flac":
+		return t.writeFlacLyrics(audioPath, lrc)
+	case ".mp3":
+		return writeMp3Lyrics(audioPath, lines)
+	default:
+		return fmt.Errorf("unsupported audio file: %s", audioPath)
+	}
+}
+
+// FindLyricsSidecars returns every FLAC or MP3 file under root that has an .lrc sidecar.
+func FindLyricsSidecars(root string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ext := strings.ToLower(filepath.Ext(path)); d.IsDir() || (ext != ".flac" && ext != ".mp3") {
+			return nil
+		}
+		if _, err := os.Stat(LyricsSidecarPath(path)); err == nil {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", root, err)
+	}
+	return files, nil
+}
+
+// writeFlacLyrics replaces the LYRICS comment of a FLAC file.
+func (t *Tagger) writeFlacLyrics(filePath, lrc string) error {
+	f, err := flac.ParseFile(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to parse flac file: %w", err)
+	}
+	oldSize := flacMetadataSize(f.Meta)
+
+	updates := NewVorbisComment()
+	addTag(updates, "LYRICS", strings.TrimSpace(lrc))
+
+	found := false
+	for _, block := range f.Meta {
+		if block.Type != flac.VorbisComment {
+			continue
+		}
+		cmts, err := ParseVorbisComment(block.Data)
+		if err != nil {
+			return fmt.Errorf("failed to parse existing comments: %w", err)
+		}
+		cmts.Merge(updates)
+		block.Data = cmts.Marshal()
+		found = true
+		break
+	}
+	if !found {
+		f.Meta = append(f.Meta, &flac.MetaDataBlock{Type: flac.VorbisComment, Data: updates.Marshal()})
+	}
+
+	if err := t.saveFlac(filePath, f, oldSize); err != nil {
+		return fmt.Errorf("failed to save tags: %w", err)
+	}
+	return nil
+}
+
+// writeMp3Lyrics replaces the SYLT and USLT frames of an MP3 file.
+func writeMp3Lyrics(filePath string, lines []LyricLine) error {
+	tag, err := id3v2.Open(filePath, id3v2.Options{Parse: true})
+	if err != nil {
+		return fmt.Errorf("failed to open mp3 file: %w", err)
+	}
+	defer tag.Close()
+
+	tag.DeleteFrames("SYLT")
+	tag.DeleteFrames(tag.CommonID("Unsynchronised lyrics/text transcription"))
+	addMp3Lyrics(tag, lines)
+
+	if err := tag.Save(); err != nil {
+		return fmt.Errorf("failed to save mp3 tags: %w", err)
+	}
+	return nil
+}
+
+// addMp3Lyrics adds lines as a SYLT frame plus a USLT frame with the plain text.
+func addMp3Lyrics(tag *id3v2.Tag, lines []LyricLine) {
+	tag.AddFrame("SYLT", id3v2.UnknownFrame{Body: BuildSYLTFrame(lines, tag.Version())})
+	tag.AddUnsynchronisedLyricsFrame(id3v2.UnsynchronisedLyricsFrame{
+		Encoding:          id3v2.EncodingUTF8,
+		Language:          syltDefaultLanguage,
+		ContentDescriptor: "",
+		Lyrics:            plainLyrics(lines),
+	})
+}
+
+// ID3v2 SYLT constants.
+const (
+	syltEncodingUTF16   = 1
+	syltEncodingUTF8    = 3
+	syltFormatMillis    = 2
+	syltContentLyrics   = 1
+	syltDefaultLanguage = "XXX" // Unknown language per ID3v2 spec
+)
+
+// BuildSYLTFrame serializes lyrics into an ID3v2 SYLT frame body with
+// millisecond timestamps. UTF-8 is only valid in ID3v2.4, so older tag
+// versions use UTF-16 with BOM.
+func BuildSYLTFrame(lines []LyricLine, id3Version byte) []byte {
+	buf := new(bytes.Buffer)
+
+	encoding := byte(syltEncodingUTF8)
+	if id3Version < 4 {
+		encoding = syltEncodingUTF16
+	}
+
+	buf.WriteByte(encoding)
+	buf.WriteString(syltDefaultLanguage)
+	buf.WriteByte(syltFormatMillis)
+	buf.WriteByte(syltContentLyrics)
+	writeSYLTString(buf, "", encoding) // Empty content descriptor
+
+	for _, l := range lines {
+		writeSYLTString(buf, l.Text, encoding)
+		binary.Write(buf, binary.BigEndian, uint32(l.Time.Milliseconds()))
+	}
+
+	return buf.Bytes()
+}
+
+// ParseSYLTFrame decodes a SYLT frame body produced by BuildSYLTFrame.
+func ParseSYLTFrame(body []byte) ([]LyricLine, error) {
+	if len(body) < 6 {
+		return nil, fmt.Errorf("SYLT frame too short")
+	}
+	encoding := body[0]
+	if body[4] != syltFormatMillis {
+		return nil, fmt.Errorf("unsupported SYLT timestamp format %d", body[4])
+	}
+
+	rest := body[6:]
+	_, rest, err := readSYLTString(rest, encoding) // Content descriptor
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []LyricLine
+	for len(rest) > 0 {
+		var text string
+		text, rest, err = readSYLTString(rest, encoding)
+		if err != nil {
+			return nil, err
+		}
+		if len(rest) < 4 {
+			return nil, fmt.Errorf("SYLT frame truncated")
+		}
+		ms := binary.BigEndian.Uint32(rest[:4])
+		rest = rest[4:]
+		lines = append(lines, LyricLine{Time: time.Duration(ms) * time.Millisecond, Text: text})
+	}
+	return lines, nil
+}
+
+// writeSYLTString writes a null-terminated string in the given ID3 encoding.
+func writeSYLTString(buf *bytes.Buffer, s string, encoding byte) {
+	if encoding != syltEncodingUTF16 {
+		buf.WriteString(s)
+		buf.WriteByte(0)
+		return
+	}
+	buf.Write([]byte{0xFF, 0xFE}) // Little-endian BOM
+	for _, u := range utf16.Encode([]rune(s)) {
+		binary.Write(buf, binary.LittleEndian, u)
+	}
+	buf.Write([]byte{0, 0})
+}
+
+// readSYLTString reads a null-terminated string in the given ID3 encoding.
+func readSYLTString(data []byte, encoding byte) (string, []byte, error) {
+	if encoding != syltEncodingUTF16 {
+		i := bytes.IndexByte(data, 0)
+		if i < 0 {
+			return "", nil, fmt.Errorf("unterminated SYLT string")
+		}
+		return string(data[:i]), data[i+1:], nil
+	}
+
+	if len(data) >= 2 && data[0] == 0xFF && data[1] == 0xFE {
+		data = data[2:]
+	}
+	var units []uint16
+	for i := 0; i+1 < len(data); i += 2 {
+		u := binary.LittleEndian.Uint16(data[i:])
+		if u == 0 {
+			return string(utf16.Decode(units)), data[i+2:], nil
+		}
+		units = append(units, u)
+	}
+	return "", nil, fmt.Errorf("unterminated SYLT string")
+}
@@ -0,0 +1,142 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bogem/id3v2/v2"
+)
+
+const sampleLRC = `[ti:Sample]
+[ar:Someone]
+[00:01.50]First line
+[00:04.00][00:12.25]Chorus
+[00:08.123]Ünïcödé 歌詞
+`
+
+func TestParseLRC(t *testing.T) {
+	tests := []struct {
+		name    string
+		lrc     string
+		want    []LyricLine
+		wantErr bool
+	}{
+		{
+			name: "repeated timestamps are expanded and sorted",
+			lrc:  sampleLRC,
+			want: []LyricLine{
+				{Time: 1500 * time.Millisecond, Text: "First line"},
+				{Time: 4 * time.Second, Text: "Chorus"},
+				{Time: 8123 * time.Millisecond, Text: "Ünïcödé 歌詞"},
+				{Time: 12250 * time.Millisecond, Text: "Chorus"},
+			},
+		},
+		{
+			name: "offset shifts timestamps and clamps at zero",
+			lrc:  "[offset:+500]\r\n[00:00.20]Early\r\n[00:02.00]Late\r\n",
+			want: []LyricLine{
+				{Time: 0, Text: "Early"},
+				{Time: 1500 * time.Millisecond, Text: "Late"},
+			},
+		},
+		{name: "plain text is rejected", lrc: "no timestamps here\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLRC(tt.lrc)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLRC() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseLRC() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSYLTRoundTrip(t *testing.T) {
+	lines, err := ParseLRC(sampleLRC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		id3Version byte
+		encoding   byte
+	}{
+		{name: "ID3v2.3 uses UTF-16", id3Version: 3, encoding: syltEncodingUTF16},
+		{name: "ID3v2.4 uses UTF-8", id3Version: 4, encoding: syltEncodingUTF8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := BuildSYLTFrame(lines, tt.id3Version)
+			if body[0] != tt.encoding {
+				t.Errorf("encoding = %d, want %d", body[0], tt.encoding)
+			}
+			got, err := ParseSYLTFrame(body)
+			if err != nil {
+				t.Fatalf("ParseSYLTFrame() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, lines) {
+				t.Errorf("round trip = %v, want %v", got, lines)
+			}
+		})
+	}
+}
+
+func TestParseSYLTFrameTruncated(t *testing.T) {
+	body := BuildSYLTFrame([]LyricLine{{Time: time.Second, Text: "x"}}, 4)
+	if _, err := ParseSYLTFrame(body[:len(body)-2]); err == nil {
+		t.Error("ParseSYLTFrame() accepted a truncated frame")
+	}
+}
+
+func TestEmbedLyricsFileMp3(t *testing.T) {
+	dir := t.TempDir()
+	audio := filepath.Join(dir, "01. Song.mp3")
+	if err := os.WriteFile(audio, make([]byte, 128), 0644); err != nil {
+		t.Fatal(err)
+	}
+	lrcPath := filepath.Join(dir, "downloaded.lrc")
+	if err := os.WriteFile(lrcPath, []byte(sampleLRC), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tagger := NewTagger()
+	// Embedding twice must replace the frames rather than stack them
+	for range 2 {
+		if err := tagger.EmbedLyricsFile(audio, lrcPath); err != nil {
+			t.Fatalf("EmbedLyricsFile() error = %v", err)
+		}
+	}
+
+	if got, err := os.ReadFile(LyricsSidecarPath(audio)); err != nil || string(got) != sampleLRC {
+		t.Errorf("sidecar = %q, %v; want a copy of the .lrc", got, err)
+	}
+
+	tag, err := id3v2.Open(audio, id3v2.Options{Parse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tag.Close()
+
+	sylt := tag.GetFrames("SYLT")
+	if len(sylt) != 1 {
+		t.Fatalf("got %d SYLT frames, want 1", len(sylt))
+	}
+	lines, err := ParseSYLTFrame(sylt[0].(id3v2.UnknownFrame).Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := ParseLRC(sampleLRC)
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("embedded lyrics = %v, want %v", lines, want)
+	}
+	if n := len(tag.GetFrames(tag.CommonID("Unsynchronised lyrics/text transcription"))); n != 1 {
+		t.Errorf("got %d USLT frames, want 1", n)
+	}
+}
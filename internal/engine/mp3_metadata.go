@@ -3,6 +3,7 @@ package engine
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
 
@@ -10,7 +11,7 @@ import (
 )
 
 // WriteMp3Tags writes ID3v2 metadata tags and optional cover art to an MP3 file.
-func (t *Tagger) WriteMp3Tags(filePath string, track *api.TrackMetadata, album *api.AlbumMetadata, coverData []byte) error {
+func (t *Tagger) WriteMp3Tags(filePath string, track *api.TrackMetadata, album *api.AlbumMetadata, coverData []byte, extra ...*Picture) error {
 	// Open MP3 file for tag editing
 	tag, err := id3v2.Open(filePath, id3v2.Options{Parse: true})
 	if err != nil {
@@ -21,44 +22,170 @@ func (t *Tagger) WriteMp3Tags(filePath string, track *api.TrackMetadata, album *
 	// Set encoding to UTF-8 for proper unicode support
 	tag.SetDefaultEncoding(id3v2.EncodingUTF8)
 
+	// setText writes a text frame; with OnlyFillMissing, frames that already have a value are kept
+	setText := func(id, value string) {
+		if t.OnlyFillMissing && strings.TrimSpace(tag.GetTextFrame(id).Text) != "" {
+			return
+		}
+		tag.AddTextFrame(id, id3v2.EncodingUTF8, value)
+	}
+
 	// Set text frames
-	tag.SetTitle(track.Title)
-	tag.SetArtist(track.Performer.Name)
-	tag.SetAlbum(album.Title)
+	setText(tag.CommonID("Title"), track.Title)
+	// Multiple artists are "/"-joined in TPE1 per ID3 convention
+	setText(tag.CommonID("Artist"), strings.Join(t.trackArtists(track), "/"))
+	setText(tag.CommonID("Album/Movie/Show title"), album.Title)
 
 	// Album artist (TPE2)
 	if album.Artist.Name != "" {
-		tag.AddTextFrame("TPE2", id3v2.EncodingUTF8, album.Artist.Name)
+		setText("TPE2", album.Artist.Name)
 	}
 
-	// Track number (TRCK)
+	// Track and disc numbers (TRCK, TPOS), as "n/total" when totals are written
+	var totals albumTotals
+	if t.WriteTotals {
+		totals = albumTotalsFor(track, album)
+	}
 	if track.TrackNumber > 0 {
-		tag.AddTextFrame("TRCK", id3v2.EncodingUTF8, fmt.Sprintf("%d", track.TrackNumber))
+		setText("TRCK", withTotal(track.TrackNumber, totals.DiscTracks))
 	}
-
-	// Disc number (TPOS)
 	if track.MediaNumber > 0 {
-		tag.AddTextFrame("TPOS", id3v2.EncodingUTF8, fmt.Sprintf("%d", track.MediaNumber))
+		setText("TPOS", withTotal(track.MediaNumber, totals.Discs))
 	}
 
 	// Genre (TCON)
 	if album.Genre != nil && album.Genre.Name != "" {
-		tag.SetGenre(album.Genre.Name)
+		setText(tag.CommonID("Content type"), album.Genre.Name)
 	}
 
 	// Year/Date (TDRC for ID3v2.4, TYER for ID3v2.3)
-	if album.ReleaseDateOrg != "" {
-		tag.SetYear(album.ReleaseDateOrg)
-	} else if album.ReleaseDateStream != "" {
-		tag.SetYear(album.ReleaseDateStream)
+	date, originalDate := t.releaseDates(album)
+	if date != "" {
+		setText(tag.CommonID("Year"), date)
+	}
+
+	// Original release date (TDOR for ID3v2.4, year-only TORY for ID3v2.3)
+	if originalDate != "" {
+		if tag.Version() < 4 && len(originalDate) > 4 {
+			originalDate = originalDate[:4]
+		}
+		setText(tag.CommonID("Original release year"), originalDate)
 	}
 
 	// Version/Subtitle (TIT3)
 	if track.Version != "" {
-		tag.AddTextFrame("TIT3", id3v2.EncodingUTF8, track.Version)
+		setText("TIT3", track.Version)
+	}
+
+	// ISRC (TSRC)
+	if track.ISRC != "" {
+		setText("TSRC", track.ISRC)
+	}
+
+	// Classical work (TXXX WORK) and movement (MVNM name, MVIN "n/total").
+	// The library parses MVNM/MVIN as unknown frames kept in sequence, so
+	// existing ones are checked by ID and deleted before writing.
+	var work trackWork
+	if t.WriteWork {
+		work, _ = t.workFor(track, album)
+		movementIndex := ""
+		if work.Number > 0 {
+			movementIndex = withTotal(work.Number, work.Total)
+		}
+		for _, frame := range [][2]string{{"MVNM", work.Movement}, {"MVIN", movementIndex}} {
+			if frame[1] == "" || (t.OnlyFillMissing && len(tag.GetFrames(frame[0])) > 0) {
+				continue
+			}
+			tag.DeleteFrames(frame[0])
+			tag.AddTextFrame(frame[0], id3v2.EncodingUTF8, frame[1])
+		}
+	}
+
+	// Album identifiers, used to match files back to the release, album totals and the work
+	var userFrames []id3v2.UserDefinedTextFrame
+	for _, frame := range [][2]string{
+		{"WORK", work.Work},
+		{"BARCODE", album.UPC},
+		{"QOBUZ_ALBUM_ID", album.ID},
+		{"TOTALTRACKS", totals.trackTotal()},
+		{"TOTALDISCS", totals.discTotal()},
+		{"ALBUMDURATION", totals.duration()},
+	} {
+		if frame[1] != "" {
+			userFrames = append(userFrames, id3v2.UserDefinedTextFrame{
+				Encoding:    id3v2.EncodingUTF8,
+				Description: frame[0],
+				Value:       frame[1],
+			})
+		}
+	}
+
+	// Provenance (TSSE encoder settings, TENC encoded by, TXXX SOURCE)
+	if t.WriteSource {
+		setText("TSSE", encodedBy())
+		setText("TENC", encodedBy())
+		userFrames = append(userFrames, id3v2.UserDefinedTextFrame{
+			Encoding:    id3v2.EncodingUTF8,
+			Description: "SOURCE",
+			Value:       sourceName,
+		})
+	}
+
+	// Loudness normalization (TXXX user-defined frames)
+	if t.WriteR128 {
+		trackGain, albumGain := r128Tags(track)
+		for _, frame := range [][2]string{{"R128_TRACK_GAIN", trackGain}, {"R128_ALBUM_GAIN", albumGain}} {
+			if frame[1] != "" {
+				userFrames = append(userFrames, id3v2.UserDefinedTextFrame{
+					Encoding:    id3v2.EncodingUTF8,
+					Description: frame[0],
+					Value:       frame[1],
+				})
+			}
+		}
+	}
+	if t.OnlyFillMissing {
+		userFrames = missingUserTextFrames(tag, userFrames)
+	}
+	replaceUserTextFrames(tag, userFrames)
+
+	// Lyrics (SYLT synchronized + USLT plain text)
+	hasLyrics := t.OnlyFillMissing && len(tag.GetFrames(tag.CommonID("Unsynchronised lyrics/text transcription"))) > 0
+	if t.EmbedLyrics && !hasLyrics {
+		if lines, err := ParseLRC(readLRCSidecar(filePath)); err == nil {
+			addMp3Lyrics(tag, lines)
+		}
 	}
 
 	// Cover art (APIC - Attached Picture)
+	if t.OnlyFillMissing {
+		present := make(map[uint32]bool)
+		for _, f := range tag.GetFrames(tag.CommonID("Attached picture")) {
+			if pic, ok := f.(id3v2.PictureFrame); ok {
+				present[uint32(pic.PictureType)] = true
+			}
+		}
+		coverData, extra = missingPictures(present, coverData, extra)
+	}
+
+	// Drop existing pictures of the types being written so re-tagging doesn't stack copies
+	replaced := make(map[byte]bool)
+	if len(coverData) > 0 {
+		replaced[id3v2.PTFrontCover] = true
+	}
+	for _, p := range extra {
+		replaced[byte(p.PictureType)] = true
+	}
+	if len(replaced) > 0 {
+		frames := tag.GetFrames(tag.CommonID("Attached picture"))
+		tag.DeleteFrames(tag.CommonID("Attached picture"))
+		for _, f := range frames {
+			if pic, ok := f.(id3v2.PictureFrame); ok && !replaced[pic.PictureType] {
+				tag.AddAttachedPicture(pic)
+			}
+		}
+	}
+
 	if len(coverData) > 0 {
 		pic := id3v2.PictureFrame{
 			Encoding:    id3v2.EncodingUTF8,
@@ -70,6 +197,17 @@ func (t *Tagger) WriteMp3Tags(filePath string, track *api.TrackMetadata, album *
 		tag.AddAttachedPicture(pic)
 	}
 
+	// Additional pictures (APIC descriptions must be unique within a tag)
+	for _, p := range extra {
+		tag.AddAttachedPicture(id3v2.PictureFrame{
+			Encoding:    id3v2.EncodingUTF8,
+			MimeType:    p.MIME,
+			PictureType: byte(p.PictureType),
+			Description: p.Description,
+			Picture:     p.ImageData,
+		})
+	}
+
 	// Save the tags
 	if err := tag.Save(); err != nil {
 		return fmt.Errorf("failed to save mp3 tags: %w", err)
@@ -77,3 +215,44 @@ func (t *Tagger) WriteMp3Tags(filePath string, track *api.TrackMetadata, album *
 
 	return nil
 }
+
+// missingUserTextFrames returns the frames whose description has no non-blank TXXX frame yet.
+func missingUserTextFrames(tag *id3v2.Tag, frames []id3v2.UserDefinedTextFrame) []id3v2.UserDefinedTextFrame {
+	present := make(map[string]bool)
+	for _, f := range tag.GetFrames(tag.CommonID("User defined text information frame")) {
+		if udtf, ok := f.(id3v2.UserDefinedTextFrame); ok && strings.TrimSpace(udtf.Value) != "" {
+			present[strings.ToUpper(udtf.Description)] = true
+		}
+	}
+
+	var missing []id3v2.UserDefinedTextFrame
+	for _, f := range frames {
+		if !present[strings.ToUpper(f.Description)] {
+			missing = append(missing, f)
+		}
+	}
+	return missing
+}
+
+// replaceUserTextFrames adds TXXX frames, replacing existing frames with the same description.
+func replaceUserTextFrames(tag *id3v2.Tag, frames []id3v2.UserDefinedTextFrame) {
+	if len(frames) == 0 {
+		return
+	}
+	replaced := make(map[string]bool)
+	for _, f := range frames {
+		replaced[strings.ToUpper(f.Description)] = true
+	}
+
+	id := tag.CommonID("User defined text information frame")
+	existing := tag.GetFrames(id)
+	tag.DeleteFrames(id)
+	for _, f := range existing {
+		if udtf, ok := f.(id3v2.UserDefinedTextFrame); ok && !replaced[strings.ToUpper(udtf.Description)] {
+			tag.AddUserDefinedTextFrame(udtf)
+		}
+	}
+	for _, f := range frames {
+		tag.AddUserDefinedTextFrame(f)
+	}
+}
@@ -0,0 +1,28 @@
+// mtime.go sets file modification times to the album's original release date,
+// so a library sorted by date reflects releases rather than download order.
+package engine
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+// applyReleaseMtime sets the access and modification times of paths to the
+// album's original release date when SetReleaseDateMtime is enabled. Albums
+// without a valid release date leave the times untouched.
+func (e *Engine) applyReleaseMtime(album *api.AlbumMetadata, paths ...string) {
+	if !e.SetReleaseDateMtime || album == nil {
+		return
+	}
+	released, ok := parseReleaseDate(album.ReleaseDateOrg)
+	if !ok {
+		return
+	}
+	for _, path := range paths {
+		if err := os.Chtimes(path, released, released); err != nil && !os.IsNotExist(err) {
+			fmt.Printf("Warning: failed to set modification time of %s: %v\n", path, err)
+		}
+	}
+}
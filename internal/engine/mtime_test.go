@@ -0,0 +1,91 @@
+package engine
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// modTimes returns the modification time of every file under dir by base name.
+func modTimes(t *testing.T, dir string) map[string]time.Time {
+	t.Helper()
+	times := make(map[string]time.Time)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		times[d.Name()] = info.ModTime()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return times
+}
+
+func TestSetReleaseDateMtime(t *testing.T) {
+	released := time.Date(1977, 5, 25, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		enabled     bool
+		releaseDate string
+		wantRelease bool // Files carry the release date rather than the download time
+	}{
+		{name: "release date", enabled: true, releaseDate: "1977-05-25", wantRelease: true},
+		{name: "invalid date", enabled: true, releaseDate: "05/25/1977"},
+		{name: "missing date", enabled: true},
+		{name: "disabled", releaseDate: "1977-05-25"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeQobuz(t)
+			album := fake.addAlbum("alb1", "Album", "Band", 100, 2)
+			album.ReleaseDateOrg = tt.releaseDate
+			fake.tracks[100].Album.ReleaseDateOrg = tt.releaseDate // Also downloaded on its own
+			e := fake.engine()
+			e.SetReleaseDateMtime = tt.enabled
+
+			albumDir, trackDir := t.TempDir(), t.TempDir()
+			start := time.Now().Add(-time.Minute)
+			if _, err := e.DownloadAlbum(context.Background(), "alb1", 6, albumDir); err != nil {
+				t.Fatalf("DownloadAlbum: %v", err)
+			}
+			if err := e.DownloadTrack(context.Background(), "100", 6, trackDir, nil); err != nil {
+				t.Fatalf("DownloadTrack: %v", err)
+			}
+
+			times := modTimes(t, albumDir)
+			if _, ok := times["cover.jpg"]; !ok {
+				t.Error("album has no cover.jpg")
+			}
+			tracks := 0
+			for name, mtime := range modTimes(t, trackDir) {
+				times["single "+name] = mtime
+				if strings.HasSuffix(name, ".flac") {
+					tracks++
+				}
+			}
+			if tracks != 1 {
+				t.Errorf("track download wrote %d audio files, want 1", tracks)
+			}
+			for name, mtime := range times {
+				if strings.HasPrefix(name, ".") {
+					continue // Bookkeeping files
+				}
+				if tt.wantRelease && !mtime.Equal(released) {
+					t.Errorf("%s modified %v, want the release date %v", name, mtime, released)
+				}
+				if !tt.wantRelease && mtime.Before(start) {
+					t.Errorf("%s modified %v, want the download time", name, mtime)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+// naming.go renders the file names of downloaded tracks from templates.
+// Single tracks and album tracks use separate templates; a single track
+// template may contain path separators to create folders (e.g. "{artist}/{album}/{title}").
+package engine
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+// Default naming templates, matching the historical file names.
+const (
+	DefaultSingleTrackTemplate = "{artist} - {title}"
+	DefaultAlbumTrackTemplate  = "{tracknumber}. {title}"
+)
+
+// templatePlaceholders lists the placeholders a naming template may use.
+var templatePlaceholders = []string{
+	"artist", "albumartist", "title", "album", "tracknumber", "discnumber", "year", "isrc",
+}
+
+// placeholderRegex matches {name} placeholders in a naming template.
+var placeholderRegex = regexp.MustCompile(`\{([a-z]+)\}`)
+
+// CheckNamingTemplate reports unknown placeholders in tmpl, and path
+// separators when allowDirs is false.
+func CheckNamingTemplate(tmpl string, allowDirs bool) error {
+	if strings.TrimSpace(tmpl) == "" {
+		return fmt.Errorf("naming template is empty")
+	}
+	if !allowDirs && strings.ContainsAny(tmpl, `/\`) {
+		return fmt.Errorf("naming template %q must not contain path separators", tmpl)
+	}
+	for _, m := range placeholderRegex.FindAllStringSubmatch(tmpl, -1) {
+		known := false
+		for _, name := range templatePlaceholders {
+			if m[1] == name {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown placeholder {%s} in naming template (use %s)", m[1], "{"+strings.Join(templatePlaceholders, "}, {")+"}")
+		}
+	}
+	return nil
+}
+
+// templateValues returns the placeholder values of a track of album.
+func templateValues(track *api.TrackMetadata, album *api.AlbumMetadata) map[string]string {
+	values := map[string]string{
+		"artist":      track.Performer.Name,
+		"title":       track.Title,
+		"tracknumber": fmt.Sprintf("%02d", track.TrackNumber),
+		"discnumber":  strconv.Itoa(max(track.MediaNumber, 1)),
+		"isrc":        track.ISRC,
+	}
+	if album != nil {
+		values["album"] = album.Title
+		values["albumartist"] = album.Artist.Name
+		if len(album.ReleaseDateOrg) >= 4 {
+			values["year"] = album.ReleaseDateOrg[:4]
+		}
+	}
+	if values["artist"] == "" {
+		values["artist"] = values["albumartist"]
+	}
+	return values
+}
+
+// renderTemplate fills tmpl for a track and returns the folder (relative, may
+// be empty) and the file name without extension. Each path component is
+// sanitized on its own, so separators inside values never create folders.
+func renderTemplate(tmpl string, track *api.TrackMetadata, album *api.AlbumMetadata) (folder, file string) {
+	values := templateValues(track, album)
+
+	var parts []string
+	for _, segment := range strings.FieldsFunc(tmpl, func(r rune) bool { return r == '/' || r == '\\' }) {
+		rendered := placeholderRegex.ReplaceAllStringFunc(segment, func(m string) string {
+			return values[m[1:len(m)-1]]
+		})
+		if rendered = sanitizeFilename(rendered); rendered != "" {
+			parts = append(parts, rendered)
+		}
+	}
+
+	if len(parts) == 0 {
+		return "", sanitizeFilename(track.Title)
+	}
+	return filepath.Join(parts[:len(parts)-1]...), parts[len(parts)-1]
+}
+
+// singleTrackName returns the folder (relative to the output directory) and
+// file name of a track downloaded on its own.
+func (e *Engine) singleTrackName(track *api.TrackMetadata) (folder, file string) {
+	tmpl := e.SingleTrackTemplate
+	if tmpl == "" {
+		tmpl = DefaultSingleTrackTemplate
+	}
+	return renderTemplate(tmpl, track, track.Album)
+}
+
+// albumTrackName returns the file name of a track inside its album folder.
+// Album tracks stay in the album folder: path components are joined with " - ".
+func (e *Engine) albumTrackName(track *api.TrackMetadata, album *api.AlbumMetadata) string {
+	tmpl := e.AlbumTrackTemplate
+	if tmpl == "" {
+		tmpl = DefaultAlbumTrackTemplate
+	}
+	folder, file := renderTemplate(tmpl, track, album)
+	if folder == "" {
+		return file
+	}
+	return sanitizeFilename(strings.ReplaceAll(filepath.Join(folder, file), string(filepath.Separator), " - "))
+}
@@ -0,0 +1,145 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+func TestCheckNamingTemplate(t *testing.T) {
+	tests := []struct {
+		tmpl      string
+		allowDirs bool
+		wantErr   string
+	}{
+		{tmpl: DefaultSingleTrackTemplate, allowDirs: true},
+		{tmpl: DefaultAlbumTrackTemplate},
+		{tmpl: "{artist}/{album}/{tracknumber} {title}", allowDirs: true},
+		{tmpl: "{discnumber}-{tracknumber} {title} [{isrc}] ({year})"},
+		{tmpl: "{artist}/{title}", wantErr: "path separators"},
+		{tmpl: `{artist}\{title}`, wantErr: "path separators"},
+		{tmpl: "{title} {genre}", allowDirs: true, wantErr: "unknown placeholder {genre}"},
+		{tmpl: "  ", allowDirs: true, wantErr: "empty"},
+	}
+	for _, tt := range tests {
+		err := CheckNamingTemplate(tt.tmpl, tt.allowDirs)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("CheckNamingTemplate(%q, %v) = %v, want nil", tt.tmpl, tt.allowDirs, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("CheckNamingTemplate(%q, %v) = %v, want %q", tt.tmpl, tt.allowDirs, err, tt.wantErr)
+		}
+	}
+}
+
+// namingTrack returns the second track of disc 2 of an album, with its album block.
+func namingTrack() *api.TrackMetadata {
+	album := &api.AlbumMetadata{Title: "Live: Part 1/2", ReleaseDateOrg: "1999-03-01"}
+	album.Artist.Name = "Various"
+	track := &api.TrackMetadata{Title: "Song", TrackNumber: 2, MediaNumber: 2, ISRC: "USABC9900001", Album: album}
+	track.Performer.Name = "Band"
+	return track
+}
+
+func TestSingleTrackName(t *testing.T) {
+	tests := []struct {
+		name       string
+		tmpl       string
+		noArtist   bool // The track has no performer
+		noAlbum    bool // The track has no album block
+		wantFolder string
+		wantFile   string
+	}{
+		{name: "default", wantFile: "Band - Song"},
+		{name: "folders", tmpl: "{albumartist}/{album} ({year})/{tracknumber}. {title}", wantFolder: filepath.Join("Various", "Live_ Part 1_2 (1999)"), wantFile: "02. Song"},
+		{name: "every placeholder", tmpl: "{discnumber}-{tracknumber} {artist} - {title} [{isrc}]", wantFile: "2-02 Band - Song [USABC9900001]"},
+		{name: "empty folder dropped", tmpl: "{album}/{title}", noAlbum: true, wantFile: "Song"},
+		{name: "artist falls back to album artist", noArtist: true, wantFile: "Various - Song"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			track := namingTrack()
+			if tt.noArtist {
+				track.Performer.Name = ""
+			}
+			if tt.noAlbum {
+				track.Album = nil
+			}
+			e := &Engine{SingleTrackTemplate: tt.tmpl}
+			folder, file := e.singleTrackName(track)
+			if folder != tt.wantFolder || file != tt.wantFile {
+				t.Errorf("singleTrackName() = %q, %q; want %q, %q", folder, file, tt.wantFolder, tt.wantFile)
+			}
+		})
+	}
+}
+
+func TestAlbumTrackName(t *testing.T) {
+	tests := []struct {
+		name string
+		tmpl string
+		want string
+	}{
+		{name: "default", want: "02. Song"},
+		{name: "disc prefix", tmpl: "{discnumber}-{tracknumber} {title}", want: "2-02 Song"},
+		{name: "separators flattened", tmpl: "{artist}/{title}", want: "Band - Song"},
+		{name: "single placeholder", tmpl: "{isrc}", want: "USABC9900001"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			track := namingTrack()
+			e := &Engine{AlbumTrackTemplate: tt.tmpl}
+			if got := e.albumTrackName(track, track.Album); got != tt.want {
+				t.Errorf("albumTrackName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDownloadNamingTemplates(t *testing.T) {
+	tests := []struct {
+		name        string
+		single      string
+		albumTrack  string
+		wantSingle  string // Relative to the output directory
+		wantInAlbum string // File name inside the album folder
+	}{
+		{name: "defaults", wantSingle: "Band - Track 1.flac", wantInAlbum: "01. Track 1.flac"},
+		{
+			name: "custom", single: "{artist}/{album}/{tracknumber} {title}", albumTrack: "{discnumber}-{tracknumber} {title}",
+			wantSingle: filepath.Join("Band", "Album", "01 Track 1.flac"), wantInAlbum: "1-01 Track 1.flac",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeQobuz(t)
+			fake.addAlbum("alb1", "Album", "Band", 100, 1)
+			e := fake.engine()
+			e.SingleTrackTemplate = tt.single
+			e.AlbumTrackTemplate = tt.albumTrack
+
+			singleDir := t.TempDir()
+			if err := e.DownloadTrack(context.Background(), "100", 6, singleDir, nil); err != nil {
+				t.Fatalf("DownloadTrack: %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(singleDir, tt.wantSingle)); err != nil {
+				t.Errorf("single track not saved as %s: %v", tt.wantSingle, err)
+			}
+
+			result, err := e.DownloadAlbum(context.Background(), "alb1", 6, t.TempDir())
+			if err != nil || len(result.Success) != 1 {
+				t.Fatalf("DownloadAlbum() = %v, %v", result, err)
+			}
+			if got := result.Success[0].Path; got != filepath.Join(result.AlbumDir, tt.wantInAlbum) {
+				t.Errorf("album track saved as %s, want %s in %s", got, tt.wantInAlbum, result.AlbumDir)
+			}
+		})
+	}
+}
@@ -0,0 +1,400 @@
+// ogg_metadata.go provides Vorbis Comment reading and writing for Ogg Opus and Ogg Vorbis files.
+// The comment format is shared with FLAC; only the Ogg page framing differs.
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+// Ogg codec header magics.
+var (
+	opusHeadMagic      = []byte("OpusHead")
+	opusTagsMagic      = []byte("OpusTags")
+	vorbisIdentMagic   = []byte("\x01vorbis")
+	vorbisCommentMagic = []byte("\x03vorbis")
+)
+
+// oggPictureKey holds base64 FLAC picture blocks in Ogg comments.
+const oggPictureKey = "METADATA_BLOCK_PICTURE"
+
+// oggCapturePattern starts every Ogg page.
+var oggCapturePattern = []byte("OggS")
+
+// oggMaxSegments is the maximum number of lacing values in one page.
+const oggMaxSegments = 255
+
+// oggPage is a single Ogg page.
+type oggPage struct {
+	HeaderType byte
+	Granule    uint64
+	Serial     uint32
+	Sequence   uint32
+	Segments   []byte // Lacing values
+	Data       []byte
+}
+
+// oggCRCTable is the CRC-32 table used by Ogg (polynomial 0x04c11db7, not reflected).
+var oggCRCTable = func() [256]uint32 {
+	var table [256]uint32
+	for i := range table {
+		r := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if r&0x80000000 != 0 {
+				r = r<<1 ^ 0x04c11db7
+			} else {
+				r <<= 1
+			}
+		}
+		table[i] = r
+	}
+	return table
+}()
+
+// oggCRC computes the Ogg page checksum.
+func oggCRC(data []byte) uint32 {
+	var crc uint32
+	for _, b := range data {
+		crc = crc<<8 ^ oggCRCTable[byte(crc>>24)^b]
+	}
+	return crc
+}
+
+// readOggPage reads the next page from r. Returns io.EOF at the end of the stream.
+func readOggPage(r io.Reader) (*oggPage, error) {
+	var header [27]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("truncated ogg page header")
+		}
+		return nil, err
+	}
+	if !bytes.Equal(header[:4], oggCapturePattern) {
+		return nil, fmt.Errorf("invalid ogg capture pattern")
+	}
+	if header[4] != 0 {
+		return nil, fmt.Errorf("unsupported ogg version %d", header[4])
+	}
+
+	page := &oggPage{
+		HeaderType: header[5],
+		Granule:    binary.LittleEndian.Uint64(header[6:14]),
+		Serial:     binary.LittleEndian.Uint32(header[14:18]),
+		Sequence:   binary.LittleEndian.Uint32(header[18:22]),
+		Segments:   make([]byte, header[26]),
+	}
+	if _, err := io.ReadFull(r, page.Segments); err != nil {
+		return nil, fmt.Errorf("truncated ogg segment table: %w", err)
+	}
+
+	size := 0
+	for _, lace := range page.Segments {
+		size += int(lace)
+	}
+	page.Data = make([]byte, size)
+	if _, err := io.ReadFull(r, page.Data); err != nil {
+		return nil, fmt.Errorf("truncated ogg page data: %w", err)
+	}
+	return page, nil
+}
+
+// Marshal serializes the page with a freshly computed checksum.
+func (p *oggPage) Marshal() []byte {
+	buf := make([]byte, 27, 27+len(p.Segments)+len(p.Data))
+	copy(buf, oggCapturePattern)
+	buf[5] = p.HeaderType
+	binary.LittleEndian.PutUint64(buf[6:14], p.Granule)
+	binary.LittleEndian.PutUint32(buf[14:18], p.Serial)
+	binary.LittleEndian.PutUint32(buf[18:22], p.Sequence)
+	buf[26] = byte(len(p.Segments))
+	buf = append(buf, p.Segments...)
+	buf = append(buf, p.Data...)
+	binary.LittleEndian.PutUint32(buf[22:26], oggCRC(buf))
+	return buf
+}
+
+// oggHeaders holds the codec header packets at the start of an Ogg stream.
+type oggHeaders struct {
+	Serial  uint32
+	Opus    bool     // Opus stream (otherwise Vorbis)
+	Packets [][]byte // Identification, comment and (Vorbis only) setup packets
+	Pages   int      // Number of pages the header packets occupy
+}
+
+// readOggHeaders reads pages until every codec header packet is complete.
+// Both Opus and Vorbis require the audio data to start on a fresh page.
+func readOggHeaders(r io.Reader) (*oggHeaders, error) {
+	headers := &oggHeaders{}
+	want := 0
+	var packet []byte
+
+	for want == 0 || len(headers.Packets) < want {
+		page, err := readOggPage(r)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("ogg stream ended before the comment header")
+			}
+			return nil, err
+		}
+		if headers.Pages == 0 {
+			headers.Serial = page.Serial
+		} else if page.Serial != headers.Serial {
+			return nil, fmt.Errorf("multiplexed ogg streams are not supported")
+		}
+		headers.Pages++
+
+		offset := 0
+		for _, lace := range page.Segments {
+			packet = append(packet, page.Data[offset:offset+int(lace)]...)
+			offset += int(lace)
+			if lace == 255 {
+				continue // Packet continues in the next segment
+			}
+			headers.Packets = append(headers.Packets, packet)
+			packet = nil
+
+			if len(headers.Packets) == 1 {
+				first := headers.Packets[0]
+				switch {
+				case bytes.HasPrefix(first, opusHeadMagic):
+					headers.Opus = true
+					want = 2
+				case bytes.HasPrefix(first, vorbisIdentMagic):
+					want = 3
+				default:
+					return nil, fmt.Errorf("unsupported ogg codec")
+				}
+			}
+		}
+	}
+
+	if len(headers.Packets) > want || len(packet) > 0 {
+		return nil, fmt.Errorf("audio data shares a page with the codec headers")
+	}
+	return headers, nil
+}
+
+// comment parses the Vorbis Comment from the comment header packet.
+func (h *oggHeaders) comment() (*VorbisComment, error) {
+	packet := h.Packets[1]
+	magic := vorbisCommentMagic
+	if h.Opus {
+		magic = opusTagsMagic
+	}
+	if !bytes.HasPrefix(packet, magic) {
+		return nil, fmt.Errorf("missing ogg comment header")
+	}
+	return ParseVorbisComment(packet[len(magic):])
+}
+
+// setComment replaces the comment header packet.
+// Vorbis comment headers end with a framing bit; Opus headers do not.
+func (h *oggHeaders) setComment(cmts *VorbisComment) {
+	if h.Opus {
+		h.Packets[1] = append(bytes.Clone(opusTagsMagic), cmts.Marshal()...)
+		return
+	}
+	packet := append(bytes.Clone(vorbisCommentMagic), cmts.Marshal()...)
+	h.Packets[1] = append(packet, 1)
+}
+
+// paginate lays out the header packets as pages: the identification packet
+// alone on the first page, the remaining packets on the following pages.
+func (h *oggHeaders) paginate() []*oggPage {
+	pages := []*oggPage{{
+		HeaderType: 0x02, // Beginning of stream
+		Serial:     h.Serial,
+		Segments:   lacing(len(h.Packets[0])),
+		Data:       h.Packets[0],
+	}}
+
+	var segments, data []byte
+	continued := false
+	flush := func() {
+		page := &oggPage{Serial: h.Serial, Sequence: uint32(len(pages)), Segments: segments, Data: data}
+		if continued {
+			page.HeaderType = 0x01
+		}
+		pages = append(pages, page)
+		segments, data = nil, nil
+	}
+
+	for _, packet := range h.Packets[1:] {
+		laces := lacing(len(packet))
+		offset := 0
+		for i, lace := range laces {
+			if len(segments) == oggMaxSegments {
+				flush()
+				continued = i > 0
+			}
+			segments = append(segments, lace)
+			data = append(data, packet[offset:offset+int(lace)]...)
+			offset += int(lace)
+		}
+	}
+	if len(segments) > 0 {
+		flush()
+	}
+	return pages
+}
+
+// lacing returns the lacing values for a packet of size n.
+func lacing(n int) []byte {
+	laces := bytes.Repeat([]byte{255}, n/255)
+	return append(laces, byte(n%255))
+}
+
+// readOggVorbisComment reads the Vorbis Comment of an Ogg Opus or Ogg Vorbis file.
+func readOggVorbisComment(path string) (*VorbisComment, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	headers, err := readOggHeaders(bufio.NewReader(f))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ogg file: %w", err)
+	}
+	return headers.comment()
+}
+
+// writeOggVorbisComment rewrites the comment header of an Ogg file.
+// The header pages are rebuilt and every following page is renumbered,
+// since the new comment may need a different number of pages.
+func writeOggVorbisComment(path string, update func(*VorbisComment)) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	r := bufio.NewReader(src)
+	headers, err := readOggHeaders(r)
+	if err != nil {
+		return fmt.Errorf("failed to parse ogg file: %w", err)
+	}
+	cmts, err := headers.comment()
+	if err != nil {
+		return err
+	}
+	update(cmts)
+	headers.setComment(cmts)
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tags-*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // No-op after a successful rename
+
+	w := bufio.NewWriter(tmp)
+	pages := headers.paginate()
+	for _, page := range pages {
+		w.Write(page.Marshal())
+	}
+
+	// Copy the audio pages, shifting their sequence numbers
+	shift := uint32(len(pages) - headers.Pages)
+	for {
+		page, err := readOggPage(r)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			tmp.Close()
+			return fmt.Errorf("failed to read ogg page: %w", err)
+		}
+		page.Sequence += shift
+		if _, err := w.Write(page.Marshal()); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	src.Close()
+	return os.Rename(tmpPath, path)
+}
+
+// WriteOggTags writes Vorbis Comments and optional cover art to an Ogg Opus or Ogg Vorbis file.
+// Pictures are stored as METADATA_BLOCK_PICTURE comments holding base64 FLAC picture blocks.
+func (t *Tagger) WriteOggTags(filePath string, track *api.TrackMetadata, album *api.AlbumMetadata, coverData []byte, extra ...*Picture) error {
+	encoding := "Vorbis"
+	if strings.EqualFold(filepath.Ext(filePath), ".opus") {
+		encoding = "Opus"
+	}
+	updates := t.vorbisCommentUpdates(filePath, encoding, track, album)
+
+	err := writeOggVorbisComment(filePath, func(cmts *VorbisComment) {
+		t.mergeComments(cmts, updates)
+
+		if t.OnlyFillMissing {
+			present := make(map[uint32]bool)
+			for _, v := range cmts.GetAll(oggPictureKey) {
+				if data, err := base64.StdEncoding.DecodeString(v); err == nil {
+					if pic, err := ParsePicture(data); err == nil {
+						present[pic.PictureType] = true
+					}
+				}
+			}
+			coverData, extra = missingPictures(present, coverData, extra)
+		}
+
+		var pictures []*Picture
+		if len(coverData) > 0 {
+			pic := NewPicture()
+			pic.Description = "Cover"
+			pic.ImageData = coverData
+			pic.detectDimensions()
+			pictures = append(pictures, pic)
+		}
+		pictures = append(pictures, extra...)
+		if len(pictures) == 0 {
+			return
+		}
+
+		// Drop existing pictures of the types being written so re-tagging doesn't stack copies
+		replaced := make(map[uint32]bool)
+		for _, pic := range pictures {
+			replaced[pic.PictureType] = true
+		}
+		kept := cmts.Comments[:0]
+		for _, c := range cmts.Comments {
+			k, v, _ := strings.Cut(c, "=")
+			if strings.EqualFold(k, oggPictureKey) {
+				if data, err := base64.StdEncoding.DecodeString(v); err == nil {
+					if pic, err := ParsePicture(data); err == nil && replaced[pic.PictureType] {
+						continue
+					}
+				}
+			}
+			kept = append(kept, c)
+		}
+		cmts.Comments = kept
+
+		for _, pic := range pictures {
+			cmts.Add(oggPictureKey, base64.StdEncoding.EncodeToString(pic.Marshal()))
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save tags: %w", err)
+	}
+	return nil
+}
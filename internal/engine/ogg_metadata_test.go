@@ -0,0 +1,180 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+// buildTestOgg returns an Ogg stream with the codec headers for an Opus or
+// Vorbis stream, followed by audio pages holding the given packets.
+func buildTestOgg(opus bool, audio ...[]byte) []byte {
+	headers := &oggHeaders{Serial: 0x1234, Opus: opus}
+	cmts := &VorbisComment{Vendor: "test encoder", Comments: []string{"COMMENT=keep me"}}
+	if opus {
+		headers.Packets = [][]byte{append(bytes.Clone(opusHeadMagic), 1, 2, 0x38, 1), nil}
+	} else {
+		headers.Packets = [][]byte{append(bytes.Clone(vorbisIdentMagic), 0, 0, 0, 0, 2), nil, []byte("\x05vorbis setup")}
+	}
+	headers.setComment(cmts)
+
+	var buf bytes.Buffer
+	pages := headers.paginate()
+	for _, page := range pages {
+		buf.Write(page.Marshal())
+	}
+	for i, packet := range audio {
+		page := &oggPage{Serial: headers.Serial, Sequence: uint32(len(pages) + i), Granule: uint64(960 * (i + 1)), Segments: lacing(len(packet)), Data: packet}
+		if i == len(audio)-1 {
+			page.HeaderType = 0x04 // End of stream
+		}
+		buf.Write(page.Marshal())
+	}
+	return buf.Bytes()
+}
+
+// readOggAudioPages returns the pages following the codec headers.
+func readOggAudioPages(t *testing.T, path string) []*oggPage {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	headers, err := readOggHeaders(r)
+	if err != nil {
+		t.Fatalf("readOggHeaders: %v", err)
+	}
+	var pages []*oggPage
+	for seq := uint32(headers.Pages); ; seq++ {
+		page, err := readOggPage(r)
+		if errors.Is(err, io.EOF) {
+			return pages
+		}
+		if err != nil {
+			t.Fatalf("readOggPage: %v", err)
+		}
+		if page.Sequence != seq {
+			t.Errorf("audio page sequence = %d, want %d", page.Sequence, seq)
+		}
+		pages = append(pages, page)
+	}
+}
+
+func TestWriteOggTags(t *testing.T) {
+	smallCover := testJPEG(t, 8, 8)
+	// Larger than one page can hold, so the comment header spans several pages
+	largeCover := append(testJPEG(t, 8, 8), bytes.Repeat([]byte{0xAB}, 100000)...)
+
+	tests := []struct {
+		name  string
+		ext   string
+		opus  bool
+		cover []byte
+	}{
+		{name: "opus", ext: ".opus", opus: true, cover: smallCover},
+		{name: "opus with large cover", ext: ".opus", opus: true, cover: largeCover},
+		{name: "vorbis", ext: ".ogg", cover: smallCover},
+		{name: "vorbis with large cover", ext: ".ogg", cover: largeCover},
+		{name: "no cover", ext: ".opus", opus: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			audio := [][]byte{[]byte("first audio packet"), bytes.Repeat([]byte{0x55}, 600)}
+			path := filepath.Join(t.TempDir(), "track"+tt.ext)
+			if err := os.WriteFile(path, buildTestOgg(tt.opus, audio...), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			track := &api.TrackMetadata{Title: "Song", TrackNumber: 3}
+			track.Performer.Name = "Singer"
+			album := &api.AlbumMetadata{Title: "Record"}
+			album.Artist.Name = "Singer"
+
+			// Tag twice: re-tagging must replace the cover, not add a second one
+			tagger := NewTagger()
+			for range 2 {
+				if err := tagger.WriteTags(path, track, album, tt.cover); err != nil {
+					t.Fatalf("WriteTags: %v", err)
+				}
+			}
+
+			cmts, err := readOggVorbisComment(path)
+			if err != nil {
+				t.Fatalf("readOggVorbisComment: %v", err)
+			}
+			if cmts.Vendor != "test encoder" {
+				t.Errorf("vendor = %q, want %q", cmts.Vendor, "test encoder")
+			}
+			for key, want := range map[string]string{"TITLE": "Song", "ALBUM": "Record", "ARTIST": "Singer", "COMMENT": "keep me"} {
+				if got := cmts.Get(key); got != want {
+					t.Errorf("%s = %q, want %q", key, got, want)
+				}
+			}
+
+			pictures := cmts.GetAll(oggPictureKey)
+			wantPictures := 0
+			if tt.cover != nil {
+				wantPictures = 1
+			}
+			if len(pictures) != wantPictures {
+				t.Fatalf("got %d pictures, want %d", len(pictures), wantPictures)
+			}
+			if wantPictures > 0 {
+				data, err := base64.StdEncoding.DecodeString(pictures[0])
+				if err != nil {
+					t.Fatalf("picture is not base64: %v", err)
+				}
+				pic, err := ParsePicture(data)
+				if err != nil {
+					t.Fatalf("ParsePicture: %v", err)
+				}
+				if pic.PictureType != PictureTypeCoverFront || !bytes.Equal(pic.ImageData, tt.cover) {
+					t.Errorf("picture type %d with %d bytes, want front cover with %d bytes", pic.PictureType, len(pic.ImageData), len(tt.cover))
+				}
+			}
+
+			pages := readOggAudioPages(t, path)
+			if len(pages) != len(audio) {
+				t.Fatalf("got %d audio pages, want %d", len(pages), len(audio))
+			}
+			for i, page := range pages {
+				if !bytes.Equal(page.Data, audio[i]) || page.Granule != uint64(960*(i+1)) {
+					t.Errorf("audio page %d changed", i)
+				}
+			}
+		})
+	}
+}
+
+func TestReadOggHeadersErrors(t *testing.T) {
+	valid := buildTestOgg(true, []byte("audio"))
+	foreign := bytes.Clone(valid)
+	copy(foreign[bytes.Index(foreign, opusHeadMagic):], "Speex   ")
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "not ogg", data: []byte("fLaC\x00\x00\x00\x22")},
+		{name: "unsupported codec", data: foreign},
+		{name: "truncated headers", data: valid[:40]},
+		{name: "empty", data: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := readOggHeaders(bytes.NewReader(tt.data)); err == nil {
+				t.Error("readOggHeaders succeeded, want error")
+			}
+		})
+	}
+}
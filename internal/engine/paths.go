@@ -0,0 +1,101 @@
+// paths.go keeps output paths within the platform's maximum path length.
+// Long album folders and track names are shortened progressively, and on
+// Windows the \\?\ long-path prefix can be used instead.
+package engine
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	// windowsMaxPath is MAX_PATH minus the terminating NUL.
+	windowsMaxPath = 259
+	// minComponentLength is the shortest a shortened folder or file name may become.
+	minComponentLength = 16
+	// maxExtLength reserves room for the longest extension we write (".flac").
+	maxExtLength = 5
+	// windowsLongPathPrefix disables MAX_PATH checks in the Windows file APIs.
+	windowsLongPathPrefix = `\\?\`
+)
+
+// maxPathLength returns the effective path length limit (0 = unlimited).
+// Defaults to MAX_PATH on Windows unless long paths are enabled.
+func (e *Engine) maxPathLength() int {
+	if e.MaxPathLength > 0 {
+		return e.MaxPathLength
+	}
+	if runtime.GOOS == "windows" && !e.LongPaths {
+		return windowsMaxPath
+	}
+	return 0
+}
+
+// resolveOutputDir applies the Windows long-path prefix to outputDir when enabled.
+func (e *Engine) resolveOutputDir(outputDir string) string {
+	if runtime.GOOS != "windows" || !e.LongPaths || strings.HasPrefix(outputDir, windowsLongPathPrefix) {
+		return outputDir
+	}
+	abs, err := filepath.Abs(outputDir)
+	if err != nil {
+		return outputDir
+	}
+	return windowsLongPathPrefix + abs
+}
+
+// pathLength returns the length of a path in UTF-16 code units, as counted by Windows.
+func pathLength(path string) int {
+	return len(utf16.Encode([]rune(path)))
+}
+
+// fitPath shortens folder and then file (base name without extension) so that
+// outputDir/folder/file+ext stays within maxLen, including the .part and .segments
+// suffixes the file carries while it downloads. folder may be empty for files
+// written directly into outputDir. Components are never shortened below
+// minComponentLength, so the result may still exceed maxLen for very deep outputDirs.
+func fitPath(outputDir, folder, file string, maxLen int) (string, string) {
+	if maxLen <= 0 {
+		return folder, file
+	}
+
+	base := outputDir
+	if abs, err := filepath.Abs(outputDir); err == nil {
+		base = abs
+	}
+
+	length := func() int {
+		return pathLength(filepath.Join(base, folder, file)) + maxExtLength + len(partSuffix) + len(segmentsSuffix)
+	}
+
+	excess := length() - maxLen
+	if excess <= 0 {
+		return folder, file
+	}
+
+	// Shorten the album folder first, then the file name
+	if folder != "" {
+		folder = shortenComponent(folder, excess)
+		excess = length() - maxLen
+	}
+	if excess > 0 {
+		file = shortenComponent(file, excess)
+	}
+
+	return folder, file
+}
+
+// shortenComponent removes up to excess UTF-16 units from the end of name,
+// keeping at least minComponentLength runes and trimming trailing spaces and dots.
+func shortenComponent(name string, excess int) string {
+	runes := []rune(name)
+	target := pathLength(name) - excess
+
+	for len(runes) > minComponentLength && pathLength(string(runes)) > target {
+		runes = runes[:len(runes)-1]
+	}
+
+	// Windows does not allow names ending in a space or dot
+	return strings.TrimRight(string(runes), " .")
+}
@@ -0,0 +1,92 @@
+package engine
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFitPathReservesPartSuffix(t *testing.T) {
+	dir := t.TempDir()
+	folder := "Artist - Album"
+	tests := []struct {
+		name   string
+		file   string
+		maxLen int
+	}{
+		{name: "fits as is", file: "01. Short", maxLen: 400},
+		{name: "long title", file: "01. " + strings.Repeat("Very Long Title ", 20), maxLen: 259},
+		{name: "just over the limit", file: "01. " + strings.Repeat("x", 200), maxLen: 259},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotFolder, gotFile := fitPath(dir, folder, tt.file, tt.maxLen)
+			final := filepath.Join(dir, gotFolder, gotFile+".flac")
+			if n := pathLength(partialPath(final)); n > tt.maxLen {
+				t.Errorf("partial path is %d characters, limit %d: %s", n, tt.maxLen, partialPath(final))
+			}
+		})
+	}
+}
+
+func TestFitPathLongCombinations(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name          string
+		folder        string
+		file          string
+		maxLen        int
+		wantFolder    bool // Folder must be shortened
+		wantFile      bool // File name must be shortened
+		wantUnchanged bool
+	}{
+		{name: "unlimited", folder: strings.Repeat("A", 300), file: strings.Repeat("T", 300), maxLen: 0, wantUnchanged: true},
+		{name: "short path", folder: "Artist - Album", file: "01. Title", maxLen: 259, wantUnchanged: true},
+		{name: "long album folder", folder: strings.Repeat("Artist ", 20) + "- " + strings.Repeat("Album ", 20), file: "01. Title", maxLen: 259, wantFolder: true},
+		{name: "long folder and title", folder: strings.Repeat("Artist ", 30), file: "01. " + strings.Repeat("Title ", 60), maxLen: 259, wantFolder: true, wantFile: true},
+		{name: "unicode counts as UTF-16", folder: strings.Repeat("歌手", 60), file: "01. " + strings.Repeat("🎵", 130), maxLen: 259, wantFolder: true, wantFile: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			folder, file := fitPath(dir, tt.folder, tt.file, tt.maxLen)
+			if tt.wantUnchanged {
+				if folder != tt.folder || file != tt.file {
+					t.Errorf("fitPath() changed the path to %q, %q", folder, file)
+				}
+				return
+			}
+			if (folder != tt.folder) != tt.wantFolder {
+				t.Errorf("folder shortened = %v, want %v", folder != tt.folder, tt.wantFolder)
+			}
+			if (file != tt.file) != tt.wantFile {
+				t.Errorf("file shortened = %v, want %v", file != tt.file, tt.wantFile)
+			}
+			if n := pathLength(filepath.Join(dir, folder, file+".flac")); n+len(partSuffix) > tt.maxLen {
+				t.Errorf("path is %d characters, limit %d", n, tt.maxLen)
+			}
+			if !strings.HasPrefix(tt.folder, folder) || !strings.HasPrefix(tt.file, file) {
+				t.Errorf("shortened names %q, %q are not prefixes of the originals", folder, file)
+			}
+		})
+	}
+}
+
+func TestShortenComponent(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     string
+		excess int
+		want   string
+	}{
+		{name: "trims to fit", in: "01. A Rather Long Track Title", excess: 6, want: "01. A Rather Long Track"},
+		{name: "keeps the minimum length", in: "01. A Rather Long Track Title", excess: 100, want: "01. A Rather Lon"},
+		{name: "drops trailing spaces and dots", in: "Album Name Vol. 2 Deluxe", excess: 9, want: "Album Name Vol"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shortenComponent(tt.in, tt.excess); got != tt.want {
+				t.Errorf("shortenComponent(%q, %d) = %q, want %q", tt.in, tt.excess, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,256 @@
+// plan.go resolves album output paths without downloading anything.
+// Plans can be exported as a shell script or JSON for running elsewhere.
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// AlbumPlan describes where each track of an album will be written.
+type AlbumPlan struct {
+	Album    *api.AlbumMetadata
+	AlbumDir string
+	Tracks   []PlannedTrack
+	Reused   bool // AlbumDir is an existing folder found by its album ID marker
+
+	root string // Output directory searched for existing album folders
+}
+
+// PlannedTrack is a single track with its output file name (without extension).
+// The extension depends on the format delivered by the server.
+type PlannedTrack struct {
+	Track    api.TrackMetadata
+	BaseName string
+}
+
+// albumFolderName returns the folder name used for an album.
+func albumFolderName(artist, title string) string {
+	return sanitizeFilename(fmt.Sprintf("%s - %s", artist, title))
+}
+
+// otherInitial is the bucket for artists whose name does not start with a letter.
+const otherInitial = "#"
+
+// artistInitial returns the bucket folder for an artist when grouping by initial:
+// the first letter or digit of the name, uppercased with diacritics removed
+// (É becomes E). Names starting with a digit, or without any letter, go to "#".
+func artistInitial(name string) string {
+	folded, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn))), name)
+	if err != nil {
+		folded = name
+	}
+	for _, r := range folded {
+		switch {
+		case unicode.IsLetter(r):
+			return string(unicode.ToUpper(r))
+		case unicode.IsDigit(r):
+			return otherInitial
+		}
+	}
+	return otherInitial
+}
+
+// trackFileName returns the file name (without extension) used for an album track.
+func trackFileName(number int, title string) string {
+	return sanitizeFilename(fmt.Sprintf("%02d. %s", number, title))
+}
+
+// PlanAlbum fetches album metadata and resolves the album folder and track file names.
+func (e *Engine) PlanAlbum(albumID string, outputDir string) (*AlbumPlan, error) {
+	album, err := e.getAlbum(albumID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get album metadata: %w", err)
+	}
+
+	outputDir = e.resolveOutputDir(outputDir)
+	root := outputDir
+	maxLen := e.maxPathLength()
+
+	baseNames := make([]string, len(album.Tracks.Items))
+	longest := ""
+	for i, track := range album.Tracks.Items {
+		baseNames[i] = e.albumTrackName(&track, album)
+		if pathLength(baseNames[i]) > pathLength(longest) {
+			longest = baseNames[i]
+		}
+	}
+
+	// Shorten the album folder so the longest track name fits, then shorten
+	// individual track names that still exceed the limit
+	if e.GroupByInitial {
+		outputDir = filepath.Join(outputDir, artistInitial(album.Artist.Name))
+	}
+	plan := &AlbumPlan{Album: album, root: root}
+	if dir, ok := e.existingAlbumDir(root, album.ID); ok {
+		plan.AlbumDir = dir
+		plan.Reused = true
+	} else {
+		folderName := albumFolderName(album.Artist.Name, album.Title)
+		folderName, _ = fitPath(outputDir, folderName, longest, maxLen)
+		plan.AlbumDir = filepath.Join(outputDir, folderName)
+	}
+
+	for i, track := range album.Tracks.Items {
+		_, baseName := fitPath(plan.AlbumDir, "", baseNames[i], maxLen)
+		plan.Tracks = append(plan.Tracks, PlannedTrack{
+			Track:    track,
+			BaseName: baseName,
+		})
+	}
+
+	return plan, nil
+}
+
+// Supported plan export formats.
+const (
+	PlanFormatShell = "sh"
+	PlanFormatJSON  = "json"
+)
+
+// ExportedTrack is a resolved track entry in an exported plan.
+type ExportedTrack struct {
+	Title     string     `json:"title"`
+	URL       string     `json:"url"`
+	Output    string     `json:"output"`
+	FormatID  int        `json:"format_id"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// ExportedPlan is the JSON representation of an exported album plan.
+type ExportedPlan struct {
+	Album       string          `json:"album"`
+	Artist      string          `json:"artist"`
+	GeneratedAt time.Time       `json:"generated_at"`
+	Tracks      []ExportedTrack `json:"tracks"`
+	Failed      []string        `json:"failed,omitempty"`
+}
+
+// ExportPlan resolves freshly signed URLs for every track of an album and writes
+// them to w as a shell script (curl commands) or JSON, without downloading audio.
+// Signed URLs expire shortly after generation, so the plan must be run promptly.
+func (e *Engine) ExportPlan(albumID string, quality int, outputDir string, format string, w io.Writer) (*ExportedPlan, error) {
+	if format != PlanFormatShell && format != PlanFormatJSON {
+		return nil, fmt.Errorf("unsupported export format: %s (use %s or %s)", format, PlanFormatShell, PlanFormatJSON)
+	}
+
+	plan, err := e.PlanAlbum(albumID, outputDir)
+	if err != nil {
+		return nil, err
+	}
+
+	exported := &ExportedPlan{
+		Album:       plan.Album.Title,
+		Artist:      plan.Album.Artist.Name,
+		GeneratedAt: time.Now(),
+	}
+
+	for _, planned := range plan.Tracks {
+		info, formatID, err := e.Client.GetTrackURLWithFallback(strconv.Itoa(planned.Track.ID), e.trackQuality(quality, &planned.Track))
+		if err != nil {
+			exported.Failed = append(exported.Failed, planned.Track.Title)
+			continue
+		}
+
+		ext, _, _ := e.outputExtension(info.MimeType)
+		exported.Tracks = append(exported.Tracks, ExportedTrack{
+			Title:     planned.Track.Title,
+			URL:       info.URL,
+			Output:    filepath.Join(plan.AlbumDir, planned.BaseName+ext),
+			FormatID:  formatID,
+			ExpiresAt: signedURLExpiry(info.URL),
+		})
+	}
+
+	if format == PlanFormatJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return exported, enc.Encode(exported)
+	}
+	return exported, writeShellPlan(w, exported, plan.AlbumDir)
+}
+
+// ExportPlanFile runs ExportPlan and writes the plan to path, replacing it only
+// once the whole plan is rendered. An invalid format or a failed lookup leaves
+// any existing file untouched.
+func (e *Engine) ExportPlanFile(albumID string, quality int, outputDir string, format string, path string) (*ExportedPlan, error) {
+	var buf bytes.Buffer
+	exported, err := e.ExportPlan(albumID, quality, outputDir, format, &buf)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(path, buf.Bytes()); err != nil {
+		return nil, fmt.Errorf("failed to write plan file: %w", err)
+	}
+	return exported, nil
+}
+
+// signedURLExpiry extracts the expiry timestamp (etsp) from a signed Qobuz file URL.
+// Returns nil if the URL carries no recognizable expiry.
+func signedURLExpiry(rawURL string) *time.Time {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+	etsp, err := strconv.ParseInt(parsed.Query().Get("etsp"), 10, 64)
+	if err != nil || etsp <= 0 {
+		return nil
+	}
+	t := time.Unix(etsp, 0)
+	return &t
+}
+
+// writeShellPlan writes the plan as a POSIX shell script using curl.
+func writeShellPlan(w io.Writer, plan *ExportedPlan, albumDir string) error {
+	var b strings.Builder
+
+	b.WriteString("#!/bin/sh\n")
+	fmt.Fprintf(&b, "# %s - %s\n", plan.Artist, plan.Album)
+	fmt.Fprintf(&b, "# Generated by qobuz-dl-go at %s\n", plan.GeneratedAt.Format(time.RFC3339))
+	b.WriteString("# WARNING: signed URLs expire shortly after generation. Run this script promptly.\n")
+	if expiry := earliestExpiry(plan.Tracks); expiry != nil {
+		fmt.Fprintf(&b, "# Earliest URL expiry: %s\n", expiry.Format(time.RFC3339))
+	}
+	for _, title := range plan.Failed {
+		fmt.Fprintf(&b, "# Unavailable: %s\n", title)
+	}
+	b.WriteString("set -e\n\n")
+
+	fmt.Fprintf(&b, "mkdir -p %s\n", shellQuote(albumDir))
+	for _, t := range plan.Tracks {
+		fmt.Fprintf(&b, "curl -fL -o %s %s\n", shellQuote(t.Output), shellQuote(t.URL))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// earliestExpiry returns the soonest URL expiry among the tracks, if known.
+func earliestExpiry(tracks []ExportedTrack) *time.Time {
+	var earliest *time.Time
+	for _, t := range tracks {
+		if t.ExpiresAt != nil && (earliest == nil || t.ExpiresAt.Before(*earliest)) {
+			earliest = t.ExpiresAt
+		}
+	}
+	return earliest
+}
+
+// shellQuote wraps s in single quotes for safe use in a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,104 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+func TestExportPlanFileInvalidFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing *string // Content of a file already at the path, nil for none
+	}{
+		{name: "no file is created"},
+		{name: "existing file is kept", existing: ptr("#!/bin/sh\necho previous plan\n")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "plan.sh")
+			if tt.existing != nil {
+				if err := os.WriteFile(path, []byte(*tt.existing), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			e := New(api.NewClient("", ""))
+			if _, err := e.ExportPlanFile("album", QualityBest, dir, "yaml", path); err == nil {
+				t.Fatal("ExportPlanFile() accepted an unsupported format")
+			}
+
+			got, err := os.ReadFile(path)
+			if tt.existing == nil {
+				if !os.IsNotExist(err) {
+					t.Errorf("plan file exists after a failed export (err = %v)", err)
+				}
+			} else if string(got) != *tt.existing {
+				t.Errorf("plan file = %q, want it unchanged", got)
+			}
+
+			if entries, _ := os.ReadDir(dir); tt.existing == nil && len(entries) != 0 {
+				t.Errorf("directory has %d entries after a failed export, want none", len(entries))
+			}
+		})
+	}
+}
+
+func TestArtistInitial(t *testing.T) {
+	tests := map[string]string{
+		"Adele":           "A",
+		"adele":           "A",
+		"Édith Piaf":      "E",
+		"Ólafur Arnalds":  "O",
+		"Øystein Sevåg":   "Ø", // A letter of its own, not a diacritic
+		"Яндекс":          "Я",
+		"坂本龍一":            "坂",
+		"2Pac":            otherInitial,
+		"50 Cent":         otherInitial,
+		"!!!":             otherInitial,
+		"...And You Will": "A",
+		"  The Beatles":   "T",
+		"":                otherInitial,
+		"(hed) p.e.":      "H",
+	}
+	for name, want := range tests {
+		if got := artistInitial(name); got != want {
+			t.Errorf("artistInitial(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestPlanAlbumGroupByInitial(t *testing.T) {
+	fake := newFakeQobuz(t)
+	fake.addAlbum("alb1", "La Vie en rose", "Édith Piaf", 100, 1)
+	fake.addAlbum("alb2", "All Eyez on Me", "2Pac", 200, 1)
+
+	tests := []struct {
+		name    string
+		albumID string
+		group   bool
+		want    string // Album folder relative to the output directory
+	}{
+		{name: "grouped", albumID: "alb1", group: true, want: filepath.Join("E", "Édith Piaf - La Vie en rose")},
+		{name: "grouped under #", albumID: "alb2", group: true, want: filepath.Join("#", "2Pac - All Eyez on Me")},
+		{name: "not grouped", albumID: "alb1", want: "Édith Piaf - La Vie en rose"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := fake.engine()
+			e.GroupByInitial = tt.group
+			outputDir := t.TempDir()
+
+			plan, err := e.PlanAlbum(tt.albumID, outputDir)
+			if err != nil {
+				t.Fatalf("PlanAlbum: %v", err)
+			}
+			if want := filepath.Join(outputDir, tt.want); plan.AlbumDir != want {
+				t.Errorf("AlbumDir = %q, want %q", plan.AlbumDir, want)
+			}
+		})
+	}
+}
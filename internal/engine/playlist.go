@@ -0,0 +1,69 @@
+// playlist.go downloads playlists. Playlist tracks usually come from different
+// albums, so every track is tagged with its own album's cover rather than one
+// playlist-wide image.
+package engine
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// DownloadPlaylist downloads every track of a playlist into a folder named after it.
+// Each track embeds its own album cover (served from the cover cache when albums
+// repeat); the first track's cover is saved as the folder image.
+func (e *Engine) DownloadPlaylist(ctx context.Context, playlistID string, quality int, outputDir string) error {
+	playlist, err := e.Client.GetPlaylist(playlistID)
+	if err != nil {
+		return fmt.Errorf("failed to get playlist metadata: %w", err)
+	}
+
+	outputDir = e.resolveOutputDir(outputDir)
+	name := playlist.Name
+	if name == "" {
+		name = "Playlist " + playlistID
+	}
+	folderName, _ := fitPath(outputDir, sanitizeFilename(name), "", e.maxPathLength())
+	playlistDir := filepath.Join(outputDir, folderName)
+	if err := os.MkdirAll(playlistDir, 0755); err != nil {
+		return err
+	}
+
+	tracks := playlist.Tracks.Items
+	fmt.Printf("Playlist: %s (%d tracks)\n", playlist.Name, len(tracks))
+
+	// Folder image: the first track's album cover
+	for _, track := range tracks {
+		if track.Album != nil && track.Album.Image.Large != "" {
+			if data, err := e.downloadCover(track.Album.Image.Large); err == nil {
+				_ = e.saveCoverFile(playlistDir, data)
+			}
+			break
+		}
+	}
+
+	failed := 0
+	for i, track := range tracks {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		fmt.Printf("\n[%d/%d] %s - %s\n", i+1, len(tracks), track.Performer.Name, track.Title)
+		// DownloadTrack fetches the full track metadata and tags the file with
+		// the cover of the track's own album
+		if err := e.DownloadTrack(ctx, strconv.Itoa(track.ID), quality, playlistDir, nil); err != nil {
+			fmt.Printf("Track download failed: %v\n", err)
+			failed++
+			if e.FailFast {
+				return &StoppedError{Item: fmt.Sprintf("track %q", track.Title), Err: err}
+			}
+		}
+	}
+
+	if failed > 0 {
+		return &PartialError{Failed: failed, Total: len(tracks), Unit: "tracks"}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestDownloadPlaylistPerTrackCovers(t *testing.T) {
+	fake := newFakeQobuz(t)
+	fake.addAlbum("alb1", "First Album", "Band", 100, 2)
+	fake.addAlbum("alb2", "Second Album", "Singer", 200, 1)
+	covers := map[string][]byte{
+		"First Album":  testJPEG(t, 8, 8),
+		"Second Album": testJPEG(t, 16, 16),
+	}
+	// The original size is the first one fetched
+	fake.covers["/covers/alb1_org.jpg"] = covers["First Album"]
+	fake.covers["/covers/alb2_org.jpg"] = covers["Second Album"]
+	fake.addPlaylist("pl1", "Mix", 200, 100, 101)
+
+	e := fake.engine()
+	outputDir := t.TempDir()
+	if err := e.DownloadPlaylist(context.Background(), "pl1", 6, outputDir); err != nil {
+		t.Fatalf("DownloadPlaylist: %v", err)
+	}
+	playlistDir := filepath.Join(outputDir, "Mix")
+
+	// Every track embeds the cover of its own album
+	downloaded := 0
+	err := filepath.WalkDir(playlistDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".flac" {
+			return err
+		}
+		downloaded++
+		tags, err := ReadTags(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pictures := flacPictures(t, path)
+		if len(pictures) != 1 || !bytes.Equal(pictures[0].ImageData, covers[tags.Album]) {
+			t.Errorf("%s (album %q) does not embed its album's cover", filepath.Base(path), tags.Album)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if downloaded != 3 {
+		t.Errorf("downloaded %d tracks, want 3", downloaded)
+	}
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{path: "/covers/alb1_org.jpg", want: 1}, // Shared by two tracks, served from the cache
+		{path: "/covers/alb2_org.jpg", want: 1},
+	}
+	for _, tt := range tests {
+		if got := fake.count(tt.path); got != tt.want {
+			t.Errorf("%s requested %d times, want %d", tt.path, got, tt.want)
+		}
+	}
+
+	// The folder image is the first track's album cover
+	folderCover, err := os.ReadFile(filepath.Join(playlistDir, "cover.jpg"))
+	if err != nil || !bytes.Equal(folderCover, covers["Second Album"]) {
+		t.Errorf("playlist cover.jpg is not the first track's album cover (err %v)", err)
+	}
+}
+
+func TestDownloadPlaylistFailFast(t *testing.T) {
+	tests := []struct {
+		name        string
+		failFast    bool
+		wantFiles   []int // Track files downloaded
+		wantPartial bool
+	}{
+		{name: "continue on error", wantFiles: []int{100, 102}, wantPartial: true},
+		{name: "fail fast", failFast: true, wantFiles: []int{100}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeQobuz(t)
+			fake.addAlbum("alb1", "Album", "Band", 100, 3)
+			fake.unavailable[101] = true
+			fake.addPlaylist("pl1", "Mix", 100, 101, 102)
+
+			e := fake.engine()
+			e.FailFast = tt.failFast
+			err := e.DownloadPlaylist(context.Background(), "pl1", 6, t.TempDir())
+
+			var partial *PartialError
+			var stopped *StoppedError
+			switch {
+			case tt.wantPartial:
+				if !errors.As(err, &partial) || partial.Failed != 1 || partial.Total != 3 {
+					t.Errorf("DownloadPlaylist() error = %v, want 1 of 3 tracks failed", err)
+				}
+			case !errors.As(err, &stopped) || stopped.Item != `track "Track 2"`:
+				t.Errorf("DownloadPlaylist() error = %v, want stopped at track \"Track 2\"", err)
+			}
+
+			var files []int
+			for _, id := range []int{100, 101, 102} {
+				if fake.count(fmt.Sprintf("/file/%d.flac", id)) > 0 {
+					files = append(files, id)
+				}
+			}
+			if !slices.Equal(files, tt.wantFiles) {
+				t.Errorf("downloaded tracks %v, want %v", files, tt.wantFiles)
+			}
+		})
+	}
+}
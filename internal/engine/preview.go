@@ -0,0 +1,104 @@
+// preview.go downloads the 30-second preview clips Qobuz serves to visitors,
+// for users who want short samples (e.g. to audition tracks for a DJ crate).
+// Clips are named with PreviewSuffix so they never pass for full downloads.
+package engine
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+// PreviewSuffix is appended to the file names of preview clips.
+const PreviewSuffix = " (preview)"
+
+// downloadPreview saves the preview clip of track as baseName+PreviewSuffix in
+// dir and tags it. Existing clips are kept; skipped reports that case.
+func (e *Engine) downloadPreview(ctx context.Context, dir, baseName string, track *api.TrackMetadata, album *api.AlbumMetadata, coverData []byte) (path string, skipped bool, err error) {
+	info, err := e.Client.GetTrackPreviewURL(strconv.Itoa(track.ID))
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get preview URL: %w", err)
+	}
+
+	ext, container, _ := resolveExtension(info.MimeType, "")
+	path = filepath.Join(dir, baseName+PreviewSuffix+ext)
+	if _, err := os.Stat(path); err == nil {
+		return path, true, nil
+	}
+
+	release, err := e.acquireDownload(ctx)
+	if err != nil {
+		return "", false, err
+	}
+	err = e.downloadFile(ctx, info.URL, path, nil)
+	release()
+	if err != nil {
+		return "", false, err
+	}
+
+	if err := e.tagFile(path, container, track, album, coverData); err != nil {
+		fmt.Printf("Warning: failed to tag %s: %v\n", filepath.Base(path), err)
+	}
+	return path, false, nil
+}
+
+// downloadAlbumPreviews saves the preview clip of every planned track into the
+// album folder, one after the other, recording the outcome in result.
+func (e *Engine) downloadAlbumPreviews(ctx context.Context, plan *AlbumPlan, coverData []byte, result *AlbumResult) *AlbumResult {
+	result.Preview = true
+	for i, planned := range plan.Tracks {
+		if ctx.Err() != nil {
+			result.Failed = append(result.Failed, TrackResult{Title: planned.Track.Title, Err: ctx.Err(), Reason: FailureCancelled})
+			continue
+		}
+		fmt.Printf("[Preview %d/%d] %s... ", i+1, len(plan.Tracks), planned.Track.Title)
+		path, skipped, err := e.downloadPreview(ctx, plan.AlbumDir, planned.BaseName, &planned.Track, plan.Album, coverData)
+		switch {
+		case err != nil:
+			fmt.Println("Failed")
+			result.Failed = append(result.Failed, TrackResult{Title: planned.Track.Title, Err: err, Reason: FailureReason(err)})
+		case skipped:
+			fmt.Println("Exists")
+			result.Skipped = append(result.Skipped, TrackResult{Title: planned.Track.Title, Path: path})
+		default:
+			fmt.Println("Done")
+			result.Success = append(result.Success, TrackResult{Title: planned.Track.Title, Path: path})
+		}
+	}
+
+	fmt.Printf("\n[Preview] %d preview clips saved, %d already present, %d failed (30-second samples, not full tracks)\n",
+		len(result.Success), len(result.Skipped), len(result.Failed))
+	return result
+}
+
+// downloadTrackPreview saves the preview clip of a single track into outputDir.
+func (e *Engine) downloadTrackPreview(ctx context.Context, track *api.TrackMetadata, outputDir string) error {
+	var coverData []byte
+	if track.Album.Image.Large != "" {
+		coverData, _ = e.downloadCover(track.Album.Image.Large)
+		coverData = e.embeddedCover(coverData)
+	}
+
+	folder, baseName := e.singleTrackName(track)
+	folder, baseName = fitPath(outputDir, folder, baseName, e.maxPathLength())
+	if folder != "" {
+		outputDir = filepath.Join(outputDir, folder)
+		if err := ensureWritable(outputDir); err != nil {
+			return err
+		}
+	}
+	path, skipped, err := e.downloadPreview(ctx, outputDir, baseName, track, track.Album, coverData)
+	if err != nil {
+		return err
+	}
+	if skipped {
+		fmt.Printf("Skipping, preview already exists: %s\n", path)
+		return nil
+	}
+	fmt.Printf("Saved 30-second preview: %s\n", path)
+	return nil
+}
@@ -0,0 +1,148 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDownloadAlbumPreview(t *testing.T) {
+	tests := []struct {
+		name        string
+		preview     bool
+		unavailable int // Track without a preview clip, 0 for none
+		wantSuffix  string
+		wantPath    string // Request path prefix of the audio
+		wantFailed  int
+	}{
+		{name: "full tracks", wantSuffix: ".flac", wantPath: "/file/"},
+		{name: "previews", preview: true, wantSuffix: PreviewSuffix + ".flac", wantPath: "/previews/"},
+		{name: "preview missing", preview: true, unavailable: 101, wantSuffix: PreviewSuffix + ".flac", wantPath: "/previews/", wantFailed: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeQobuz(t)
+			fake.addAlbum("alb1", "Album", "Band", 100, 2)
+			if tt.unavailable != 0 {
+				fake.unavailable[tt.unavailable] = true
+			}
+			e := fake.engine()
+			e.Preview = tt.preview
+
+			result, err := e.DownloadAlbum(context.Background(), "alb1", 6, t.TempDir())
+			if err != nil {
+				t.Fatalf("DownloadAlbum: %v", err)
+			}
+			if result.Preview != tt.preview {
+				t.Errorf("result.Preview = %v, want %v", result.Preview, tt.preview)
+			}
+			if len(result.Success) != 2-tt.wantFailed || len(result.Failed) != tt.wantFailed {
+				t.Fatalf("got %d downloaded and %d failed, want %d failed", len(result.Success), len(result.Failed), tt.wantFailed)
+			}
+			for _, track := range result.Success {
+				name := filepath.Base(track.Path)
+				if !strings.HasSuffix(name, tt.wantSuffix) || (!tt.preview && strings.Contains(name, PreviewSuffix)) {
+					t.Errorf("saved %s, want a name ending in %q", name, tt.wantSuffix)
+				}
+				if got, err := os.ReadFile(track.Path); err != nil || len(got) < len(fake.audio) {
+					t.Errorf("%s holds %d bytes, want the audio (%v)", name, len(got), err)
+				}
+			}
+			for _, path := range []string{"/file/100.flac", "/previews/100.flac"} {
+				want := 0
+				if strings.HasPrefix(path, tt.wantPath) {
+					want = 1
+				}
+				if n := fake.count(path); n != want {
+					t.Errorf("%d requests to %s, want %d", n, path, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDownloadAlbumPreviewSkipsExisting(t *testing.T) {
+	fake := newFakeQobuz(t)
+	fake.addAlbum("alb1", "Album", "Band", 100, 2)
+	outputDir := t.TempDir()
+
+	// Full tracks in the folder don't count as existing previews
+	if _, err := fake.engine().DownloadAlbum(context.Background(), "alb1", 6, outputDir); err != nil {
+		t.Fatal(err)
+	}
+	e := fake.engine()
+	e.Preview = true
+	for i, want := range []struct{ success, skipped int }{{2, 0}, {0, 2}} {
+		result, err := e.DownloadAlbum(context.Background(), "alb1", 6, outputDir)
+		if err != nil {
+			t.Fatalf("preview run %d: %v", i+1, err)
+		}
+		if len(result.Success) != want.success || len(result.Skipped) != want.skipped {
+			t.Errorf("preview run %d: got %d downloaded and %d skipped, want %d and %d",
+				i+1, len(result.Success), len(result.Skipped), want.success, want.skipped)
+		}
+	}
+	if n := fake.count("/previews/100.flac"); n != 1 {
+		t.Errorf("preview downloaded %d times, want once", n)
+	}
+}
+
+func TestDownloadTrackPreview(t *testing.T) {
+	tests := []struct {
+		name    string
+		preview bool
+		want    string
+	}{
+		{name: "full track", want: "Band - Track 1.flac"},
+		{name: "preview", preview: true, want: "Band - Track 1" + PreviewSuffix + ".flac"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeQobuz(t)
+			fake.addAlbum("alb1", "Album", "Band", 100, 1)
+			e := fake.engine()
+			e.Preview = tt.preview
+			outputDir := t.TempDir()
+
+			if err := e.DownloadTrack(context.Background(), "100", 6, outputDir, nil); err != nil {
+				t.Fatalf("DownloadTrack: %v", err)
+			}
+			var names []string
+			filepath.WalkDir(outputDir, func(path string, d os.DirEntry, err error) error {
+				if err == nil && !d.IsDir() && filepath.Ext(path) == ".flac" {
+					names = append(names, d.Name())
+				}
+				return nil
+			})
+			if len(names) != 1 || names[0] != tt.want {
+				t.Errorf("saved %v, want one file named %q", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestDownloadArtistPreviewKeepsSyncState(t *testing.T) {
+	fake := newFakeQobuz(t)
+	fake.addAlbum("alb1", "First", "Band", 100, 2)
+	fake.addArtist("art1", "Band", "alb1")
+	outputDir := t.TempDir()
+
+	e := fake.engine()
+	e.Preview = true
+	if err := e.DownloadArtist(context.Background(), "art1", 6, outputDir); err != nil {
+		t.Fatalf("DownloadArtist: %v", err)
+	}
+	if got := syncedAlbums(t, outputDir, "art1"); len(got) != 0 {
+		t.Errorf("preview run marked %v completed", got)
+	}
+
+	// A full sync afterwards still downloads the album
+	if err := fake.engine().DownloadArtist(context.Background(), "art1", 6, outputDir); err != nil {
+		t.Fatalf("DownloadArtist: %v", err)
+	}
+	if n := fake.count("/file/100.flac"); n != 1 {
+		t.Errorf("full sync downloaded track 100 %d times, want once", n)
+	}
+}
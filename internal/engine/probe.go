@@ -0,0 +1,97 @@
+// probe.go reports which quality tiers are actually available for album tracks.
+// Each track is probed once from the highest tier down; results are cached so
+// repeated probes do not issue further signed requests.
+package engine
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+// highestQuality is the top quality tier, used as the starting point for probes.
+const highestQuality = 27
+
+// TrackQuality is the best quality available for a track.
+type TrackQuality struct {
+	TrackNumber  int     `json:"track_number"`
+	DiscNumber   int     `json:"disc_number"`
+	Title        string  `json:"title"`
+	FormatID     int     `json:"format_id,omitempty"`
+	BitDepth     int     `json:"bit_depth,omitempty"`
+	SamplingRate float64 `json:"sampling_rate,omitempty"` // kHz
+	MimeType     string  `json:"mime_type,omitempty"`
+	Error        string  `json:"error,omitempty"`
+}
+
+// AlbumQualities lists the available quality of every track of an album.
+type AlbumQualities struct {
+	AlbumID string         `json:"album_id"`
+	Title   string         `json:"title"`
+	Artist  string         `json:"artist"`
+	Tracks  []TrackQuality `json:"tracks"`
+}
+
+// probeCache caches probe results by track ID.
+type probeCache struct {
+	mu      sync.Mutex
+	entries map[int]TrackQuality
+}
+
+// newProbeCache creates an empty probe cache.
+func newProbeCache() *probeCache {
+	return &probeCache{entries: make(map[int]TrackQuality)}
+}
+
+// ProbeAlbumQualities queries the highest available format of every track of an album.
+func (e *Engine) ProbeAlbumQualities(albumID string) (*AlbumQualities, error) {
+	album, err := e.Client.GetAlbum(albumID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &AlbumQualities{
+		AlbumID: albumID,
+		Title:   album.Title,
+		Artist:  album.Artist.Name,
+	}
+	for _, track := range album.Tracks.Items {
+		result.Tracks = append(result.Tracks, e.probeTrack(track))
+	}
+
+	return result, nil
+}
+
+// probeTrack returns the best available quality of a track, using the cache when possible.
+func (e *Engine) probeTrack(track api.TrackMetadata) TrackQuality {
+	e.probes.mu.Lock()
+	cached, ok := e.probes.entries[track.ID]
+	e.probes.mu.Unlock()
+	if ok {
+		return cached
+	}
+
+	q := TrackQuality{
+		TrackNumber: track.TrackNumber,
+		DiscNumber:  track.MediaNumber,
+		Title:       track.Title,
+	}
+
+	// Start at the track's advertised maximum instead of probing tiers it cannot have
+	info, formatID, err := e.Client.GetTrackURLWithFallback(strconv.Itoa(track.ID), trackMaxQuality(&track))
+	if err != nil {
+		q.Error = err.Error()
+		return q // Failures are not cached so they can be retried
+	}
+	q.FormatID = formatID
+	q.BitDepth = info.BitDepth
+	q.SamplingRate = info.SamplingRate
+	q.MimeType = info.MimeType
+
+	e.probes.mu.Lock()
+	e.probes.entries[track.ID] = q
+	e.probes.mu.Unlock()
+
+	return q
+}
@@ -0,0 +1,51 @@
+// progress_events.go reports structured per-track progress for frontends that
+// wrap the CLI, independent of the terminal display.
+package engine
+
+import "github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+
+// Phases reported in ProgressEvent.Phase.
+const (
+	PhaseDownloading = "downloading"
+	PhaseTagging     = "tagging"
+	PhaseDone        = "done"
+	PhaseSkipped     = "skipped"
+	PhaseFailed      = "failed"
+)
+
+// ProgressEvent is a progress update of a single track.
+type ProgressEvent struct {
+	TrackID int    `json:"track_id"`
+	Title   string `json:"title"`
+	AlbumID string `json:"album_id,omitempty"`
+	Phase   string `json:"phase"`
+	Percent int    `json:"percent"`         // 0-100; stays 0 while the size is unknown
+	Bytes   int64  `json:"bytes"`           // Bytes received so far
+	Total   int64  `json:"total,omitempty"` // File size, 0 if unknown
+	Error   string `json:"error,omitempty"` // Failure reason of failed tracks
+}
+
+// emitProgress sends a progress event for track to OnProgressEvent, if set.
+func (e *Engine) emitProgress(track *api.TrackMetadata, albumID, phase string, current, total int64, err error) {
+	if e.OnProgressEvent == nil {
+		return
+	}
+	event := ProgressEvent{
+		TrackID: track.ID,
+		Title:   track.Title,
+		AlbumID: albumID,
+		Phase:   phase,
+		Bytes:   current,
+	}
+	if total > 0 {
+		event.Total = total
+		event.Percent = int(min(current*100/total, 100))
+	}
+	if phase == PhaseDone {
+		event.Percent = 100
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	e.OnProgressEvent(event)
+}
@@ -0,0 +1,103 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+func TestDownloadProgressUnknownLength(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789abcdef"), 64<<10) // 1 MiB
+	tests := []struct {
+		name          string
+		contentLength bool
+		wantTotal     int64
+	}{
+		{name: "chunked", wantTotal: -1},
+		{name: "content length", contentLength: true, wantTotal: int64(len(data))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.contentLength {
+					w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+				}
+				for off := 0; off < len(data); off += 128 << 10 {
+					w.Write(data[off : off+128<<10])
+					w.(http.Flusher).Flush() // Chunked encoding without a Content-Length
+				}
+			}))
+			defer srv.Close()
+
+			var calls int
+			var last int64
+			onProgress := func(current, total int64) {
+				calls++
+				if total != tt.wantTotal {
+					t.Errorf("progress total = %d, want %d", total, tt.wantTotal)
+				}
+				if current < last {
+					t.Errorf("progress went backwards: %d after %d", current, last)
+				}
+				last = current
+			}
+			e := New(api.NewClient("app", "secret"))
+			path := filepath.Join(t.TempDir(), "track.flac")
+			if err := e.downloadFileWithProgress(context.Background(), srv.URL, path, onProgress, nil); err != nil {
+				t.Fatalf("downloadFileWithProgress: %v", err)
+			}
+			if calls == 0 || last != int64(len(data)) {
+				t.Errorf("progress reported %d times, last at %d bytes; want the %d bytes received", calls, last, len(data))
+			}
+			if got, err := os.ReadFile(path); err != nil || !bytes.Equal(got, data) {
+				t.Errorf("downloaded %d bytes, want %d (%v)", len(got), len(data), err)
+			}
+		})
+	}
+}
+
+func TestProgressLinesUnknownLength(t *testing.T) {
+	tests := []struct {
+		name        string
+		state       trackState
+		wantThread  string
+		wantSong    string
+		notInThread string
+	}{
+		{
+			name:        "indeterminate",
+			state:       trackState{FileName: "01. Song", Status: StatusDownloading, Received: 5 << 20, Indeterminate: true},
+			wantThread:  "<=>",
+			wantSong:    "> 5.0 MB",
+			notInThread: "%",
+		},
+		{
+			name:       "known size",
+			state:      trackState{FileName: "01. Song", Status: StatusDownloading, Progress: 42, Received: 5 << 20},
+			wantThread: "  42%",
+			wantSong:   ">  42%",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thread := buildThreadLine(0, tt.state, true, 60)
+			if !strings.Contains(thread, tt.wantThread) || (tt.notInThread != "" && strings.Contains(thread, tt.notInThread)) {
+				t.Errorf("thread line %q, want %q without %q", thread, tt.wantThread, tt.notInThread)
+			}
+			if tt.state.Indeterminate && !strings.HasSuffix(thread, "   5M") {
+				t.Errorf("thread line %q does not end with the megabytes received", thread)
+			}
+			if song := buildSongLine(tt.state, 60); !strings.Contains(song, tt.wantSong) {
+				t.Errorf("song line %q, want %q", song, tt.wantSong)
+			}
+		})
+	}
+}
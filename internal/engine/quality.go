@@ -0,0 +1,84 @@
+// quality.go resolves the quality actually requested for each track.
+// Requests are capped at the tier the track's maximum bit depth and sampling
+// rate allow, and "best" is additionally capped by the subscription, avoiding
+// requests that would only be downgraded.
+package engine
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+// QualityBest requests the highest quality the subscription and track allow.
+const QualityBest = -1
+
+// QualityAliases maps the quality names accepted by ParseQuality to quality IDs.
+var QualityAliases = map[string]int{
+	"mp3":   5,
+	"cd":    6,
+	"hires": 27,
+}
+
+// ParseQuality parses a quality ID, a name from QualityAliases or "best".
+func ParseQuality(s string) (int, error) {
+	if strings.EqualFold(s, "best") {
+		return QualityBest, nil
+	}
+	if q, ok := QualityAliases[strings.ToLower(s)]; ok {
+		return q, nil
+	}
+	q, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid quality %q (use 5, 6, 7, 27, mp3, cd, hires or best)", s)
+	}
+	if _, ok := qualityNames[q]; !ok {
+		return 0, fmt.Errorf("unknown quality %d (use 5, 6, 7, 27, mp3, cd, hires or best)", q)
+	}
+	return q, nil
+}
+
+// trackMaxQuality maps a track's maximum bit depth and sampling rate to the highest
+// quality ID it is available in. Tracks without that information are not capped.
+func trackMaxQuality(track *api.TrackMetadata) int {
+	switch {
+	case track.MaximumBitDepth <= 0:
+		return highestQuality
+	case track.MaximumBitDepth <= 16:
+		return 6
+	case track.MaximumSamplingRate > 96:
+		return 27
+	case track.MaximumSamplingRate > 0:
+		return 7
+	default:
+		return highestQuality
+	}
+}
+
+// bestQuality returns the lowest of the requested quality (QualityBest = no limit),
+// the subscription's ceiling (0 = unknown) and the track's maximum.
+func bestQuality(requested, subscriptionMax int, track *api.TrackMetadata) int {
+	quality := requested
+	if quality == QualityBest {
+		quality = highestQuality
+	}
+	if subscriptionMax > 0 && api.QualityRank(subscriptionMax) < api.QualityRank(quality) {
+		quality = subscriptionMax
+	}
+	if trackMax := trackMaxQuality(track); api.QualityRank(trackMax) < api.QualityRank(quality) {
+		quality = trackMax
+	}
+	return quality
+}
+
+// trackQuality returns the quality to request for track. Explicit quality IDs are
+// lowered to the track's maximum, since asking a 16-bit track for a 24-bit tier
+// only gets downgraded; QualityBest is also capped by the subscription.
+func (e *Engine) trackQuality(quality int, track *api.TrackMetadata) int {
+	if quality != QualityBest {
+		return bestQuality(quality, 0, track)
+	}
+	return bestQuality(quality, e.subscriptionMaxQuality(), track)
+}
@@ -0,0 +1,79 @@
+// quality_override.go reads per-album quality overrides from a .qobuz-quality
+// file in the output directory, so curated libraries can be rebuilt reproducibly.
+package engine
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+// QualityOverrideFile lists per-album qualities, one "<album ID or URL> <quality>"
+// pair per line. Blank lines and lines starting with # are ignored. Its entries
+// take precedence over the --quality flag and the configured quality.
+const QualityOverrideFile = ".qobuz-quality"
+
+// readQualityOverrides parses a quality override file into album ID -> quality.
+func readQualityOverrides(path string) (map[string]int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	overrides := make(map[string]int)
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(strings.ReplaceAll(line, "=", " "))
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected \"<album> <quality>\"", path, lineNo)
+		}
+		quality, err := ParseQuality(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNo, err)
+		}
+
+		albumID := fields[0]
+		if resType, id, err := api.ParseURL(albumID); err == nil {
+			if resType != api.TypeAlbum {
+				return nil, fmt.Errorf("%s:%d: expected an album, got %s", path, lineNo, resType)
+			}
+			albumID = id
+		}
+		overrides[albumID] = quality
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return overrides, nil
+}
+
+// albumQuality returns the quality for an album: the entry of the override file
+// in outputDir if there is one, otherwise quality.
+func (e *Engine) albumQuality(albumID string, quality int, outputDir string) int {
+	overrides, err := readQualityOverrides(filepath.Join(outputDir, QualityOverrideFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fmt.Printf("Warning: ignoring %s: %v\n", QualityOverrideFile, err)
+		}
+		return quality
+	}
+	if override, ok := overrides[albumID]; ok {
+		name := qualityName(override)
+		if override == QualityBest {
+			name = "best"
+		}
+		fmt.Printf("[Quality] %s sets album %s to %s\n", QualityOverrideFile, albumID, name)
+		return override
+	}
+	return quality
+}
@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+func TestReadQualityOverrides(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]int
+		wantErr bool
+	}{
+		{
+			name:    "IDs, URLs and aliases",
+			content: "# Curated library\n0603497863212 27\n\nhttps://play.qobuz.com/album/abc123 cd\n  xyz = mp3  \nbest1 best\n",
+			want:    map[string]int{"0603497863212": 27, "abc123": 6, "xyz": 5, "best1": QualityBest},
+		},
+		{name: "later entries win", content: "abc 6\nabc 7\n", want: map[string]int{"abc": 7}},
+		{name: "empty file", content: "", want: map[string]int{}},
+		{name: "missing quality", content: "abc\n", wantErr: true},
+		{name: "unknown quality", content: "abc 9\n", wantErr: true},
+		{name: "track URL", content: "https://open.qobuz.com/track/42 6\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), QualityOverrideFile)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := readQualityOverrides(path)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("readQualityOverrides() = %v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readQualityOverrides() error = %v", err)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("readQualityOverrides() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAlbumQuality(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string // Override file contents; empty for no file
+		albumID string
+		quality int
+		want    int
+	}{
+		{name: "override beats the flag", content: "abc 27\n", albumID: "abc", quality: 6, want: 27},
+		{name: "other albums keep the flag", content: "abc 27\n", albumID: "def", quality: 6, want: 6},
+		{name: "no override file", albumID: "abc", quality: 7, want: 7},
+		{name: "invalid file is ignored", content: "abc\n", albumID: "abc", quality: 7, want: 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.content != "" {
+				if err := os.WriteFile(filepath.Join(dir, QualityOverrideFile), []byte(tt.content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			e := New(api.NewClient("", ""))
+			if got := e.albumQuality(tt.albumID, tt.quality, dir); got != tt.want {
+				t.Errorf("albumQuality(%q, %d) = %d, want %d", tt.albumID, tt.quality, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package engine
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+func TestParseQuality(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int
+		wantErr bool
+	}{
+		{in: "best", want: QualityBest},
+		{in: "BEST", want: QualityBest},
+		{in: "hires", want: 27},
+		{in: "CD", want: 6},
+		{in: "mp3", want: 5},
+		{in: "7", want: 7},
+		{in: "8", wantErr: true},
+		{in: "lossless", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseQuality(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseQuality(%q) = %d, %v; want %d, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestBestQuality(t *testing.T) {
+	hiRes192 := &api.TrackMetadata{MaximumBitDepth: 24, MaximumSamplingRate: 192}
+	hiRes96 := &api.TrackMetadata{MaximumBitDepth: 24, MaximumSamplingRate: 96}
+	cd := &api.TrackMetadata{MaximumBitDepth: 16, MaximumSamplingRate: 44.1}
+	unknown := &api.TrackMetadata{}
+
+	tests := []struct {
+		name            string
+		requested       int
+		subscriptionMax int
+		track           *api.TrackMetadata
+		want            int
+	}{
+		{name: "best, hi-res plan, 192 kHz track", requested: QualityBest, subscriptionMax: 27, track: hiRes192, want: 27},
+		{name: "best, hi-res plan, 96 kHz track", requested: QualityBest, subscriptionMax: 27, track: hiRes96, want: 7},
+		{name: "best, hi-res plan, CD track", requested: QualityBest, subscriptionMax: 27, track: cd, want: 6},
+		{name: "best, CD plan, hi-res track", requested: QualityBest, subscriptionMax: 6, track: hiRes192, want: 6},
+		{name: "best, MP3 plan, CD track", requested: QualityBest, subscriptionMax: 5, track: cd, want: 5},
+		{name: "best, unknown plan and track", requested: QualityBest, track: unknown, want: highestQuality},
+		{name: "explicit 24-bit, CD track", requested: 7, subscriptionMax: 27, track: cd, want: 6},
+		{name: "explicit CD, hi-res track", requested: 6, subscriptionMax: 27, track: hiRes192, want: 6},
+		{name: "explicit MP3 is never raised", requested: 5, track: hiRes96, want: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bestQuality(tt.requested, tt.subscriptionMax, tt.track); got != tt.want {
+				t.Errorf("bestQuality(%d, %d, %d-bit/%v kHz) = %d, want %d",
+					tt.requested, tt.subscriptionMax, tt.track.MaximumBitDepth, tt.track.MaximumSamplingRate, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrackQualityUsesSubscriptionOnlyForBest(t *testing.T) {
+	info := &api.UserInfo{}
+	if err := json.Unmarshal([]byte(`{"credential": {"parameters": {"lossless_streaming": true}}}`), info); err != nil {
+		t.Fatal(err)
+	}
+	e := New(api.NewClient("", ""))
+	e.subscriptionOnce.Do(func() { e.subscription = info }) // CD plan, without a user/get request
+
+	track := &api.TrackMetadata{MaximumBitDepth: 24, MaximumSamplingRate: 192}
+	tests := []struct {
+		quality int
+		want    int
+	}{
+		{quality: QualityBest, want: 6},
+		{quality: 27, want: 27}, // Explicit requests are left for the server to downgrade
+	}
+	for _, tt := range tests {
+		if got := e.trackQuality(tt.quality, track); got != tt.want {
+			t.Errorf("trackQuality(%d) = %d, want %d", tt.quality, got, tt.want)
+		}
+	}
+}
+
+func TestTrackMaxQuality(t *testing.T) {
+	tests := []struct {
+		bitDepth     int
+		samplingRate float64
+		want         int
+	}{
+		{bitDepth: 16, samplingRate: 44.1, want: 6},
+		{bitDepth: 16, samplingRate: 48, want: 6},
+		{bitDepth: 24, samplingRate: 44.1, want: 7},
+		{bitDepth: 24, samplingRate: 96, want: 7},
+		{bitDepth: 24, samplingRate: 176.4, want: 27},
+		{bitDepth: 24, samplingRate: 192, want: 27},
+		{bitDepth: 24, want: highestQuality}, // Unknown sampling rate
+		{want: highestQuality},               // No maxima in the metadata
+	}
+	for _, tt := range tests {
+		track := &api.TrackMetadata{MaximumBitDepth: tt.bitDepth, MaximumSamplingRate: tt.samplingRate}
+		if got := trackMaxQuality(track); got != tt.want {
+			t.Errorf("trackMaxQuality(%d-bit, %v kHz) = %d, want %d", tt.bitDepth, tt.samplingRate, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+// queue.go provides a download queue for batches mixing tracks, albums and artists.
+// Jobs are processed in order and their outcomes collected into a combined report.
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+// Job is a single queued download of any supported resource type.
+type Job struct {
+	Type api.ResourceType
+	ID   string
+}
+
+// String returns a human readable description of the job.
+func (j Job) String() string {
+	return fmt.Sprintf("%s %s", j.Type, j.ID)
+}
+
+// ParseJob converts a Qobuz URL or bare ID into a Job.
+// Inputs that are not recognized URLs are treated as track IDs.
+func ParseJob(input string) Job {
+	input = strings.TrimSpace(input)
+	resType, id, err := api.ParseURL(input)
+	if err != nil {
+		return Job{Type: api.TypeTrack, ID: input}
+	}
+	return Job{Type: resType, ID: id}
+}
+
+// JobResult is the outcome of a processed job.
+type JobResult struct {
+	Job      Job
+	Err      error
+	Duration time.Duration
+}
+
+// ErrNotStarted is the error of queued jobs skipped because Engine.FailFast
+// stopped the run at an earlier failure.
+var ErrNotStarted = errors.New("not started (stopped by fail-fast)")
+
+// QueueResult is the combined report of a queue run.
+type QueueResult struct {
+	Results   []JobResult
+	StoppedBy *JobResult // Job whose failure stopped a fail-fast run, nil otherwise
+}
+
+// Succeeded returns the number of jobs that completed without error.
+func (r *QueueResult) Succeeded() int {
+	n := 0
+	for _, res := range r.Results {
+		if res.Err == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// Failed returns the results of jobs that ended with an error.
+func (r *QueueResult) Failed() []JobResult {
+	var failed []JobResult
+	for _, res := range r.Results {
+		if res.Err != nil {
+			failed = append(failed, res)
+		}
+	}
+	return failed
+}
+
+// DownloadQueue processes heterogeneous download jobs with shared settings.
+type DownloadQueue struct {
+	engine    *Engine
+	jobs      []Job
+	Quality   int
+	OutputDir string
+}
+
+// NewQueue creates an empty download queue bound to the engine.
+func (e *Engine) NewQueue(quality int, outputDir string) *DownloadQueue {
+	return &DownloadQueue{
+		engine:    e,
+		Quality:   quality,
+		OutputDir: outputDir,
+	}
+}
+
+// Add appends jobs to the queue.
+func (q *DownloadQueue) Add(jobs ...Job) {
+	q.jobs = append(q.jobs, jobs...)
+}
+
+// Len returns the number of queued jobs.
+func (q *DownloadQueue) Len() int {
+	return len(q.jobs)
+}
+
+// Run processes every job in order, continuing past failures unless the engine's
+// FailFast is set, in which case the first failure stops the run and the remaining
+// jobs are reported with ErrNotStarted. Processing also stops early if ctx is
+// cancelled; remaining jobs are then reported as cancelled.
+func (q *DownloadQueue) Run(ctx context.Context) *QueueResult {
+	result := &QueueResult{}
+
+	for i, job := range q.jobs {
+		if result.StoppedBy != nil {
+			result.Results = append(result.Results, JobResult{Job: job, Err: ErrNotStarted})
+			continue
+		}
+		if i > 0 {
+			if err := q.engine.waitAlbumDelay(ctx); err != nil {
+				result.Results = append(result.Results, JobResult{Job: job, Err: err})
+				continue
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			result.Results = append(result.Results, JobResult{Job: job, Err: err})
+			continue
+		}
+
+		fmt.Printf("\n[Queue %d/%d] %s\n", i+1, len(q.jobs), job)
+		start := time.Now()
+		err := q.engine.Download(ctx, job, q.Quality, q.OutputDir)
+		result.Results = append(result.Results, JobResult{
+			Job:      job,
+			Err:      err,
+			Duration: time.Since(start),
+		})
+		if err != nil {
+			fmt.Printf("[Queue %d/%d] Failed: %v\n", i+1, len(q.jobs), err)
+			if q.engine.FailFast {
+				stopped := result.Results[len(result.Results)-1]
+				result.StoppedBy = &stopped
+			}
+		}
+	}
+
+	return result
+}
+
+// Download runs a single job by dispatching to the matching Download* method.
+func (e *Engine) Download(ctx context.Context, job Job, quality int, outputDir string) error {
+	switch job.Type {
+	case api.TypeTrack:
+		return e.DownloadTrack(ctx, job.ID, quality, outputDir, nil)
+	case api.TypeAlbum:
+		_, err := e.DownloadAlbum(ctx, job.ID, quality, outputDir)
+		return err
+	case api.TypeArtist:
+		return e.DownloadArtist(ctx, job.ID, quality, outputDir)
+	case api.TypePlaylist:
+		return e.DownloadPlaylist(ctx, job.ID, quality, outputDir)
+	default:
+		return fmt.Errorf("unsupported resource type: %s", job.Type)
+	}
+}
@@ -0,0 +1,103 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+func TestParseJob(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Job
+	}{
+		{in: "https://play.qobuz.com/album/abc123", want: Job{Type: api.TypeAlbum, ID: "abc123"}},
+		{in: "https://open.qobuz.com/track/42", want: Job{Type: api.TypeTrack, ID: "42"}},
+		{in: "  12345 ", want: Job{Type: api.TypeTrack, ID: "12345"}},
+	}
+	for _, tt := range tests {
+		if got := ParseJob(tt.in); got != tt.want {
+			t.Errorf("ParseJob(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDownloadQueueMixedJobs(t *testing.T) {
+	fake := newFakeQobuz(t)
+	fake.addAlbum("alb1", "First Album", "Band", 100, 2)
+	fake.addAlbum("alb2", "Second Album", "Band", 200, 1)
+
+	jobs := []Job{
+		{Type: api.TypeAlbum, ID: "alb1"},
+		{Type: api.TypeTrack, ID: "999"}, // Unknown track
+		{Type: api.TypeTrack, ID: "200"},
+		{Type: api.TypeAlbum, ID: "missing"},
+	}
+
+	tests := []struct {
+		name        string
+		failFast    bool
+		wantErrs    []bool
+		wantSkipped int // Jobs reported as not started
+	}{
+		{name: "continue on error", wantErrs: []bool{false, true, false, true}},
+		{name: "fail fast", failFast: true, wantErrs: []bool{false, true, true, true}, wantSkipped: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := fake.engine()
+			e.FailFast = tt.failFast
+			q := e.NewQueue(6, t.TempDir())
+			q.Add(jobs...)
+
+			result := q.Run(context.Background())
+			if len(result.Results) != len(jobs) {
+				t.Fatalf("got %d results, want %d", len(result.Results), len(jobs))
+			}
+			skipped := 0
+			for i, res := range result.Results {
+				if res.Job != jobs[i] {
+					t.Errorf("result %d is for %v, want %v", i, res.Job, jobs[i])
+				}
+				if (res.Err != nil) != tt.wantErrs[i] {
+					t.Errorf("job %v error = %v, want error %v", res.Job, res.Err, tt.wantErrs[i])
+				}
+				if errors.Is(res.Err, ErrNotStarted) {
+					skipped++
+				}
+			}
+			if skipped != tt.wantSkipped {
+				t.Errorf("%d jobs not started, want %d", skipped, tt.wantSkipped)
+			}
+			if tt.failFast != (result.StoppedBy != nil) {
+				t.Errorf("StoppedBy = %v, want set = %v", result.StoppedBy, tt.failFast)
+			}
+			wantOK := 0
+			for _, failed := range tt.wantErrs {
+				if !failed {
+					wantOK++
+				}
+			}
+			if result.Succeeded() != wantOK || len(result.Failed()) != len(jobs)-wantOK {
+				t.Errorf("Succeeded() = %d, Failed() = %d; want %d and %d", result.Succeeded(), len(result.Failed()), wantOK, len(jobs)-wantOK)
+			}
+		})
+	}
+}
+
+func TestDownloadQueueCancelled(t *testing.T) {
+	fake := newFakeQobuz(t)
+	e := fake.engine()
+	q := e.NewQueue(6, t.TempDir())
+	q.Add(Job{Type: api.TypeTrack, ID: "1"}, Job{Type: api.TypeTrack, ID: "2"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, res := range q.Run(ctx).Results {
+		if !errors.Is(res.Err, context.Canceled) {
+			t.Errorf("job %v error = %v, want %v", res.Job, res.Err, context.Canceled)
+		}
+	}
+}
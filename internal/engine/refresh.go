@@ -0,0 +1,232 @@
+// refresh.go re-applies current Qobuz metadata and cover art to already downloaded
+// albums without touching the audio. Albums are matched by the album ID or barcode
+// embedded in their files, or by an album ID marker file in the folder.
+package engine
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+// albumIDMarker is the file in an album folder holding the Qobuz album ID.
+// Downloads write it; folders from older versions may lack it.
+const albumIDMarker = ".album-id"
+
+// TagChange is a tag whose value differs from the current Qobuz metadata.
+type TagChange struct {
+	Path  string `json:"path"`
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// RefreshResult reports what refreshing one album folder changed (or would change).
+type RefreshResult struct {
+	Dir          string        `json:"dir"`
+	AlbumID      string        `json:"album_id"`
+	Title        string        `json:"title"`
+	Changes      []TagChange   `json:"changes"`
+	CoverUpdated bool          `json:"cover_updated"` // A larger cover is available
+	Retagged     int           `json:"retagged"`      // Files rewritten (0 in dry run)
+	Skipped      []SkippedFile `json:"skipped"`
+}
+
+// FindAlbumDirs returns every directory under root that directly contains audio files.
+func FindAlbumDirs(root string) ([]string, error) {
+	var dirs []string
+	seen := make(map[string]bool)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !isAudioFile(path) {
+			return nil
+		}
+		if dir := filepath.Dir(path); !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", root, err)
+	}
+	return dirs, nil
+}
+
+// isAudioFile reports whether path has a FLAC, MP3, Opus or Ogg extension.
+func isAudioFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".flac" || ext == ".mp3" || ext == ".opus" || ext == ".ogg"
+}
+
+// RefreshAlbum re-fetches the metadata of the album in dir and compares it with the
+// tags of its files. When apply is true, files with outdated tags are re-tagged and a
+// higher resolution cover replaces the embedded one and cover.jpg. Audio is never
+// downloaded.
+func (e *Engine) RefreshAlbum(dir string, apply bool) (*RefreshResult, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RefreshResult{Dir: dir}
+	files := make(map[string]*FileTags)
+	var paths []string
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if entry.IsDir() || !isAudioFile(path) {
+			continue
+		}
+		tags, err := ReadTags(path)
+		if err != nil {
+			result.Skipped = append(result.Skipped, SkippedFile{Path: path, Reason: err.Error()})
+			continue
+		}
+		files[path] = tags
+		paths = append(paths, path)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no readable audio files in %s", dir)
+	}
+
+	album, err := e.lookupAlbum(dir, paths, files)
+	if err != nil {
+		return nil, err
+	}
+	result.AlbumID = album.ID
+	result.Title = album.Title
+
+	// Cover: only replace it when the new one is larger than what is on disk,
+	// or only add it when missing if existing tags are kept
+	var coverData []byte
+	if album.Image.Large != "" {
+		if data, err := e.downloadCover(album.Image.Large); err == nil {
+			stat, statErr := os.Stat(e.coverPath(dir))
+			if statErr != nil || (!e.Tagger.OnlyFillMissing && int64(len(data)) > stat.Size()) {
+				coverData = data
+				result.CoverUpdated = true
+			}
+		}
+	}
+
+	tracks := make(map[[2]int]*api.TrackMetadata)
+	for i := range album.Tracks.Items {
+		track := &album.Tracks.Items[i]
+		tracks[[2]int{track.MediaNumber, track.TrackNumber}] = track
+	}
+
+	for _, path := range paths {
+		tags := files[path]
+		disc := tags.DiscNumber
+		if disc == 0 {
+			disc = 1
+		}
+		track, ok := tracks[[2]int{disc, tags.TrackNumber}]
+		if !ok {
+			result.Skipped = append(result.Skipped, SkippedFile{Path: path, Reason: "no matching track on the album"})
+			continue
+		}
+
+		changes := diffTags(path, tags, e.Tagger.expectedTags(track, album, filepath.Ext(path)), e.Tagger.OnlyFillMissing)
+		result.Changes = append(result.Changes, changes...)
+		if !apply || (len(changes) == 0 && coverData == nil) {
+			continue
+		}
+
+		if err := e.Tagger.WriteTags(path, track, album, coverData); err != nil {
+			result.Skipped = append(result.Skipped, SkippedFile{Path: path, Reason: err.Error()})
+			continue
+		}
+		result.Retagged++
+	}
+
+	if apply && coverData != nil {
+		if err := e.saveCoverFile(dir, coverData); err != nil {
+			return result, fmt.Errorf("failed to save cover: %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+// lookupAlbum fetches the album the files in dir belong to, using the album ID
+// marker, then the embedded album ID, then the embedded barcode.
+func (e *Engine) lookupAlbum(dir string, paths []string, files map[string]*FileTags) (*api.AlbumMetadata, error) {
+	if id := readAlbumMarker(dir); id != "" {
+		return e.Client.GetAlbum(id)
+	}
+
+	var upc string
+	for _, path := range paths {
+		if id := files[path].AlbumID; id != "" {
+			return e.Client.GetAlbum(id)
+		}
+		if upc == "" {
+			upc = files[path].UPC
+		}
+	}
+
+	if upc != "" {
+		album, err := e.Client.GetAlbumByUPC(upc)
+		if err != nil {
+			return nil, err
+		}
+		// Search results carry no track list
+		return e.Client.GetAlbum(album.ID)
+	}
+
+	return nil, fmt.Errorf("no album ID or barcode found in %s", dir)
+}
+
+// expectedTags returns the tags the tagger would write for track, in the form
+// ReadTags reports them for a file with extension ext.
+func (t *Tagger) expectedTags(track *api.TrackMetadata, album *api.AlbumMetadata, ext string) *FileTags {
+	separator := ", "
+	if strings.EqualFold(ext, ".mp3") {
+		separator = "/"
+	}
+	date, _ := t.releaseDates(album)
+
+	tags := &FileTags{
+		Title:       track.Title,
+		Artist:      strings.Join(t.trackArtists(track), separator),
+		AlbumArtist: album.Artist.Name,
+		Album:       album.Title,
+		Date:        date,
+	}
+	if album.Genre != nil {
+		tags.Genre = album.Genre.Name
+	}
+	return tags
+}
+
+// diffTags lists the fields of have that differ from want.
+// With onlyMissing, only fields that are blank in have are listed.
+func diffTags(path string, have, want *FileTags, onlyMissing bool) []TagChange {
+	var changes []TagChange
+	for _, field := range []struct {
+		name       string
+		have, want string
+	}{
+		{"title", have.Title, want.Title},
+		{"artist", have.Artist, want.Artist},
+		{"album artist", have.AlbumArtist, want.AlbumArtist},
+		{"album", have.Album, want.Album},
+		{"genre", have.Genre, want.Genre},
+		{"date", have.Date, want.Date},
+	} {
+		if onlyMissing && strings.TrimSpace(field.have) != "" {
+			continue
+		}
+		if field.want != "" && field.have != field.want {
+			changes = append(changes, TagChange{Path: path, Field: field.name, Old: field.have, New: field.want})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,163 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDiffTags(t *testing.T) {
+	want := &FileTags{Title: "Song", Artist: "Band", AlbumArtist: "Band", Album: "Album", Genre: "Rock", Date: "2020-05-01"}
+
+	tests := []struct {
+		name        string
+		have        FileTags
+		onlyMissing bool
+		want        []TagChange
+	}{
+		{name: "up to date", have: *want},
+		{
+			name: "changed fields",
+			have: FileTags{Title: "Song (Live)", Artist: "Band", AlbumArtist: "Band", Album: "Old Album", Genre: "Rock", Date: "2020-05-01"},
+			want: []TagChange{
+				{Path: "a.flac", Field: "title", Old: "Song (Live)", New: "Song"},
+				{Path: "a.flac", Field: "album", Old: "Old Album", New: "Album"},
+			},
+		},
+		{
+			name: "missing fields",
+			have: FileTags{Title: "Song", Artist: "Band", AlbumArtist: "Band", Album: "Album"},
+			want: []TagChange{
+				{Path: "a.flac", Field: "genre", Old: "", New: "Rock"},
+				{Path: "a.flac", Field: "date", Old: "", New: "2020-05-01"},
+			},
+		},
+		{
+			name:        "only missing keeps existing values",
+			have:        FileTags{Title: "Song (Live)", Artist: "Band", AlbumArtist: "Band", Album: "Old Album", Genre: " "},
+			onlyMissing: true,
+			want: []TagChange{
+				{Path: "a.flac", Field: "genre", Old: " ", New: "Rock"},
+				{Path: "a.flac", Field: "date", Old: "", New: "2020-05-01"},
+			},
+		},
+		{
+			name: "outdated genre and date",
+			have: FileTags{Title: "Song", Artist: "Band", AlbumArtist: "Band", Album: "Album", Genre: "Jazz", Date: "2020"},
+			want: []TagChange{
+				{Path: "a.flac", Field: "genre", Old: "Jazz", New: "Rock"},
+				{Path: "a.flac", Field: "date", Old: "2020", New: "2020-05-01"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffTags("a.flac", &tt.have, want, tt.onlyMissing); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffTags() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	// Fields Qobuz has no value for are left alone
+	if got := diffTags("a.flac", want, &FileTags{Title: "Song"}, false); got != nil {
+		t.Errorf("diffTags() with blank metadata = %+v, want no changes", got)
+	}
+}
+
+func TestRefreshAlbum(t *testing.T) {
+	tests := []struct {
+		name         string
+		newTitle     string // Album title on Qobuz at refresh time
+		largerCover  bool   // Qobuz now has a larger cover
+		removeMarker bool   // Match the album by its embedded album ID
+		apply        bool
+		wantChanges  int
+		wantRetagged int
+		wantCover    bool
+	}{
+		{name: "up to date", newTitle: "Album", apply: true},
+		{name: "dry run", newTitle: "Album (Deluxe)", wantChanges: 2},
+		{name: "retitled album", newTitle: "Album (Deluxe)", apply: true, wantChanges: 2, wantRetagged: 2},
+		{name: "matched by album ID", newTitle: "Album (Deluxe)", removeMarker: true, apply: true, wantChanges: 2, wantRetagged: 2},
+		{name: "larger cover", newTitle: "Album", largerCover: true, apply: true, wantRetagged: 2, wantCover: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeQobuz(t)
+			album := fake.addAlbum("alb1", "Album", "Band", 100, 2)
+			downloaded, err := fake.engine().DownloadAlbum(context.Background(), "alb1", 6, t.TempDir())
+			if err != nil || len(downloaded.Success) != 2 {
+				t.Fatalf("DownloadAlbum() = %v, %v", downloaded, err)
+			}
+			dir := downloaded.AlbumDir
+			if tt.removeMarker {
+				if err := os.Remove(filepath.Join(dir, albumIDMarker)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			album.Title = tt.newTitle
+			newCover := fake.cover
+			if tt.largerCover {
+				newCover = testJPEG(t, 64, 64)
+				fake.covers["/covers/alb1_org.jpg"] = newCover // Largest size tried first
+			}
+
+			e := fake.engine()
+			result, err := e.RefreshAlbum(dir, tt.apply)
+			if err != nil {
+				t.Fatalf("RefreshAlbum: %v", err)
+			}
+			if result.AlbumID != "alb1" || result.Title != tt.newTitle {
+				t.Errorf("refreshed %s %q, want alb1 %q", result.AlbumID, result.Title, tt.newTitle)
+			}
+			if len(result.Changes) != tt.wantChanges || result.Retagged != tt.wantRetagged || result.CoverUpdated != tt.wantCover {
+				t.Errorf("got %d changes, %d retagged, cover updated %v; want %d, %d, %v",
+					len(result.Changes), result.Retagged, result.CoverUpdated, tt.wantChanges, tt.wantRetagged, tt.wantCover)
+			}
+			if len(result.Skipped) != 0 {
+				t.Errorf("skipped %+v", result.Skipped)
+			}
+
+			// Tags on disk follow Qobuz only when applied
+			wantAlbum := "Album"
+			if tt.apply {
+				wantAlbum = tt.newTitle
+			}
+			for _, track := range downloaded.Success {
+				tags, err := ReadTags(track.Path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if tags.Album != wantAlbum {
+					t.Errorf("%s has album %q, want %q", track.Path, tags.Album, wantAlbum)
+				}
+				if tt.wantCover && !bytes.Equal(frontCover(t, track.Path), newCover) {
+					t.Errorf("%s does not embed the larger cover", track.Path)
+				}
+			}
+			if cover, err := os.ReadFile(filepath.Join(dir, "cover.jpg")); err != nil || !bytes.Equal(cover, newCover) {
+				t.Errorf("cover.jpg does not hold the expected cover (%v)", err)
+			}
+		})
+	}
+}
+
+func TestRefreshAlbumWithoutAlbumID(t *testing.T) {
+	fake := newFakeQobuz(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "01. Song.flac")
+	if err := os.WriteFile(path, fake.audio, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := fake.engine().RefreshAlbum(dir, true); err == nil {
+		t.Error("RefreshAlbum succeeded on a folder without album ID or barcode")
+	}
+	if n := fake.count("/album/get"); n != 0 {
+		t.Errorf("%d album requests, want none", n)
+	}
+}
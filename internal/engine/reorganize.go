@@ -0,0 +1,119 @@
+// reorganize.go moves previously downloaded files into the current naming layout.
+// Target paths are computed from each file's embedded tags, so nothing is re-downloaded.
+package engine
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Move is a planned file rename.
+type Move struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// SkippedFile is a file left in place, with the reason.
+type SkippedFile struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// ReorganizePlan lists the moves needed to bring a directory into the album layout
+// (<root>/<Album Artist> - <Album>/<NN>. <Title>.<ext>).
+type ReorganizePlan struct {
+	Moves   []Move
+	Skipped []SkippedFile
+}
+
+// PlanReorganize scans root for FLAC and MP3 files and plans moves to the album layout.
+// Files already in place are omitted; files with incomplete tags or whose target is
+// taken are reported as skipped.
+func PlanReorganize(root string) (*ReorganizePlan, error) {
+	plan := &ReorganizePlan{}
+	targets := make(map[string]string) // Target -> source, to detect collisions
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		if d.IsDir() || (ext != ".flac" && ext != ".mp3") {
+			return nil
+		}
+
+		tags, err := ReadTags(path)
+		if err != nil {
+			plan.Skipped = append(plan.Skipped, SkippedFile{Path: path, Reason: err.Error()})
+			return nil
+		}
+
+		target, reason := reorganizeTarget(root, tags, filepath.Ext(path))
+		if target == "" {
+			plan.Skipped = append(plan.Skipped, SkippedFile{Path: path, Reason: reason})
+			return nil
+		}
+		if filepath.Clean(path) == target {
+			return nil // Already correct
+		}
+		if other, ok := targets[target]; ok {
+			plan.Skipped = append(plan.Skipped, SkippedFile{Path: path, Reason: "same target as " + other})
+			return nil
+		}
+		if _, err := os.Stat(target); err == nil {
+			plan.Skipped = append(plan.Skipped, SkippedFile{Path: path, Reason: "target already exists: " + target})
+			return nil
+		}
+
+		targets[target] = path
+		plan.Moves = append(plan.Moves, Move{From: path, To: target})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", root, err)
+	}
+
+	return plan, nil
+}
+
+// reorganizeTarget computes the album layout path for a file from its tags.
+// Returns "" and a reason if required tags are missing.
+func reorganizeTarget(root string, tags *FileTags, ext string) (string, string) {
+	artist := tags.AlbumArtist
+	if artist == "" {
+		artist = tags.Artist
+	}
+	switch {
+	case tags.Title == "":
+		return "", "missing title tag"
+	case tags.Album == "":
+		return "", "missing album tag"
+	case artist == "":
+		return "", "missing artist tag"
+	}
+
+	return filepath.Join(filepath.Clean(root), albumFolderName(artist, tags.Album), trackFileName(tags.TrackNumber, tags.Title)+ext), ""
+}
+
+// ApplyReorganize performs the planned moves, creating folders as needed.
+// Lyrics sidecars (.lrc) are moved along with their audio file.
+func ApplyReorganize(moves []Move) error {
+	for _, m := range moves {
+		if err := os.MkdirAll(filepath.Dir(m.To), 0755); err != nil {
+			return err
+		}
+		if err := os.Rename(m.From, m.To); err != nil {
+			return fmt.Errorf("failed to move %s: %w", m.From, err)
+		}
+
+		lrcFrom := strings.TrimSuffix(m.From, filepath.Ext(m.From)) + ".lrc"
+		if _, err := os.Stat(lrcFrom); err == nil {
+			lrcTo := strings.TrimSuffix(m.To, filepath.Ext(m.To)) + ".lrc"
+			os.Rename(lrcFrom, lrcTo)
+		}
+	}
+	return nil
+}
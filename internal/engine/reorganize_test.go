@@ -0,0 +1,129 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+// writeTaggedFLAC writes a FLAC file at path tagged with the given track details.
+func writeTaggedFLAC(t *testing.T, path, artist, albumTitle, title string, number int) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buildTestFLAC(1, 64), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if title == "" && albumTitle == "" && artist == "" {
+		return // Untagged
+	}
+	album := &api.AlbumMetadata{Title: albumTitle}
+	album.Artist.Name = artist
+	track := &api.TrackMetadata{Title: title, TrackNumber: number, Album: album}
+	track.Performer.Name = artist
+	if err := NewTagger().WriteFlacTags(path, track, album, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReorganizeTarget(t *testing.T) {
+	root := filepath.Join("music", "lib")
+	tests := []struct {
+		name       string
+		tags       FileTags
+		want       string
+		wantReason string
+	}{
+		{
+			name: "album artist",
+			tags: FileTags{Title: "Dreams", Artist: "Stevie Nicks", AlbumArtist: "Fleetwood Mac", Album: "Rumours", TrackNumber: 2},
+			want: filepath.Join(root, "Fleetwood Mac - Rumours", "02. Dreams.flac"),
+		},
+		{
+			name: "falls back to artist",
+			tags: FileTags{Title: "Dreams", Artist: "Fleetwood Mac", Album: "Rumours", TrackNumber: 2},
+			want: filepath.Join(root, "Fleetwood Mac - Rumours", "02. Dreams.flac"),
+		},
+		{
+			name: "unsafe characters sanitized",
+			tags: FileTags{Title: "What/Why?", Artist: "A", Album: "B: C", TrackNumber: 10},
+			want: filepath.Join(root, sanitizeFilename("A - B: C"), trackFileName(10, "What/Why?")+".flac"),
+		},
+		{name: "missing title", tags: FileTags{Artist: "A", Album: "B"}, wantReason: "missing title tag"},
+		{name: "missing album", tags: FileTags{Title: "T", Artist: "A"}, wantReason: "missing album tag"},
+		{name: "missing artist", tags: FileTags{Title: "T", Album: "B"}, wantReason: "missing artist tag"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := reorganizeTarget(root+string(filepath.Separator), &tt.tags, ".flac")
+			if got != tt.want || reason != tt.wantReason {
+				t.Errorf("reorganizeTarget() = %q, %q; want %q, %q", got, reason, tt.want, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestPlanReorganize(t *testing.T) {
+	root := t.TempDir()
+	albumDir := filepath.Join(root, "Band - Album")
+	files := []struct {
+		path                 string
+		artist, album, title string
+		number               int
+	}{
+		{path: filepath.Join(albumDir, "01. One.flac"), artist: "Band", album: "Album", title: "One", number: 1},   // Already in place
+		{path: filepath.Join(root, "old", "track2.flac"), artist: "Band", album: "Album", title: "Two", number: 2}, // Moved
+		{path: filepath.Join(root, "old", "copy of one.flac"), artist: "Band", album: "Album", title: "One", number: 1},
+		{path: filepath.Join(root, "a.flac"), artist: "Other", album: "Record", title: "Three", number: 3},
+		{path: filepath.Join(root, "b", "a.flac"), artist: "Other", album: "Record", title: "Three", number: 3},
+		{path: filepath.Join(root, "untagged.flac")},
+	}
+	for _, f := range files {
+		writeTaggedFLAC(t, f.path, f.artist, f.album, f.title, f.number)
+	}
+	lrc := filepath.Join(root, "old", "track2.lrc")
+	if err := os.WriteFile(lrc, []byte("[00:01.00]Two"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := PlanReorganize(root)
+	if err != nil {
+		t.Fatalf("PlanReorganize: %v", err)
+	}
+
+	wantMoves := []Move{
+		{From: filepath.Join(root, "a.flac"), To: filepath.Join(root, "Other - Record", "03. Three.flac")},
+		{From: filepath.Join(root, "old", "track2.flac"), To: filepath.Join(albumDir, "02. Two.flac")},
+	}
+	if !slices.Equal(plan.Moves, wantMoves) {
+		t.Errorf("Moves = %v, want %v", plan.Moves, wantMoves)
+	}
+	var skipped []string
+	for _, s := range plan.Skipped {
+		skipped = append(skipped, s.Path)
+	}
+	wantSkipped := []string{
+		filepath.Join(root, "b", "a.flac"),             // Same target as a.flac
+		filepath.Join(root, "old", "copy of one.flac"), // Target exists
+		filepath.Join(root, "untagged.flac"),           // Missing tags
+	}
+	if !slices.Equal(skipped, wantSkipped) {
+		t.Errorf("Skipped = %v, want %v", skipped, wantSkipped)
+	}
+
+	if err := ApplyReorganize(plan.Moves); err != nil {
+		t.Fatalf("ApplyReorganize: %v", err)
+	}
+	for _, path := range []string{wantMoves[0].To, wantMoves[1].To, filepath.Join(albumDir, "02. Two.lrc")} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("expected %s after applying: %v", path, err)
+		}
+	}
+	if again, err := PlanReorganize(root); err != nil || len(again.Moves) != 0 {
+		t.Errorf("second plan = %v, %v; want no moves", again, err)
+	}
+}
@@ -0,0 +1,80 @@
+// result.go defines the structured outcome of album downloads.
+// Embedders get per-track results instead of parsing terminal output.
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// TrackResult is the outcome of a single track in an album download.
+type TrackResult struct {
+	Title    string `json:"title"`
+	Path     string `json:"path,omitempty"`      // Output file (existing file for skipped tracks)
+	FormatID int    `json:"format_id,omitempty"` // Delivered quality after fallback
+	Err      error  `json:"-"`                   // Download failure
+	Reason   string `json:"reason,omitempty"`    // Failure category of Err (FailureUnavailable, ...)
+	TagErr   error  `json:"-"`                   // Tagging failure (the audio file is kept)
+	TagError string `json:"tag_error,omitempty"` // TagErr message, for JSON reports
+}
+
+// AlbumResult collects per-track results of an album download.
+type AlbumResult struct {
+	AlbumID  string        `json:"album_id"`
+	Title    string        `json:"title"`
+	AlbumDir string        `json:"album_dir"`
+	Success  []TrackResult `json:"success"`
+	Failed   []TrackResult `json:"failed"`
+	Skipped  []TrackResult `json:"skipped"`
+	Preview  bool          `json:"preview,omitempty"` // Tracks are 30-second preview clips
+}
+
+// Partial reports whether some tracks failed while others were downloaded or skipped.
+func (r *AlbumResult) Partial() bool {
+	return len(r.Failed) > 0 && len(r.Success)+len(r.Skipped) > 0
+}
+
+// PartialError reports that some items of a multi-item download (artist albums,
+// playlist tracks) failed while the rest succeeded.
+type PartialError struct {
+	Failed int    // Number of failed items
+	Total  int    // Number of items attempted
+	Unit   string // Item kind for the message ("albums", "tracks")
+}
+
+// Error implements the error interface.
+func (e *PartialError) Error() string {
+	return fmt.Sprintf("%d of %d %s failed", e.Failed, e.Total, e.Unit)
+}
+
+// StoppedError reports that Engine.FailFast stopped a multi-item download at
+// its first failed item; the remaining items were not attempted.
+type StoppedError struct {
+	Item string // Description of the item that failed
+	Err  error  // Failure of that item
+}
+
+// Error implements the error interface.
+func (e *StoppedError) Error() string {
+	return fmt.Sprintf("stopped after %s failed: %v", e.Item, e.Err)
+}
+
+// Unwrap returns the failure of the item that stopped the run.
+func (e *StoppedError) Unwrap() error { return e.Err }
+
+// existingTrackPath returns the path of an already downloaded track in albumDir,
+// checking every extension the track may have been saved under.
+func (e *Engine) existingTrackPath(albumDir, baseName string) (string, bool) {
+	exts := []string{".flac", ".mp3"}
+	if forced := normalizeExt(e.ForceExt); forced != "" {
+		exts = append(exts, forced)
+	}
+	for _, ext := range exts {
+		path := filepath.Join(albumDir, baseName+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,72 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDownloadAlbumResult(t *testing.T) {
+	fake := newFakeQobuz(t)
+	fake.addAlbum("alb1", "Album", "Band", 100, 3)
+	fake.unavailable[102] = true
+
+	e := fake.engine()
+	outputDir := t.TempDir()
+	plan, err := e.PlanAlbum("alb1", outputDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	existing := filepath.Join(plan.AlbumDir, plan.Tracks[0].BaseName+".flac")
+	if err := os.MkdirAll(plan.AlbumDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(existing, fake.audio, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := e.DownloadAlbum(context.Background(), "alb1", 6, outputDir)
+	if err != nil {
+		t.Fatalf("DownloadAlbum: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		got        []TrackResult
+		wantTitle  string
+		wantPath   string
+		wantFormat int
+		wantReason string
+	}{
+		{name: "success", got: result.Success, wantTitle: "Track 2", wantPath: filepath.Join(plan.AlbumDir, plan.Tracks[1].BaseName+".flac"), wantFormat: 6},
+		{name: "failed", got: result.Failed, wantTitle: "Track 3", wantReason: FailureUnavailable},
+		{name: "skipped", got: result.Skipped, wantTitle: "Track 1", wantPath: existing},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if len(tt.got) != 1 {
+				t.Fatalf("got %d tracks, want 1: %+v", len(tt.got), tt.got)
+			}
+			tr := tt.got[0]
+			if tr.Title != tt.wantTitle || tr.Path != tt.wantPath || tr.FormatID != tt.wantFormat || tr.Reason != tt.wantReason {
+				t.Errorf("track = {%q %q %d %q}, want {%q %q %d %q}",
+					tr.Title, tr.Path, tr.FormatID, tr.Reason, tt.wantTitle, tt.wantPath, tt.wantFormat, tt.wantReason)
+			}
+			if (tr.Err != nil) != (tt.wantReason != "") {
+				t.Errorf("Err = %v", tr.Err)
+			}
+			if tr.TagErr != nil {
+				t.Errorf("TagErr = %v", tr.TagErr)
+			}
+		})
+	}
+	if result.AlbumID != "alb1" || result.Title != "Album" || !result.Partial() {
+		t.Errorf("result = %q %q, Partial() = %v", result.AlbumID, result.Title, result.Partial())
+	}
+	for _, tr := range result.Success {
+		if _, err := os.Stat(tr.Path); err != nil {
+			t.Errorf("downloaded track missing: %v", err)
+		}
+	}
+}
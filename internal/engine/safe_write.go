@@ -0,0 +1,88 @@
+// safe_write.go protects audio files while their tags are rewritten, so a crash
+// or write error during tagging cannot leave a library file half-written.
+package engine
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// backupSuffix is appended to the backup kept while a file is modified in place.
+const backupSuffix = ".bak"
+
+// withBackup runs write, which modifies filePath in place, keeping a copy of the
+// original as filePath.bak until write succeeds. If write fails the original is
+// restored; if the process dies instead, the .bak file is left for recovery.
+func withBackup(filePath string, write func() error) error {
+	backup := filePath + backupSuffix
+	if err := copyFile(filePath, backup); err != nil {
+		os.Remove(backup)
+		return fmt.Errorf("failed to back up %s: %w", filepath.Base(filePath), err)
+	}
+
+	if err := write(); err != nil {
+		if restoreErr := os.Rename(backup, filePath); restoreErr != nil {
+			return fmt.Errorf("%w (original kept as %s: %v)", err, backup, restoreErr)
+		}
+		return err
+	}
+	return os.Remove(backup)
+}
+
+// writeFileAtomic writes data to a temp file next to filePath and renames it over
+// filePath, so readers see either the old or the new content, never a mix.
+func writeFileAtomic(filePath string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // No-op after a successful rename
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if info, err := os.Stat(filePath); err == nil {
+		os.Chmod(tmpPath, info.Mode().Perm())
+	} else {
+		os.Chmod(tmpPath, 0644)
+	}
+	return os.Rename(tmpPath, filePath)
+}
+
+// copyFile copies src to dst with the same permissions, replacing dst.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
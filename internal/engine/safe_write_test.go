@@ -0,0 +1,140 @@
+package engine
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+func TestWithBackup(t *testing.T) {
+	original := []byte("original audio")
+	errDiskFull := errors.New("no space left on device")
+
+	tests := []struct {
+		name    string
+		write   func(path string) error
+		wantErr error
+		want    []byte
+	}{
+		{
+			name:  "write succeeds",
+			write: func(path string) error { return os.WriteFile(path, []byte("retagged audio"), 0644) },
+			want:  []byte("retagged audio"),
+		},
+		{
+			name: "write fails halfway",
+			write: func(path string) error {
+				f, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0)
+				if err != nil {
+					return err
+				}
+				f.Write([]byte("retag"))
+				f.Close()
+				return errDiskFull
+			},
+			wantErr: errDiskFull,
+			want:    original,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "track.flac")
+			if err := os.WriteFile(path, original, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			err := withBackup(path, func() error { return tt.write(path) })
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("withBackup() error = %v, want %v", err, tt.wantErr)
+			}
+			if got, _ := os.ReadFile(path); !bytes.Equal(got, tt.want) {
+				t.Errorf("file = %q, want %q", got, tt.want)
+			}
+			if _, err := os.Stat(path + backupSuffix); !os.IsNotExist(err) {
+				t.Errorf("backup left behind: %v", err)
+			}
+		})
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing bool
+		mode     os.FileMode
+		wantMode os.FileMode
+	}{
+		{name: "replaces the file keeping its mode", existing: true, mode: 0600, wantMode: 0600},
+		{name: "creates a missing file", wantMode: 0644},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "track.flac")
+			if tt.existing {
+				if err := os.WriteFile(path, []byte("old"), tt.mode); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := writeFileAtomic(path, []byte("new")); err != nil {
+				t.Fatalf("writeFileAtomic: %v", err)
+			}
+			if got, _ := os.ReadFile(path); string(got) != "new" {
+				t.Errorf("file = %q, want %q", got, "new")
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm() != tt.wantMode {
+				t.Errorf("mode = %v, want %v", info.Mode().Perm(), tt.wantMode)
+			}
+			if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+				t.Errorf("temp files left behind: %v", entries)
+			}
+		})
+	}
+
+	if err := writeFileAtomic(filepath.Join(t.TempDir(), "missing", "track.flac"), []byte("new")); err == nil {
+		t.Error("writeFileAtomic succeeded in a missing directory")
+	}
+}
+
+func TestSafeWriteRetagLeavesNoFiles(t *testing.T) {
+	tests := []struct {
+		name  string
+		cover []byte // A cover larger than the padding forces a full rewrite
+	}{
+		{name: "in place"},
+		{name: "full rewrite", cover: bytes.Repeat([]byte{0xAB}, 3*DefaultPaddingBytes)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "track.flac")
+			if err := os.WriteFile(path, buildTestFLAC(2, 64), 0644); err != nil {
+				t.Fatal(err)
+			}
+			tagger := NewTagger()
+			tagger.SafeWrite = true
+			album := &api.AlbumMetadata{Title: "Album"}
+			for _, cover := range [][]byte{nil, tt.cover} {
+				if err := tagger.WriteTags(path, &api.TrackMetadata{Title: "Track"}, album, cover); err != nil {
+					t.Fatalf("WriteTags: %v", err)
+				}
+			}
+
+			if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+				t.Errorf("backup or temp files left behind: %v", entries)
+			}
+			if tags, err := ReadTags(path); err != nil || tags.Title != "Track" {
+				t.Errorf("ReadTags() = %+v, %v", tags, err)
+			}
+		})
+	}
+}
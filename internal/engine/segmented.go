@@ -0,0 +1,189 @@
+// segmented.go downloads a single large file over several connections using
+// Range requests, which helps on high-latency links where one connection
+// cannot fill the bandwidth. Servers without Range support fall back to a
+// plain download.
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// minSegmentSize is the smallest segment worth its own connection; smaller
+// files use fewer connections, down to a plain download.
+const minSegmentSize = 4 << 20
+
+// segmentsSuffix is appended to the output path while its segments download.
+// Segments complete out of order, so the file only takes the output path once
+// all of them are in; a killed download never leaves a .part with gaps that a
+// later run would resume.
+const segmentsSuffix = ".segments"
+
+// errRangeUnsupported means the server does not serve byte ranges of the file.
+var errRangeUnsupported = errors.New("server does not support range requests")
+
+// getFileSegmented downloads url into outputPath over up to connections parallel
+// Range requests, merging their progress into onProgress. It returns
+// errRangeUnsupported (with nothing written) if the server does not answer a
+// range probe with 206 and the total size. Segments are written to a separate
+// file that is renamed to outputPath when complete; on errors it is removed,
+// since its segments may have gaps.
+func (e *Engine) getFileSegmented(ctx context.Context, url, outputPath string, connections int, onProgress ProgressCallback) error {
+	total, err := e.probeRangeSize(ctx, url)
+	if err != nil {
+		return err
+	}
+	segments := int(min(int64(connections), total/minSegmentSize))
+	if segments < 2 {
+		return errRangeUnsupported
+	}
+
+	tmpPath := outputPath + segmentsSuffix
+	f, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if err := f.Truncate(total); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var received atomic.Int64
+	var progressMu sync.Mutex
+	lastPercent := -1
+	report := func(n int64) {
+		done := received.Add(n)
+		if onProgress == nil {
+			return
+		}
+		percent := int(done * 100 / total)
+		progressMu.Lock()
+		if percent != lastPercent {
+			lastPercent = percent
+			onProgress(done, total)
+		}
+		progressMu.Unlock()
+	}
+
+	var wg sync.WaitGroup
+	size := total / int64(segments)
+	for i := range segments {
+		start := int64(i) * size
+		end := start + size - 1
+		if i == segments-1 {
+			end = total - 1
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := e.getSegment(ctx, url, f, start, end, report); err != nil {
+				cancel(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	closeErr := f.Close()
+	if err := context.Cause(ctx); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if closeErr != nil {
+		os.Remove(tmpPath)
+		return closeErr
+	}
+	return os.Rename(tmpPath, outputPath)
+}
+
+// probeRangeSize requests the first byte of url and returns the file size from
+// the Content-Range header, or errRangeUnsupported.
+func (e *Engine) probeRangeSize(ctx context.Context, url string) (int64, error) {
+	resp, err := e.Client.HTTP.R().
+		SetContext(ctx).
+		SetHeader("Range", "bytes=0-0").
+		DisableAutoReadResponse().
+		Get(url)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return 0, &httpStatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	if resp.StatusCode != http.StatusPartialContent {
+		return 0, errRangeUnsupported
+	}
+	// Content-Range: bytes 0-0/12345
+	_, size, ok := strings.Cut(resp.Header.Get("Content-Range"), "/")
+	total, err := strconv.ParseInt(size, 10, 64)
+	if !ok || err != nil || total <= 0 {
+		return 0, errRangeUnsupported
+	}
+	return total, nil
+}
+
+// getSegment downloads bytes start..end (inclusive) of url into f at the same
+// offset, reporting received byte counts.
+func (e *Engine) getSegment(ctx context.Context, url string, f *os.File, start, end int64, report func(int64)) error {
+	reqCtx, watchdog := newIdleWatchdog(ctx, e.IdleTimeout)
+	defer watchdog.Stop()
+
+	resp, err := e.Client.HTTP.R().
+		SetContext(reqCtx).
+		SetHeader("Range", fmt.Sprintf("bytes=%d-%d", start, end)).
+		DisableAutoReadResponse().
+		Get(url)
+	if err != nil {
+		if stalled := stallError(reqCtx); stalled != nil {
+			return stalled
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusPartialContent {
+		return &httpStatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	w := io.NewOffsetWriter(f, start)
+	want := end - start + 1
+	var written int64
+	buf := make([]byte, 32*1024)
+	for written < want {
+		n, readErr := resp.Body.Read(buf[:min(int64(len(buf)), want-written)])
+		if n > 0 {
+			if _, err := w.Write(buf[:n]); err != nil {
+				return err
+			}
+			written += int64(n)
+			watchdog.Touch()
+			report(int64(n))
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			if stalled := stallError(reqCtx); stalled != nil {
+				return stalled
+			}
+			return fmt.Errorf("segment %d-%d interrupted: %w", start, end, readErr)
+		}
+	}
+	if written != want {
+		return fmt.Errorf("segment %d-%d incomplete: got %d of %d bytes", start, end, written, want)
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+// rangeServer serves data, honouring Range requests if ranges is set, and
+// records the Range header of every request.
+type rangeServer struct {
+	srv    *httptest.Server
+	mu     sync.Mutex
+	ranges []string
+}
+
+func newRangeServer(t *testing.T, data []byte, ranges bool, failSegment string) *rangeServer {
+	s := &rangeServer{}
+	s.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rng := r.Header.Get("Range")
+		s.mu.Lock()
+		s.ranges = append(s.ranges, rng)
+		s.mu.Unlock()
+		switch {
+		case failSegment != "" && rng == failSegment:
+			http.Error(w, "gone", http.StatusGone)
+		case ranges:
+			http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+		default:
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+			w.Write(data)
+		}
+	}))
+	t.Cleanup(s.srv.Close)
+	return s
+}
+
+// testData returns n bytes that differ between offsets, so misplaced segments
+// are caught.
+func testData(n int) []byte {
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte(i * 7 / 3)
+	}
+	return data
+}
+
+func TestDownloadFileSegmented(t *testing.T) {
+	large := testData(3*minSegmentSize + 12345)
+	small := testData(minSegmentSize + 1)
+
+	tests := []struct {
+		name        string
+		data        []byte
+		ranges      bool
+		connections int
+		wantRanges  int // Segment requests after the probe
+	}{
+		{name: "segments capped by size", data: large, ranges: true, connections: 8, wantRanges: 3},
+		{name: "segments capped by connections", data: large, ranges: true, connections: 2, wantRanges: 2},
+		{name: "no range support", data: large, connections: 4},
+		{name: "too small to split", data: small, ranges: true, connections: 4},
+		{name: "single connection", data: large, ranges: true, connections: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newRangeServer(t, tt.data, tt.ranges, "")
+			e := New(api.NewClient("app", "secret"))
+			e.ConnectionsPerFile = tt.connections
+
+			var progress []int64
+			onProgress := func(current, total int64) {
+				if total != int64(len(tt.data)) {
+					t.Errorf("progress total = %d, want %d", total, len(tt.data))
+				}
+				progress = append(progress, current)
+			}
+			path := filepath.Join(t.TempDir(), "track.flac")
+			if err := e.downloadFileWithProgress(context.Background(), s.srv.URL, path, onProgress, nil); err != nil {
+				t.Fatalf("downloadFileWithProgress: %v", err)
+			}
+
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.data) {
+				t.Error("downloaded file differs from the served file")
+			}
+			if len(progress) == 0 || progress[len(progress)-1] != int64(len(tt.data)) {
+				t.Errorf("final progress = %v, want %d", progress, len(tt.data))
+			}
+			for i := 1; i < len(progress); i++ {
+				if progress[i] < progress[i-1] {
+					t.Fatalf("progress went backwards: %d after %d", progress[i], progress[i-1])
+				}
+			}
+
+			segments := 0
+			for _, rng := range s.ranges {
+				if rng != "" && rng != "bytes=0-0" {
+					segments++
+				}
+			}
+			if segments != tt.wantRanges {
+				t.Errorf("got %d segment requests %q, want %d", segments, s.ranges, tt.wantRanges)
+			}
+		})
+	}
+}
+
+func TestGetFileSegmentedFailure(t *testing.T) {
+	data := testData(2 * minSegmentSize)
+	s := newRangeServer(t, data, true, fmt.Sprintf("bytes=%d-%d", minSegmentSize, len(data)-1))
+	e := New(api.NewClient("app", "secret"))
+
+	path := filepath.Join(t.TempDir(), "track.flac")
+	err := e.getFileSegmented(context.Background(), s.srv.URL, path, 2, nil)
+	var statusErr *httpStatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusGone {
+		t.Fatalf("getFileSegmented error = %v, want status %d", err, http.StatusGone)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("partial file left after a failed segment: %v", err)
+	}
+	if s.ranges[0] != "bytes=0-0" {
+		t.Errorf("first request range = %q, want the probe", s.ranges[0])
+	}
+}
+
+func TestSegmentedDownloadKilledThenResumed(t *testing.T) {
+	data := testData(2*minSegmentSize + 100)
+	size := int64(len(data) / 2)
+	second := fmt.Sprintf("bytes=%d-%d", size, len(data)-1)
+	var hold atomic.Bool // Stall the second segment
+	hold.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hold.Load() && r.Header.Get("Range") == second {
+			<-r.Context().Done()
+			return
+		}
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+	}))
+	defer srv.Close()
+	e := New(api.NewClient("app", "secret"))
+	e.ConnectionsPerFile = 2
+
+	// Interrupt the download once the first segment is in, keeping what a
+	// killed process would leave on disk
+	dir, killed := t.TempDir(), t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	firstDone := make(chan struct{})
+	var once sync.Once
+	onProgress := func(current, total int64) {
+		if current == size {
+			once.Do(func() { close(firstDone) })
+		}
+	}
+	errc := make(chan error, 1)
+	go func() {
+		errc <- e.getFileSegmented(ctx, srv.URL, partialPath(filepath.Join(dir, "01. Song.flac")), 2, onProgress)
+	}()
+	select {
+	case <-firstDone:
+	case <-time.After(10 * time.Second):
+		t.Fatal("first segment did not complete")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(killed, entry.Name()), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cancel()
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("interrupted getFileSegmented error = %v, want %v", err, context.Canceled)
+	}
+
+	// The next run resumes a .part as album downloads do, or starts over
+	hold.Store(false)
+	target := partialPath(filepath.Join(killed, "01. Song.flac"))
+	if partial, ok := e.partialTrackPath(killed, "01. Song"); ok {
+		err = e.resumeFile(context.Background(), srv.URL, partial, nil)
+	} else {
+		err = e.downloadFileWithProgress(context.Background(), srv.URL, target, nil, nil)
+	}
+	if err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	got, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Error("file completed after the interruption differs from the served file")
+	}
+	if _, err := os.Stat(target + segmentsSuffix); !os.IsNotExist(err) {
+		t.Errorf("segments of the killed run left behind: %v", err)
+	}
+}
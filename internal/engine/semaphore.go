@@ -0,0 +1,70 @@
+// semaphore.go bounds the total number of simultaneous downloads across albums.
+// Concurrency limits workers per album; GlobalConcurrency caps all of them
+// together when several albums are downloaded at once. APIConcurrency separately
+// caps metadata and URL signing requests, which Qobuz rate-limits, so byte
+// transfers can run wider than API calls.
+package engine
+
+import (
+	"context"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+// globalSlots returns the shared download semaphore, or nil if unlimited.
+// It is created on first use, so GlobalConcurrency must be set before downloading.
+func (e *Engine) globalSlots() chan struct{} {
+	e.globalOnce.Do(func() {
+		if e.GlobalConcurrency > 0 {
+			e.globalSem = make(chan struct{}, e.GlobalConcurrency)
+		}
+	})
+	return e.globalSem
+}
+
+// acquireDownload waits for a global download slot. The returned release
+// function must be called when the download finishes.
+func (e *Engine) acquireDownload(ctx context.Context) (func(), error) {
+	return acquireSlot(ctx, e.globalSlots())
+}
+
+// apiSlots returns the API request semaphore, or nil if unlimited.
+// It is created on first use, so APIConcurrency must be set before downloading.
+func (e *Engine) apiSlots() chan struct{} {
+	e.apiOnce.Do(func() {
+		if e.APIConcurrency > 0 {
+			e.apiSem = make(chan struct{}, e.APIConcurrency)
+		}
+	})
+	return e.apiSem
+}
+
+// acquireAPI waits for an API request slot. The returned release function must
+// be called when the request finishes.
+func (e *Engine) acquireAPI(ctx context.Context) (func(), error) {
+	return acquireSlot(ctx, e.apiSlots())
+}
+
+// trackURL requests a signed track URL, with quality fallback, within an API slot.
+func (e *Engine) trackURL(ctx context.Context, trackID string, quality int) (*api.TrackURLResponse, int, error) {
+	release, err := e.acquireAPI(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer release()
+	return e.Client.GetTrackURLWithFallback(trackID, quality)
+}
+
+// acquireSlot takes a slot from slots, waiting until one is free or ctx ends.
+// A nil slots channel means unlimited.
+func acquireSlot(ctx context.Context, slots chan struct{}) (func(), error) {
+	if slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
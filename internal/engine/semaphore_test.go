@@ -0,0 +1,154 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+// peakCounter tracks the highest number of simultaneously active calls.
+type peakCounter struct {
+	active atomic.Int32
+	peak   atomic.Int32
+}
+
+// run marks a call active for d.
+func (c *peakCounter) run(d time.Duration) {
+	n := c.active.Add(1)
+	for {
+		peak := c.peak.Load()
+		if n <= peak || c.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(d)
+	c.active.Add(-1)
+}
+
+func TestAcquireDownload(t *testing.T) {
+	tests := []struct {
+		name     string
+		global   int
+		workers  int
+		wantPeak int32
+	}{
+		{name: "cap of one", global: 1, workers: 6, wantPeak: 1},
+		{name: "cap of three", global: 3, workers: 8, wantPeak: 3},
+		{name: "unlimited", global: 0, workers: 5, wantPeak: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New(api.NewClient("", ""))
+			e.GlobalConcurrency = tt.global
+
+			var counter peakCounter
+			var wg sync.WaitGroup
+			for range tt.workers {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					release, err := e.acquireDownload(context.Background())
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					defer release()
+					counter.run(20 * time.Millisecond)
+				}()
+			}
+			wg.Wait()
+			if got := counter.peak.Load(); got != tt.wantPeak {
+				t.Errorf("peak concurrent downloads = %d, want %d", got, tt.wantPeak)
+			}
+		})
+	}
+}
+
+func TestAcquireDownloadCancelled(t *testing.T) {
+	e := New(api.NewClient("", ""))
+	e.GlobalConcurrency = 1
+	release, err := e.acquireDownload(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := e.acquireDownload(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("acquireDownload() with every slot taken = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestGlobalConcurrencyAcrossAlbums(t *testing.T) {
+	fake := newFakeQobuz(t)
+	albums := []string{"alb1", "alb2", "alb3"}
+	for i, id := range albums {
+		fake.addAlbum(id, "Album "+id, "Band", 100*(i+1), 4)
+	}
+	var counter peakCounter
+	fake.onFile = func() { counter.run(20 * time.Millisecond) }
+
+	e := fake.engine()
+	e.Concurrency = 4
+	e.GlobalConcurrency = 2
+	outputDir := t.TempDir()
+
+	var wg sync.WaitGroup
+	for _, id := range albums {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := e.DownloadAlbum(context.Background(), id, 6, outputDir)
+			if err != nil || len(result.Success) != 4 {
+				t.Errorf("DownloadAlbum(%s) = %v, %v", id, result, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := counter.peak.Load(); got < 1 || got > 2 {
+		t.Errorf("peak concurrent downloads = %d, want at most %d", got, e.GlobalConcurrency)
+	}
+}
+
+func TestAPIConcurrencyIndependentOfDownloads(t *testing.T) {
+	tests := []struct {
+		name         string
+		concurrency  int
+		api          int
+		wantAPIPeak  int32
+		wantFilePeak int32
+	}{
+		{name: "narrow api, wide downloads", concurrency: 4, api: 1, wantAPIPeak: 1, wantFilePeak: 4},
+		{name: "api limit above downloads", concurrency: 2, api: 4, wantAPIPeak: 2, wantFilePeak: 2},
+		{name: "unlimited api", concurrency: 3, wantAPIPeak: 3, wantFilePeak: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeQobuz(t)
+			fake.addAlbum("alb1", "Album", "Band", 100, 8)
+			var urls, files peakCounter
+			fake.onTrackURL = func() { urls.run(20 * time.Millisecond) }
+			fake.onFile = func() { files.run(150 * time.Millisecond) }
+
+			e := fake.engine()
+			e.Concurrency = tt.concurrency
+			e.APIConcurrency = tt.api
+			result, err := e.DownloadAlbum(context.Background(), "alb1", 6, t.TempDir())
+			if err != nil || len(result.Success) != 8 {
+				t.Fatalf("DownloadAlbum() = %v, %v", result, err)
+			}
+			if got := urls.peak.Load(); got != tt.wantAPIPeak {
+				t.Errorf("peak concurrent track URL requests = %d, want %d", got, tt.wantAPIPeak)
+			}
+			if got := files.peak.Load(); got != tt.wantFilePeak {
+				t.Errorf("peak concurrent downloads = %d, want %d", got, tt.wantFilePeak)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+// sidecar.go writes per-track JSON metadata files next to the audio, named like
+// the audio file ("01. Title.json"), for external tools and later re-tagging.
+package engine
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+// TrackSidecar is the content of a per-track JSON sidecar.
+type TrackSidecar struct {
+	Track     api.TrackMetadata  `json:"track"`           // Track metadata (its album is in Album)
+	Album     *api.AlbumMetadata `json:"album,omitempty"` // Album metadata without the track list
+	Delivered DeliveredFormat    `json:"delivered"`
+	WrittenAt time.Time          `json:"written_at"`
+}
+
+// DeliveredFormat describes the file actually delivered by the server.
+type DeliveredFormat struct {
+	FormatID     int        `json:"format_id"`
+	MimeType     string     `json:"mime_type"`
+	BitDepth     int        `json:"bit_depth,omitempty"`
+	SamplingRate float64    `json:"sampling_rate,omitempty"` // kHz
+	URLExpiresAt *time.Time `json:"url_expires_at,omitempty"`
+}
+
+// sidecarPath returns the sidecar file of an audio file: same name, .json extension.
+func sidecarPath(audioPath string) string {
+	return strings.TrimSuffix(audioPath, filepath.Ext(audioPath)) + ".json"
+}
+
+// writeTrackSidecar writes the sidecar of the audio file at audioPath.
+func writeTrackSidecar(audioPath string, track *api.TrackMetadata, album *api.AlbumMetadata, info *api.TrackURLResponse, formatID int) error {
+	sidecar := TrackSidecar{
+		Track: *track,
+		Delivered: DeliveredFormat{
+			FormatID:     formatID,
+			MimeType:     info.MimeType,
+			BitDepth:     info.BitDepth,
+			SamplingRate: info.SamplingRate,
+			URLExpiresAt: signedURLExpiry(info.URL),
+		},
+		WrittenAt: time.Now(),
+	}
+	sidecar.Track.Album = nil
+	if album != nil {
+		trimmed := *album
+		trimmed.Tracks.Items = nil
+		sidecar.Album = &trimmed
+	}
+
+	data, err := json.MarshalIndent(sidecar, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(sidecarPath(audioPath), data)
+}
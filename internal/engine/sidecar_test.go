@@ -0,0 +1,163 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+// readSidecar decodes the sidecar of the audio file at audioPath.
+func readSidecar(t *testing.T, audioPath string) *TrackSidecar {
+	t.Helper()
+	data, err := os.ReadFile(sidecarPath(audioPath))
+	if err != nil {
+		t.Fatalf("no sidecar for %s: %v", filepath.Base(audioPath), err)
+	}
+	var sidecar TrackSidecar
+	if err := json.Unmarshal(data, &sidecar); err != nil {
+		t.Fatalf("invalid sidecar for %s: %v", filepath.Base(audioPath), err)
+	}
+	return &sidecar
+}
+
+func TestSidecarPath(t *testing.T) {
+	tests := []struct{ audio, want string }{
+		{audio: filepath.Join("Band - Album", "01. Song.flac"), want: filepath.Join("Band - Album", "01. Song.json")},
+		{audio: "02. Op. 27.mp3", want: "02. Op. 27.json"},
+	}
+	for _, tt := range tests {
+		if got := sidecarPath(tt.audio); got != tt.want {
+			t.Errorf("sidecarPath(%q) = %q, want %q", tt.audio, got, tt.want)
+		}
+	}
+}
+
+func TestWriteTrackSidecar(t *testing.T) {
+	album := &api.AlbumMetadata{ID: "alb1", Title: "Album", UPC: "0123456789012"}
+	album.Tracks.Items = []api.TrackMetadata{{ID: 1}, {ID: 2}}
+	track := &api.TrackMetadata{ID: 2, Title: "Song", ISRC: "USABC9900001", TrackNumber: 2, Album: album}
+	expiry := time.Unix(1767225600, 0)
+
+	tests := []struct {
+		name       string
+		url        string
+		wantExpiry *time.Time
+	}{
+		{name: "signed URL", url: "https://streaming.qobuz.com/file?uid=1&eid=2&etsp=1767225600&hmac=x", wantExpiry: &expiry},
+		{name: "unsigned URL", url: "https://cdn.example/2.flac"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "02. Song.flac")
+			info := &api.TrackURLResponse{URL: tt.url, MimeType: "audio/flac", BitDepth: 24, SamplingRate: 96}
+			if err := writeTrackSidecar(path, track, album, info, 7); err != nil {
+				t.Fatalf("writeTrackSidecar: %v", err)
+			}
+			got := readSidecar(t, path)
+			if got.Track.ID != 2 || got.Track.ISRC != track.ISRC || got.Track.Album != nil {
+				t.Errorf("track = %+v, want track 2 without its album", got.Track)
+			}
+			if got.Album == nil || got.Album.UPC != album.UPC || len(got.Album.Tracks.Items) != 0 {
+				t.Errorf("album = %+v, want the album without its track list", got.Album)
+			}
+			want := DeliveredFormat{FormatID: 7, MimeType: "audio/flac", BitDepth: 24, SamplingRate: 96}
+			if gotExpiry := got.Delivered.URLExpiresAt; (gotExpiry == nil) != (tt.wantExpiry == nil) || (gotExpiry != nil && !gotExpiry.Equal(*tt.wantExpiry)) {
+				t.Errorf("URL expiry = %v, want %v", gotExpiry, tt.wantExpiry)
+			}
+			got.Delivered.URLExpiresAt = nil
+			if got.Delivered != want {
+				t.Errorf("delivered = %+v, want %+v", got.Delivered, want)
+			}
+			if got.WrittenAt.IsZero() {
+				t.Error("written_at is missing")
+			}
+			if len(album.Tracks.Items) != 2 {
+				t.Error("writing the sidecar modified the album's track list")
+			}
+		})
+	}
+}
+
+func TestDownloadTrackSidecars(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  bool
+		template string // Album track naming template
+	}{
+		{name: "enabled", enabled: true},
+		{name: "custom naming", enabled: true, template: "{discnumber}-{tracknumber} {artist} - {title}"},
+		{name: "disabled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeQobuz(t)
+			fake.addAlbum("alb1", "Album", "Band", 100, 2)
+			e := fake.engine()
+			e.TrackSidecars = tt.enabled
+			e.AlbumTrackTemplate = tt.template
+
+			result, err := e.DownloadAlbum(context.Background(), "alb1", 6, t.TempDir())
+			if err != nil || len(result.Success) != 2 {
+				t.Fatalf("DownloadAlbum() = %v, %v", result, err)
+			}
+			singleDir := t.TempDir()
+			if err := e.DownloadTrack(context.Background(), "101", 6, singleDir, nil); err != nil {
+				t.Fatalf("DownloadTrack: %v", err)
+			}
+
+			audio := []string{filepath.Join(singleDir, "Band - Track 2.flac")}
+			for _, track := range result.Success {
+				audio = append(audio, track.Path)
+			}
+			for _, path := range audio {
+				if _, err := os.Stat(sidecarPath(path)); !tt.enabled {
+					if err == nil {
+						t.Errorf("sidecar written for %s while disabled", filepath.Base(path))
+					}
+					continue
+				}
+				sidecar := readSidecar(t, path)
+				title := strings.TrimSuffix(filepath.Base(path), ".flac")
+				if !strings.HasSuffix(title, sidecar.Track.Title) {
+					t.Errorf("sidecar of %s describes %q", filepath.Base(path), sidecar.Track.Title)
+				}
+				if sidecar.Album == nil || sidecar.Album.ID != "alb1" {
+					t.Errorf("sidecar of %s has album %+v, want alb1", filepath.Base(path), sidecar.Album)
+				}
+				want := DeliveredFormat{FormatID: 6, MimeType: "audio/flac", BitDepth: 16, SamplingRate: 44.1}
+				if sidecar.Delivered != want {
+					t.Errorf("sidecar of %s delivered = %+v, want %+v", filepath.Base(path), sidecar.Delivered, want)
+				}
+			}
+
+			// The album folder holds one sidecar per track and no other JSON file
+			entries, err := os.ReadDir(result.AlbumDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var sidecars []string
+			for _, entry := range entries {
+				if filepath.Ext(entry.Name()) == ".json" {
+					sidecars = append(sidecars, entry.Name())
+				}
+			}
+			var want []string
+			if tt.enabled {
+				for _, track := range result.Success {
+					want = append(want, filepath.Base(sidecarPath(track.Path)))
+				}
+			}
+			slices.Sort(want)
+			if !slices.Equal(sidecars, want) {
+				t.Errorf("JSON files in the album folder = %v, want %v", sidecars, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+// square_cover.go center-crops non-square cover art before embedding, since
+// some players stretch or letterbox it. The saved cover.jpg keeps the original.
+package engine
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	_ "image/png" // Covers and goodies may be PNG
+)
+
+// squareCoverQuality is the JPEG quality used when re-encoding a cropped cover.
+const squareCoverQuality = 95
+
+// squareCover center-crops an image to a square JPEG.
+// Images that are already square are returned unchanged.
+func squareCover(data []byte) ([]byte, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode cover: %w", err)
+	}
+	bounds := img.Bounds()
+	if bounds.Dx() == bounds.Dy() {
+		return data, nil
+	}
+
+	side := min(bounds.Dx(), bounds.Dy())
+	x := bounds.Min.X + (bounds.Dx()-side)/2
+	y := bounds.Min.Y + (bounds.Dy()-side)/2
+	square := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(square, square.Bounds(), img, image.Pt(x, y), draw.Src)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, square, &jpeg.Options{Quality: squareCoverQuality}); err != nil {
+		return nil, fmt.Errorf("failed to encode cover: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// embeddedCover returns the cover to embed in tracks: squared when SquareCover
+// is set, otherwise data unchanged. On failure the original is embedded.
+func (e *Engine) embeddedCover(data []byte) []byte {
+	if !e.SquareCover || len(data) == 0 {
+		return data
+	}
+	squared, err := squareCover(data)
+	if err != nil {
+		fmt.Printf("Warning: could not square cover: %v\n", err)
+		return data
+	}
+	return squared
+}
+
+// detectDimensions fills in the picture's width, height and color depth from
+// its image data. Unrecognized images keep zero values, which the FLAC picture
+// block allows.
+func (p *Picture) detectDimensions() {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(p.ImageData))
+	if err != nil {
+		return
+	}
+	p.Width = uint32(cfg.Width)
+	p.Height = uint32(cfg.Height)
+	switch cfg.ColorModel {
+	case color.GrayModel:
+		p.Depth = 8
+	case color.Gray16Model:
+		p.Depth = 16
+	case color.RGBA64Model, color.NRGBA64Model:
+		p.Depth = 48
+	default:
+		p.Depth = 24
+	}
+}
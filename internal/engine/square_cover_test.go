@@ -0,0 +1,136 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// bandedPNG returns a width x height PNG that is white in a centered vertical
+// band of bandWidth pixels and black elsewhere.
+func bandedPNG(t *testing.T, width, height, bandWidth int) []byte {
+	t.Helper()
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	left := (width - bandWidth) / 2
+	for y := range height {
+		for x := left; x < left+bandWidth; x++ {
+			img.SetGray(x, y, color.Gray{Y: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestSquareCover(t *testing.T) {
+	square := testJPEG(t, 16, 16)
+	tests := []struct {
+		name      string
+		in        []byte
+		wantSide  int
+		unchanged bool
+		wantErr   bool
+	}{
+		{name: "landscape", in: testJPEG(t, 30, 20), wantSide: 20},
+		{name: "portrait", in: testJPEG(t, 20, 30), wantSide: 20},
+		{name: "png", in: bandedPNG(t, 40, 10, 10), wantSide: 10},
+		{name: "already square", in: square, wantSide: 16, unchanged: true},
+		{name: "not an image", in: []byte("<html>"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := squareCover(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("squareCover() succeeded, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("squareCover() error = %v", err)
+			}
+			if tt.unchanged && !bytes.Equal(got, tt.in) {
+				t.Error("square cover was re-encoded")
+			}
+			cfg, format, err := image.DecodeConfig(bytes.NewReader(got))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Width != tt.wantSide || cfg.Height != tt.wantSide {
+				t.Errorf("squared cover is %dx%d, want %dx%[3]d", cfg.Width, cfg.Height, tt.wantSide)
+			}
+			if !tt.unchanged && format != "jpeg" {
+				t.Errorf("squared cover format = %q, want jpeg", format)
+			}
+		})
+	}
+}
+
+func TestSquareCoverKeepsCenter(t *testing.T) {
+	got, err := squareCover(bandedPNG(t, 40, 10, 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, _, err := image.Decode(bytes.NewReader(got))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The crop is the white band; JPEG artifacts may darken it slightly
+	for _, pt := range []image.Point{{1, 1}, {5, 5}, {8, 8}} {
+		if r, _, _, _ := img.At(pt.X, pt.Y).RGBA(); r < 0xE000 {
+			t.Errorf("pixel %v is dark: the crop is not centered", pt)
+		}
+	}
+}
+
+func TestDownloadAlbumSquareCover(t *testing.T) {
+	tests := []struct {
+		name         string
+		square       bool
+		wantEmbedded [2]uint32 // Width and height of the embedded picture
+	}{
+		{name: "squared", square: true, wantEmbedded: [2]uint32{12, 12}},
+		{name: "original", wantEmbedded: [2]uint32{24, 12}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeQobuz(t)
+			fake.cover = testJPEG(t, 24, 12)
+			fake.addAlbum("alb1", "Album", "Band", 100, 1)
+
+			e := fake.engine()
+			e.SquareCover = tt.square
+			outputDir := t.TempDir()
+			result, err := e.DownloadAlbum(context.Background(), "alb1", 6, outputDir)
+			if err != nil || len(result.Success) != 1 {
+				t.Fatalf("DownloadAlbum() = %+v, %v", result, err)
+			}
+
+			saved, err := os.ReadFile(filepath.Join(filepath.Dir(result.Success[0].Path), "cover.jpg"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(saved, fake.cover) {
+				t.Error("cover.jpg is not the original cover")
+			}
+			pics := flacPictures(t, result.Success[0].Path)
+			if len(pics) != 1 {
+				t.Fatalf("got %d embedded pictures, want 1", len(pics))
+			}
+			if got := [2]uint32{pics[0].Width, pics[0].Height}; got != tt.wantEmbedded {
+				t.Errorf("embedded picture is %dx%d, want %dx%d", got[0], got[1], tt.wantEmbedded[0], tt.wantEmbedded[1])
+			}
+			cfg, _, err := image.DecodeConfig(bytes.NewReader(pics[0].ImageData))
+			if err != nil || [2]uint32{uint32(cfg.Width), uint32(cfg.Height)} != tt.wantEmbedded {
+				t.Errorf("embedded image data is %dx%d, want the recorded size", cfg.Width, cfg.Height)
+			}
+		})
+	}
+}
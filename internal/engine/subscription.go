@@ -0,0 +1,97 @@
+// subscription.go warns when the requested quality exceeds what the account's
+// subscription can stream, instead of letting Qobuz silently downgrade.
+package engine
+
+import (
+	"fmt"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+// qualityNames describes quality IDs for user-facing messages.
+var qualityNames = map[int]string{
+	5:  "MP3 320",
+	6:  "FLAC 16-bit",
+	7:  "FLAC 24-bit",
+	27: "FLAC 24-bit >96kHz",
+}
+
+// qualityName returns the description of a quality ID.
+func qualityName(formatID int) string {
+	if name, ok := qualityNames[formatID]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// qualityCeilingWarning returns a warning if requested exceeds maxAllowed, or "".
+// maxAllowed is the subscription's highest format ID (0 = unknown, no warning).
+func qualityCeilingWarning(requested, maxAllowed int, plan string) string {
+	if maxAllowed == 0 || api.QualityRank(requested) <= api.QualityRank(maxAllowed) {
+		return ""
+	}
+	if plan == "" {
+		plan = "your subscription"
+	}
+	return fmt.Sprintf("Warning: quality %d (%s) exceeds what %s allows; downloads will be downgraded. Use -q %d (%s) or lower.",
+		requested, qualityName(requested), plan, maxAllowed, qualityName(maxAllowed))
+}
+
+// userInfo returns the account details, fetched once per engine.
+// Returns nil if they could not be retrieved.
+func (e *Engine) userInfo() *api.UserInfo {
+	e.subscriptionOnce.Do(func() {
+		if info, err := e.Client.GetUserInfo(); err == nil {
+			e.subscription = info
+		}
+	})
+	return e.subscription
+}
+
+// subscriptionMaxQuality returns the subscription's highest quality ID (0 = unknown).
+func (e *Engine) subscriptionMaxQuality() int {
+	if info := e.userInfo(); info != nil {
+		return info.MaxFormatID()
+	}
+	return 0
+}
+
+// accountWarning returns a warning for accounts that can only stream previews,
+// or a note for trial accounts, or "".
+func accountWarning(info *api.UserInfo) string {
+	if info.PreviewOnly() {
+		return "Warning: this account has no active streaming subscription and can only stream 30-second previews; downloads will be previews or fail."
+	}
+	if info.IsTrial() {
+		if s := info.Subscription; s != nil && s.EndDate != "" {
+			return fmt.Sprintf("Note: this account is on a trial subscription ending %s; downloads will become previews after that.", s.EndDate)
+		}
+		return "Note: this account is on a trial subscription; downloads will become previews once it ends."
+	}
+	return ""
+}
+
+// warnQualityCeiling prints a one-time warning for preview-only and trial
+// accounts, or when quality exceeds the subscription's ceiling. Failing to
+// fetch the user info is not an error. QualityBest never exceeds the ceiling.
+func (e *Engine) warnQualityCeiling(quality int) {
+	e.warnOnce.Do(func() {
+		info := e.userInfo()
+		if info == nil {
+			return
+		}
+		if msg := accountWarning(info); msg != "" {
+			fmt.Println(msg)
+		}
+		if quality == QualityBest {
+			return
+		}
+		plan := info.Credential.Label
+		if p := info.Credential.Parameters; p != nil && p.ShortLabel != "" {
+			plan = p.ShortLabel
+		}
+		if msg := qualityCeilingWarning(quality, info.MaxFormatID(), plan); msg != "" {
+			fmt.Println(msg)
+		}
+	})
+}
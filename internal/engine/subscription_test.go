@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+func TestQualityCeilingWarning(t *testing.T) {
+	tests := []struct {
+		name       string
+		requested  int
+		maxAllowed int
+		plan       string
+		want       []string // Substrings of the warning; nil = no warning
+	}{
+		{name: "hi-res on hi-res plan", requested: 27, maxAllowed: 27},
+		{name: "24-bit on hi-res plan", requested: 7, maxAllowed: 27},
+		{name: "CD on CD plan", requested: 6, maxAllowed: 6},
+		{name: "MP3 on CD plan", requested: 5, maxAllowed: 6},
+		{name: "unknown ceiling", requested: 27, maxAllowed: 0},
+		{name: "hi-res on CD plan", requested: 27, maxAllowed: 6, plan: "Audiophile", want: []string{"quality 27", "Audiophile", "-q 6 (FLAC 16-bit)"}},
+		{name: "24-bit on CD plan", requested: 7, maxAllowed: 6, want: []string{"quality 7", "your subscription", "-q 6"}},
+		{name: "CD on MP3 plan", requested: 6, maxAllowed: 5, want: []string{"-q 5 (MP3 320)"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := qualityCeilingWarning(tt.requested, tt.maxAllowed, tt.plan)
+			if (got != "") != (tt.want != nil) {
+				t.Fatalf("qualityCeilingWarning(%d, %d) = %q, want warning %v", tt.requested, tt.maxAllowed, got, tt.want != nil)
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(got, s) {
+					t.Errorf("warning %q does not contain %q", got, s)
+				}
+			}
+		})
+	}
+}
+
+func TestAccountWarning(t *testing.T) {
+	tests := []struct {
+		name     string
+		userInfo string // user/get response
+		want     string // Substring of the warning; "" = no warning
+	}{
+		{
+			name:     "active subscription",
+			userInfo: `{"credential": {"label": "Studio", "parameters": {"lossless_streaming": true, "hires_streaming": true}}, "subscription": {"offer": "studio"}}`,
+		},
+		{
+			name:     "free account",
+			userInfo: `{"credential": {"label": "Free"}}`,
+			want:     "30-second previews",
+		},
+		{
+			name:     "lapsed subscription",
+			userInfo: `{"credential": {"label": "Studio", "parameters": {}}, "subscription": {"offer": "studio", "end_date": "2024-01-01"}}`,
+			want:     "no active streaming subscription",
+		},
+		{
+			name:     "trial with end date",
+			userInfo: `{"credential": {"label": "Studio", "parameters": {"hires_streaming": true}}, "subscription": {"offer": "trial", "end_date": "2026-11-01"}}`,
+			want:     "trial subscription ending 2026-11-01",
+		},
+		{
+			name:     "trial in credential label",
+			userInfo: `{"credential": {"label": "Studio Trial", "parameters": {"lossless_streaming": true}}}`,
+			want:     "once it ends",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var info api.UserInfo
+			if err := json.Unmarshal([]byte(tt.userInfo), &info); err != nil {
+				t.Fatal(err)
+			}
+			got := accountWarning(&info)
+			if (got == "") != (tt.want == "") || !strings.Contains(got, tt.want) {
+				t.Errorf("accountWarning() = %q, want it to contain %q", got, tt.want)
+			}
+		})
+	}
+}
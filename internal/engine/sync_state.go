@@ -0,0 +1,99 @@
+// sync_state.go records which artist albums completed in an output directory,
+// so an interrupted discography download resumes where it left off instead of
+// re-checking every album.
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+// SyncStateFile is the state file written to the output directory.
+const SyncStateFile = ".sync-state.json"
+
+// SyncState holds the completed albums of every artist synced into a directory.
+type SyncState struct {
+	Artists map[string]*ArtistSync `json:"artists"` // Keyed by artist ID
+}
+
+// ArtistSync holds the albums of one artist that downloaded completely.
+type ArtistSync struct {
+	Name   string               `json:"name"`
+	Albums map[string]AlbumSync `json:"albums"` // Keyed by album ID
+}
+
+// AlbumSync records a completed album.
+type AlbumSync struct {
+	Title       string    `json:"title"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// loadSyncState reads the state file in dir. A missing file yields an empty
+// state; an unreadable one is reported and replaced.
+func loadSyncState(dir string) *SyncState {
+	state := &SyncState{Artists: make(map[string]*ArtistSync)}
+	data, err := os.ReadFile(filepath.Join(dir, SyncStateFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fmt.Printf("Warning: failed to read %s: %v\n", SyncStateFile, err)
+		}
+		return state
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		fmt.Printf("Warning: ignoring corrupt %s: %v\n", SyncStateFile, err)
+		return &SyncState{Artists: make(map[string]*ArtistSync)}
+	}
+	if state.Artists == nil {
+		state.Artists = make(map[string]*ArtistSync)
+	}
+	return state
+}
+
+// save writes the state file in dir atomically.
+func (s *SyncState) save(dir string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(dir, SyncStateFile), data)
+}
+
+// completed reports whether the album of the artist completed in an earlier run.
+func (s *SyncState) completed(artistID, albumID string) bool {
+	artist, ok := s.Artists[artistID]
+	if !ok {
+		return false
+	}
+	_, ok = artist.Albums[albumID]
+	return ok
+}
+
+// pending returns the albums of the artist not completed in an earlier run.
+func (s *SyncState) pending(artistID string, albums []api.AlbumMetadata) []api.AlbumMetadata {
+	var pending []api.AlbumMetadata
+	for _, album := range albums {
+		if !s.completed(artistID, album.ID) {
+			pending = append(pending, album)
+		}
+	}
+	return pending
+}
+
+// markCompleted records a completed album of the artist.
+func (s *SyncState) markCompleted(artistID, artistName, albumID, albumTitle string) {
+	artist, ok := s.Artists[artistID]
+	if !ok {
+		artist = &ArtistSync{Albums: make(map[string]AlbumSync)}
+		s.Artists[artistID] = artist
+	}
+	if artist.Albums == nil {
+		artist.Albums = make(map[string]AlbumSync)
+	}
+	artist.Name = artistName
+	artist.Albums[albumID] = AlbumSync{Title: albumTitle, CompletedAt: time.Now()}
+}
@@ -0,0 +1,101 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// syncedAlbums returns the album IDs the state file in dir marks completed for the artist.
+func syncedAlbums(t *testing.T, dir, artistID string) []string {
+	t.Helper()
+	artist, ok := loadSyncState(dir).Artists[artistID]
+	if !ok {
+		return nil
+	}
+	return slices.Sorted(maps.Keys(artist.Albums))
+}
+
+func TestDownloadArtistResume(t *testing.T) {
+	fake := newFakeQobuz(t)
+	fake.addAlbum("alb1", "First", "Band", 100, 2)
+	fake.addAlbum("alb2", "Second", "Band", 200, 2)
+	fake.addAlbum("alb3", "Third", "Band", 300, 2)
+	fake.addArtist("art1", "Band", "alb1", "alb2", "alb3")
+	outputDir := t.TempDir()
+
+	// The first run is interrupted by a failing album
+	fake.unavailable[201] = true
+	err := fake.engine().DownloadArtist(context.Background(), "art1", 6, outputDir)
+	var partial *PartialError
+	if !errors.As(err, &partial) || partial.Failed != 1 {
+		t.Fatalf("first DownloadArtist() = %v, want one failed album", err)
+	}
+	if got, want := syncedAlbums(t, outputDir, "art1"), []string{"alb1", "alb3"}; !slices.Equal(got, want) {
+		t.Fatalf("completed albums after the first run = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		name         string
+		ignoreState  bool
+		wantRequests int // album/get requests of the run
+	}{
+		{name: "resume only the failed album", wantRequests: 1},
+		{name: "nothing left", wantRequests: 0},
+		{name: "force re-checks every album", ignoreState: true, wantRequests: 3},
+	}
+	fake.unavailable[201] = false
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := fake.engine()
+			e.IgnoreSyncState = tt.ignoreState
+			before := fake.count("/album/get")
+			if err := e.DownloadArtist(context.Background(), "art1", 6, outputDir); err != nil {
+				t.Fatalf("DownloadArtist: %v", err)
+			}
+			if got := fake.count("/album/get") - before; got != tt.wantRequests {
+				t.Errorf("%d album requests, want %d", got, tt.wantRequests)
+			}
+			if got, want := syncedAlbums(t, outputDir, "art1"), []string{"alb1", "alb2", "alb3"}; !slices.Equal(got, want) {
+				t.Errorf("completed albums = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestLoadSyncState(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string // "" for no state file
+		want     []string
+	}{
+		{name: "no state file"},
+		{name: "corrupt state file", contents: "{not json"},
+		{name: "saved state", contents: `{"artists":{"art1":{"name":"Band","albums":{"alb1":{"title":"First"},"alb2":{"title":"Second"}}}}}`, want: []string{"alb1", "alb2"}},
+		{name: "artist without albums", contents: `{"artists":{"art1":{"name":"Band"}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.contents != "" {
+				if err := os.WriteFile(filepath.Join(dir, SyncStateFile), []byte(tt.contents), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := syncedAlbums(t, dir, "art1"); !slices.Equal(got, tt.want) {
+				t.Errorf("completed albums = %v, want %v", got, tt.want)
+			}
+
+			// Recording an album must work on any loaded state
+			state := loadSyncState(dir)
+			state.markCompleted("art1", "Band", "alb9", "Ninth")
+			if !state.completed("art1", "alb9") || state.completed("art2", "alb9") {
+				t.Error("markCompleted did not record the album for the artist only")
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+// tag_errors.go records tagging failures of unattended runs in a log inside the
+// album folder, so the affected files can be found and re-tagged later.
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// TagErrorLog is the file listing tagging failures, written to the album folder
+// when Engine.KeepGoing is set.
+const TagErrorLog = ".tag-errors.log"
+
+// TagFailures returns the downloaded tracks whose tagging failed.
+func (r *AlbumResult) TagFailures() []TrackResult {
+	var failed []TrackResult
+	for _, tr := range r.Success {
+		if tr.TagErr != nil {
+			failed = append(failed, tr)
+		}
+	}
+	return failed
+}
+
+// writeTagErrorLog rewrites the album's tag error log from result, one
+// "file: reason" line per failure. The log is removed when every track tagged
+// cleanly, so a successful re-run clears it.
+func writeTagErrorLog(result *AlbumResult) error {
+	logPath := filepath.Join(result.AlbumDir, TagErrorLog)
+	failures := result.TagFailures()
+	if len(failures) == 0 {
+		if err := os.Remove(logPath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Tagging failures, %s\n", time.Now().Format(time.RFC3339))
+	for _, tr := range failures {
+		fmt.Fprintf(&b, "%s: %v\n", filepath.Base(tr.Path), tr.TagErr)
+	}
+	return os.WriteFile(logPath, []byte(b.String()), 0644)
+}
+
+// appendTagErrorLog adds a single tagging failure to the log in dir, used for
+// tracks downloaded outside an album run.
+func appendTagErrorLog(dir, filePath string, tagErr error) error {
+	f, err := os.OpenFile(filepath.Join(dir, TagErrorLog), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(f, "%s: %v\n", filepath.Base(filePath), tagErr); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,143 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// readTagErrorLog returns the entries of the tag error log in dir, without the
+// header line, or nil when there is no log.
+func readTagErrorLog(t *testing.T, dir string) []string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, TagErrorLog))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if !strings.HasPrefix(lines[0], "# Tagging failures, ") {
+		t.Errorf("log header = %q", lines[0])
+	}
+	return lines[1:]
+}
+
+func TestWriteTagErrorLog(t *testing.T) {
+	tests := []struct {
+		name     string
+		success  []TrackResult
+		existing bool // A log from an earlier run is present
+		want     []string
+	}{
+		{
+			name: "failures listed in track order",
+			success: []TrackResult{
+				{Path: "/music/Album/01 - One.flac", TagErr: errors.New("bad header")},
+				{Path: "/music/Album/02 - Two.flac"},
+				{Path: "/music/Album/03 - Three.flac", TagErr: errors.New("permission denied")},
+			},
+			want: []string{"01 - One.flac: bad header", "03 - Three.flac: permission denied"},
+		},
+		{
+			name:     "earlier log replaced",
+			success:  []TrackResult{{Path: "/music/Album/02 - Two.flac", TagErr: errors.New("disk full")}},
+			existing: true,
+			want:     []string{"02 - Two.flac: disk full"},
+		},
+		{
+			name:     "clean run removes earlier log",
+			success:  []TrackResult{{Path: "/music/Album/01 - One.flac"}},
+			existing: true,
+		},
+		{
+			name:    "clean run without log",
+			success: []TrackResult{{Path: "/music/Album/01 - One.flac"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.existing {
+				if err := os.WriteFile(filepath.Join(dir, TagErrorLog), []byte("# Tagging failures\nold.flac: stale\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			result := &AlbumResult{AlbumDir: dir, Success: tt.success}
+			if err := writeTagErrorLog(result); err != nil {
+				t.Fatalf("writeTagErrorLog: %v", err)
+			}
+			if got := readTagErrorLog(t, dir); !slices.Equal(got, tt.want) {
+				t.Errorf("log entries = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppendTagErrorLog(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, TagErrorLog), []byte("# Tagging failures, earlier\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.flac", "b.mp3"} {
+		if err := appendTagErrorLog(dir, filepath.Join(dir, name), errors.New("unsupported")); err != nil {
+			t.Fatalf("appendTagErrorLog: %v", err)
+		}
+	}
+	want := []string{"a.flac: unsupported", "b.mp3: unsupported"}
+	if got := readTagErrorLog(t, dir); !slices.Equal(got, want) {
+		t.Errorf("log entries = %q, want %q", got, want)
+	}
+}
+
+func TestDownloadAlbumKeepGoing(t *testing.T) {
+	tests := []struct {
+		name      string
+		keepGoing bool
+		wantLog   bool
+	}{
+		{name: "keep going", keepGoing: true, wantLog: true},
+		{name: "default", keepGoing: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeQobuz(t)
+			fake.audio = []byte("not an audio file")
+			fake.addAlbum("alb1", "Broken", "Band", 100, 2)
+			e := fake.engine()
+			e.KeepGoing = tt.keepGoing
+
+			result, err := e.DownloadAlbum(context.Background(), "alb1", 6, t.TempDir())
+			if err != nil {
+				t.Fatalf("DownloadAlbum: %v", err)
+			}
+			if len(result.Success) != 2 || len(result.Failed) != 0 {
+				t.Fatalf("got %d downloaded and %d failed, want 2 and 0", len(result.Success), len(result.Failed))
+			}
+			failures := result.TagFailures()
+			if len(failures) != 2 {
+				t.Fatalf("got %d tag failures, want 2", len(failures))
+			}
+
+			entries := readTagErrorLog(t, result.AlbumDir)
+			if !tt.wantLog {
+				if entries != nil {
+					t.Errorf("log written without KeepGoing: %q", entries)
+				}
+				return
+			}
+			var want []string
+			for _, tr := range failures {
+				want = append(want, filepath.Base(tr.Path)+": "+tr.TagErr.Error())
+			}
+			if !slices.Equal(entries, want) {
+				t.Errorf("log entries = %q, want %q", entries, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+// tag_index.go recognizes already downloaded tracks by their embedded tags,
+// so files that were renamed after downloading are still skipped.
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+// trackKey identifies a track by its position and title when no ISRC is available.
+type trackKey struct {
+	Disc  int
+	Track int
+	Title string
+}
+
+// tagIndex maps embedded track identifiers to the files in a folder.
+type tagIndex struct {
+	byISRC     map[string]string
+	byPosition map[trackKey]string
+}
+
+// newTrackKey builds the position key for a track, normalizing the title.
+func newTrackKey(disc, track int, title string) trackKey {
+	if disc == 0 {
+		disc = 1
+	}
+	return trackKey{Disc: disc, Track: track, Title: strings.ToLower(strings.TrimSpace(title))}
+}
+
+// buildTagIndex reads the tags of every FLAC and MP3 file directly in dir.
+// Unreadable files are ignored.
+func buildTagIndex(dir string) *tagIndex {
+	idx := &tagIndex{
+		byISRC:     make(map[string]string),
+		byPosition: make(map[trackKey]string),
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return idx
+	}
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if entry.IsDir() || !isAudioFile(path) {
+			continue
+		}
+		tags, err := ReadTags(path)
+		if err != nil {
+			continue
+		}
+		if tags.ISRC != "" {
+			idx.byISRC[strings.ToUpper(tags.ISRC)] = path
+		}
+		if tags.TrackNumber > 0 && tags.Title != "" {
+			idx.byPosition[newTrackKey(tags.DiscNumber, tags.TrackNumber, tags.Title)] = path
+		}
+	}
+	return idx
+}
+
+// find returns the file holding track, matching by ISRC first and then by
+// disc number, track number and title.
+func (idx *tagIndex) find(track *api.TrackMetadata) (string, bool) {
+	if track.ISRC != "" {
+		if path, ok := idx.byISRC[strings.ToUpper(track.ISRC)]; ok {
+			return path, true
+		}
+	}
+	path, ok := idx.byPosition[newTrackKey(track.MediaNumber, track.TrackNumber, track.Title)]
+	return path, ok
+}
@@ -0,0 +1,101 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+func TestTagIndexFind(t *testing.T) {
+	dir := t.TempDir()
+	album := &api.AlbumMetadata{Title: "Album"}
+	album.Artist.Name = "Band"
+	existing := []struct {
+		name  string
+		track api.TrackMetadata
+	}{
+		{name: "renamed.flac", track: api.TrackMetadata{Title: "Intro", ISRC: "GBAYE0000001", TrackNumber: 1, MediaNumber: 1}},
+		{name: "my favourite.mp3", track: api.TrackMetadata{Title: "Ballad", TrackNumber: 2, MediaNumber: 1}},
+		{name: "disc two.flac", track: api.TrackMetadata{Title: "Intro", TrackNumber: 1, MediaNumber: 2}},
+	}
+	for _, f := range existing {
+		path := filepath.Join(dir, f.name)
+		data := buildTestFLAC(1, 64)
+		if filepath.Ext(path) == ".mp3" {
+			data = make([]byte, 128)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := NewTagger().WriteTags(path, &f.track, album, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("Intro"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		track api.TrackMetadata
+		want  string // File name found; "" = none
+	}{
+		{name: "ISRC match ignores title", track: api.TrackMetadata{Title: "Intro (Remastered)", ISRC: "gbaye0000001", TrackNumber: 9}, want: "renamed.flac"},
+		{name: "position and title in MP3", track: api.TrackMetadata{Title: " ballad ", TrackNumber: 2, MediaNumber: 1}, want: "my favourite.mp3"},
+		{name: "disc number tells tracks apart", track: api.TrackMetadata{Title: "Intro", TrackNumber: 1, MediaNumber: 2}, want: "disc two.flac"},
+		{name: "unknown ISRC falls back to position", track: api.TrackMetadata{Title: "Ballad", ISRC: "USXXX0000002", TrackNumber: 2}, want: "my favourite.mp3"},
+		{name: "different title", track: api.TrackMetadata{Title: "Outro", TrackNumber: 2, MediaNumber: 1}},
+		{name: "different position", track: api.TrackMetadata{Title: "Ballad", TrackNumber: 3, MediaNumber: 1}},
+	}
+	idx := buildTagIndex(dir)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, ok := idx.find(&tt.track)
+			if ok != (tt.want != "") || (ok && path != filepath.Join(dir, tt.want)) {
+				t.Errorf("find() = %q, %v; want %q", path, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrepareTrackTaskMatchByTags(t *testing.T) {
+	dir := t.TempDir()
+	renamed := filepath.Join(dir, "track one (old name).flac")
+	if err := os.WriteFile(renamed, buildTestFLAC(1, 64), 0644); err != nil {
+		t.Fatal(err)
+	}
+	track := api.TrackMetadata{Title: "One", ISRC: "GBAYE0000001", TrackNumber: 1, MediaNumber: 1}
+	album := &api.AlbumMetadata{Title: "Album"}
+	if err := NewTagger().WriteFlacTags(renamed, &track, album, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		matchByTags bool
+		wantSkip    bool
+	}{
+		{name: "renamed file found by tags", matchByTags: true, wantSkip: true},
+		{name: "file names only", matchByTags: false, wantSkip: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Engine{MatchByTags: tt.matchByTags}
+			task := trackTask{Track: track, FileName: "01. One"}
+			var index *tagIndex
+
+			path, skip := e.prepareTrackTask(dir, &task, 6, &index)
+			if skip != tt.wantSkip {
+				t.Fatalf("skip = %v, want %v", skip, tt.wantSkip)
+			}
+			if skip && path != renamed {
+				t.Errorf("skipped as %q, want %q", path, renamed)
+			}
+			if (index != nil) != tt.matchByTags {
+				t.Errorf("tag index built = %v, want %v", index != nil, tt.matchByTags)
+			}
+		})
+	}
+}
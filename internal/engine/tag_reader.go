@@ -0,0 +1,162 @@
+// tag_reader.go reads back the metadata embedded in downloaded files.
+// Only the fields written by the tagger are extracted.
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/bogem/id3v2/v2"
+	"github.com/go-flac/go-flac"
+)
+
+// FileTags holds the metadata read from an audio file.
+type FileTags struct {
+	Title       string
+	Artist      string
+	AlbumArtist string
+	Album       string
+	Genre       string
+	Date        string
+	TrackNumber int
+	DiscNumber  int
+	ISRC        string
+	AlbumID     string // Qobuz album ID (QOBUZ_ALBUM_ID)
+	UPC         string // Album barcode (BARCODE)
+	HasCover    bool   // A front cover picture is embedded
+}
+
+// ReadTags reads the embedded tags of a FLAC, Ogg or MP3 file.
+func ReadTags(path string) (*FileTags, error) {
+	return readTagsAs(path, filepath.Ext(path))
+}
+
+// readTagsAs reads the embedded tags of a file holding the given container,
+// which may differ from the file's extension (see Engine.ForceExtension).
+func readTagsAs(path, container string) (*FileTags, error) {
+	switch strings.ToLower(container) {
+	case ".flac":
+		return readFlacTags(path)
+	case ".mp3":
+		return readMp3Tags(path)
+	case ".opus", ".ogg":
+		return readOggTags(path)
+	default:
+		return nil, fmt.Errorf("unsupported file type: %s", container)
+	}
+}
+
+// readFlacTags extracts tags from a FLAC file's Vorbis Comments and PICTURE blocks.
+func readFlacTags(path string) (*FileTags, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	meta, err := flac.ParseMetadata(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse flac file: %w", err)
+	}
+	var cmts *VorbisComment
+	hasCover := false
+	for _, block := range meta.Meta {
+		switch block.Type {
+		case flac.VorbisComment:
+			if cmts, err = ParseVorbisComment(block.Data); err != nil {
+				return nil, err
+			}
+		case flac.Picture:
+			if pic, err := ParsePicture(block.Data); err == nil && pic.PictureType == PictureTypeCoverFront {
+				hasCover = true
+			}
+		}
+	}
+	tags := vorbisCommentTags(cmts)
+	tags.HasCover = hasCover
+	return tags, nil
+}
+
+// readOggTags extracts tags from an Ogg Opus or Ogg Vorbis file's comment header.
+func readOggTags(path string) (*FileTags, error) {
+	cmts, err := readOggVorbisComment(path)
+	if err != nil {
+		return nil, err
+	}
+	return vorbisCommentTags(cmts), nil
+}
+
+// vorbisCommentTags maps Vorbis Comments to FileTags. cmts may be nil.
+func vorbisCommentTags(cmts *VorbisComment) *FileTags {
+	tags := &FileTags{}
+	if cmts == nil {
+		return tags
+	}
+
+	tags.Title = cmts.Get("TITLE")
+	tags.Artist = strings.Join(cmts.GetAll("ARTIST"), ", ")
+	tags.AlbumArtist = cmts.Get("ALBUMARTIST")
+	tags.Album = cmts.Get("ALBUM")
+	tags.Genre = cmts.Get("GENRE")
+	tags.Date = cmts.Get("DATE")
+	tags.TrackNumber = parseTagNumber(cmts.Get("TRACKNUMBER"))
+	tags.DiscNumber = parseTagNumber(cmts.Get("DISCNUMBER"))
+	tags.ISRC = cmts.Get("ISRC")
+	tags.AlbumID = cmts.Get("QOBUZ_ALBUM_ID")
+	tags.UPC = cmts.Get("BARCODE")
+	for _, value := range cmts.GetAll(oggPictureKey) {
+		if pic, err := decodeOggPicture(value); err == nil && pic.PictureType == PictureTypeCoverFront {
+			tags.HasCover = true
+		}
+	}
+	return tags
+}
+
+// readMp3Tags extracts tags from an MP3 file's ID3v2 frames.
+func readMp3Tags(path string) (*FileTags, error) {
+	tag, err := id3v2.Open(path, id3v2.Options{Parse: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mp3 file: %w", err)
+	}
+	defer tag.Close()
+
+	tags := &FileTags{
+		Title:       tag.Title(),
+		Artist:      tag.Artist(),
+		AlbumArtist: tag.GetTextFrame("TPE2").Text,
+		Album:       tag.Album(),
+		Genre:       tag.Genre(),
+		Date:        tag.Year(),
+		TrackNumber: parseTagNumber(tag.GetTextFrame("TRCK").Text),
+		DiscNumber:  parseTagNumber(tag.GetTextFrame("TPOS").Text),
+		ISRC:        tag.GetTextFrame("TSRC").Text,
+	}
+	for _, f := range tag.GetFrames(tag.CommonID("Attached picture")) {
+		if pic, ok := f.(id3v2.PictureFrame); ok && pic.PictureType == id3v2.PTFrontCover {
+			tags.HasCover = true
+		}
+	}
+	for _, f := range tag.GetFrames(tag.CommonID("User defined text information frame")) {
+		udtf, ok := f.(id3v2.UserDefinedTextFrame)
+		if !ok {
+			continue
+		}
+		switch strings.ToUpper(udtf.Description) {
+		case "QOBUZ_ALBUM_ID":
+			tags.AlbumID = udtf.Value
+		case "BARCODE":
+			tags.UPC = udtf.Value
+		}
+	}
+	return tags, nil
+}
+
+// parseTagNumber parses track/disc numbers, accepting the "N/TOTAL" form.
+func parseTagNumber(s string) int {
+	s, _, _ = strings.Cut(strings.TrimSpace(s), "/")
+	n, _ := strconv.Atoi(s)
+	return n
+}
@@ -0,0 +1,78 @@
+// tag_verify.go reads tags back after writing them, catching tagger failures
+// that leave a file without its metadata but report no error.
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+// TagVerifyError lists the tags that did not read back as written.
+type TagVerifyError struct {
+	Path   string
+	Fields []string // Missing or mismatched tags (title, artist, album, cover)
+}
+
+// Error implements the error interface.
+func (e *TagVerifyError) Error() string {
+	return fmt.Sprintf("tag verification failed for %s: %s", e.Path, strings.Join(e.Fields, ", "))
+}
+
+// tagFile writes the tags of a downloaded file and, with VerifyTags, reads them
+// back to confirm they stuck.
+func (e *Engine) tagFile(path, container string, track *api.TrackMetadata, album *api.AlbumMetadata, coverData []byte, extra ...*Picture) error {
+	if err := e.Tagger.WriteTagsAs(path, container, track, album, coverData, extra...); err != nil {
+		return err
+	}
+	if !e.VerifyTags {
+		return nil
+	}
+	return verifyTags(path, container, track, album, len(coverData) > 0, e.Tagger.OnlyFillMissing)
+}
+
+// verifyTags checks the title, artist, album and front cover of a tagged file.
+// With presentOnly (OnlyFillMissing tagging) existing values may legitimately
+// differ from the metadata, so only their presence is checked.
+func verifyTags(path, container string, track *api.TrackMetadata, album *api.AlbumMetadata, wantCover, presentOnly bool) error {
+	tags, err := readTagsAs(path, container)
+	if err != nil {
+		return fmt.Errorf("tag verification failed: %w", err)
+	}
+
+	var fields []string
+	check := func(name, got, want string) {
+		if want == "" {
+			return
+		}
+		if got == "" || (!presentOnly && got != want) {
+			fields = append(fields, name)
+		}
+	}
+	check("title", tags.Title, track.Title)
+	check("album", tags.Album, album.Title)
+	// Artists may be split and re-joined, so only the first one is looked for
+	if track.Performer.Name != "" && (tags.Artist == "" || (!presentOnly && !sharesFirstArtist(track.Performer.Name, tags.Artist))) {
+		fields = append(fields, "artist")
+	}
+	if wantCover && !tags.HasCover {
+		fields = append(fields, "cover")
+	}
+
+	if len(fields) > 0 {
+		return &TagVerifyError{Path: path, Fields: fields}
+	}
+	return nil
+}
+
+// sharesFirstArtist reports whether the artist tag contains the first artist
+// of the performer string, ignoring case. The performer is split as finely as
+// possible so the check holds whichever splitting the tagger used.
+func sharesFirstArtist(performer, artistTag string) bool {
+	first := performer
+	if artists := splitArtists(performer, true); len(artists) > 0 {
+		first = artists[0]
+	}
+	return strings.Contains(strings.ToLower(artistTag), strings.ToLower(first))
+}
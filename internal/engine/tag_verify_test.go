@@ -0,0 +1,179 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+
+	"github.com/bogem/id3v2/v2"
+	"github.com/go-flac/go-flac"
+)
+
+// editFlac applies edit to the metadata blocks of a FLAC file and saves it.
+func editFlac(t *testing.T, path string, edit func(blocks []*flac.MetaDataBlock) []*flac.MetaDataBlock) {
+	t.Helper()
+	f, err := flac.ParseFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Meta = edit(f.Meta)
+	if err := f.Save(path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// dropFlacBlocks removes every metadata block of the given type.
+func dropFlacBlocks(blockType flac.BlockType) func([]*flac.MetaDataBlock) []*flac.MetaDataBlock {
+	return func(blocks []*flac.MetaDataBlock) []*flac.MetaDataBlock {
+		return slices.DeleteFunc(blocks, func(b *flac.MetaDataBlock) bool { return b.Type == blockType })
+	}
+}
+
+// setFlacComment replaces a Vorbis comment of a FLAC file.
+func setFlacComment(t *testing.T, key, value string) func([]*flac.MetaDataBlock) []*flac.MetaDataBlock {
+	return func(blocks []*flac.MetaDataBlock) []*flac.MetaDataBlock {
+		for _, b := range blocks {
+			if b.Type != flac.VorbisComment {
+				continue
+			}
+			cmts, err := ParseVorbisComment(b.Data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			update := NewVorbisComment()
+			update.Add(key, value)
+			cmts.Merge(update)
+			b.Data = cmts.Marshal()
+		}
+		return blocks
+	}
+}
+
+func TestVerifyTags(t *testing.T) {
+	album := &api.AlbumMetadata{Title: "Album"}
+	album.Artist.Name = "Band"
+	track := &api.TrackMetadata{Title: "Song", TrackNumber: 1, MediaNumber: 1, Album: album}
+	track.Performer.Name = "Band feat. Guest"
+	cover := testJPEG(t, 8, 8)
+
+	tests := []struct {
+		name        string
+		file        string
+		data        []byte
+		sabotage    func(t *testing.T, path string) // Undoes part of the tag write
+		presentOnly bool
+		wantFields  []string // nil for no verification error
+		wantReadErr bool
+	}{
+		{name: "flac intact", file: "track.flac", data: buildTestFLAC(2, 64)},
+		{name: "mp3 intact", file: "track.mp3", data: testMP3},
+		{name: "opus intact", file: "track.opus", data: buildTestOgg(true, []byte("audio"))},
+		{
+			name: "flac comments lost", file: "track.flac", data: buildTestFLAC(2, 64),
+			sabotage:   func(t *testing.T, path string) { editFlac(t, path, dropFlacBlocks(flac.VorbisComment)) },
+			wantFields: []string{"title", "album", "artist"},
+		},
+		{
+			name: "flac cover lost", file: "track.flac", data: buildTestFLAC(2, 64),
+			sabotage:   func(t *testing.T, path string) { editFlac(t, path, dropFlacBlocks(flac.Picture)) },
+			wantFields: []string{"cover"},
+		},
+		{
+			name: "flac title overwritten", file: "track.flac", data: buildTestFLAC(2, 64),
+			sabotage:   func(t *testing.T, path string) { editFlac(t, path, setFlacComment(t, "TITLE", "Other")) },
+			wantFields: []string{"title"},
+		},
+		{
+			name: "other title kept when filling missing tags", file: "track.flac", data: buildTestFLAC(2, 64),
+			sabotage:    func(t *testing.T, path string) { editFlac(t, path, setFlacComment(t, "TITLE", "Other")) },
+			presentOnly: true,
+		},
+		{
+			name: "mp3 artist overwritten", file: "track.mp3", data: testMP3,
+			sabotage: func(t *testing.T, path string) {
+				tag, err := id3v2.Open(path, id3v2.Options{Parse: true})
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer tag.Close()
+				tag.SetArtist("Someone Else")
+				if err := tag.Save(); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantFields: []string{"artist"},
+		},
+		{
+			name: "flac truncated", file: "track.flac", data: buildTestFLAC(2, 64),
+			sabotage:    func(t *testing.T, path string) { os.Truncate(path, 10) },
+			wantReadErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, tt.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := NewTagger().WriteTags(path, track, album, cover); err != nil {
+				t.Fatalf("WriteTags: %v", err)
+			}
+			if tt.sabotage != nil {
+				tt.sabotage(t, path)
+			}
+
+			err := verifyTags(path, filepath.Ext(path), track, album, true, tt.presentOnly)
+			var verifyErr *TagVerifyError
+			switch {
+			case tt.wantReadErr:
+				if err == nil || errors.As(err, &verifyErr) {
+					t.Errorf("verifyTags() = %v, want a read error", err)
+				}
+			case tt.wantFields == nil:
+				if err != nil {
+					t.Errorf("verifyTags() = %v, want nil", err)
+				}
+			case !errors.As(err, &verifyErr):
+				t.Errorf("verifyTags() = %v, want a *TagVerifyError", err)
+			case !slices.Equal(verifyErr.Fields, tt.wantFields) || verifyErr.Path != path:
+				t.Errorf("verification failed on %v of %s, want %v", verifyErr.Fields, verifyErr.Path, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestDownloadAlbumVerifyTags(t *testing.T) {
+	tests := []struct {
+		name       string
+		verify     bool
+		emptyCover bool // The cover download yields no image, so none is expected
+	}{
+		{name: "verified", verify: true},
+		{name: "verified without cover", verify: true, emptyCover: true},
+		{name: "not verified"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeQobuz(t)
+			fake.addAlbum("alb1", "Album", "Band", 100, 2)
+			if tt.emptyCover {
+				fake.cover = nil
+			}
+			e := fake.engine()
+			e.VerifyTags = tt.verify
+
+			result, err := e.DownloadAlbum(context.Background(), "alb1", 6, t.TempDir())
+			if err != nil || len(result.Success) != 2 {
+				t.Fatalf("DownloadAlbum() = %v, %v", result, err)
+			}
+			if failures := result.TagFailures(); len(failures) != 0 {
+				t.Errorf("tag failures on a correctly tagged album: %v", failures)
+			}
+		})
+	}
+}
@@ -1,47 +1,179 @@
 // tagger.go provides audio metadata tagging functionality.
-// It handles FLAC (Vorbis Comments) and MP3 (ID3v2) formats.
+// It handles FLAC and Ogg (Vorbis Comments) and MP3 (ID3v2) formats.
 package engine
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/version"
 
 	"github.com/go-flac/go-flac"
 )
 
 // Tagger handles metadata embedding for audio files.
-type Tagger struct{}
+type Tagger struct {
+	SplitArtists bool   // Split "A feat. B" and "A (feat. B)" performer names into separate artist values
+	EmbedLyrics  bool   // Embed synchronized lyrics from a user-supplied .lrc sidecar (Qobuz has none)
+	DateSource   string // Which release date fills DATE: DateSourceOriginal or DateSourceStream
+	WriteR128    bool   // Write R128 gain tags from Qobuz loudness metadata
+	WriteSource  bool   // Write provenance tags (SOURCE, ENCODEDBY, ...) naming Qobuz and this tool
+	WriteTotals  bool   // Write track/disc totals and the album duration (TRACKTOTAL, DISCTOTAL, ALBUMDURATION)
+	WriteWork    bool   // Write classical work and movement tags (WORK, MOVEMENT, MOVEMENTNUMBER, MOVEMENTTOTAL)
+
+	SplitArtistLists bool // With SplitArtists, also split on "&" and ", " (breaks names like "Simon & Garfunkel")
+	SplitWorkTitles  bool // Derive the work from "Work: I. Movement" titles when Qobuz has no work field
+	OnlyFillMissing  bool // Only write tags and pictures the file does not have yet, keeping curated values
+	PaddingBytes     int  // FLAC padding reserved after the metadata for fast future retags (0 = none)
+	SafeWrite        bool // Never leave a half-written file: rewrite via temp file, back up in-place edits to .bak
+}
+
+// DefaultPaddingBytes is the FLAC padding reserved by default, as the reference encoder does.
+const DefaultPaddingBytes = 8192
+
+// maxFlacBlockSize is the largest FLAC metadata block body (24-bit length).
+const maxFlacBlockSize = 1<<24 - 1
+
+// sourceName is the value of the SOURCE provenance tag.
+const sourceName = "Qobuz"
+
+// encodedBy returns the tool name and version written to ENCODEDBY/ENCODER/TSSE.
+func encodedBy() string {
+	return "qobuz-dl-go " + version.Short()
+}
+
+// Release date sources for the DATE tag.
+// The original release date is always written separately as ORIGINALDATE.
+const (
+	DateSourceOriginal = "original" // Original release date (default)
+	DateSourceStream   = "stream"   // Date this edition became available on Qobuz
+)
 
 // NewTagger creates a new Tagger instance.
 func NewTagger() *Tagger {
-	return &Tagger{}
+	return &Tagger{
+		SplitArtists: true,
+		EmbedLyrics:  true,
+		DateSource:   DateSourceOriginal,
+		WriteR128:    true,
+		WriteSource:  true,
+		WriteTotals:  true,
+		WriteWork:    true,
+
+		SplitWorkTitles: true,
+		PaddingBytes:    DefaultPaddingBytes,
+		SafeWrite:       true,
+	}
+}
+
+// featuringRegex matches the markers Qobuz puts before featured performers:
+// "feat.", "ft." and "featuring".
+var featuringRegex = regexp.MustCompile(`(?i)\s+(?:feat\.?|ft\.|featuring)\s+`)
+
+// bracketedFeaturingRegex matches a featured credit in brackets, as in "A (feat. B)".
+var bracketedFeaturingRegex = regexp.MustCompile(`(?i)\s*[(\[]\s*(?:feat\.?|ft\.?|featuring)\s+([^)\]]*)[)\]]`)
+
+// artistListRegex matches the list separators "&" and ", ". They also occur in
+// single artist names, so splitting on them is opt-in.
+var artistListRegex = regexp.MustCompile(`\s+&\s+|,\s+`)
+
+// splitArtists splits a combined performer string into individual artist names
+// at featuring markers, and with lists also at "&" and ", ".
+// Empty parts are dropped and duplicates are removed, preserving order.
+func splitArtists(name string, lists bool) []string {
+	name = bracketedFeaturingRegex.ReplaceAllString(name, " feat. $1")
+
+	var artists []string
+	seen := make(map[string]bool)
+	for _, credit := range featuringRegex.Split(name, -1) {
+		parts := []string{credit}
+		if lists {
+			parts = artistListRegex.Split(credit, -1)
+		}
+		for _, part := range parts {
+			part = strings.TrimSpace(part)
+			if part == "" || seen[strings.ToLower(part)] {
+				continue
+			}
+			seen[strings.ToLower(part)] = true
+			artists = append(artists, part)
+		}
+	}
+	return artists
+}
+
+// releaseDates returns the value for DATE and the original release date.
+// DATE follows DateSource and falls back to the other date when missing.
+func (t *Tagger) releaseDates(album *api.AlbumMetadata) (date, original string) {
+	original = album.ReleaseDateOrg
+	if t.DateSource == DateSourceStream {
+		date = album.ReleaseDateStream
+		if date == "" {
+			date = album.ReleaseDateOrg
+		}
+	} else {
+		date = album.ReleaseDateOrg
+		if date == "" {
+			date = album.ReleaseDateStream
+		}
+	}
+	return date, original
+}
+
+// trackArtists returns the artist values to write for a track.
+// When splitting is disabled, the performer name is returned as a single value.
+func (t *Tagger) trackArtists(track *api.TrackMetadata) []string {
+	if !t.SplitArtists {
+		if track.Performer.Name == "" {
+			return nil
+		}
+		return []string{track.Performer.Name}
+	}
+	return splitArtists(track.Performer.Name, t.SplitArtistLists)
 }
 
 // WriteTags writes metadata tags and optional cover art to an audio file.
-// It automatically detects the file format based on extension and uses
-// the appropriate tagging method (Vorbis Comments for FLAC, ID3v2 for MP3).
-func (t *Tagger) WriteTags(filePath string, track *api.TrackMetadata, album *api.AlbumMetadata, coverData []byte) error {
-	lowerPath := strings.ToLower(filePath)
-
-	switch {
-	case strings.HasSuffix(lowerPath, ".mp3"):
-		return t.WriteMp3Tags(filePath, track, album, coverData)
-	case strings.HasSuffix(lowerPath, ".flac"):
-		return t.WriteFlacTags(filePath, track, album, coverData)
+// It detects the file format from the file's magic bytes, falling back to the
+// extension, and uses the appropriate tagging method (Vorbis Comments for FLAC
+// and Ogg, ID3v2 for MP3).
+func (t *Tagger) WriteTags(filePath string, track *api.TrackMetadata, album *api.AlbumMetadata, coverData []byte, extra ...*Picture) error {
+	container := filepath.Ext(filePath)
+	if format, err := DetectFormat(filePath); err == nil && format != FormatUnknown {
+		container = string(format)
+	}
+	return t.WriteTagsAs(filePath, container, track, album, coverData, extra...)
+}
+
+// WriteTagsAs writes tags using the tagging method for container (".mp3", ".flac",
+// ".opus" or ".ogg") regardless of the file's own extension, for files saved under a
+// forced extension. Unknown containers are tagged as FLAC.
+// extra pictures (back cover, booklet pages) are embedded after the front cover.
+func (t *Tagger) WriteTagsAs(filePath, container string, track *api.TrackMetadata, album *api.AlbumMetadata, coverData []byte, extra ...*Picture) error {
+	switch strings.ToLower(container) {
+	case ".mp3":
+		return t.WriteMp3Tags(filePath, track, album, coverData, extra...)
+	case ".flac":
+		return t.WriteFlacTags(filePath, track, album, coverData, extra...)
+	case ".opus", ".ogg":
+		return t.WriteOggTags(filePath, track, album, coverData, extra...)
 	default:
 		// Try FLAC as default
-		return t.WriteFlacTags(filePath, track, album, coverData)
+		return t.WriteFlacTags(filePath, track, album, coverData, extra...)
 	}
 }
 
 // WriteFlacTags writes Vorbis Comments and Picture block to a FLAC file.
-func (t *Tagger) WriteFlacTags(filePath string, track *api.TrackMetadata, album *api.AlbumMetadata, coverData []byte) error {
+func (t *Tagger) WriteFlacTags(filePath string, track *api.TrackMetadata, album *api.AlbumMetadata, coverData []byte, extra ...*Picture) error {
 	f, err := flac.ParseFile(filePath)
 	if err != nil {
 		return fmt.Errorf("failed to parse flac file: %w", err)
 	}
+	oldSize := flacMetadataSize(f.Meta)
 
 	// 1. Vorbis Comments (Text Tags)
 	var cmts *VorbisComment
@@ -67,23 +199,7 @@ func (t *Tagger) WriteFlacTags(filePath string, track *api.TrackMetadata, album
 		cmts = NewVorbisComment()
 	}
 
-	// Add Tags
-	addTag(cmts, "TITLE", track.Title)
-	addTag(cmts, "VERSION", track.Version)
-	addTag(cmts, "ARTIST", track.Performer.Name)
-	addTag(cmts, "ALBUM", album.Title)
-	addTag(cmts, "ALBUMARTIST", album.Artist.Name)
-	addTag(cmts, "TRACKNUMBER", fmt.Sprintf("%d", track.TrackNumber))
-	addTag(cmts, "DISCNUMBER", fmt.Sprintf("%d", track.MediaNumber))
-
-	if album.Genre != nil {
-		addTag(cmts, "GENRE", album.Genre.Name)
-	}
-	if album.ReleaseDateOrg != "" {
-		addTag(cmts, "DATE", album.ReleaseDateOrg)
-	} else if album.ReleaseDateStream != "" {
-		addTag(cmts, "DATE", album.ReleaseDateStream)
-	}
+	t.mergeComments(cmts, t.vorbisCommentUpdates(filePath, "FLAC", track, album))
 
 	// Re-serialize comments block
 	resCmts := cmts.Marshal()
@@ -99,12 +215,43 @@ func (t *Tagger) WriteFlacTags(filePath string, track *api.TrackMetadata, album
 	}
 
 	// 2. Cover Art (Picture Block)
+	if t.OnlyFillMissing {
+		present := make(map[uint32]bool)
+		for _, block := range f.Meta {
+			if block.Type == flac.Picture {
+				if pic, err := ParsePicture(block.Data); err == nil {
+					present[pic.PictureType] = true
+				}
+			}
+		}
+		coverData, extra = missingPictures(present, coverData, extra)
+	}
+
+	// Drop existing pictures of the types being written so re-tagging doesn't stack copies
+	replaced := make(map[uint32]bool)
+	if len(coverData) > 0 {
+		replaced[PictureTypeCoverFront] = true
+	}
+	for _, pic := range extra {
+		replaced[pic.PictureType] = true
+	}
+	if len(replaced) > 0 {
+		f.Meta = slices.DeleteFunc(f.Meta, func(block *flac.MetaDataBlock) bool {
+			if block.Type != flac.Picture {
+				return false
+			}
+			pic, err := ParsePicture(block.Data)
+			return err == nil && replaced[pic.PictureType]
+		})
+	}
+
 	if len(coverData) > 0 {
 		pic := NewPicture()
 		pic.MIME = "image/jpeg"
 		pic.Description = "Cover"
 		pic.PictureType = PictureTypeCoverFront
 		pic.ImageData = coverData
+		pic.detectDimensions()
 
 		picBlock := pic.Marshal()
 
@@ -114,8 +261,16 @@ func (t *Tagger) WriteFlacTags(filePath string, track *api.TrackMetadata, album
 		})
 	}
 
+	// Additional pictures, each in its own Picture block
+	for _, pic := range extra {
+		f.Meta = append(f.Meta, &flac.MetaDataBlock{
+			Type: flac.Picture,
+			Data: pic.Marshal(),
+		})
+	}
+
 	// 3. Save
-	err = f.Save(filePath)
+	err = t.saveFlac(filePath, f, oldSize)
 	if err != nil {
 		return fmt.Errorf("failed to save tags: %w", err)
 	}
@@ -123,6 +278,161 @@ func (t *Tagger) WriteFlacTags(filePath string, track *api.TrackMetadata, album
 	return nil
 }
 
+// vorbisCommentUpdates builds the Vorbis Comments written to FLAC and Ogg files.
+// They are collected separately and merged so re-tagging replaces old values
+// instead of duplicating them. encoding is the ENCODING provenance value.
+func (t *Tagger) vorbisCommentUpdates(filePath, encoding string, track *api.TrackMetadata, album *api.AlbumMetadata) *VorbisComment {
+	updates := &VorbisComment{}
+	addTag(updates, "TITLE", track.Title)
+	addTag(updates, "VERSION", track.Version)
+	// Multiple ARTIST values plus an ARTISTS list (Picard convention)
+	artists := t.trackArtists(track)
+	for _, artist := range artists {
+		addTag(updates, "ARTIST", artist)
+	}
+	if len(artists) > 1 {
+		for _, artist := range artists {
+			addTag(updates, "ARTISTS", artist)
+		}
+	}
+	addTag(updates, "ALBUM", album.Title)
+	addTag(updates, "ALBUMARTIST", album.Artist.Name)
+	addTag(updates, "TRACKNUMBER", fmt.Sprintf("%d", track.TrackNumber))
+	addTag(updates, "DISCNUMBER", fmt.Sprintf("%d", track.MediaNumber))
+	if t.WriteTotals {
+		totals := albumTotalsFor(track, album)
+		addTag(updates, "TRACKTOTAL", totals.trackTotal())
+		addTag(updates, "TOTALTRACKS", totals.trackTotal())
+		addTag(updates, "DISCTOTAL", totals.discTotal())
+		addTag(updates, "TOTALDISCS", totals.discTotal())
+		addTag(updates, "ALBUMDURATION", totals.duration())
+	}
+	if t.WriteWork {
+		if work, ok := t.workFor(track, album); ok {
+			addTag(updates, "WORK", work.Work)
+			addTag(updates, "MOVEMENT", work.Movement)
+			addTag(updates, "MOVEMENTNUMBER", work.movementNumber())
+			addTag(updates, "MOVEMENTTOTAL", work.movementTotal())
+		}
+	}
+	addTag(updates, "ISRC", track.ISRC)
+	addTag(updates, "BARCODE", album.UPC)
+	addTag(updates, "QOBUZ_ALBUM_ID", album.ID)
+
+	if album.Genre != nil {
+		addTag(updates, "GENRE", album.Genre.Name)
+	}
+	date, originalDate := t.releaseDates(album)
+	addTag(updates, "DATE", date)
+	addTag(updates, "ORIGINALDATE", originalDate)
+
+	// Loudness normalization (no audio analysis needed)
+	if t.WriteR128 {
+		trackGain, albumGain := r128Tags(track)
+		addTag(updates, "R128_TRACK_GAIN", trackGain)
+		addTag(updates, "R128_ALBUM_GAIN", albumGain)
+	}
+
+	// Provenance
+	if t.WriteSource {
+		addTag(updates, "SOURCE", sourceName)
+		addTag(updates, "ENCODEDBY", encodedBy())
+		addTag(updates, "ENCODER", encodedBy())
+		addTag(updates, "ENCODING", encoding)
+	}
+
+	// Synchronized lyrics (LRC text, understood by most players)
+	if t.EmbedLyrics {
+		if lrc := readLRCSidecar(filePath); lrc != "" {
+			if _, err := ParseLRC(lrc); err == nil {
+				addTag(updates, "LYRICS", strings.TrimSpace(lrc))
+			}
+		}
+	}
+
+	return updates
+}
+
+// flacMetadataSize returns the size of the "fLaC" marker and metadata blocks.
+func flacMetadataSize(blocks []*flac.MetaDataBlock) int {
+	size := 4
+	for _, block := range blocks {
+		size += 4 + len(block.Data)
+	}
+	return size
+}
+
+// saveFlac writes f to filePath with PaddingBytes of padding after the metadata.
+// When the new metadata fits in the space of the old one (padding included), only
+// the metadata is rewritten and the remaining space becomes the padding, so the
+// audio frames are not rewritten. With SafeWrite, full rewrites go through a temp
+// file and in-place rewrites keep a .bak copy until they succeed.
+func (t *Tagger) saveFlac(filePath string, f *flac.File, oldSize int) error {
+	f.Meta = slices.DeleteFunc(f.Meta, func(block *flac.MetaDataBlock) bool {
+		return block.Type == flac.Padding
+	})
+
+	// Each padding block needs a 4 byte header
+	if room := oldSize - flacMetadataSize(f.Meta) - 4; t.PaddingBytes > 0 && room >= 0 && room <= maxFlacBlockSize {
+		f.Meta = append(f.Meta, &flac.MetaDataBlock{Type: flac.Padding, Data: make([]byte, room)})
+		if t.SafeWrite {
+			return withBackup(filePath, func() error { return writeFlacMetadata(filePath, f.Meta) })
+		}
+		return writeFlacMetadata(filePath, f.Meta)
+	}
+
+	if t.PaddingBytes > 0 {
+		f.Meta = append(f.Meta, &flac.MetaDataBlock{Type: flac.Padding, Data: make([]byte, min(t.PaddingBytes, maxFlacBlockSize))})
+	}
+	if t.SafeWrite {
+		return writeFileAtomic(filePath, f.Marshal())
+	}
+	return f.Save(filePath)
+}
+
+// writeFlacMetadata overwrites the metadata at the start of filePath in place.
+// blocks must serialize to exactly the size of the metadata already in the file.
+func writeFlacMetadata(filePath string, blocks []*flac.MetaDataBlock) error {
+	buf := []byte("fLaC")
+	for i, block := range blocks {
+		buf = append(buf, block.Marshal(i == len(blocks)-1)...)
+	}
+
+	file, err := os.OpenFile(filePath, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteAt(buf, 0); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// mergeComments applies updates to cmts, replacing existing values unless
+// OnlyFillMissing is set, in which case only absent or blank keys are written.
+func (t *Tagger) mergeComments(cmts, updates *VorbisComment) {
+	if t.OnlyFillMissing {
+		cmts.FillMissing(updates)
+		return
+	}
+	cmts.Merge(updates)
+}
+
+// missingPictures drops the cover and extra pictures whose picture type is already present.
+func missingPictures(present map[uint32]bool, coverData []byte, extra []*Picture) ([]byte, []*Picture) {
+	if present[PictureTypeCoverFront] {
+		coverData = nil
+	}
+	var missing []*Picture
+	for _, pic := range extra {
+		if !present[pic.PictureType] {
+			missing = append(missing, pic)
+		}
+	}
+	return coverData, missing
+}
+
 func addTag(cmts *VorbisComment, key, value string) {
 	if value == "" {
 		return
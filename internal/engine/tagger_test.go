@@ -0,0 +1,402 @@
+package engine
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+
+	"github.com/bogem/id3v2/v2"
+	"github.com/go-flac/go-flac"
+)
+
+func TestSplitArtists(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    string
+		lists bool
+		want  []string
+	}{
+		{name: "single artist", in: "Adele", want: []string{"Adele"}},
+		{name: "feat.", in: "Calvin Harris feat. Rihanna", want: []string{"Calvin Harris", "Rihanna"}},
+		{name: "ft. and featuring", in: "A ft. B featuring C", want: []string{"A", "B", "C"}},
+		{name: "feat without dot", in: "A Feat B", want: []string{"A", "B"}},
+		{name: "parenthesized feat.", in: "Mark Ronson (feat. Bruno Mars)", want: []string{"Mark Ronson", "Bruno Mars"}},
+		{name: "bracketed ft", in: "A [ft B]", want: []string{"A", "B"}},
+		{name: "ampersand kept by default", in: "Simon & Garfunkel", want: []string{"Simon & Garfunkel"}},
+		{name: "comma kept by default", in: "Earth, Wind & Fire", want: []string{"Earth, Wind & Fire"}},
+		{name: "band with featured guest", in: "Earth, Wind & Fire feat. The Emotions", want: []string{"Earth, Wind & Fire", "The Emotions"}},
+		{name: "ampersand with lists", in: "Simon & Garfunkel", lists: true, want: []string{"Simon", "Garfunkel"}},
+		{name: "comma with lists", in: "A, B & C (feat. D)", lists: true, want: []string{"A", "B", "C", "D"}},
+		{name: "duplicates removed", in: "A feat. a", want: []string{"A"}},
+		{name: "empty", in: "", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitArtists(tt.in, tt.lists); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitArtists(%q, %v) = %q, want %q", tt.in, tt.lists, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSharesFirstArtist(t *testing.T) {
+	tests := []struct {
+		performer string
+		tag       string
+		want      bool
+	}{
+		{performer: "Simon & Garfunkel", tag: "Simon & Garfunkel", want: true},
+		{performer: "Simon & Garfunkel", tag: "Simon/Garfunkel", want: true},
+		{performer: "A (feat. B)", tag: "a", want: true},
+		{performer: "A feat. B", tag: "B", want: false},
+	}
+	for _, tt := range tests {
+		if got := sharesFirstArtist(tt.performer, tt.tag); got != tt.want {
+			t.Errorf("sharesFirstArtist(%q, %q) = %v, want %v", tt.performer, tt.tag, got, tt.want)
+		}
+	}
+}
+
+func TestReleaseDateTags(t *testing.T) {
+	tests := []struct {
+		name         string
+		source       string
+		original     string
+		stream       string
+		wantDate     string
+		wantOriginal string
+	}{
+		{name: "original source writes both dates", source: DateSourceOriginal, original: "1977-02-04", stream: "2013-01-01", wantDate: "1977-02-04", wantOriginal: "1977-02-04"},
+		{name: "stream source keeps the original separately", source: DateSourceStream, original: "1977-02-04", stream: "2013-01-01", wantDate: "2013-01-01", wantOriginal: "1977-02-04"},
+		{name: "stream source falls back to original", source: DateSourceStream, original: "1977-02-04", wantDate: "1977-02-04", wantOriginal: "1977-02-04"},
+		{name: "original source falls back to stream", source: DateSourceOriginal, stream: "2013-01-01", wantDate: "2013-01-01"},
+		{name: "no dates", source: DateSourceOriginal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tagger := NewTagger()
+			tagger.DateSource = tt.source
+			album := &api.AlbumMetadata{ReleaseDateOrg: tt.original, ReleaseDateStream: tt.stream}
+
+			cmts := tagger.vorbisCommentUpdates("", "FLAC", &api.TrackMetadata{Title: "T"}, album)
+			if got := cmts.Get("DATE"); got != tt.wantDate {
+				t.Errorf("DATE = %q, want %q", got, tt.wantDate)
+			}
+			if got := cmts.Get("ORIGINALDATE"); got != tt.wantOriginal {
+				t.Errorf("ORIGINALDATE = %q, want %q", got, tt.wantOriginal)
+			}
+		})
+	}
+}
+
+// readSourceTags returns the provenance tags of a tagged FLAC or MP3 file,
+// keyed by their Vorbis comment names.
+func readSourceTags(t *testing.T, path string) map[string]string {
+	t.Helper()
+	tags := make(map[string]string)
+	if filepath.Ext(path) == ".mp3" {
+		tag, err := id3v2.Open(path, id3v2.Options{Parse: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tag.Close()
+		if v := tag.GetTextFrame("TSSE").Text; v != "" {
+			tags["ENCODER"] = v
+		}
+		if v := tag.GetTextFrame("TENC").Text; v != "" {
+			tags["ENCODEDBY"] = v
+		}
+		for _, f := range tag.GetFrames("TXXX") {
+			if udf, ok := f.(id3v2.UserDefinedTextFrame); ok && udf.Description == "SOURCE" {
+				tags["SOURCE"] = udf.Value
+			}
+		}
+		return tags
+	}
+
+	f, err := flac.ParseFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, block := range f.Meta {
+		if block.Type != flac.VorbisComment {
+			continue
+		}
+		cmts, err := ParseVorbisComment(block.Data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, key := range []string{"SOURCE", "ENCODER", "ENCODEDBY", "ENCODING"} {
+			if v := cmts.Get(key); v != "" {
+				tags[key] = v
+			}
+		}
+	}
+	return tags
+}
+
+func TestSourceTags(t *testing.T) {
+	tests := []struct {
+		name        string
+		file        string
+		writeSource bool
+		want        map[string]string
+	}{
+		{
+			name: "FLAC", file: "track.flac", writeSource: true,
+			want: map[string]string{"SOURCE": sourceName, "ENCODER": encodedBy(), "ENCODEDBY": encodedBy(), "ENCODING": "FLAC"},
+		},
+		{
+			name: "MP3", file: "track.mp3", writeSource: true,
+			want: map[string]string{"SOURCE": sourceName, "ENCODER": encodedBy(), "ENCODEDBY": encodedBy()},
+		},
+		{name: "FLAC disabled", file: "track.flac", want: map[string]string{}},
+		{name: "MP3 disabled", file: "track.mp3", want: map[string]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			data := buildTestFLAC(1, 64)
+			if filepath.Ext(path) == ".mp3" {
+				data = make([]byte, 128)
+			}
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			tagger := NewTagger()
+			tagger.WriteSource = tt.writeSource
+			album := &api.AlbumMetadata{Title: "Album"}
+			if err := tagger.WriteTags(path, &api.TrackMetadata{Title: "Track", Album: album}, album, nil); err != nil {
+				t.Fatalf("WriteTags: %v", err)
+			}
+			if got := readSourceTags(t, path); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("provenance tags = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVorbisCommentFillMissing(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []string
+		updates  []string
+		want     []string
+	}{
+		{name: "absent key filled", existing: []string{"TITLE=Curated"}, updates: []string{"ALBUM=Qobuz"}, want: []string{"TITLE=Curated", "ALBUM=Qobuz"}},
+		{name: "existing key kept", existing: []string{"TITLE=Curated"}, updates: []string{"TITLE=Qobuz"}, want: []string{"TITLE=Curated"}},
+		{name: "keys compared case-insensitively", existing: []string{"title=Curated"}, updates: []string{"TITLE=Qobuz"}, want: []string{"title=Curated"}},
+		{name: "blank value replaced", existing: []string{"GENRE= ", "TITLE=Curated"}, updates: []string{"GENRE=Jazz"}, want: []string{"TITLE=Curated", "GENRE=Jazz"}},
+		{name: "every value of a missing key", existing: nil, updates: []string{"ARTIST=A", "ARTIST=B"}, want: []string{"ARTIST=A", "ARTIST=B"}},
+		{name: "multi-valued key kept whole", existing: []string{"ARTIST=Curated"}, updates: []string{"ARTIST=A", "ARTIST=B"}, want: []string{"ARTIST=Curated"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmts := &VorbisComment{Comments: slices.Clone(tt.existing)}
+			cmts.FillMissing(&VorbisComment{Comments: tt.updates})
+			if !slices.Equal(cmts.Comments, tt.want) {
+				t.Errorf("comments = %q, want %q", cmts.Comments, tt.want)
+			}
+		})
+	}
+}
+
+// frontCover returns the image data of the front cover embedded in a FLAC,
+// Ogg or MP3 file, or nil.
+func frontCover(t *testing.T, path string) []byte {
+	t.Helper()
+	switch filepath.Ext(path) {
+	case ".mp3":
+		tag, err := id3v2.Open(path, id3v2.Options{Parse: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tag.Close()
+		for _, f := range tag.GetFrames(tag.CommonID("Attached picture")) {
+			if pic, ok := f.(id3v2.PictureFrame); ok && pic.PictureType == id3v2.PTFrontCover {
+				return pic.Picture
+			}
+		}
+	case ".opus":
+		cmts, err := readOggVorbisComment(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range cmts.GetAll(oggPictureKey) {
+			if pic, err := decodeOggPicture(v); err == nil && pic.PictureType == PictureTypeCoverFront {
+				return pic.ImageData
+			}
+		}
+	default:
+		for _, pic := range flacPictures(t, path) {
+			if pic.PictureType == PictureTypeCoverFront {
+				return pic.ImageData
+			}
+		}
+	}
+	return nil
+}
+
+func TestWriteTagsOnlyFillMissing(t *testing.T) {
+	curatedCover := testJPEG(t, 8, 8)
+	qobuzCover := testJPEG(t, 16, 16)
+
+	tests := []struct {
+		name string
+		file string
+		data []byte
+	}{
+		{name: "FLAC", file: "track.flac", data: buildTestFLAC(1, 64)},
+		{name: "MP3", file: "track.mp3", data: make([]byte, 128)},
+		{name: "Opus", file: "track.opus", data: buildTestOgg(true, []byte("audio"))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, tt.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			// The user's curated tags, without an album artist
+			curated := &api.AlbumMetadata{Title: "Curated Album"}
+			if err := NewTagger().WriteTags(path, &api.TrackMetadata{Title: "Curated Title", TrackNumber: 1}, curated, curatedCover); err != nil {
+				t.Fatalf("WriteTags: %v", err)
+			}
+
+			album := &api.AlbumMetadata{Title: "Qobuz Album"}
+			album.Artist.Name = "Qobuz Band"
+			tagger := NewTagger()
+			tagger.OnlyFillMissing = true
+			if err := tagger.WriteTags(path, &api.TrackMetadata{Title: "Qobuz Title", TrackNumber: 1}, album, qobuzCover); err != nil {
+				t.Fatalf("WriteTags with OnlyFillMissing: %v", err)
+			}
+
+			tags, err := ReadTags(path)
+			if err != nil {
+				t.Fatalf("ReadTags: %v", err)
+			}
+			if tags.Title != "Curated Title" || tags.Album != "Curated Album" {
+				t.Errorf("curated tags = %q, %q; want them kept", tags.Title, tags.Album)
+			}
+			if tags.AlbumArtist != "Qobuz Band" {
+				t.Errorf("album artist = %q, want the gap filled with %q", tags.AlbumArtist, "Qobuz Band")
+			}
+			if got := frontCover(t, path); !bytes.Equal(got, curatedCover) {
+				t.Errorf("front cover has %d bytes, want the curated %d byte cover", len(got), len(curatedCover))
+			}
+		})
+	}
+}
+
+// flacPaddingSizes returns the sizes of the PADDING blocks of a FLAC file and
+// whether the last metadata block is one.
+func flacPaddingSizes(t *testing.T, path string) ([]int, bool) {
+	t.Helper()
+	f, err := flac.ParseFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sizes []int
+	for _, block := range f.Meta {
+		if block.Type == flac.Padding {
+			sizes = append(sizes, len(block.Data))
+		}
+	}
+	return sizes, len(f.Meta) > 0 && f.Meta[len(f.Meta)-1].Type == flac.Padding
+}
+
+func TestFlacPadding(t *testing.T) {
+	tests := []struct {
+		name    string
+		padding int
+		want    []int
+	}{
+		{name: "disabled", padding: 0},
+		{name: "default", padding: DefaultPaddingBytes, want: []int{DefaultPaddingBytes}},
+		{name: "custom", padding: 1000, want: []int{1000}},
+		{name: "capped at the block size limit", padding: 1 << 25, want: []int{maxFlacBlockSize}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "track.flac")
+			if err := os.WriteFile(path, buildTestFLAC(2, 64), 0644); err != nil {
+				t.Fatal(err)
+			}
+			tagger := NewTagger()
+			tagger.PaddingBytes = tt.padding
+			album := &api.AlbumMetadata{Title: "Album"}
+			if err := tagger.WriteTags(path, &api.TrackMetadata{Title: "Track"}, album, nil); err != nil {
+				t.Fatalf("WriteTags: %v", err)
+			}
+
+			sizes, last := flacPaddingSizes(t, path)
+			if !slices.Equal(sizes, tt.want) {
+				t.Errorf("padding blocks = %v, want %v", sizes, tt.want)
+			}
+			if len(sizes) > 0 && !last {
+				t.Error("padding is not the last metadata block")
+			}
+		})
+	}
+}
+
+func TestFlacRetagInPlace(t *testing.T) {
+	audio := buildTestFLAC(2, 64)
+	frames := audio[4+4+34:] // After the marker and STREAMINFO
+
+	tests := []struct {
+		name        string
+		title       string
+		cover       []byte
+		wantInPlace bool
+	}{
+		{name: "longer title fits in the padding", title: "A considerably longer track title", wantInPlace: true},
+		{name: "shorter title grows the padding", title: "T", wantInPlace: true},
+		{name: "cover larger than the padding", title: "Track", cover: bytes.Repeat([]byte{0xAB}, 3*DefaultPaddingBytes)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "track.flac")
+			if err := os.WriteFile(path, audio, 0644); err != nil {
+				t.Fatal(err)
+			}
+			tagger := NewTagger()
+			album := &api.AlbumMetadata{Title: "Album"}
+			if err := tagger.WriteTags(path, &api.TrackMetadata{Title: "Track"}, album, nil); err != nil {
+				t.Fatal(err)
+			}
+			before, _ := os.Stat(path)
+
+			if err := tagger.WriteTags(path, &api.TrackMetadata{Title: tt.title}, album, tt.cover); err != nil {
+				t.Fatalf("retag: %v", err)
+			}
+			after, _ := os.Stat(path)
+			if inPlace := after.Size() == before.Size(); inPlace != tt.wantInPlace {
+				t.Errorf("file size %d -> %d, want rewritten in place = %v", before.Size(), after.Size(), tt.wantInPlace)
+			}
+			sizes, last := flacPaddingSizes(t, path)
+			if len(sizes) != 1 || !last {
+				t.Errorf("padding blocks = %v (last %v), want one trailing block", sizes, last)
+			} else if !tt.wantInPlace && sizes[0] != DefaultPaddingBytes {
+				t.Errorf("padding after a full rewrite = %d, want %d", sizes[0], DefaultPaddingBytes)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.HasSuffix(data, frames) {
+				t.Error("audio frames changed")
+			}
+			if tags, err := ReadTags(path); err != nil || tags.Title != tt.title {
+				t.Errorf("title = %v, %v; want %q", tags, err, tt.title)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+// template_preview.go resolves the output paths the naming templates produce
+// for an album or track without downloading, and reports paths shared by
+// several tracks, so template mistakes show up before a large run.
+package engine
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// PreviewedTrack is a track with the output path it would be written to
+// (without extension, which depends on the delivered format).
+type PreviewedTrack struct {
+	Title string `json:"title"`
+	Path  string `json:"path"`
+}
+
+// PathCollision is an output path rendered for more than one track.
+type PathCollision struct {
+	Path   string   `json:"path"`
+	Titles []string `json:"titles"`
+}
+
+// TemplatePreview lists the output paths of a resource's tracks.
+type TemplatePreview struct {
+	Template   string           `json:"template"`
+	Tracks     []PreviewedTrack `json:"tracks"`
+	Collisions []PathCollision  `json:"collisions,omitempty"`
+}
+
+// PreviewAlbumTemplate resolves the output path of every track of an album
+// with the album track template.
+func (e *Engine) PreviewAlbumTemplate(albumID, outputDir string) (*TemplatePreview, error) {
+	plan, err := e.PlanAlbum(albumID, outputDir)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &TemplatePreview{Template: e.AlbumTrackTemplate}
+	if preview.Template == "" {
+		preview.Template = DefaultAlbumTrackTemplate
+	}
+	for _, planned := range plan.Tracks {
+		preview.Tracks = append(preview.Tracks, PreviewedTrack{
+			Title: planned.Track.Title,
+			Path:  filepath.Join(plan.AlbumDir, planned.BaseName),
+		})
+	}
+	preview.Collisions = findPathCollisions(preview.Tracks)
+	return preview, nil
+}
+
+// PreviewTrackTemplate resolves the output path of a single track with the
+// single track template.
+func (e *Engine) PreviewTrackTemplate(trackID, outputDir string) (*TemplatePreview, error) {
+	track, err := e.Client.GetTrack(trackID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get track metadata: %w", err)
+	}
+
+	outputDir = e.resolveOutputDir(outputDir)
+	folder, baseName := e.singleTrackName(track)
+	folder, baseName = fitPath(outputDir, folder, baseName, e.maxPathLength())
+
+	preview := &TemplatePreview{Template: e.SingleTrackTemplate}
+	if preview.Template == "" {
+		preview.Template = DefaultSingleTrackTemplate
+	}
+	preview.Tracks = []PreviewedTrack{{
+		Title: track.Title,
+		Path:  filepath.Join(outputDir, folder, baseName),
+	}}
+	return preview, nil
+}
+
+// findPathCollisions returns the paths shared by more than one track, in the
+// order they first appear. Paths are compared case-insensitively because the
+// default file systems of Windows and macOS are.
+func findPathCollisions(tracks []PreviewedTrack) []PathCollision {
+	byPath := make(map[string]int) // Lowercased path -> index in collisions
+	var collisions []PathCollision
+	for _, t := range tracks {
+		key := strings.ToLower(t.Path)
+		if i, ok := byPath[key]; ok {
+			collisions[i].Titles = append(collisions[i].Titles, t.Title)
+			continue
+		}
+		byPath[key] = len(collisions)
+		collisions = append(collisions, PathCollision{Path: t.Path, Titles: []string{t.Title}})
+	}
+
+	shared := collisions[:0]
+	for _, c := range collisions {
+		if len(c.Titles) > 1 {
+			shared = append(shared, c)
+		}
+	}
+	if len(shared) == 0 {
+		return nil
+	}
+	return shared
+}
@@ -0,0 +1,167 @@
+package engine
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFindPathCollisions(t *testing.T) {
+	tests := []struct {
+		name   string
+		tracks []PreviewedTrack
+		want   []PathCollision
+	}{
+		{name: "no tracks"},
+		{
+			name:   "distinct paths",
+			tracks: []PreviewedTrack{{Title: "Intro", Path: "01. Intro"}, {Title: "Song", Path: "02. Song"}},
+		},
+		{
+			name:   "shared path",
+			tracks: []PreviewedTrack{{Title: "Intro", Path: "Intro"}, {Title: "Song", Path: "Song"}, {Title: "Intro", Path: "Intro"}},
+			want:   []PathCollision{{Path: "Intro", Titles: []string{"Intro", "Intro"}}},
+		},
+		{
+			name:   "differs only in case",
+			tracks: []PreviewedTrack{{Title: "Intro", Path: "01. Intro"}, {Title: "INTRO", Path: "01. INTRO"}},
+			want:   []PathCollision{{Path: "01. Intro", Titles: []string{"Intro", "INTRO"}}},
+		},
+		{
+			name: "several collisions in order of appearance",
+			tracks: []PreviewedTrack{
+				{Title: "A", Path: "02"}, {Title: "B", Path: "01"}, {Title: "C", Path: "03"},
+				{Title: "D", Path: "01"}, {Title: "E", Path: "02"}, {Title: "F", Path: "02"},
+			},
+			want: []PathCollision{
+				{Path: "02", Titles: []string{"A", "E", "F"}},
+				{Path: "01", Titles: []string{"B", "D"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := findPathCollisions(tt.tracks); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findPathCollisions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreviewAlbumTemplate(t *testing.T) {
+	tests := []struct {
+		name      string
+		template  string
+		wantFiles []string
+		want      []PathCollision // Collisions, with paths relative to the album folder
+	}{
+		{
+			name:      "default",
+			wantFiles: []string{"01. Intro", "02. Song", "01. Reprise", "02. intro"},
+		},
+		{
+			name:      "disc numbers",
+			template:  "{discnumber}-{tracknumber}. {title}",
+			wantFiles: []string{"1-01. Intro", "1-02. Song", "2-01. Reprise", "2-02. intro"},
+		},
+		{
+			name:      "track numbers repeat across discs",
+			template:  "{tracknumber}",
+			wantFiles: []string{"01", "02", "01", "02"},
+			want: []PathCollision{
+				{Path: "01", Titles: []string{"Intro", "Reprise"}},
+				{Path: "02", Titles: []string{"Song", "intro"}},
+			},
+		},
+		{
+			name:      "titles differ only in case",
+			template:  "{title}",
+			wantFiles: []string{"Intro", "Song", "Reprise", "intro"},
+			want:      []PathCollision{{Path: "Intro", Titles: []string{"Intro", "intro"}}},
+		},
+		{
+			name:      "every track",
+			template:  "{album}",
+			wantFiles: []string{"Album", "Album", "Album", "Album"},
+			want:      []PathCollision{{Path: "Album", Titles: []string{"Intro", "Song", "Reprise", "intro"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeQobuz(t)
+			album := fake.addAlbum("alb1", "Album", "Band", 100, 4)
+			album.MediaCount = 2
+			for i, title := range []string{"Intro", "Song", "Reprise", "intro"} {
+				album.Tracks.Items[i].Title = title
+				album.Tracks.Items[i].MediaNumber = i/2 + 1
+				album.Tracks.Items[i].TrackNumber = i%2 + 1
+			}
+			e := fake.engine()
+			e.AlbumTrackTemplate = tt.template
+			outputDir := t.TempDir()
+
+			preview, err := e.PreviewAlbumTemplate("alb1", outputDir)
+			if err != nil {
+				t.Fatalf("PreviewAlbumTemplate: %v", err)
+			}
+			wantTemplate := tt.template
+			if wantTemplate == "" {
+				wantTemplate = DefaultAlbumTrackTemplate
+			}
+			if preview.Template != wantTemplate {
+				t.Errorf("template = %q, want %q", preview.Template, wantTemplate)
+			}
+
+			albumDir := filepath.Join(outputDir, "Band - Album")
+			var files []string
+			for _, track := range preview.Tracks {
+				if filepath.Dir(track.Path) != albumDir {
+					t.Errorf("%s is outside the album folder %s", track.Path, albumDir)
+				}
+				files = append(files, filepath.Base(track.Path))
+			}
+			if !reflect.DeepEqual(files, tt.wantFiles) {
+				t.Errorf("files = %q, want %q", files, tt.wantFiles)
+			}
+			var collisions []PathCollision
+			for _, c := range preview.Collisions {
+				collisions = append(collisions, PathCollision{Path: filepath.Base(c.Path), Titles: c.Titles})
+			}
+			if !reflect.DeepEqual(collisions, tt.want) {
+				t.Errorf("collisions = %+v, want %+v", collisions, tt.want)
+			}
+			if n := fake.count("/track/getFileUrl"); n != 0 {
+				t.Errorf("%d file URLs requested, want none for a preview", n)
+			}
+		})
+	}
+}
+
+func TestPreviewTrackTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     string // Path relative to the output folder
+	}{
+		{name: "default", want: "Band - Track 1"},
+		{name: "with folders", template: "{artist}/{album}/{title}", want: filepath.Join("Band", "Album", "Track 1")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeQobuz(t)
+			fake.addAlbum("alb1", "Album", "Band", 100, 1)
+			e := fake.engine()
+			e.SingleTrackTemplate = tt.template
+			outputDir := t.TempDir()
+
+			preview, err := e.PreviewTrackTemplate("100", outputDir)
+			if err != nil {
+				t.Fatalf("PreviewTrackTemplate: %v", err)
+			}
+			want := []PreviewedTrack{{Title: "Track 1", Path: filepath.Join(outputDir, tt.want)}}
+			if !reflect.DeepEqual(preview.Tracks, want) || preview.Collisions != nil {
+				t.Errorf("preview = %+v, want tracks %+v without collisions", preview, want)
+			}
+		})
+	}
+}
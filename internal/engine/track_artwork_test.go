@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDownloadTrackFetchesCoverConcurrently(t *testing.T) {
+	fake := newFakeQobuz(t)
+	fake.addAlbum("alb1", "Album", "Band", 100, 1)
+
+	var fetches peakCounter
+	fake.onFile = func() { fetches.run(300 * time.Millisecond) }
+	fake.onCover = func() { fetches.run(300 * time.Millisecond) }
+
+	outputDir := t.TempDir()
+	if err := fake.engine().DownloadTrack(context.Background(), "100", 6, outputDir, nil); err != nil {
+		t.Fatalf("DownloadTrack: %v", err)
+	}
+	if peak := fetches.peak.Load(); peak != 2 {
+		t.Errorf("at most %d fetches in flight, want the audio and cover at once", peak)
+	}
+	if got := frontCover(t, filepath.Join(outputDir, "Band - Track 1.flac")); !bytes.Equal(got, fake.cover) {
+		t.Errorf("embedded cover has %d bytes, want the %d byte cover fetched alongside", len(got), len(fake.cover))
+	}
+}
+
+func TestDownloadTrackCoverFailure(t *testing.T) {
+	tests := []struct {
+		name      string
+		coverPath string // Cover URL path of the track's album
+		slow      bool   // The cover arrives after the audio
+		wantCover bool
+	}{
+		{name: "slow cover", coverPath: "/covers/alb1_600.jpg", slow: true, wantCover: true},
+		{name: "missing cover", coverPath: "/missing.jpg"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeQobuz(t)
+			fake.addAlbum("alb1", "Album", "Band", 100, 1)
+			fake.tracks[100].Album.Image.Large = fake.srv.URL + tt.coverPath
+			if tt.slow {
+				fake.onCover = func() { time.Sleep(200 * time.Millisecond) }
+			}
+
+			outputDir := t.TempDir()
+			if err := fake.engine().DownloadTrack(context.Background(), "100", 6, outputDir, nil); err != nil {
+				t.Fatalf("DownloadTrack: %v", err)
+			}
+			cover := frontCover(t, filepath.Join(outputDir, "Band - Track 1.flac"))
+			if got := cover != nil; got != tt.wantCover {
+				t.Errorf("cover embedded = %v, want %v", got, tt.wantCover)
+			}
+		})
+	}
+}
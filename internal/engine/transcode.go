@@ -0,0 +1,133 @@
+// transcode.go provides on-the-fly transcoding of track streams via ffmpeg.
+// It lets browsers without FLAC support play tracks directly.
+package engine
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// ErrFFmpegNotFound is returned when transcoding is requested but ffmpeg is not installed.
+var ErrFFmpegNotFound = errors.New("ffmpeg not found in PATH")
+
+// transcodeFormat describes the ffmpeg output settings for a target format.
+type transcodeFormat struct {
+	Args     []string // ffmpeg output arguments
+	MimeType string   // Content-Type of the encoded stream
+}
+
+// transcodeFormats lists the supported transcoding targets.
+var transcodeFormats = map[string]transcodeFormat{
+	"mp3": {
+		Args:     []string{"-f", "mp3", "-codec:a", "libmp3lame", "-b:a", "320k"},
+		MimeType: "audio/mpeg",
+	},
+	"aac": {
+		Args:     []string{"-f", "adts", "-codec:a", "aac", "-b:a", "256k"},
+		MimeType: "audio/aac",
+	},
+	"opus": {
+		Args:     []string{"-f", "ogg", "-codec:a", "libopus", "-b:a", "192k"},
+		MimeType: "audio/ogg",
+	},
+}
+
+// TranscodeMimeType returns the Content-Type for a transcoding target format.
+func TranscodeMimeType(format string) (string, error) {
+	tf, ok := transcodeFormats[strings.ToLower(format)]
+	if !ok {
+		return "", fmt.Errorf("unsupported transcode format: %s", format)
+	}
+	return tf.MimeType, nil
+}
+
+// FFmpegPath returns the path to the ffmpeg binary, or ErrFFmpegNotFound.
+func FFmpegPath() (string, error) {
+	path, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return "", ErrFFmpegNotFound
+	}
+	return path, nil
+}
+
+// StreamTrackAs streams a track to w in the given target format ("mp3",
+// "aac", "opus"); an empty format streams the original audio unchanged.
+// With tagged set, the source is tagged first and ffmpeg carries the tags
+// over to the encoded stream.
+func (e *Engine) StreamTrackAs(ctx context.Context, trackID string, quality int, format string, tagged bool, w io.Writer) (*StreamInfo, error) {
+	feed := func(src io.Writer) (*StreamInfo, error) {
+		if tagged {
+			return e.StreamTaggedTrack(ctx, trackID, quality, src)
+		}
+		return e.StreamTrack(ctx, trackID, quality, src, nil)
+	}
+	if format == "" {
+		return feed(w)
+	}
+
+	tf, ok := transcodeFormats[strings.ToLower(format)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported transcode format: %s", format)
+	}
+	ffmpeg, err := FFmpegPath()
+	if err != nil {
+		return nil, err
+	}
+	return transcodeStream(ctx, ffmpeg, tf, w, feed)
+}
+
+// StreamTrackTranscoded streams a track through ffmpeg, writing the encoded
+// output to w as it is produced. The source stream is piped into ffmpeg, so
+// nothing is buffered beyond the pipe buffers.
+func (e *Engine) StreamTrackTranscoded(ctx context.Context, trackID string, quality int, format string, w io.Writer) (*StreamInfo, error) {
+	return e.StreamTrackAs(ctx, trackID, quality, format, false, w)
+}
+
+// transcodeStream runs the encoder at ffmpeg, feeding it the source written by
+// feed and copying its output to w. Cancelling ctx kills the encoder.
+// The returned StreamInfo is nil if feed failed before producing any audio.
+func transcodeStream(ctx context.Context, ffmpeg string, tf transcodeFormat, w io.Writer, feed func(io.Writer) (*StreamInfo, error)) (*StreamInfo, error) {
+	args := append([]string{"-hide_banner", "-loglevel", "error", "-i", "pipe:0", "-vn"}, tf.Args...)
+	args = append(args, "pipe:1")
+
+	cmd := exec.CommandContext(ctx, ffmpeg, args...)
+	var stderr bytes.Buffer
+	cmd.Stdout = w
+	cmd.Stderr = &stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+
+	streamInfo := &StreamInfo{MimeType: tf.MimeType}
+
+	// Feed the source stream into ffmpeg; closing stdin signals end of input
+	srcInfo, streamErr := feed(stdin)
+	stdin.Close()
+
+	waitErr := cmd.Wait()
+	if err := ctx.Err(); err != nil {
+		return streamInfo, err
+	}
+	if streamErr != nil && srcInfo == nil {
+		return nil, streamErr // Failed before any audio reached ffmpeg
+	}
+	if waitErr != nil {
+		// An encoder failure also breaks the pipe, so it explains a stream error too
+		return streamInfo, fmt.Errorf("ffmpeg failed: %w: %s", waitErr, strings.TrimSpace(stderr.String()))
+	}
+	if streamErr != nil {
+		return streamInfo, streamErr
+	}
+
+	return streamInfo, nil
+}
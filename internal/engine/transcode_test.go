@@ -0,0 +1,116 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeFFmpeg installs an ffmpeg shell script running body first in PATH and
+// returns the directory holding it. The script records its arguments in the
+// file "args" of that directory.
+func fakeFFmpeg(t *testing.T, body string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake encoder is a shell script")
+	}
+	dir := t.TempDir()
+	script := "#!/bin/sh\necho \"$@\" > '" + filepath.Join(dir, "args") + "'\n" + body + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "ffmpeg"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return dir
+}
+
+func TestStreamTrackAs(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		encoder  string // Fake ffmpeg script body
+		wantOut  string // Relative to the source audio, "" for unchanged
+		wantMime string
+		wantArgs string
+		wantErr  string
+	}{
+		{name: "mp3", format: "mp3", encoder: "printf 'MP3:'; exec cat", wantOut: "MP3:", wantMime: "audio/mpeg", wantArgs: "-f mp3 -codec:a libmp3lame"},
+		{name: "opus", format: "OPUS", encoder: "printf 'OGG:'; exec cat", wantOut: "OGG:", wantMime: "audio/ogg", wantArgs: "-f ogg -codec:a libopus"},
+		{name: "original", encoder: "exit 1", wantMime: "audio/flac"},
+		{name: "unsupported format", format: "wma", encoder: "exec cat", wantErr: "unsupported transcode format"},
+		{name: "encoder failure", format: "mp3", encoder: "cat > /dev/null; echo 'Unknown encoder libmp3lame' >&2; exit 1", wantErr: "Unknown encoder libmp3lame"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := fakeFFmpeg(t, tt.encoder)
+			fake := newFakeQobuz(t)
+			fake.addAlbum("alb1", "Album", "Band", 100, 1)
+			e := fake.engine()
+
+			var out bytes.Buffer
+			info, err := e.StreamTrackAs(context.Background(), "100", 6, tt.format, false, &out)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("StreamTrackAs error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("StreamTrackAs: %v", err)
+			}
+			if info.MimeType != tt.wantMime {
+				t.Errorf("MimeType = %q, want %q", info.MimeType, tt.wantMime)
+			}
+			if want := append([]byte(tt.wantOut), fake.audio...); !bytes.Equal(out.Bytes(), want) {
+				t.Errorf("got %d bytes of output, want %d", out.Len(), len(want))
+			}
+			args, err := os.ReadFile(filepath.Join(dir, "args"))
+			if tt.wantArgs == "" {
+				if err == nil {
+					t.Errorf("ffmpeg ran with %q for the original format", args)
+				}
+				return
+			}
+			if got := strings.TrimSpace(string(args)); !strings.Contains(got, tt.wantArgs) || !strings.HasSuffix(got, "pipe:1") {
+				t.Errorf("ffmpeg arguments %q, want %q writing to stdout", got, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestStreamTrackAsWithoutFFmpeg(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	fake := newFakeQobuz(t)
+	fake.addAlbum("alb1", "Album", "Band", 100, 1)
+
+	var out bytes.Buffer
+	_, err := fake.engine().StreamTrackAs(context.Background(), "100", 6, "mp3", false, &out)
+	if !errors.Is(err, ErrFFmpegNotFound) {
+		t.Errorf("StreamTrackAs error = %v, want %v", err, ErrFFmpegNotFound)
+	}
+	if out.Len() != 0 {
+		t.Errorf("wrote %d bytes without an encoder", out.Len())
+	}
+}
+
+func TestStreamTrackAsCancelled(t *testing.T) {
+	fakeFFmpeg(t, "exec sleep 30")
+	fake := newFakeQobuz(t)
+	fake.addAlbum("alb1", "Album", "Band", 100, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := fake.engine().StreamTrackAs(ctx, "100", 6, "mp3", false, &bytes.Buffer{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("StreamTrackAs error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("cancelled transcode took %v, want the encoder killed", elapsed)
+	}
+}
@@ -0,0 +1,100 @@
+// watchdog.go aborts downloads that stop receiving data.
+// Some CDN edges accept the connection but never send a byte; a slow transfer
+// that keeps making progress is not affected.
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// defaultIdleTimeout is how long a download may go without receiving data.
+const defaultIdleTimeout = 30 * time.Second
+
+// errStalled is the cancellation cause for downloads aborted by the watchdog.
+var errStalled = errors.New("download stalled")
+
+// idleWatchdog cancels a context when no progress is reported for the idle window.
+type idleWatchdog struct {
+	last   atomic.Int64 // UnixNano of the last progress
+	busy   atomic.Int32 // Writes to a slow consumer in progress; the source is not idle then
+	cancel context.CancelCauseFunc
+	done   chan struct{}
+}
+
+// newIdleWatchdog returns a context derived from ctx that is cancelled with errStalled
+// if Touch is not called for idle. A non-positive idle disables the watchdog.
+// Stop must be called once the download finishes.
+func newIdleWatchdog(ctx context.Context, idle time.Duration) (context.Context, *idleWatchdog) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	w := &idleWatchdog{cancel: cancel, done: make(chan struct{})}
+	w.Touch()
+
+	if idle <= 0 {
+		return ctx, w
+	}
+
+	go func() {
+		ticker := time.NewTicker(max(idle/4, 10*time.Millisecond))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if w.busy.Load() == 0 && time.Since(time.Unix(0, w.last.Load())) >= idle {
+					cancel(fmt.Errorf("%w: no data received for %s", errStalled, idle))
+					return
+				}
+			}
+		}
+	}()
+
+	return ctx, w
+}
+
+// Touch records that data was received.
+func (w *idleWatchdog) Touch() {
+	w.last.Store(time.Now().UnixNano())
+}
+
+// Writer wraps dst so that time spent blocked writing to it (a slow consumer
+// applying backpressure) counts as activity rather than a stalled source.
+func (w *idleWatchdog) Writer(dst io.Writer) io.Writer {
+	return watchedWriter{dst: dst, w: w}
+}
+
+// watchedWriter is the io.Writer returned by idleWatchdog.Writer.
+type watchedWriter struct {
+	dst io.Writer
+	w   *idleWatchdog
+}
+
+func (ww watchedWriter) Write(p []byte) (int, error) {
+	ww.w.busy.Add(1)
+	defer func() {
+		ww.w.Touch()
+		ww.w.busy.Add(-1)
+	}()
+	return ww.dst.Write(p)
+}
+
+// Stop ends the watchdog and releases the derived context.
+func (w *idleWatchdog) Stop() {
+	close(w.done)
+	w.cancel(nil)
+}
+
+// stallError returns the watchdog's error if ctx was cancelled because of a stall.
+func stallError(ctx context.Context) error {
+	if cause := context.Cause(ctx); errors.Is(cause, errStalled) {
+		return cause
+	}
+	return nil
+}
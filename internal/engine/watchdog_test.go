@@ -0,0 +1,98 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+func TestResumeFileWatchdog(t *testing.T) {
+	tests := []struct {
+		name      string
+		stall     bool
+		wantStall bool
+	}{
+		{name: "completes when data keeps flowing"},
+		{name: "aborts when the body stalls", stall: true, wantStall: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			release := make(chan struct{})
+			defer close(release)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Range") != "bytes=4-" {
+					t.Errorf("Range = %q, want bytes=4-", r.Header.Get("Range"))
+				}
+				w.Header().Set("Content-Range", "bytes 4-7/8")
+				w.WriteHeader(http.StatusPartialContent)
+				w.Write([]byte("ef"))
+				w.(http.Flusher).Flush()
+				if tt.stall {
+					select {
+					case <-release:
+					case <-r.Context().Done():
+					}
+					return
+				}
+				w.Write([]byte("gh"))
+			}))
+			defer srv.Close()
+
+			path := filepath.Join(t.TempDir(), "track.flac.part")
+			if err := os.WriteFile(path, []byte("abcd"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			e := New(api.NewClient("", ""))
+			e.IdleTimeout = 100 * time.Millisecond
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			err := e.resumeFile(ctx, srv.URL, path, nil)
+			if tt.wantStall {
+				if !errors.Is(err, errStalled) {
+					t.Fatalf("resumeFile() error = %v, want %v", err, errStalled)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resumeFile() error = %v", err)
+			}
+			if got, _ := os.ReadFile(path); string(got) != "abcdefgh" {
+				t.Errorf("file = %q, want %q", got, "abcdefgh")
+			}
+		})
+	}
+}
+
+// slowWriter blocks every write for delay, like a client reading a stream slowly.
+type slowWriter struct{ delay time.Duration }
+
+func (s slowWriter) Write(p []byte) (int, error) {
+	time.Sleep(s.delay)
+	return len(p), nil
+}
+
+func TestWatchdogWriterIgnoresSlowConsumer(t *testing.T) {
+	ctx, watchdog := newIdleWatchdog(context.Background(), 40*time.Millisecond)
+	defer watchdog.Stop()
+
+	w := watchdog.Writer(slowWriter{delay: 150 * time.Millisecond})
+	if _, err := w.Write([]byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if err := stallError(ctx); err != nil {
+		t.Fatalf("stalled while the consumer was writing: %v", err)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	if err := stallError(ctx); err == nil {
+		t.Fatal("watchdog did not fire once the source went idle")
+	}
+}
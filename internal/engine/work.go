@@ -0,0 +1,156 @@
+// work.go derives classical work and movement tags. Qobuz provides a work
+// field for some tracks; otherwise titles such as "Symphony No. 5, Op. 67:
+// I. Allegro con brio" are split into the work and the numbered movement.
+package engine
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+// maxMovementNumber bounds numbered movements, so words that happen to be
+// Roman numerals ("C. P. E. Bach") are not taken for movement numbers.
+const maxMovementNumber = 50
+
+// movementRegex matches a numbered movement: "I. Allegro", "12. Finale".
+var movementRegex = regexp.MustCompile(`^([IVXL]+|\d+)\.\s+(\S.*)$`)
+
+// trackWork is the work and movement of a track. Zero numbers are unknown.
+type trackWork struct {
+	Work     string
+	Movement string
+	Number   int
+	Total    int
+}
+
+// parseMovement splits a numbered movement into its number and name.
+func parseMovement(s string) (number int, name string, ok bool) {
+	m := movementRegex.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0, "", false
+	}
+	number, err := strconv.Atoi(m[1])
+	if err != nil {
+		number = romanValue(m[1])
+	}
+	if number < 1 || number > maxMovementNumber {
+		return 0, "", false
+	}
+	return number, m[2], true
+}
+
+// romanValue returns the value of a Roman numeral of I, V, X and L, or 0 if malformed.
+func romanValue(s string) int {
+	values := map[byte]int{'I': 1, 'V': 5, 'X': 10, 'L': 50}
+	total := 0
+	for i := 0; i < len(s); i++ {
+		v := values[s[i]]
+		if i+1 < len(s) && v < values[s[i+1]] {
+			total -= v
+		} else {
+			total += v
+		}
+	}
+	if total <= 0 || romanNumeral(total) != s {
+		return 0 // Reject non-canonical forms such as "IIII" or "VX"
+	}
+	return total
+}
+
+// romanNumeral formats n (1-89) as a Roman numeral.
+func romanNumeral(n int) string {
+	var b strings.Builder
+	for _, r := range []struct {
+		value  int
+		symbol string
+	}{{50, "L"}, {40, "XL"}, {10, "X"}, {9, "IX"}, {5, "V"}, {4, "IV"}, {1, "I"}} {
+		for n >= r.value {
+			b.WriteString(r.symbol)
+			n -= r.value
+		}
+	}
+	return b.String()
+}
+
+// splitWorkTitle splits "Work: I. Movement" at the first ": " followed by a
+// numbered movement. Titles without one are not split.
+func splitWorkTitle(title string) (work, movement string, number int, ok bool) {
+	rest := title
+	offset := 0
+	for {
+		i := strings.Index(rest, ": ")
+		if i < 0 {
+			return "", "", 0, false
+		}
+		work = strings.TrimSpace(title[:offset+i])
+		if n, name, found := parseMovement(rest[i+2:]); found && work != "" {
+			return work, name, n, true
+		}
+		offset += i + 2
+		rest = rest[i+2:]
+	}
+}
+
+// workOf returns the work and movement of track without album context.
+// A work field from Qobuz is used as is; otherwise the title is split when
+// splitTitles is set.
+func workOf(track *api.TrackMetadata, splitTitles bool) (trackWork, bool) {
+	if work := strings.TrimSpace(track.Work); work != "" {
+		movement := strings.TrimSpace(track.Title)
+		if rest, found := strings.CutPrefix(movement, work+":"); found {
+			movement = strings.TrimSpace(rest)
+		}
+		w := trackWork{Work: work, Movement: movement}
+		if n, name, ok := parseMovement(movement); ok {
+			w.Number, w.Movement = n, name
+		}
+		return w, true
+	}
+	if !splitTitles {
+		return trackWork{}, false
+	}
+	work, movement, number, ok := splitWorkTitle(track.Title)
+	if !ok {
+		return trackWork{}, false
+	}
+	return trackWork{Work: work, Movement: movement, Number: number}, true
+}
+
+// workFor returns the work and movement of track. The album's track list
+// gives the movement total and, for unnumbered movements, the position of
+// the track within its work.
+func (t *Tagger) workFor(track *api.TrackMetadata, album *api.AlbumMetadata) (trackWork, bool) {
+	w, ok := workOf(track, t.SplitWorkTitles)
+	if !ok {
+		return trackWork{}, false
+	}
+
+	position := 0
+	for i := range album.Tracks.Items {
+		item := &album.Tracks.Items[i]
+		if other, found := workOf(item, t.SplitWorkTitles); !found || other.Work != w.Work {
+			continue
+		}
+		w.Total++
+		if item.ID == track.ID && position == 0 {
+			position = w.Total
+		}
+	}
+	if w.Number == 0 {
+		w.Number = position
+	}
+	return w, true
+}
+
+// movementNumber returns the MOVEMENTNUMBER value, or "" if unknown.
+func (w trackWork) movementNumber() string {
+	return positiveString(w.Number)
+}
+
+// movementTotal returns the MOVEMENTTOTAL value, or "" if unknown.
+func (w trackWork) movementTotal() string {
+	return positiveString(w.Total)
+}
@@ -0,0 +1,213 @@
+package engine
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+
+	"github.com/bogem/id3v2/v2"
+	"github.com/go-flac/go-flac"
+)
+
+func TestParseMovement(t *testing.T) {
+	tests := []struct {
+		in         string
+		wantNumber int
+		wantName   string
+		wantOK     bool
+	}{
+		{in: "I. Allegro con brio", wantNumber: 1, wantName: "Allegro con brio", wantOK: true},
+		{in: "IV. Finale. Presto", wantNumber: 4, wantName: "Finale. Presto", wantOK: true},
+		{in: "XXIV. Var. 23", wantNumber: 24, wantName: "Var. 23", wantOK: true},
+		{in: "12. Gigue", wantNumber: 12, wantName: "Gigue", wantOK: true},
+		{in: "IIII. Adagio"},     // Non-canonical numeral
+		{in: "C. P. E. Bach"},    // C is not a movement numeral
+		{in: "LX. Too Many"},     // Beyond maxMovementNumber
+		{in: "Allegro con brio"}, // Unnumbered
+		{in: "I.Allegro"},        // No space after the number
+		{in: "0. Prelude"},       // Movements start at 1
+	}
+	for _, tt := range tests {
+		number, name, ok := parseMovement(tt.in)
+		if number != tt.wantNumber || name != tt.wantName || ok != tt.wantOK {
+			t.Errorf("parseMovement(%q) = %d, %q, %v; want %d, %q, %v", tt.in, number, name, ok, tt.wantNumber, tt.wantName, tt.wantOK)
+		}
+	}
+}
+
+func TestSplitWorkTitle(t *testing.T) {
+	tests := []struct {
+		title        string
+		wantWork     string
+		wantMovement string
+		wantNumber   int
+		wantOK       bool
+	}{
+		{
+			title:    "Symphony No. 5 in C Minor, Op. 67: I. Allegro con brio",
+			wantWork: "Symphony No. 5 in C Minor, Op. 67", wantMovement: "Allegro con brio", wantNumber: 1, wantOK: true,
+		},
+		{title: "Goldberg Variations, BWV 988: Var. 1 a 1 Clav."}, // "Var." is not a movement number
+		{
+			title:    "Mass in B Minor, BWV 232: Gloria: IV. Gloria in excelsis",
+			wantWork: "Mass in B Minor, BWV 232: Gloria", wantMovement: "Gloria in excelsis", wantNumber: 4, wantOK: true,
+		},
+		{
+			title:    "Le nozze di Figaro, K. 492: 3. Cinque, dieci, venti",
+			wantWork: "Le nozze di Figaro, K. 492", wantMovement: "Cinque, dieci, venti", wantNumber: 3, wantOK: true,
+		},
+		{title: "Song 2"},
+		{title: "Interlude: Radio Edit"}, // Colon without a numbered movement
+		{title: ": I. Allegro"},          // No work before the movement
+	}
+	for _, tt := range tests {
+		work, movement, number, ok := splitWorkTitle(tt.title)
+		if work != tt.wantWork || movement != tt.wantMovement || number != tt.wantNumber || ok != tt.wantOK {
+			t.Errorf("splitWorkTitle(%q) = %q, %q, %d, %v; want %q, %q, %d, %v",
+				tt.title, work, movement, number, ok, tt.wantWork, tt.wantMovement, tt.wantNumber, tt.wantOK)
+		}
+	}
+}
+
+// classicalAlbum returns an album of a symphony in four numbered movements,
+// a suite whose tracks carry a work field but unnumbered movements, and an
+// unrelated track.
+func classicalAlbum() *api.AlbumMetadata {
+	album := &api.AlbumMetadata{ID: "alb1", Title: "Beethoven & Bach"}
+	titles := []struct{ title, work string }{
+		{title: "Symphony No. 5 in C Minor, Op. 67: I. Allegro con brio"},
+		{title: "Symphony No. 5 in C Minor, Op. 67: II. Andante con moto"},
+		{title: "Symphony No. 5 in C Minor, Op. 67: III. Scherzo. Allegro"},
+		{title: "Symphony No. 5 in C Minor, Op. 67: IV. Allegro"},
+		{title: "Cello Suite No. 1 in G Major, BWV 1007: Prélude", work: "Cello Suite No. 1 in G Major, BWV 1007"},
+		{title: "Allemande", work: "Cello Suite No. 1 in G Major, BWV 1007"},
+		{title: "Encore: Für Elise"},
+	}
+	for i, tt := range titles {
+		album.Tracks.Items = append(album.Tracks.Items, api.TrackMetadata{ID: i + 1, Title: tt.title, Work: tt.work, TrackNumber: i + 1, MediaNumber: 1})
+	}
+	return album
+}
+
+func TestWorkFor(t *testing.T) {
+	album := classicalAlbum()
+	tests := []struct {
+		name        string
+		track       int // Index into the album's tracks
+		splitTitles bool
+		want        trackWork
+		wantOK      bool
+	}{
+		{name: "numbered movement", track: 1, splitTitles: true, want: trackWork{Work: "Symphony No. 5 in C Minor, Op. 67", Movement: "Andante con moto", Number: 2, Total: 4}, wantOK: true},
+		{name: "movement name with a period", track: 2, splitTitles: true, want: trackWork{Work: "Symphony No. 5 in C Minor, Op. 67", Movement: "Scherzo. Allegro", Number: 3, Total: 4}, wantOK: true},
+		{name: "work field with prefixed title", track: 4, splitTitles: true, want: trackWork{Work: "Cello Suite No. 1 in G Major, BWV 1007", Movement: "Prélude", Number: 1, Total: 2}, wantOK: true},
+		{name: "work field positions unnumbered movements", track: 5, splitTitles: true, want: trackWork{Work: "Cello Suite No. 1 in G Major, BWV 1007", Movement: "Allemande", Number: 2, Total: 2}, wantOK: true},
+		{name: "not classical", track: 6, splitTitles: true},
+		{name: "splitting disabled", track: 0},
+		{name: "work field without splitting", track: 5, want: trackWork{Work: "Cello Suite No. 1 in G Major, BWV 1007", Movement: "Allemande", Number: 2, Total: 2}, wantOK: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tagger := NewTagger()
+			tagger.SplitWorkTitles = tt.splitTitles
+			got, ok := tagger.workFor(&album.Tracks.Items[tt.track], album)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("workFor() = %+v, %v; want %+v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+// workTags returns the work and movement tags of a FLAC or MP3 file.
+func workTags(t *testing.T, path string) map[string]string {
+	t.Helper()
+	tags := make(map[string]string)
+	if filepath.Ext(path) == ".mp3" {
+		tag, err := id3v2.Open(path, id3v2.Options{Parse: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tag.Close()
+		for _, id := range []string{"MVNM", "MVIN"} {
+			for _, f := range tag.GetFrames(id) {
+				switch f := f.(type) {
+				case id3v2.TextFrame:
+					tags[id] = f.Text
+				case id3v2.UnknownFrame:
+					tags[id] = strings.TrimRight(string(f.Body[1:]), "\x00") // After the encoding byte
+				}
+			}
+		}
+		for _, f := range tag.GetFrames("TXXX") {
+			if udf, ok := f.(id3v2.UserDefinedTextFrame); ok && udf.Description == "WORK" {
+				tags["WORK"] = udf.Value
+			}
+		}
+		return tags
+	}
+
+	f, err := flac.ParseFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, block := range f.Meta {
+		if block.Type != flac.VorbisComment {
+			continue
+		}
+		cmts, err := ParseVorbisComment(block.Data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, key := range []string{"WORK", "MOVEMENT", "MOVEMENTNUMBER", "MOVEMENTTOTAL"} {
+			if v := cmts.Get(key); v != "" {
+				tags[key] = v
+			}
+		}
+	}
+	return tags
+}
+
+func TestWriteTagsWork(t *testing.T) {
+	album := classicalAlbum()
+	symphony := "Symphony No. 5 in C Minor, Op. 67"
+	tests := []struct {
+		name      string
+		file      string
+		data      []byte
+		track     int
+		writeWork bool
+		want      map[string]string
+	}{
+		{
+			name: "flac", file: "track.flac", data: buildTestFLAC(2, 64), track: 2, writeWork: true,
+			want: map[string]string{"WORK": symphony, "MOVEMENT": "Scherzo. Allegro", "MOVEMENTNUMBER": "3", "MOVEMENTTOTAL": "4"},
+		},
+		{
+			name: "mp3", file: "track.mp3", data: testMP3, track: 2, writeWork: true,
+			want: map[string]string{"WORK": symphony, "MVNM": "Scherzo. Allegro", "MVIN": "3/4"},
+		},
+		{name: "not classical", file: "track.flac", data: buildTestFLAC(2, 64), track: 6, writeWork: true, want: map[string]string{}},
+		{name: "disabled", file: "track.mp3", data: testMP3, track: 2, want: map[string]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, tt.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			tagger := NewTagger()
+			tagger.WriteWork = tt.writeWork
+			track := album.Tracks.Items[tt.track]
+			if err := tagger.WriteTags(path, &track, album, nil); err != nil {
+				t.Fatalf("WriteTags: %v", err)
+			}
+			if got := workTags(t, path); !maps.Equal(got, tt.want) {
+				t.Errorf("work tags = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
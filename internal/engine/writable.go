@@ -0,0 +1,31 @@
+// writable.go checks output directories before anything is downloaded, so a
+// read-only or full destination fails once with a clear error instead of
+// failing every track deep inside the workers.
+package engine
+
+import (
+	"fmt"
+	"os"
+)
+
+// ensureWritable creates dir if needed and writes and removes a probe file in it.
+// The returned error wraps the underlying one, so errors.Is(err, fs.ErrPermission) works.
+func ensureWritable(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("cannot create output directory %s: %w", dir, err)
+	}
+	probe, err := os.CreateTemp(dir, ".qobuz-dl-write-test-*")
+	if err != nil {
+		return fmt.Errorf("output directory %s is not writable: %w", dir, err)
+	}
+	name := probe.Name()
+	_, err = probe.Write([]byte{0})
+	if closeErr := probe.Close(); err == nil {
+		err = closeErr
+	}
+	os.Remove(name)
+	if err != nil {
+		return fmt.Errorf("output directory %s is not writable: %w", dir, err)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// readOnlyDir returns a directory that cannot be written to, skipping the test
+// where permissions don't apply.
+func readOnlyDir(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("directory permissions are not enforced on Windows")
+	}
+	if os.Geteuid() == 0 {
+		t.Skip("root ignores directory permissions")
+	}
+	dir := t.TempDir()
+	if err := os.Chmod(dir, 0555); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(dir, 0755) })
+	return dir
+}
+
+func TestEnsureWritable(t *testing.T) {
+	tests := []struct {
+		name     string
+		dir      func(t *testing.T) string
+		wantErr  bool
+		wantPerm bool // The error is a permission error
+	}{
+		{name: "existing directory", dir: func(t *testing.T) string { return t.TempDir() }},
+		{name: "created on demand", dir: func(t *testing.T) string { return filepath.Join(t.TempDir(), "a", "b") }},
+		{name: "read-only directory", dir: readOnlyDir, wantErr: true, wantPerm: true},
+		{name: "read-only parent", dir: func(t *testing.T) string { return filepath.Join(readOnlyDir(t), "album") }, wantErr: true, wantPerm: true},
+		{name: "path under a file", dir: func(t *testing.T) string {
+			file := filepath.Join(t.TempDir(), "file")
+			if err := os.WriteFile(file, nil, 0644); err != nil {
+				t.Fatal(err)
+			}
+			return filepath.Join(file, "album")
+		}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := tt.dir(t)
+			err := ensureWritable(dir)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ensureWritable() = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantPerm && !errors.Is(err, fs.ErrPermission) {
+				t.Errorf("ensureWritable() = %v, want a permission error", err)
+			}
+			if err != nil {
+				return
+			}
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 0 {
+				t.Errorf("probe file left behind: %v", entries)
+			}
+		})
+	}
+}
+
+func TestDownloadUnwritableOutput(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		outputDir func(t *testing.T) string
+	}{
+		{name: "under a file", outputDir: func(t *testing.T) string { return filepath.Join(file, "music") }},
+		{name: "read-only", outputDir: readOnlyDir},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputDir := tt.outputDir(t)
+			fake := newFakeQobuz(t)
+			fake.addAlbum("alb1", "Album", "Band", 100, 2)
+			e := fake.engine()
+
+			if _, err := e.DownloadAlbum(context.Background(), "alb1", 6, outputDir); err == nil {
+				t.Error("DownloadAlbum succeeded into an unwritable directory")
+			}
+			if err := e.DownloadTrack(context.Background(), "100", 6, outputDir, nil); err == nil {
+				t.Error("DownloadTrack succeeded into an unwritable directory")
+			}
+			for _, path := range []string{"/album/get", "/track/get"} {
+				if n := fake.count(path); n != 0 {
+					t.Errorf("%d requests to %s before failing", n, path)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+// zip.go streams a whole album as a ZIP archive.
+// Tracks are downloaded and tagged one at a time and written to the archive
+// as soon as they complete, so the album is never held in memory.
+package engine
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+)
+
+// zipFailureManifest is the archive entry listing tracks that could not be downloaded.
+const zipFailureManifest = "FAILED.txt"
+
+// ZipFileName returns the download file name for an album archive.
+func (p *AlbumPlan) ZipFileName() string {
+	return filepath.Base(p.AlbumDir) + ".zip"
+}
+
+// WriteAlbumZip downloads and tags every track of plan and streams them into a ZIP
+// archive written to w. Tracks that fail are listed in a FAILED.txt entry instead of
+// aborting the archive. An error is returned only if writing the archive itself fails.
+func (e *Engine) WriteAlbumZip(ctx context.Context, plan *AlbumPlan, quality int, w io.Writer) (*AlbumResult, error) {
+	album := plan.Album
+	folder := filepath.Base(plan.AlbumDir)
+	result := &AlbumResult{AlbumID: album.ID, Title: album.Title, AlbumDir: folder}
+
+	tmpDir, err := os.MkdirTemp("", "qobuz-dl-zip-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	zw := zip.NewWriter(w)
+
+	var coverData []byte
+	if album.Image.Large != "" {
+		if coverData, err = e.downloadCover(album.Image.Large); err == nil {
+			for _, name := range e.coverFilenames() {
+				if err := writeZipEntry(zw, path.Join(folder, name), bytes.NewReader(coverData)); err != nil {
+					return result, err
+				}
+			}
+		}
+	}
+
+	for _, planned := range plan.Tracks {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		track := planned.Track
+		tr := TrackResult{Title: track.Title}
+
+		tmpPath, entryName, formatID, err := e.downloadTaggedTrack(ctx, tmpDir, album, planned, quality, coverData)
+		if err != nil {
+			tr.Err = err
+			result.Failed = append(result.Failed, tr)
+			continue
+		}
+		tr.FormatID = formatID
+		tr.Path = path.Join(folder, entryName)
+
+		f, err := os.Open(tmpPath)
+		if err != nil {
+			tr.Err = err
+			result.Failed = append(result.Failed, tr)
+			continue
+		}
+		err = writeZipEntry(zw, tr.Path, f)
+		f.Close()
+		os.Remove(tmpPath)
+		if err != nil {
+			return result, err // The archive stream is broken
+		}
+		result.Success = append(result.Success, tr)
+	}
+
+	if len(result.Failed) > 0 {
+		var b strings.Builder
+		b.WriteString("The following tracks could not be downloaded:\n\n")
+		for _, t := range result.Failed {
+			fmt.Fprintf(&b, "%s: %v\n", t.Title, t.Err)
+		}
+		if err := writeZipEntry(zw, path.Join(folder, zipFailureManifest), strings.NewReader(b.String())); err != nil {
+			return result, err
+		}
+	}
+
+	return result, zw.Close()
+}
+
+// downloadTaggedTrack downloads and tags a planned track into dir.
+// Returns the temp file path, the archive entry name and the delivered format ID.
+func (e *Engine) downloadTaggedTrack(ctx context.Context, dir string, album *api.AlbumMetadata, planned PlannedTrack, quality int, coverData []byte) (string, string, int, error) {
+	track := planned.Track
+	info, formatID, err := e.trackURL(ctx, strconv.Itoa(track.ID), e.trackQuality(quality, &track))
+	if err != nil {
+		return "", "", 0, err
+	}
+
+	ext, container, _ := e.outputExtension(info.MimeType)
+	tmpPath := filepath.Join(dir, planned.BaseName+ext)
+	release, err := e.acquireDownload(ctx)
+	if err != nil {
+		return "", "", 0, err
+	}
+	err = e.downloadFile(ctx, info.URL, tmpPath, nil)
+	release()
+	if err != nil {
+		return "", "", 0, err
+	}
+
+	// Tagging failures keep the audio, as for regular downloads
+	_ = e.Tagger.WriteTagsAs(tmpPath, container, &track, album, coverData)
+
+	return tmpPath, planned.BaseName + ext, formatID, nil
+}
+
+// writeZipEntry stores r as an uncompressed archive entry (audio and JPEG do not compress).
+func writeZipEntry(zw *zip.Writer, name string, r io.Reader) error {
+	entry, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Store,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(entry, r)
+	return err
+}
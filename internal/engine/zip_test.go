@@ -0,0 +1,138 @@
+package engine
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// readZip returns the entries of a ZIP archive by name.
+func readZip(t *testing.T, data []byte) map[string][]byte {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("invalid archive: %v", err)
+	}
+	entries := make(map[string][]byte)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("entry %s: %v", f.Name, err)
+		}
+		entries[f.Name] = content
+	}
+	return entries
+}
+
+func TestWriteAlbumZip(t *testing.T) {
+	tests := []struct {
+		name        string
+		unavailable []int // Track IDs without a file URL
+		wantEntries []string
+		wantFailed  []string // Titles listed in FAILED.txt
+	}{
+		{
+			name:        "complete album",
+			wantEntries: []string{"Band - Album/01. Track 1.flac", "Band - Album/02. Track 2.flac", "Band - Album/03. Track 3.flac", "Band - Album/cover.jpg"},
+		},
+		{
+			name:        "failed track",
+			unavailable: []int{101},
+			wantEntries: []string{"Band - Album/01. Track 1.flac", "Band - Album/03. Track 3.flac", "Band - Album/FAILED.txt", "Band - Album/cover.jpg"},
+			wantFailed:  []string{"Track 2"},
+		},
+		{
+			name:        "every track failed",
+			unavailable: []int{100, 101, 102},
+			wantEntries: []string{"Band - Album/FAILED.txt", "Band - Album/cover.jpg"},
+			wantFailed:  []string{"Track 1", "Track 2", "Track 3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeQobuz(t)
+			fake.addAlbum("alb1", "Album", "Band", 100, 3)
+			for _, id := range tt.unavailable {
+				fake.unavailable[id] = true
+			}
+			e := fake.engine()
+			plan, err := e.PlanAlbum("alb1", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := plan.ZipFileName(); got != "Band - Album.zip" {
+				t.Errorf("ZipFileName() = %q", got)
+			}
+
+			var buf bytes.Buffer
+			result, err := e.WriteAlbumZip(context.Background(), plan, 6, &buf)
+			if err != nil {
+				t.Fatalf("WriteAlbumZip: %v", err)
+			}
+			entries := readZip(t, buf.Bytes())
+
+			var names []string
+			for name := range entries {
+				names = append(names, name)
+			}
+			slices.Sort(names)
+			if !slices.Equal(names, tt.wantEntries) {
+				t.Errorf("entries = %q, want %q", names, tt.wantEntries)
+			}
+			if !bytes.Equal(entries["Band - Album/cover.jpg"], fake.cover) {
+				t.Error("cover entry does not hold the album cover")
+			}
+			// Tracks are stored tagged, with the cover embedded
+			for _, track := range result.Success {
+				path := filepath.Join(t.TempDir(), "track.flac")
+				if err := os.WriteFile(path, entries[track.Path], 0644); err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(frontCover(t, path), fake.cover) {
+					t.Errorf("%s is not tagged with the cover", track.Path)
+				}
+			}
+
+			if len(result.Failed) != len(tt.wantFailed) {
+				t.Fatalf("failed tracks = %+v, want %q", result.Failed, tt.wantFailed)
+			}
+			manifest := string(entries["Band - Album/FAILED.txt"])
+			for _, title := range tt.wantFailed {
+				if !strings.Contains(manifest, title+": ") {
+					t.Errorf("FAILED.txt does not list %s:\n%s", title, manifest)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteAlbumZipCancelled(t *testing.T) {
+	fake := newFakeQobuz(t)
+	fake.addAlbum("alb1", "Album", "Band", 100, 3)
+	e := fake.engine()
+	plan, err := e.PlanAlbum("alb1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := e.WriteAlbumZip(ctx, plan, 6, io.Discard); !errors.Is(err, context.Canceled) {
+		t.Errorf("WriteAlbumZip() = %v, want %v", err, context.Canceled)
+	}
+	if n := fake.count("/track/getFileUrl"); n != 0 {
+		t.Errorf("%d tracks requested after cancelling", n)
+	}
+}
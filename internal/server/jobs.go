@@ -0,0 +1,216 @@
+// jobs.go provides the registry of background download jobs started through the server.
+// Each job runs with its own context so it can be cancelled while queued or running.
+package server
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/engine"
+)
+
+// Job states reported by the jobs endpoints.
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobCompleted = "completed"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+// Registry errors, mapped to 404 and 409 by the handlers.
+var (
+	errJobNotFound = errors.New("job not found")
+	errJobFinished = errors.New("job already finished")
+)
+
+// JobStatus is a snapshot of a download job.
+type JobStatus struct {
+	ID       string     `json:"id"`
+	Type     string     `json:"type"`
+	Resource string     `json:"resource"`
+	State    string     `json:"state"`
+	Progress int        `json:"progress"`         // Percent, for single tracks; 100 once an album finishes
+	Tracks   *JobTracks `json:"tracks,omitempty"` // Track outcome of album jobs
+	Error    string     `json:"error,omitempty"`  // Failure reason
+	Created  time.Time  `json:"created"`
+	Started  *time.Time `json:"started,omitempty"`
+	Finished *time.Time `json:"finished,omitempty"`
+}
+
+// JobTracks counts the track outcomes of an album job.
+type JobTracks struct {
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	Skipped   int `json:"skipped"`
+}
+
+// jobRunner runs a download. It may report progress and album results through the job.
+type jobRunner func(ctx context.Context, j *job) error
+
+// job is a registered download job.
+type job struct {
+	mu     sync.Mutex
+	status JobStatus
+	cancel context.CancelFunc
+}
+
+// setProgress records the download progress in percent.
+func (j *job) setProgress(percent int) {
+	j.mu.Lock()
+	j.status.Progress = percent
+	j.mu.Unlock()
+}
+
+// setAlbumResult records the track outcome of an album download.
+func (j *job) setAlbumResult(result *engine.AlbumResult) {
+	j.mu.Lock()
+	j.status.Tracks = &JobTracks{
+		Succeeded: len(result.Success),
+		Failed:    len(result.Failed),
+		Skipped:   len(result.Skipped),
+	}
+	j.mu.Unlock()
+}
+
+// snapshot returns a copy of the job status.
+func (j *job) snapshot() JobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	status := j.status
+	if status.Tracks != nil {
+		tracks := *status.Tracks
+		status.Tracks = &tracks
+	}
+	return status
+}
+
+// finished reports whether the job reached a final state.
+func (j *job) finished() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.status.State == JobCompleted || j.status.State == JobFailed || j.status.State == JobCancelled
+}
+
+// jobRegistry tracks download jobs and runs them a limited number at a time.
+type jobRegistry struct {
+	mu    sync.Mutex
+	jobs  map[string]*job
+	order []string      // Job IDs in submission order
+	next  int           // Last assigned job ID
+	slots chan struct{} // Running job slots
+}
+
+// newJobRegistry creates a registry running at most concurrent jobs at once.
+func newJobRegistry(concurrent int) *jobRegistry {
+	if concurrent < 1 {
+		concurrent = 1
+	}
+	return &jobRegistry{
+		jobs:  make(map[string]*job),
+		slots: make(chan struct{}, concurrent),
+	}
+}
+
+// Submit registers a job and starts it in the background once a slot is free.
+func (r *jobRegistry) Submit(download engine.Job, run jobRunner) JobStatus {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	r.mu.Lock()
+	r.next++
+	j := &job{
+		status: JobStatus{
+			ID:       strconv.Itoa(r.next),
+			Type:     string(download.Type),
+			Resource: download.ID,
+			State:    JobQueued,
+			Created:  time.Now(),
+		},
+		cancel: cancel,
+	}
+	r.jobs[j.status.ID] = j
+	r.order = append(r.order, j.status.ID)
+	r.mu.Unlock()
+
+	go r.run(ctx, j, run)
+	return j.snapshot()
+}
+
+// run waits for a slot, runs the job and records its final state.
+func (r *jobRegistry) run(ctx context.Context, j *job, run jobRunner) {
+	defer j.cancel()
+
+	select {
+	case r.slots <- struct{}{}:
+		defer func() { <-r.slots }()
+	case <-ctx.Done():
+		r.finish(j, ctx.Err())
+		return
+	}
+
+	now := time.Now()
+	j.mu.Lock()
+	j.status.State = JobRunning
+	j.status.Started = &now
+	j.mu.Unlock()
+
+	err := run(ctx, j)
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	r.finish(j, err)
+}
+
+// finish records the final state of a job.
+func (r *jobRegistry) finish(j *job, err error) {
+	now := time.Now()
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.status.Finished = &now
+	switch {
+	case errors.Is(err, context.Canceled):
+		j.status.State = JobCancelled
+	case err != nil:
+		j.status.State = JobFailed
+		j.status.Error = err.Error()
+	default:
+		j.status.State = JobCompleted
+		j.status.Progress = 100
+	}
+}
+
+// List returns snapshots of all jobs in submission order.
+func (r *jobRegistry) List() []JobStatus {
+	r.mu.Lock()
+	jobs := make([]*job, 0, len(r.order))
+	for _, id := range r.order {
+		jobs = append(jobs, r.jobs[id])
+	}
+	r.mu.Unlock()
+
+	statuses := make([]JobStatus, 0, len(jobs))
+	for _, j := range jobs {
+		statuses = append(statuses, j.snapshot())
+	}
+	return statuses
+}
+
+// Cancel cancels a queued or running job.
+// Returns errJobNotFound for unknown IDs and errJobFinished for finished jobs.
+func (r *jobRegistry) Cancel(id string) error {
+	r.mu.Lock()
+	j, ok := r.jobs[id]
+	r.mu.Unlock()
+	if !ok {
+		return errJobNotFound
+	}
+	if j.finished() {
+		return errJobFinished
+	}
+	j.cancel()
+	return nil
+}
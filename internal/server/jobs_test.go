@@ -0,0 +1,176 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/engine"
+)
+
+var testDownload = engine.Job{Type: api.TypeAlbum, ID: "abc"}
+
+// waitState waits until job id reaches state and returns its status.
+func waitState(t *testing.T, r *jobRegistry, id, state string) JobStatus {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		for _, status := range r.List() {
+			if status.ID == id && status.State == state {
+				return status
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s never reached %q: %+v", id, state, r.List())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// blockUntilCancelled is a runner that only returns once its job is cancelled.
+func blockUntilCancelled(ctx context.Context, j *job) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestJobRegistryFinalState(t *testing.T) {
+	tests := []struct {
+		name         string
+		run          jobRunner
+		wantState    string
+		wantError    string
+		wantProgress int
+		wantTracks   *JobTracks
+	}{
+		{
+			name:      "completed",
+			run:       func(ctx context.Context, j *job) error { j.setProgress(40); return nil },
+			wantState: JobCompleted, wantProgress: 100,
+		},
+		{
+			name: "album result recorded",
+			run: func(ctx context.Context, j *job) error {
+				j.setAlbumResult(&engine.AlbumResult{Success: make([]engine.TrackResult, 3), Skipped: make([]engine.TrackResult, 1)})
+				return nil
+			},
+			wantState: JobCompleted, wantProgress: 100, wantTracks: &JobTracks{Succeeded: 3, Skipped: 1},
+		},
+		{
+			name:      "failed",
+			run:       func(ctx context.Context, j *job) error { j.setProgress(40); return errors.New("no space left") },
+			wantState: JobFailed, wantError: "no space left", wantProgress: 40,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newJobRegistry(1)
+			submitted := r.Submit(testDownload, tt.run)
+			if submitted.Type != "album" || submitted.Resource != "abc" {
+				t.Errorf("submitted job = %+v", submitted)
+			}
+
+			status := waitState(t, r, submitted.ID, tt.wantState)
+			if status.Error != tt.wantError || status.Progress != tt.wantProgress {
+				t.Errorf("error = %q, progress = %d; want %q, %d", status.Error, status.Progress, tt.wantError, tt.wantProgress)
+			}
+			if (status.Tracks == nil) != (tt.wantTracks == nil) || (status.Tracks != nil && *status.Tracks != *tt.wantTracks) {
+				t.Errorf("tracks = %+v, want %+v", status.Tracks, tt.wantTracks)
+			}
+			if status.Started == nil || status.Finished == nil {
+				t.Errorf("started = %v, finished = %v; want both set", status.Started, status.Finished)
+			}
+		})
+	}
+}
+
+func TestJobRegistryCancel(t *testing.T) {
+	r := newJobRegistry(1)
+	running := r.Submit(testDownload, blockUntilCancelled)
+	waitState(t, r, running.ID, JobRunning)
+
+	queuedRan := make(chan struct{})
+	queued := r.Submit(testDownload, func(ctx context.Context, j *job) error {
+		close(queuedRan)
+		return nil
+	})
+	if queued.State != JobQueued {
+		t.Fatalf("second job state = %q, want %q while the only slot is busy", queued.State, JobQueued)
+	}
+
+	done := r.Submit(engine.Job{Type: api.TypeTrack, ID: "1"}, blockUntilCancelled)
+	if err := r.Cancel(done.ID); err != nil {
+		t.Fatal(err)
+	}
+	waitState(t, r, done.ID, JobCancelled)
+
+	tests := []struct {
+		name      string
+		id        string
+		wantErr   error
+		wantState string
+	}{
+		{name: "unknown job", id: "999", wantErr: errJobNotFound},
+		{name: "queued job", id: queued.ID, wantState: JobCancelled},
+		{name: "running job", id: running.ID, wantState: JobCancelled},
+		{name: "finished job", id: done.ID, wantErr: errJobFinished, wantState: JobCancelled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := r.Cancel(tt.id); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Cancel(%q) = %v, want %v", tt.id, err, tt.wantErr)
+			}
+			if tt.wantState != "" {
+				waitState(t, r, tt.id, tt.wantState)
+			}
+		})
+	}
+
+	select {
+	case <-queuedRan:
+		t.Error("cancelled queued job ran")
+	default:
+	}
+}
+
+func TestJobRegistryList(t *testing.T) {
+	r := newJobRegistry(1)
+	ids := []string{r.Submit(testDownload, blockUntilCancelled).ID}
+	waitState(t, r, ids[0], JobRunning)
+	for range 2 {
+		ids = append(ids, r.Submit(testDownload, blockUntilCancelled).ID)
+	}
+
+	list := r.List()
+	wantStates := []string{JobRunning, JobQueued, JobQueued}
+	if len(list) != len(ids) {
+		t.Fatalf("List() has %d jobs, want %d", len(list), len(ids))
+	}
+	for i, status := range list {
+		if status.ID != ids[i] || status.State != wantStates[i] {
+			t.Errorf("job %d = %s %q, want %s %q", i, status.ID, status.State, ids[i], wantStates[i])
+		}
+	}
+
+	// Cancelling the running job frees its slot for one of the queued jobs
+	if err := r.Cancel(ids[0]); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for running := 0; running != 1; {
+		if time.Now().After(deadline) {
+			t.Fatalf("no queued job started after cancelling the running one: %+v", r.List())
+		}
+		time.Sleep(time.Millisecond)
+		running = 0
+		for _, status := range r.List() {
+			if status.State == JobRunning {
+				running++
+			}
+		}
+	}
+	for _, id := range ids[1:] {
+		r.Cancel(id)
+	}
+}
@@ -3,10 +3,14 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
-	"strconv"
+	"net/url"
+	"strings"
 
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
 	"github.com/WenqiOfficial/qobuz-dl-go/internal/engine"
 
 	"github.com/labstack/echo/v4"
@@ -14,8 +18,15 @@ import (
 )
 
 // Start initializes and starts the web server on the specified port.
-// It provides endpoints for health checks and audio streaming.
-func Start(eng *engine.Engine, port string) {
+// It provides endpoints for health checks, audio streaming and background
+// download jobs saved under outputDir.
+func Start(eng *engine.Engine, port, outputDir string) {
+	e := newServer(eng, outputDir)
+	e.Logger.Fatal(e.Start(":" + port))
+}
+
+// newServer creates the Echo instance with every route registered.
+func newServer(eng *engine.Engine, outputDir string) *echo.Echo {
 	e := echo.New()
 	e.HideBanner = true
 
@@ -32,13 +43,29 @@ func Start(eng *engine.Engine, port string) {
 		qualityStr := c.QueryParam("quality")
 		quality := 6
 		if qualityStr != "" {
-			if q, err := strconv.Atoi(qualityStr); err == nil {
+			if q, err := engine.ParseQuality(qualityStr); err == nil {
 				quality = q
 			}
 		}
 
-		// Stream track - headers will be set based on actual response
-		streamInfo, err := eng.StreamTrack(c.Request().Context(), trackID, quality, c.Response().Writer, nil)
+		// Optional transcoding for browsers without FLAC support (default: passthrough)
+		format := c.QueryParam("format")
+		var streamInfo *engine.StreamInfo
+		var err error
+		if format != "" {
+			mimeType, mimeErr := engine.TranscodeMimeType(format)
+			if mimeErr != nil {
+				return c.String(http.StatusBadRequest, mimeErr.Error())
+			}
+			if _, ffErr := engine.FFmpegPath(); ffErr != nil {
+				return c.String(http.StatusServiceUnavailable, fmt.Sprintf("Transcoding unavailable: %v", ffErr))
+			}
+			c.Response().Header().Set(echo.HeaderContentType, mimeType)
+			streamInfo, err = eng.StreamTrackTranscoded(c.Request().Context(), trackID, quality, format, c.Response().Writer)
+		} else {
+			// Stream track - headers will be set based on actual response
+			streamInfo, err = eng.StreamTrack(c.Request().Context(), trackID, quality, c.Response().Writer, nil)
+		}
 		if err != nil {
 			// If streaming failed before any data was sent, return error
 			if streamInfo == nil {
@@ -52,5 +79,114 @@ func Start(eng *engine.Engine, port string) {
 		return nil
 	})
 
-	e.Logger.Fatal(e.Start(":" + port))
+	e.GET("/album/:albumID/zip", func(c echo.Context) error {
+		quality := 6
+		if q, err := engine.ParseQuality(c.QueryParam("quality")); err == nil {
+			quality = q
+		}
+
+		plan, err := eng.PlanAlbum(c.Param("albumID"), "")
+		if err != nil {
+			return c.String(http.StatusNotFound, fmt.Sprintf("Album error: %v", err))
+		}
+
+		// Entries are streamed as tracks complete, so no Content-Length is known
+		res := c.Response()
+		res.Header().Set(echo.HeaderContentType, "application/zip")
+		res.Header().Set(echo.HeaderContentDisposition, contentDisposition(plan.ZipFileName()))
+		res.WriteHeader(http.StatusOK)
+
+		result, err := eng.WriteAlbumZip(c.Request().Context(), plan, quality, res.Writer)
+		if err != nil {
+			// Headers are already sent; the client receives a truncated archive
+			fmt.Printf("Album zip error: %v\n", err)
+			return nil
+		}
+		if len(result.Failed) > 0 {
+			fmt.Printf("Album zip %s: %d tracks failed\n", plan.Album.ID, len(result.Failed))
+		}
+		return nil
+	})
+
+	jobs := newJobRegistry(1)
+
+	e.POST("/jobs", func(c echo.Context) error {
+		var body struct {
+			URL     string `json:"url"`     // Qobuz URL or track ID
+			Quality string `json:"quality"` // Quality ID or "best" (default 6)
+		}
+		if err := c.Bind(&body); err != nil || body.URL == "" {
+			return c.String(http.StatusBadRequest, "Request body must contain a url")
+		}
+		quality := 6
+		if body.Quality != "" {
+			q, err := engine.ParseQuality(body.Quality)
+			if err != nil {
+				return c.String(http.StatusBadRequest, err.Error())
+			}
+			quality = q
+		}
+
+		download := engine.ParseJob(body.URL)
+		status := jobs.Submit(download, func(ctx context.Context, j *job) error {
+			return runDownload(ctx, eng, download, quality, outputDir, j)
+		})
+		return c.JSON(http.StatusAccepted, status)
+	})
+
+	e.GET("/jobs", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, jobs.List())
+	})
+
+	e.DELETE("/jobs/:id", func(c echo.Context) error {
+		switch err := jobs.Cancel(c.Param("id")); {
+		case errors.Is(err, errJobNotFound):
+			return c.String(http.StatusNotFound, err.Error())
+		case errors.Is(err, errJobFinished):
+			return c.String(http.StatusConflict, err.Error())
+		}
+		return c.NoContent(http.StatusAccepted)
+	})
+
+	return e
+}
+
+// runDownload performs the download of a job, reporting progress and album results.
+func runDownload(ctx context.Context, eng *engine.Engine, download engine.Job, quality int, outputDir string, j *job) error {
+	switch download.Type {
+	case api.TypeAlbum:
+		result, err := eng.DownloadAlbum(ctx, download.ID, quality, outputDir)
+		if err != nil {
+			return err
+		}
+		j.setAlbumResult(result)
+		if len(result.Failed) > 0 {
+			return fmt.Errorf("%d of %d tracks failed", len(result.Failed), len(result.Success)+len(result.Failed)+len(result.Skipped))
+		}
+		return nil
+	case api.TypeTrack:
+		return eng.DownloadTrack(ctx, download.ID, quality, outputDir, func(current, total int64) {
+			if total > 0 {
+				j.setProgress(int(current * 100 / total))
+			}
+		})
+	case api.TypeArtist:
+		return eng.DownloadArtist(ctx, download.ID, quality, outputDir)
+	case api.TypePlaylist:
+		return eng.DownloadPlaylist(ctx, download.ID, quality, outputDir)
+	default:
+		return fmt.Errorf("unsupported resource type: %s", download.Type)
+	}
+}
+
+// contentDisposition builds an attachment header with an ASCII fallback name
+// and the UTF-8 file name per RFC 6266.
+func contentDisposition(filename string) string {
+	fallback := strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7E || r == '"' || r == '\\' {
+			return '_'
+		}
+		return r
+	}, filename)
+	return fmt.Sprintf(`attachment; filename="%s"; filename*=UTF-8''%s`, fallback, url.PathEscape(filename))
 }
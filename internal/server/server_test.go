@@ -0,0 +1,260 @@
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/api"
+	"github.com/WenqiOfficial/qobuz-dl-go/internal/engine"
+)
+
+// testAudio is the audio served for every track file.
+var testAudio = []byte("fLaC\x00\x00\x00\x22 not really audio")
+
+// fakeQobuz serves track URLs and files for the tracks in audio; track URLs of
+// other tracks fail as Qobuz does for unknown tracks.
+type fakeQobuz struct {
+	srv   *httptest.Server
+	mu    sync.Mutex
+	audio map[string][]byte // Track ID -> file content
+	// Format IDs requested from track/getFileUrl, in order
+	formats []string
+	// Extra handlers by path, for metadata endpoints
+	routes map[string]http.HandlerFunc
+}
+
+func newFakeQobuz(t *testing.T) *fakeQobuz {
+	t.Helper()
+	f := &fakeQobuz{audio: map[string][]byte{"1": testAudio}, routes: map[string]http.HandlerFunc{}}
+	f.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if route, ok := f.routes[r.URL.Path]; ok {
+			route(w, r)
+			return
+		}
+		switch {
+		case r.URL.Path == "/track/getFileUrl":
+			f.mu.Lock()
+			f.formats = append(f.formats, q.Get("format_id"))
+			_, ok := f.audio[q.Get("track_id")]
+			f.mu.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"status":"error","code":404,"message":"No result matching given argument"}`))
+				return
+			}
+			fmt.Fprintf(w, `{"url":"%s/file/%s.flac","mime_type":"audio/flac","format_id":%s}`, f.srv.URL, q.Get("track_id"), q.Get("format_id"))
+		case strings.HasPrefix(r.URL.Path, "/file/"):
+			f.mu.Lock()
+			data, ok := f.audio[strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/file/"), ".flac")]
+			f.mu.Unlock()
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write(data)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+// server starts the web server on an engine using the fake API.
+func (f *fakeQobuz) server(t *testing.T) *httptest.Server {
+	t.Helper()
+	client := api.NewClient("app", "secret")
+	client.HTTP.SetBaseURL(f.srv.URL)
+	client.SetUserToken("token")
+	srv := httptest.NewServer(newServer(engine.New(client), t.TempDir()))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// fakeFFmpeg puts an ffmpeg shell script running body first in PATH.
+func fakeFFmpeg(t *testing.T, body string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake encoder is a shell script")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "ffmpeg"), []byte("#!/bin/sh\n"+body+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestStreamRoute(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		ffmpeg      string // Fake ffmpeg script body, "" for no ffmpeg at all
+		wantStatus  int
+		wantType    string
+		wantBody    string
+		wantFormats []string // Format IDs requested for the track URL
+	}{
+		{
+			name: "original audio", path: "/stream/1",
+			wantStatus: http.StatusOK, wantBody: string(testAudio), wantFormats: []string{"6"},
+		},
+		{
+			name: "quality", path: "/stream/1?quality=27",
+			wantStatus: http.StatusOK, wantBody: string(testAudio), wantFormats: []string{"27"},
+		},
+		{
+			name: "invalid quality falls back to CD", path: "/stream/1?quality=lossless",
+			wantStatus: http.StatusOK, wantBody: string(testAudio), wantFormats: []string{"6"},
+		},
+		{
+			name: "transcoded", path: "/stream/1?format=mp3", ffmpeg: "printf 'MP3:'; exec cat",
+			wantStatus: http.StatusOK, wantType: "audio/mpeg", wantBody: "MP3:" + string(testAudio), wantFormats: []string{"6"},
+		},
+		{
+			name: "unsupported format", path: "/stream/1?format=wma",
+			wantStatus: http.StatusBadRequest, wantBody: "unsupported transcode format",
+		},
+		{
+			name: "ffmpeg missing", path: "/stream/1?format=opus",
+			wantStatus: http.StatusServiceUnavailable, wantBody: "Transcoding unavailable",
+		},
+		{
+			name: "unknown track", path: "/stream/2",
+			wantStatus: http.StatusInternalServerError, wantBody: "Stream error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.ffmpeg != "" {
+				fakeFFmpeg(t, tt.ffmpeg)
+			} else if _, err := engine.FFmpegPath(); err == nil {
+				t.Setenv("PATH", t.TempDir()) // Hide the real ffmpeg
+			}
+			fake := newFakeQobuz(t)
+			srv := fake.server(t)
+
+			resp, err := http.Get(srv.URL + tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+
+			if resp.StatusCode != tt.wantStatus || !strings.Contains(string(body), tt.wantBody) {
+				t.Errorf("GET %s = %d %q, want %d with %q", tt.path, resp.StatusCode, body, tt.wantStatus, tt.wantBody)
+			}
+			if tt.wantType != "" && resp.Header.Get("Content-Type") != tt.wantType {
+				t.Errorf("Content-Type = %q, want %q", resp.Header.Get("Content-Type"), tt.wantType)
+			}
+			fake.mu.Lock()
+			defer fake.mu.Unlock()
+			if tt.wantFormats != nil && !slices.Equal(fake.formats, tt.wantFormats) {
+				t.Errorf("track URLs requested for formats %q, want %q", fake.formats, tt.wantFormats)
+			}
+		})
+	}
+}
+
+func TestAlbumZipRoute(t *testing.T) {
+	fake := newFakeQobuz(t)
+	fake.audio["3"] = testAudio
+	cover := []byte("\xff\xd8\xff\xe0 cover")
+	fake.routes["/album/get"] = func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("album_id") != "alb1" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"status":"error","code":404,"message":"No result matching given argument"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id":"alb1","title":"Album","artist":{"name":"Band"},"image":{"large":"%s/covers/alb1.jpg"},
+			"tracks_count":3,"media_count":1,"tracks":{"total":3,"items":[
+			{"id":1,"title":"One","track_number":1,"media_number":1},
+			{"id":2,"title":"Two","track_number":2,"media_number":1},
+			{"id":3,"title":"Three","track_number":3,"media_number":1}]}}`, fake.srv.URL)
+	}
+	fake.routes["/covers/alb1.jpg"] = func(w http.ResponseWriter, r *http.Request) { w.Write(cover) }
+	srv := fake.server(t)
+
+	resp, err := http.Get(srv.URL + "/album/alb1/zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/zip" {
+		t.Fatalf("GET /album/alb1/zip = %d %s, want 200 application/zip", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	if got := resp.Header.Get("Content-Disposition"); !strings.Contains(got, `filename="Band - Album.zip"`) {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("invalid archive: %v", err)
+	}
+	entries := make(map[string]string)
+	var names []string
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("entry %s: %v", f.Name, err)
+		}
+		entries[f.Name] = string(content)
+		names = append(names, f.Name)
+	}
+	slices.Sort(names)
+	want := []string{"Band - Album/01. One.flac", "Band - Album/03. Three.flac", "Band - Album/FAILED.txt", "Band - Album/cover.jpg"}
+	if !slices.Equal(names, want) {
+		t.Fatalf("entries = %q, want %q", names, want)
+	}
+	if entries["Band - Album/cover.jpg"] != string(cover) {
+		t.Error("cover entry does not hold the album cover")
+	}
+	if !strings.HasPrefix(entries["Band - Album/01. One.flac"], "fLaC") {
+		t.Error("track entry does not hold the audio")
+	}
+	if manifest := entries["Band - Album/FAILED.txt"]; !strings.Contains(manifest, "Two: ") {
+		t.Errorf("FAILED.txt does not list the unknown track:\n%s", manifest)
+	}
+}
+
+func TestAlbumZipRouteUnknownAlbum(t *testing.T) {
+	fake := newFakeQobuz(t)
+	fake.routes["/album/get"] = func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"status":"error","code":404,"message":"No result matching given argument"}`))
+	}
+	srv := fake.server(t)
+
+	resp, err := http.Get(srv.URL + "/album/nope/zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusNotFound || !strings.Contains(string(body), "Album error") {
+		t.Errorf("GET /album/nope/zip = %d %q, want 404 with an album error", resp.StatusCode, body)
+	}
+}
@@ -0,0 +1,97 @@
+package updater
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxChecksumFileSize bounds the checksum list read from a release.
+const maxChecksumFileSize = 1 << 20
+
+// checksumAssetNames are release assets listing the SHA256 of the other assets,
+// in sha256sum format ("<hex>  <name>"). Our release workflow publishes
+// checksums-sha256.txt.
+var checksumAssetNames = []string{"checksums-sha256.txt", "checksums.txt", "sha256sums", "sha256sums.txt"}
+
+// AssetChecksum returns the expected SHA256 (hex) of asset. GitHub reports it as
+// the asset digest; older releases are looked up in a checksum list or a
+// "<asset>.sha256" file published with the release. An update is refused when
+// neither exists, since the archive could not be verified.
+func (r *ReleaseInfo) AssetChecksum(asset *Asset) (string, error) {
+	if sum, ok := strings.CutPrefix(asset.Digest, "sha256:"); ok && sum != "" {
+		return strings.ToLower(sum), nil
+	}
+
+	for _, a := range r.Assets {
+		name := strings.ToLower(a.Name)
+		isList := false
+		for _, n := range checksumAssetNames {
+			if name == n || strings.HasSuffix(name, "_"+n) || strings.HasSuffix(name, "-"+n) {
+				isList = true
+			}
+		}
+		if !isList && name != strings.ToLower(asset.Name)+".sha256" {
+			continue
+		}
+		sums, err := fetchChecksums(a.BrowserDownloadURL)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", a.Name, err)
+		}
+		if sum, ok := sums[asset.Name]; ok {
+			return sum, nil
+		}
+		if sum, ok := sums[""]; ok && !isList {
+			return sum, nil // A "<asset>.sha256" file holding only the hash
+		}
+	}
+	return "", fmt.Errorf("release publishes no SHA256 checksum for %s", asset.Name)
+}
+
+// fetchChecksums downloads a checksum list and returns the hashes by file name.
+// A line holding only a hash is stored under the empty name.
+func fetchChecksums(url string) (map[string]string, error) {
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download returned status %d", resp.StatusCode)
+	}
+	return parseChecksums(io.LimitReader(resp.Body, maxChecksumFileSize))
+}
+
+// parseChecksums parses sha256sum output ("<hex>  <name>" or "<hex> *<name>").
+func parseChecksums(r io.Reader) (map[string]string, error) {
+	sums := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || len(fields[0]) != sha256.Size*2 {
+			continue
+		}
+		if _, err := hex.DecodeString(fields[0]); err != nil {
+			continue
+		}
+		name := ""
+		if len(fields) > 1 {
+			name = strings.TrimPrefix(fields[1], "*")
+		}
+		sums[name] = strings.ToLower(fields[0])
+	}
+	return sums, scanner.Err()
+}
+
+// verifyChecksum reports an error if data does not hash to the hex SHA256 want.
+func verifyChecksum(data []byte, want string) error {
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return fmt.Errorf("checksum mismatch: got %s, expected %s", got, want)
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package updater
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// maxResumeAttempts is how many times an interrupted archive download is resumed
+// within a single update before giving up. The partial file is kept for the next run.
+const maxResumeAttempts = 3
+
+// partialDownloadDir returns the per-user directory holding partial downloads,
+// creating it with 0700 permissions. Unlike the shared temp directory, other
+// local users cannot plant or replace files in it.
+func partialDownloadDir() (string, error) {
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(cache, "qobuz-dl-go", "updates")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", dir)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		if err := os.Chmod(dir, 0700); err != nil {
+			return "", err
+		}
+	}
+	return dir, nil
+}
+
+// partialDownloadPath returns the file used to persist a partially downloaded asset.
+func partialDownloadPath(asset *Asset) (string, error) {
+	dir, err := partialDownloadDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, filepath.Base(asset.Name)+".part"), nil
+}
+
+// removePartialDownload deletes the partial download of asset, if any.
+func removePartialDownload(asset *Asset) {
+	if path, err := partialDownloadPath(asset); err == nil {
+		os.Remove(path)
+	}
+}
+
+// openPartial opens the partial download at path for writing. A new file is
+// created exclusively with 0600 permissions; an existing one is only reused if
+// it is a regular file, never a symlink or device.
+func openPartial(path string) (*os.File, error) {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("%s is not a regular file", path)
+	}
+	return os.OpenFile(path, os.O_WRONLY, 0600)
+}
+
+// downloadArchive downloads the release archive, resuming into a partial file when
+// possible. Falls back to an in-memory download if the partial file cannot be used.
+// The caller verifies the returned data against the release checksum.
+func downloadArchive(asset *Asset, progressFn func(current, total int64)) ([]byte, error) {
+	partPath, err := partialDownloadPath(asset)
+	if err != nil {
+		return downloadToMemory(asset, progressFn)
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < maxResumeAttempts; attempt++ {
+		lastErr = downloadToFile(asset, partPath, progressFn)
+		if lastErr == nil {
+			data, err := os.ReadFile(partPath)
+			if err != nil {
+				break
+			}
+			return data, nil
+		}
+		var fe *fileError
+		if errors.As(lastErr, &fe) {
+			break // Temp file unusable, resuming cannot help
+		}
+	}
+
+	var fe *fileError
+	if lastErr == nil || errors.As(lastErr, &fe) {
+		// Keep the in-memory path for systems without a writable temp directory
+		return downloadToMemory(asset, progressFn)
+	}
+	return nil, lastErr
+}
+
+// fileError marks local file failures, as opposed to network failures worth retrying.
+type fileError struct {
+	err error
+}
+
+func (e *fileError) Error() string { return e.err.Error() }
+func (e *fileError) Unwrap() error { return e.err }
+
+// downloadToFile downloads the asset into path, continuing from any bytes already
+// present via a Range request. The finished file is checked against asset.Size;
+// its content is verified against the release checksum by the caller.
+func downloadToFile(asset *Asset, path string, progressFn func(current, total int64)) error {
+	f, err := openPartial(path)
+	if err != nil {
+		return &fileError{err}
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return &fileError{err}
+	}
+	offset := info.Size()
+
+	// Discard stale data that cannot belong to this asset
+	if asset.Size > 0 && offset > asset.Size {
+		offset = 0
+	}
+	if asset.Size > 0 && offset == asset.Size {
+		if progressFn != nil {
+			progressFn(offset, asset.Size)
+		}
+		return nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, asset.BrowserDownloadURL, nil)
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		// Server honoured the range; append to existing data
+	case http.StatusOK:
+		offset = 0 // Range ignored, start over
+	default:
+		return fmt.Errorf("download returned status %d", resp.StatusCode)
+	}
+
+	if err := f.Truncate(offset); err != nil {
+		return &fileError{err}
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return &fileError{err}
+	}
+
+	written := offset
+	buf := make([]byte, 32*1024)
+	for {
+		n, readErr := resp.Body.Read(buf)
+		if n > 0 {
+			if _, err := f.Write(buf[:n]); err != nil {
+				return &fileError{err}
+			}
+			written += int64(n)
+			if progressFn != nil {
+				progressFn(written, asset.Size)
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return fmt.Errorf("download interrupted at %d bytes: %w", written, readErr)
+		}
+	}
+
+	if asset.Size > 0 && written != asset.Size {
+		return fmt.Errorf("downloaded size mismatch: got %d bytes, expected %d", written, asset.Size)
+	}
+
+	return nil
+}
+
+// downloadToMemory downloads the whole archive into memory (releases are small, ~6MB).
+func downloadToMemory(asset *Asset, progressFn func(current, total int64)) ([]byte, error) {
+	resp, err := httpClient.Get(asset.BrowserDownloadURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download returned status %d", resp.StatusCode)
+	}
+
+	var buf bytes.Buffer
+	if progressFn != nil {
+		var written int64
+		tmpBuf := make([]byte, 32*1024)
+		for {
+			n, readErr := resp.Body.Read(tmpBuf)
+			if n > 0 {
+				buf.Write(tmpBuf[:n])
+				written += int64(n)
+				progressFn(written, asset.Size)
+			}
+			if readErr == io.EOF {
+				break
+			}
+			if readErr != nil {
+				return nil, readErr
+			}
+		}
+	} else {
+		if _, err := io.Copy(&buf, resp.Body); err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+	}
+
+	return buf.Bytes(), nil
+}
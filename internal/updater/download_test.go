@@ -0,0 +1,175 @@
+package updater
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// useTempCache points the user cache directory at a temporary directory.
+func useTempCache(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", dir)
+	t.Setenv("HOME", dir)
+	t.Setenv("LocalAppData", dir)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestPartialDownloadDirIsPrivate(t *testing.T) {
+	useTempCache(t)
+
+	path, err := partialDownloadPath(&Asset{Name: "../qobuz-dl-go-linux-amd64.tar.gz"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(path, "qobuz-dl-go-linux-amd64.tar.gz.part") || strings.Contains(path, "..") {
+		t.Errorf("unexpected partial path %s", path)
+	}
+
+	f, err := openPartial(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("partial file permissions = %o, want 600", perm)
+	}
+}
+
+func TestOpenPartialRejectsSymlink(t *testing.T) {
+	useTempCache(t)
+
+	path, err := partialDownloadPath(&Asset{Name: "archive.tar.gz"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := path + ".target"
+	if err := os.WriteFile(target, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, path); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+	if f, err := openPartial(path); err == nil {
+		f.Close()
+		t.Fatal("openPartial followed a symlink")
+	}
+}
+
+func TestDownloadAndApplyRejectsPlantedArchive(t *testing.T) {
+	useTempCache(t)
+
+	genuine := []byte("genuine release archive")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(genuine)
+	}))
+	defer srv.Close()
+
+	asset := &Asset{
+		Name:               "qobuz-dl-go-linux-amd64.tar.gz",
+		BrowserDownloadURL: srv.URL,
+		Size:               int64(len(genuine)),
+		Digest:             "sha256:" + sha256Hex(genuine),
+	}
+	path, err := partialDownloadPath(asset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A full-size file with other content must not be trusted as complete
+	planted := []byte(strings.Repeat("x", len(genuine)))
+	if err := os.WriteFile(path, planted, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	err = DownloadAndApply(&ReleaseInfo{TagName: "v9.9.9", Assets: []Asset{*asset}}, asset, nil)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("DownloadAndApply error = %v, want checksum mismatch", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("partial file failing verification was kept")
+	}
+}
+
+func TestAssetChecksum(t *testing.T) {
+	archive := "qobuz-dl-go-v1.2.0-linux-amd64.tar.gz"
+	sum := strings.Repeat("ab", 32)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/checksums.txt", "/checksums-sha256.txt":
+			fmt.Fprintf(w, "%s  other.zip\n%s *%s\n", strings.Repeat("cd", 32), strings.ToUpper(sum), archive)
+		case "/single.sha256":
+			fmt.Fprintln(w, sum)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		asset   Asset
+		assets  []Asset
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "github digest",
+			asset: Asset{Name: archive, Digest: "sha256:" + sum},
+			want:  sum,
+		},
+		{
+			name:   "checksum list",
+			asset:  Asset{Name: archive},
+			assets: []Asset{{Name: "checksums.txt", BrowserDownloadURL: srv.URL + "/checksums.txt"}},
+			want:   sum,
+		},
+		{
+			name:   "checksum list of our release workflow",
+			asset:  Asset{Name: archive},
+			assets: []Asset{{Name: "checksums-sha256.txt", BrowserDownloadURL: srv.URL + "/checksums-sha256.txt"}},
+			want:   sum,
+		},
+		{
+			name:   "per-asset sha256 file",
+			asset:  Asset{Name: archive},
+			assets: []Asset{{Name: archive + ".sha256", BrowserDownloadURL: srv.URL + "/single.sha256"}},
+			want:   sum,
+		},
+		{
+			name:    "no checksum published",
+			asset:   Asset{Name: archive},
+			wantErr: true,
+		},
+		{
+			name:    "asset missing from list",
+			asset:   Asset{Name: "qobuz-dl-go-v1.2.0-darwin-arm64.tar.gz"},
+			assets:  []Asset{{Name: "checksums.txt", BrowserDownloadURL: srv.URL + "/checksums.txt"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			release := &ReleaseInfo{Assets: append([]Asset{tt.asset}, tt.assets...)}
+			got, err := release.AssetChecksum(&tt.asset)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AssetChecksum error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("AssetChecksum = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package updater
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newReleaseServer starts a TLS server with a self-signed certificate serving
+// a release, and gives the updater a fresh transport for the test.
+func newReleaseServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(ReleaseInfo{TagName: "v9.9.9"})
+	}))
+	t.Cleanup(srv.Close)
+
+	orig := httpClient.Transport
+	httpClient.Transport = nil
+	t.Cleanup(func() { httpClient.Transport = orig })
+	return srv
+}
+
+func TestSetInsecureSkipVerify(t *testing.T) {
+	tests := []struct {
+		name    string
+		skips   []bool // Successive SetInsecureSkipVerify calls
+		wantErr bool
+	}{
+		{name: "verified by default", wantErr: true},
+		{name: "skipped", skips: []bool{true}},
+		{name: "restored", skips: []bool{true, false}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newReleaseServer(t)
+			for _, skip := range tt.skips {
+				SetInsecureSkipVerify(skip)
+			}
+
+			release, err := fetchReleaseInfo(srv.URL)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "certificate") {
+					t.Errorf("fetchReleaseInfo() error = %v, want a certificate error", err)
+				}
+				return
+			}
+			if err != nil || release.TagName != "v9.9.9" {
+				t.Errorf("fetchReleaseInfo() = %+v, %v; want v9.9.9", release, err)
+			}
+		})
+	}
+}
+
+func TestSetRootCAs(t *testing.T) {
+	tests := []struct {
+		name    string
+		trust   bool // Add the server's self-signed CA certificate to the pool
+		wantErr bool
+	}{
+		{name: "server CA trusted", trust: true},
+		{name: "server CA not in the pool", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newReleaseServer(t)
+			pool := x509.NewCertPool()
+			if tt.trust {
+				pool.AddCert(srv.Certificate())
+			}
+			SetRootCAs(pool)
+
+			release, err := fetchReleaseInfo(srv.URL)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "certificate") {
+					t.Errorf("fetchReleaseInfo() error = %v, want a certificate error", err)
+				}
+				return
+			}
+			if err != nil || release.TagName != "v9.9.9" {
+				t.Errorf("fetchReleaseInfo() = %+v, %v; want v9.9.9", release, err)
+			}
+		})
+	}
+}
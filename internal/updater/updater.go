@@ -8,11 +8,14 @@ import (
 	"archive/zip"
 	"bytes"
 	"compress/gzip"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"regexp"
 	"runtime"
 	"strings"
 
@@ -46,6 +49,7 @@ type Asset struct {
 	Name               string `json:"name"`
 	BrowserDownloadURL string `json:"browser_download_url"`
 	Size               int64  `json:"size"`
+	Digest             string `json:"digest"` // "sha256:<hex>", reported by GitHub for recent uploads
 }
 
 // UpdateResult contains the result of an update check
@@ -66,12 +70,40 @@ func SetProxy(proxyURL string) error {
 	if err != nil {
 		return fmt.Errorf("invalid proxy URL: %w", err)
 	}
-	httpClient.Transport = &http.Transport{
-		Proxy: http.ProxyURL(parsed),
-	}
+	transport().Proxy = http.ProxyURL(parsed)
 	return nil
 }
 
+// SetInsecureSkipVerify disables (or restores) TLS certificate verification of
+// update checks and downloads, for TLS-intercepting proxies.
+func SetInsecureSkipVerify(skip bool) {
+	t := transport()
+	if t.TLSClientConfig == nil {
+		t.TLSClientConfig = &tls.Config{}
+	}
+	t.TLSClientConfig.InsecureSkipVerify = skip
+}
+
+// SetRootCAs sets the certificates trusted by update checks and downloads,
+// e.g. the system roots plus a proxy's private CA.
+func SetRootCAs(pool *x509.CertPool) {
+	t := transport()
+	if t.TLSClientConfig == nil {
+		t.TLSClientConfig = &tls.Config{}
+	}
+	t.TLSClientConfig.RootCAs = pool
+}
+
+// transport returns the transport of httpClient, creating it on first use.
+func transport() *http.Transport {
+	if t, ok := httpClient.Transport.(*http.Transport); ok {
+		return t
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	httpClient.Transport = t
+	return t
+}
+
 // CheckForUpdate checks GitHub for the latest release and compares versions.
 // If useCDN is true, tries CDN first then falls back to direct API.
 func CheckForUpdate(useCDN bool) (*UpdateResult, error) {
@@ -131,74 +163,145 @@ func fetchReleaseInfo(apiURL string) (ReleaseInfo, error) {
 
 // GetPlatformAsset returns the appropriate asset for the current platform
 func (r *ReleaseInfo) GetPlatformAsset() (*Asset, error) {
-	goos := runtime.GOOS
-	goarch := runtime.GOARCH
+	return matchPlatformAsset(r.Assets, r.TagName, runtime.GOOS, runtime.GOARCH)
+}
+
+// osAliases lists the names a release may use for each GOOS.
+var osAliases = map[string][]string{
+	"darwin":  {"darwin", "macos", "osx", "mac"},
+	"windows": {"windows", "win"},
+	"linux":   {"linux"},
+}
 
+// archAliases lists the names a release may use for each GOARCH.
+var archAliases = map[string][]string{
+	"amd64": {"amd64", "x64"}, // "x86_64" is normalized to "amd64" by assetNameTokens
+	"arm64": {"arm64", "aarch64"},
+	"386":   {"386", "i386", "i686", "x86"},
+	"arm":   {"arm", "armv7", "armv6", "armhf"},
+}
+
+// universalArchNames mark macOS assets containing a universal (fat) binary.
+var universalArchNames = []string{"universal", "all"}
+
+// aliasesFor returns the known aliases for key, or key itself if none are registered.
+func aliasesFor(table map[string][]string, key string) []string {
+	if aliases, ok := table[key]; ok {
+		return aliases
+	}
+	return []string{key}
+}
+
+// x86_64Regex matches the "x86_64"/"x86-64" arch name, which contains a separator.
+var x86_64Regex = regexp.MustCompile(`x86[-_]64`)
+
+// assetNameTokens splits an asset name into lowercase components on '-', '_', '.'
+// and spaces. "x86_64" is rewritten to "amd64" first so it stays one component.
+func assetNameTokens(name string) map[string]bool {
+	name = x86_64Regex.ReplaceAllString(strings.ToLower(name), "amd64")
+	tokens := make(map[string]bool)
+	for _, t := range strings.FieldsFunc(name, func(r rune) bool {
+		return r == '-' || r == '_' || r == '.' || r == ' '
+	}) {
+		tokens[t] = true
+	}
+	return tokens
+}
+
+// hasAnyToken reports whether tokens contains any of names.
+func hasAnyToken(tokens map[string]bool, names []string) bool {
+	for _, n := range names {
+		if tokens[n] {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPlatformAsset picks the release asset for goos/goarch. Asset names only need
+// to carry the expected archive extension and an OS and architecture component
+// (under any known alias); among several candidates, one naming the release tag wins.
+// On macOS a universal binary is accepted when no arch-specific asset exists.
+func matchPlatformAsset(assets []Asset, tagName, goos, goarch string) (*Asset, error) {
 	// Determine expected file extension
-	var ext string
+	ext := ".tar.gz"
 	if goos == "windows" {
 		ext = ".zip"
-	} else {
-		ext = ".tar.gz"
 	}
 
-	// Build expected asset name pattern
-	pattern := fmt.Sprintf("qobuz-dl-go-%s-%s-%s%s", r.TagName, goos, goarch, ext)
+	version := strings.TrimPrefix(strings.ToLower(tagName), "v")
+	osNames := aliasesFor(osAliases, goos)
+	archNames := aliasesFor(archAliases, goarch)
+
+	var best *Asset
+	bestScore := -1
+	for i := range assets {
+		name := strings.ToLower(assets[i].Name)
+		if !strings.HasSuffix(name, ext) {
+			continue
+		}
+
+		tokens := assetNameTokens(strings.TrimSuffix(name, ext))
+		if !hasAnyToken(tokens, osNames) {
+			continue
+		}
+
+		score := 0
+		switch {
+		case hasAnyToken(tokens, archNames):
+			score += 2 // Arch-specific builds are preferred over universal ones
+		case goos == "darwin" && hasAnyToken(tokens, universalArchNames):
+		default:
+			continue
+		}
+		if version != "" && strings.Contains(name, version) {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = &assets[i], score
+		}
+	}
 
-	for _, asset := range r.Assets {
-		if asset.Name == pattern {
-			return &asset, nil
+	if best == nil {
+		names := make([]string, len(assets))
+		for i, a := range assets {
+			names[i] = a.Name
 		}
+		return nil, fmt.Errorf("no release found for %s/%s (available assets: %s)", goos, goarch, strings.Join(names, ", "))
 	}
 
-	return nil, fmt.Errorf("no release found for %s/%s", goos, goarch)
+	return best, nil
 }
 
-// DownloadAndApply downloads the release and applies it atomically using selfupdate
-func DownloadAndApply(asset *Asset, tagName string, progressFn func(current, total int64)) error {
-	// Download the archive (uses httpClient which respects proxy settings)
-	resp, err := httpClient.Get(asset.BrowserDownloadURL)
+// DownloadAndApply downloads asset of release, verifies it against the release
+// checksum and applies it atomically using selfupdate
+func DownloadAndApply(release *ReleaseInfo, asset *Asset, progressFn func(current, total int64)) error {
+	tagName := release.TagName
+	checksum, err := release.AssetChecksum(asset)
 	if err != nil {
-		return fmt.Errorf("failed to download: %w", err)
+		return err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("download returned status %d", resp.StatusCode)
+	// Download the archive, resuming a previous partial download if present
+	// (uses httpClient which respects proxy settings)
+	data, err := downloadArchive(asset, progressFn)
+	if err != nil {
+		return err
 	}
-
-	// Read entire archive into memory (releases are small, ~6MB)
-	var buf bytes.Buffer
-	if progressFn != nil {
-		var written int64
-		tmpBuf := make([]byte, 32*1024)
-		for {
-			n, readErr := resp.Body.Read(tmpBuf)
-			if n > 0 {
-				buf.Write(tmpBuf[:n])
-				written += int64(n)
-				progressFn(written, asset.Size)
-			}
-			if readErr == io.EOF {
-				break
-			}
-			if readErr != nil {
-				return readErr
-			}
-		}
-	} else {
-		if _, err := io.Copy(&buf, resp.Body); err != nil {
-			return fmt.Errorf("failed to read archive: %w", err)
-		}
+	if err := verifyChecksum(data, checksum); err != nil {
+		removePartialDownload(asset) // Never resume or reuse a file that fails verification
+		return fmt.Errorf("downloaded archive rejected: %w", err)
 	}
 
 	// Extract binary from archive
 	var binaryReader io.Reader
 	if strings.HasSuffix(asset.Name, ".zip") {
-		binaryReader, err = extractFromZip(buf.Bytes(), tagName)
+		binaryReader, err = extractFromZip(data, tagName)
 	} else {
-		binaryReader, err = extractFromTarGz(buf.Bytes(), tagName)
+		binaryReader, err = extractFromTarGz(data, tagName)
 	}
+	// The archive is fully read at this point; a corrupt one must not be resumed
+	removePartialDownload(asset)
 	if err != nil {
 		return fmt.Errorf("failed to extract binary: %w", err)
 	}
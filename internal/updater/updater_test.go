@@ -0,0 +1,137 @@
+package updater
+
+import (
+	"strings"
+	"testing"
+)
+
+// assets builds an asset list from names.
+func assets(names ...string) []Asset {
+	out := make([]Asset, len(names))
+	for i, n := range names {
+		out[i] = Asset{Name: n}
+	}
+	return out
+}
+
+func TestMatchPlatformAssetNaming(t *testing.T) {
+	tests := []struct {
+		name   string
+		assets []Asset
+		goos   string
+		goarch string
+		want   string
+	}{
+		{
+			name:   "build script naming",
+			assets: assets("qobuz-dl-go-windows-amd64.zip", "qobuz-dl-go-linux-amd64.tar.gz", "checksums.txt"),
+			goos:   "linux", goarch: "amd64",
+			want: "qobuz-dl-go-linux-amd64.tar.gz",
+		},
+		{
+			name:   "goreleaser naming",
+			assets: assets("qobuz-dl-go_1.2.0_Linux_x86_64.tar.gz", "qobuz-dl-go_1.2.0_Linux_i386.tar.gz"),
+			goos:   "linux", goarch: "amd64",
+			want: "qobuz-dl-go_1.2.0_Linux_x86_64.tar.gz",
+		},
+		{
+			name:   "wrong extension is skipped",
+			assets: assets("qobuz-dl-go-linux-amd64.zip", "qobuz-dl-go-linux-amd64.tar.gz"),
+			goos:   "linux", goarch: "amd64",
+			want: "qobuz-dl-go-linux-amd64.tar.gz",
+		},
+		{
+			name:   "windows uses zip",
+			assets: assets("qobuz-dl-go-win-x64.tar.gz", "qobuz-dl-go-win-x64.zip"),
+			goos:   "windows", goarch: "amd64",
+			want: "qobuz-dl-go-win-x64.zip",
+		},
+		{
+			name:   "asset naming the tag wins",
+			assets: assets("qobuz-dl-go-linux-amd64-v1.1.0.tar.gz", "qobuz-dl-go-v1.2.0-linux-amd64.tar.gz"),
+			goos:   "linux", goarch: "amd64",
+			want: "qobuz-dl-go-v1.2.0-linux-amd64.tar.gz",
+		},
+		{
+			name:   "os substring inside a word does not match",
+			assets: assets("qobuz-dl-go-darwinian-amd64.tar.gz", "qobuz-dl-go-darwin-amd64.tar.gz"),
+			goos:   "darwin", goarch: "amd64",
+			want: "qobuz-dl-go-darwin-amd64.tar.gz",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := matchPlatformAsset(tt.assets, "v1.2.0", tt.goos, tt.goarch)
+			if err != nil {
+				t.Fatalf("matchPlatformAsset() error = %v", err)
+			}
+			if got.Name != tt.want {
+				t.Errorf("matchPlatformAsset() = %q, want %q", got.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchPlatformAssetNoMatch(t *testing.T) {
+	list := assets("qobuz-dl-go-linux-amd64.tar.gz", "qobuz-dl-go-windows-amd64.zip")
+	_, err := matchPlatformAsset(list, "v1.2.0", "freebsd", "amd64")
+	if err == nil {
+		t.Fatal("matchPlatformAsset() succeeded for an unpublished platform")
+	}
+	for _, a := range list {
+		if !strings.Contains(err.Error(), a.Name) {
+			t.Errorf("error %q does not list asset %q", err, a.Name)
+		}
+	}
+}
+
+func TestMatchPlatformAssetArch(t *testing.T) {
+	release := assets(
+		"qobuz-dl-go-linux-x86_64.tar.gz",
+		"qobuz-dl-go-linux-aarch64.tar.gz",
+		"qobuz-dl-go-linux-armv7.tar.gz",
+		"qobuz-dl-go-linux-i686.tar.gz",
+		"qobuz-dl-go-windows-x86.zip",
+		"qobuz-dl-go-windows-arm64.zip",
+		"qobuz-dl-go-macos-arm64.tar.gz",
+		"qobuz-dl-go-macos-universal.tar.gz",
+	)
+	universalOnly := assets("qobuz-dl-go-darwin-all.tar.gz", "qobuz-dl-go-linux-amd64.tar.gz")
+
+	tests := []struct {
+		goos    string
+		goarch  string
+		assets  []Asset
+		want    string
+		wantErr bool
+	}{
+		{goos: "linux", goarch: "amd64", assets: release, want: "qobuz-dl-go-linux-x86_64.tar.gz"},
+		{goos: "linux", goarch: "arm64", assets: release, want: "qobuz-dl-go-linux-aarch64.tar.gz"},
+		{goos: "linux", goarch: "arm", assets: release, want: "qobuz-dl-go-linux-armv7.tar.gz"},
+		{goos: "linux", goarch: "386", assets: release, want: "qobuz-dl-go-linux-i686.tar.gz"},
+		{goos: "windows", goarch: "386", assets: release, want: "qobuz-dl-go-windows-x86.zip"},
+		{goos: "windows", goarch: "arm64", assets: release, want: "qobuz-dl-go-windows-arm64.zip"},
+		{goos: "windows", goarch: "amd64", assets: release, wantErr: true},
+		{goos: "darwin", goarch: "arm64", assets: release, want: "qobuz-dl-go-macos-arm64.tar.gz"},
+		{goos: "darwin", goarch: "amd64", assets: release, want: "qobuz-dl-go-macos-universal.tar.gz"},
+		{goos: "darwin", goarch: "arm64", assets: universalOnly, want: "qobuz-dl-go-darwin-all.tar.gz"},
+		{goos: "linux", goarch: "riscv64", assets: release, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.goos+"/"+tt.goarch, func(t *testing.T) {
+			got, err := matchPlatformAsset(tt.assets, "v1.2.0", tt.goos, tt.goarch)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("matchPlatformAsset() = %q, want error", got.Name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("matchPlatformAsset() error = %v", err)
+			}
+			if got.Name != tt.want {
+				t.Errorf("matchPlatformAsset() = %q, want %q", got.Name, tt.want)
+			}
+		})
+	}
+}